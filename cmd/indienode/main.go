@@ -5,6 +5,9 @@ import (
 	"IndieNode/internal/api"
 	"IndieNode/internal/dev"
 	"IndieNode/internal/services/auth"
+	"IndieNode/internal/services/ens"
+	"IndieNode/internal/services/payments"
+	"IndieNode/internal/services/pinning"
 	"IndieNode/internal/services/shop"
 	"IndieNode/internal/ui/theme"
 	"IndieNode/internal/ui/windows"
@@ -15,6 +18,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2/app"
 	iface_ipfs "github.com/ipfs/interface-go-ipfs-core"
@@ -72,6 +77,12 @@ func main() {
 	apiFlag := flag.Bool("api", false, "Start only the API server without the UI")
 	portFlag := flag.Int("port", 8080, "Port to run development server on")
 	apiPortFlag := flag.Int("api-port", 8000, "Port to run the API server on")
+	sessionTTLFlag := flag.Duration("session-ttl", auth.DefaultSessionTTL, "How long a wallet login stays valid")
+	ethRPCFlag := flag.String("eth-rpc", os.Getenv("ETH_RPC_URL"), "Ethereum RPC URL used to verify order payments")
+	confirmationsFlag := flag.Uint64("payment-confirmations", payments.DefaultConfirmations, "Blocks required before a payment is confirmed")
+	migrateFlag := flag.Bool("migrate-shops", false, "Upgrade all local shops to the current OrbitDB schema and exit")
+	keepVersionsFlag := flag.Int("keep-versions", shop.DefaultKeepVersions, "Published versions of each shop to keep pinned, 0 keeps all")
+	ipfsModeFlag := flag.String("ipfs-mode", "", "IPFS node to use: system, app-specific or embedded (default: system if installed, else app-specific)")
 	flag.Parse()
 
 	// If serve flag is set, start the development server
@@ -86,6 +97,7 @@ func main() {
 
 	// Initialize IPFS manager first
 	ipfsMgr, err := ipfs.NewIPFSManager(&ipfs.Config{
+		Mode:           ipfs.IPFSMode(*ipfsModeFlag),
 		CustomGateways: []string{"http://localhost:5001"},
 	})
 	if err != nil {
@@ -100,6 +112,9 @@ func main() {
 	// Initialize OrbitDB manager - this is needed for the API server
 	orbitConfig := &orbitdb.Config{
 		Directory: filepath.Join(".", "db", "orbitdb", "data"),
+
+		// The dev user has no wallet key to sign writer proofs with
+		DisableWalletAccess: auth.IsDevMode(),
 	}
 
 	// Get the IPFS CoreAPI for OrbitDB
@@ -119,8 +134,52 @@ func main() {
 		log.Fatalf("Failed to initialize OrbitDB manager: %v", err)
 	}
 
+	// If migrate flag is set, upgrade the stored shops and exit
+	if *migrateFlag {
+		results, err := orbitMgr.MigrateAllShops(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to migrate shops: %v", err)
+		}
+
+		failed := 0
+		for _, result := range results {
+			log.Printf("%s", result)
+			if result.Error != nil {
+				failed++
+			}
+		}
+		log.Printf("Migrated %d shops to schema version %d, %d failed", len(results)-failed, orbitdb.CurrentSchemaVersion, failed)
+
+		orbitMgr.Close()
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Verify order payments on-chain when an RPC endpoint is configured
+	var verifier *payments.Verifier
+	if *ethRPCFlag != "" {
+		verifier, err = payments.NewVerifier(&payments.Config{
+			RPCURL:        *ethRPCFlag,
+			ChainID:       ens.LoadENSConfig().ChainID,
+			Confirmations: *confirmationsFlag,
+		}, orbitMgr)
+		if err != nil {
+			log.Printf("Warning: Failed to start payment verifier: %v", err)
+		} else {
+			verifier.Start(context.Background())
+			defer verifier.Stop()
+		}
+	} else {
+		log.Printf("No Ethereum RPC configured, the API won't take orders (set -eth-rpc or ETH_RPC_URL)")
+	}
+
 	// Start the API server either in standalone mode or alongside the UI
 	apiServer := api.NewServer(orbitMgr, *apiPortFlag)
+	if verifier != nil {
+		apiServer.SetPaymentVerifier(verifier)
+	}
 
 	// If API-only mode is requested, start the API server and exit
 	if *apiFlag {
@@ -145,12 +204,63 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize shop manager: %v", err)
 	}
+	shopMgr.SetRetention(*keepVersionsFlag)
+
+	// Shops saved in the UI are served by the API and followed by mirrors from OrbitDB
+	shopMgr.SetStore(orbitMgr)
+
+	// Keep shops' ENS names pointed at their current version when a key that manages them is configured
+	if ensKey := os.Getenv("ENS_PRIVATE_KEY"); ensKey != "" && *ethRPCFlag != "" {
+		updater, err := ens.NewContenthashUpdater(*ethRPCFlag, ensKey, ens.LoadENSConfig())
+		if err != nil {
+			log.Printf("Warning: Failed to initialize ENS updates: %v", err)
+		} else {
+			shopMgr.SetENSUpdater(updater)
+		}
+	}
+
+	// Pin published shops on any remote pinning services the user configures
+	pinningSvc, err := pinning.NewService(&pinning.Config{
+		Origins: func() []string {
+			_, addrs, err := ipfsMgr.GetNodeInfo()
+			if err != nil {
+				return nil
+			}
+			origins := []string{}
+			for _, addr := range addrs {
+				// Loopback addresses are of no use to a remote service
+				if strings.HasPrefix(addr, "/ip4/127.") || strings.HasPrefix(addr, "/ip6/::1/") {
+					continue
+				}
+				origins = append(origins, addr)
+			}
+			return origins
+		},
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to initialize remote pinning: %v", err)
+	} else {
+		pinningSvc.Start(context.Background())
+		defer pinningSvc.Stop()
+		shopMgr.SetPinner(pinningSvc)
+	}
 
 	// Continue with UI initialization
 	mainApp := app.NewWithID("com.mrteacher.indienode")
 	mainApp.Settings().SetTheme(theme.NewIndieNodeTheme())
 
-	authSvc := auth.NewService()
+	// Persist wallet logins so they survive restarts until the TTL expires
+	sessionStore, err := auth.NewSessionStore(&auth.SessionConfig{
+		TTL: *sessionTTLFlag,
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to initialize session store: %v", err)
+	}
+
+	authSvc := auth.NewService(sessionStore)
+
+	// Signing in delegates shop writes to this node's OrbitDB identity
+	authSvc.SetSignInResources(orbitdb.IdentityResource(orbitMgr.IdentityID()))
 
 	if auth.IsDevMode() {
 		log.Printf("Running in DEV_MODE")
@@ -161,7 +271,7 @@ func main() {
 
 		// Skip login in dev mode
 		authSvc.SetDevModeUser()
-		mainWindow := windows.NewMainWindow(mainApp, shopMgr, ipfsMgr, authSvc, orbitMgr, apiServer, *apiPortFlag)
+		mainWindow := windows.NewMainWindow(mainApp, shopMgr, ipfsMgr, authSvc, orbitMgr, pinningSvc, apiServer, *apiPortFlag)
 		mainWindow.SetCloseIntercept(func() {
 			// Gracefully shut down API server when closing the app
 			ctx, cancel := context.WithTimeout(context.Background(), 5000)
@@ -176,7 +286,14 @@ func main() {
 		// Create login window first
 		loginWindow := windows.NewLoginWindow(mainApp, authSvc, func() {
 			// This is called after successful login
-			mainWindow := windows.NewMainWindow(mainApp, shopMgr, ipfsMgr, authSvc, orbitMgr, apiServer, *apiPortFlag)
+			if user := authSvc.GetAuthenticatedUser(); user != nil && user.SignInMessage != "" {
+				proof := &orbitdb.WriterProof{Message: user.SignInMessage, Signature: user.SignInSignature}
+				if err := orbitMgr.SetWriterProof(proof); err != nil {
+					log.Printf("Warning: Failed to set writer proof: %v", err)
+				}
+			}
+
+			mainWindow := windows.NewMainWindow(mainApp, shopMgr, ipfsMgr, authSvc, orbitMgr, pinningSvc, apiServer, *apiPortFlag)
 			mainWindow.SetCloseIntercept(func() {
 				// Gracefully shut down API server when closing the app
 				ctx, cancel := context.WithTimeout(context.Background(), 5000)
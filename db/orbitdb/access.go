@@ -0,0 +1,898 @@
+package orbitdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"IndieNode/internal/services/auth"
+	"IndieNode/internal/services/ens"
+
+	"berty.tech/go-ipfs-log/identityprovider"
+	ipfslog "berty.tech/go-ipfs-log/iface"
+	"berty.tech/go-orbit-db/accesscontroller"
+	"berty.tech/go-orbit-db/address"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// WalletAccessControllerType is the access controller type of shop databases
+// that only accept writes authorised by the shop owner's wallet. Shops created
+// before it keep OrbitDB's default controller and aren't migrated, only the
+// node that created them can write to them.
+const WalletAccessControllerType = "indienode-wallet"
+
+const (
+	// ownerCapability lists the wallet that owns a shop database
+	ownerCapability = "owner"
+	// writeCapability lists extra wallets allowed to write
+	writeCapability = "write"
+	// chainCapability holds the chain writer proofs must be signed for. Shops
+	// created before it was added accept proofs for any chain.
+	chainCapability = "chain"
+
+	// writerProofField is the document field carrying the writer's WriterProof
+	writerProofField = "writerProof"
+	// writtenAtField is the document field with the time it was written, at
+	// which its writer proof must have been valid
+	writtenAtField = "writtenAt"
+
+	// opDelete is the docstore operation that removes a document
+	opDelete = "DEL"
+
+	// clockSkew is how far in the future a document's write time may be
+	clockSkew = 30 * time.Second
+
+	// identityResourcePrefix marks an OrbitDB identity in a sign-in message's resources
+	identityResourcePrefix = "orbitdb:identity:"
+
+	// writerProofFile stores this node's proof between restarts
+	writerProofFile = "writer-proof.json"
+)
+
+// WriterProof is a wallet-signed sign-in message that lists an OrbitDB identity
+// in its resources, delegating writes to that identity. It is attached to every
+// document this node writes so replicas can check which wallet authorised it.
+type WriterProof struct {
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// IdentityResource returns the sign-in resource that delegates writes to an OrbitDB identity
+func IdentityResource(identityID string) string {
+	return identityResourcePrefix + identityID
+}
+
+// Signer verifies the proof and returns the wallet that delegated writes to
+// identityID. The proof must have been valid at the given time and, unless
+// chainID is 0, signed for that chain.
+func (p *WriterProof) Signer(identityID string, chainID int64, at time.Time) (common.Address, error) {
+	_, signer, err := p.verify(identityID, chainID, at)
+	return signer, err
+}
+
+// verify checks the proof like Signer and also returns the parsed message
+func (p *WriterProof) verify(identityID string, chainID int64, at time.Time) (*auth.SIWEMessage, common.Address, error) {
+	if p == nil || p.Message == "" || p.Signature == "" {
+		return nil, common.Address{}, fmt.Errorf("%w: missing writer proof", ErrUnauthorizedWriter)
+	}
+
+	msg, err := auth.ParseSIWEMessage(p.Message)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrUnauthorizedWriter, err)
+	}
+	if err := msg.CheckDelegation(chainID, at); err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrUnauthorizedWriter, err)
+	}
+
+	delegated := false
+	for _, resource := range msg.Resources {
+		if resource == IdentityResource(identityID) {
+			delegated = true
+			break
+		}
+	}
+	if !delegated {
+		return nil, common.Address{}, fmt.Errorf("%w: proof does not name identity %s", ErrUnauthorizedWriter, identityID)
+	}
+
+	signer, err := auth.RecoverAddress(p.Message, p.Signature)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrUnauthorizedWriter, err)
+	}
+	if !common.IsHexAddress(msg.Address) || signer != common.HexToAddress(msg.Address) {
+		return nil, common.Address{}, fmt.Errorf("%w: proof was not signed by %s", ErrUnauthorizedWriter, msg.Address)
+	}
+
+	return msg, signer, nil
+}
+
+// Nonce returns the nonce of the proof's sign-in message, which identifies it
+// in revocations, or "" if the message can't be read
+func (p *WriterProof) Nonce() string {
+	if p == nil {
+		return ""
+	}
+	msg, err := auth.ParseSIWEMessage(p.Message)
+	if err != nil {
+		return ""
+	}
+	return msg.Nonce
+}
+
+// accessDocID returns the ID of the document listing a shop's extra writers
+func accessDocID(shopID string) string {
+	return "acl-" + shopID
+}
+
+// ShopAccessData lists the wallets, besides the owner, allowed to write to a
+// shop, and the writer proofs that were revoked before they expired
+type ShopAccessData struct {
+	SchemaVersion int                 `json:"schemaVersion"`
+	ID            string              `json:"id"`
+	ShopID        string              `json:"shopId"`
+	Writers       []string            `json:"writers"`
+	Revoked       []RevokedDelegation `json:"revoked,omitempty"`
+	Updated       time.Time           `json:"updated"`
+}
+
+// RevokedDelegation is a writer proof that no longer authorises writes. Documents
+// written with it from the time it was revoked on, or in log entries after the
+// one that recorded the revocation, are rejected.
+type RevokedDelegation struct {
+	Nonce   string    `json:"nonce"` // Nonce of the proof's sign-in message
+	Revoked time.Time `json:"revoked"`
+
+	clock int // Lamport time of the log entry that recorded the revocation, 0 if unknown
+}
+
+// revocation returns the revocation of the proof with nonce, or nil
+func (a *ShopAccessData) revocation(nonce string) *RevokedDelegation {
+	if a == nil {
+		return nil
+	}
+	for i := range a.Revoked {
+		if a.Revoked[i].Nonce == nonce {
+			return &a.Revoked[i]
+		}
+	}
+	return nil
+}
+
+// covers reports whether the revocation rejects a document written at
+// writtenAt in a log entry with Lamport time clock. The write time is set by
+// the writer, who could backdate it, so entries that come after the revocation
+// in the log are rejected whatever time they claim.
+func (r *RevokedDelegation) covers(writtenAt time.Time, clock int) bool {
+	if r == nil {
+		return false
+	}
+	if !writtenAt.Before(r.Revoked) {
+		return true
+	}
+	return r.clock > 0 && clock > r.clock
+}
+
+// clockedEntry is a log entry with a Lamport clock, as ipfs-log's entries are
+type clockedEntry interface {
+	GetClock() ipfslog.IPFSLogLamportClock
+}
+
+// entryClock returns the Lamport time of a log entry, or 0 if it has none
+func entryClock(entry accesscontroller.LogEntry) int {
+	clocked, ok := entry.(clockedEntry)
+	if !ok || clocked.GetClock() == nil {
+		return 0
+	}
+	return clocked.GetClock().GetTime()
+}
+
+// walletAccessController accepts log entries written by an OrbitDB identity that
+// the shop owner, or a writer the owner granted, delegated to with a WriterProof
+type walletAccessController struct {
+	owner   common.Address
+	writers []common.Address // Writers fixed when the database was created
+	chainID int64            // Chain proofs must be signed for, 0 for any
+	logger  *zap.Logger
+}
+
+// newWalletAccessController builds the controller from its manifest. It is
+// registered with OrbitDB so replicas opening a shop database use it too.
+func newWalletAccessController(ctx context.Context, db iface.BaseOrbitDB, params accesscontroller.ManifestParams, options ...accesscontroller.Option) (accesscontroller.Interface, error) {
+	owners := params.GetAccess(ownerCapability)
+	if len(owners) != 1 || !common.IsHexAddress(owners[0]) {
+		return nil, fmt.Errorf("wallet access controller needs exactly one owner address")
+	}
+
+	ac := &walletAccessController{
+		owner:  common.HexToAddress(owners[0]),
+		logger: zap.NewNop(),
+	}
+	for _, writer := range params.GetAccess(writeCapability) {
+		if common.IsHexAddress(writer) {
+			ac.writers = append(ac.writers, common.HexToAddress(writer))
+		}
+	}
+	if chains := params.GetAccess(chainCapability); len(chains) > 0 {
+		chainID, err := strconv.ParseInt(chains[0], 10, 64)
+		if err != nil || chainID <= 0 {
+			return nil, fmt.Errorf("wallet access controller has an invalid chain %q", chains[0])
+		}
+		ac.chainID = chainID
+	}
+
+	for _, option := range options {
+		option(ac)
+	}
+
+	return ac, nil
+}
+
+// walletAccessManifest returns the manifest for a new shop database owned by
+// owner, accepting writer proofs signed for chainID
+func walletAccessManifest(owner string, chainID int64) accesscontroller.ManifestParams {
+	return accesscontroller.NewSimpleManifestParams(WalletAccessControllerType, map[string][]string{
+		ownerCapability: {common.HexToAddress(owner).Hex()},
+		writeCapability: {},
+		chainCapability: {strconv.FormatInt(chainID, 10)},
+	})
+}
+
+// shopAccessController returns the access controller options for a new shop
+// database, or nil when wallet access is disabled
+func (m *Manager) shopAccessController(shopID string, owner string) (accesscontroller.ManifestParams, error) {
+	if m.config.DisableWalletAccess {
+		return nil, nil
+	}
+	if !common.IsHexAddress(owner) {
+		return nil, fmt.Errorf("%w: shop %s has no owner wallet", ErrUnauthorizedWriter, shopID)
+	}
+	return walletAccessManifest(owner, ens.LoadENSConfig().ChainID), nil
+}
+
+// Type returns the access controller type
+func (ac *walletAccessController) Type() string {
+	return WalletAccessControllerType
+}
+
+// Address returns nil, the controller's state lives in its manifest and the shop's access document
+func (ac *walletAccessController) Address() address.Address {
+	return nil
+}
+
+// CanAppend checks that entry was written by an identity the owner or a granted
+// writer delegated to, with a proof that was valid and not revoked when the
+// entry was written. Changes to the access document and to the shop's owner,
+// and deleting anything but inventory, are only accepted from the owner.
+func (ac *walletAccessController) CanAppend(entry accesscontroller.LogEntry, identityProvider identityprovider.Interface, additionalContext accesscontroller.CanAppendAdditionalContext) error {
+	identity := entry.GetIdentity()
+	if identity == nil {
+		return fmt.Errorf("%w: entry has no identity", ErrUnauthorizedWriter)
+	}
+	if err := identityProvider.VerifyIdentity(identity); err != nil {
+		return fmt.Errorf("%w: invalid identity: %v", ErrUnauthorizedWriter, err)
+	}
+
+	op, docs, err := parseEntry(entry.GetPayload())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnauthorizedWriter, err)
+	}
+
+	access := ac.latestAccess(additionalContext)
+
+	signer, err := ac.entrySigner(identity.ID, entryClock(entry), docs, access)
+	if err != nil {
+		return err
+	}
+
+	if signer == ac.owner {
+		return ac.checkOwnerUnchanged(docs)
+	}
+
+	if op.Op == opDelete && !strings.HasPrefix(*op.Key, inventoryDocID("")) {
+		return fmt.Errorf("%w: only the owner can delete %s", ErrUnauthorizedWriter, *op.Key)
+	}
+	for _, doc := range docs {
+		if id, _ := doc["id"].(string); strings.HasPrefix(id, "acl-") {
+			return fmt.Errorf("%w: only the owner can change who may write", ErrUnauthorizedWriter)
+		}
+	}
+
+	if !ac.isWriter(signer, access) {
+		return fmt.Errorf("%w: %s may not write to this shop", ErrUnauthorizedWriter, signer.Hex())
+	}
+
+	return ac.checkOwnerUnchanged(docs)
+}
+
+// entrySigner returns the wallet behind an entry with Lamport time clock. Every
+// document, including the one a delete carries, must have a proof for the
+// entry's identity that was valid when it was written and wasn't revoked by
+// then, see RevokedDelegation.covers.
+func (ac *walletAccessController) entrySigner(identityID string, clock int, docs []map[string]interface{}, access *ShopAccessData) (common.Address, error) {
+	if len(docs) == 0 {
+		return common.Address{}, fmt.Errorf("%w: entry has no documents", ErrUnauthorizedWriter)
+	}
+
+	var signer common.Address
+	for i, doc := range docs {
+		proof, writtenAt, err := documentProof(doc)
+		if err != nil {
+			return common.Address{}, err
+		}
+
+		msg, docSigner, err := proof.verify(identityID, ac.chainID, writtenAt)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if revoked := access.revocation(msg.Nonce); revoked.covers(writtenAt, clock) {
+			return common.Address{}, fmt.Errorf("%w: writer proof was revoked at %s", ErrUnauthorizedWriter, revoked.Revoked.Format(time.RFC3339))
+		}
+		if i > 0 && docSigner != signer {
+			return common.Address{}, fmt.Errorf("%w: documents in one entry have different signers", ErrUnauthorizedWriter)
+		}
+		signer = docSigner
+	}
+
+	return signer, nil
+}
+
+// checkOwnerUnchanged rejects shop documents that name a different owner than the database's
+func (ac *walletAccessController) checkOwnerUnchanged(docs []map[string]interface{}) error {
+	for _, doc := range docs {
+		owner, ok := doc["owner"].(string)
+		if !ok {
+			continue
+		}
+		if !common.IsHexAddress(owner) || common.HexToAddress(owner) != ac.owner {
+			return fmt.Errorf("%w: shop owner must stay %s", ErrUnauthorizedWriter, ac.owner.Hex())
+		}
+	}
+	return nil
+}
+
+// isWriter reports whether address was granted write access, either when the
+// database was created or by the shop's access document
+func (ac *walletAccessController) isWriter(address common.Address, access *ShopAccessData) bool {
+	for _, writer := range ac.writers {
+		if writer == address {
+			return true
+		}
+	}
+
+	if access == nil {
+		return false
+	}
+	for _, writer := range access.Writers {
+		if common.IsHexAddress(writer) && common.HexToAddress(writer) == address {
+			return true
+		}
+	}
+	return false
+}
+
+// latestAccess reads the access documents the owner wrote to the log. Writers
+// come from the newest one that wasn't written with a revoked proof.
+// Revocations are collected from all of them, so a later document can't undo
+// one, along with the log position of the first entry recording each.
+func (ac *walletAccessController) latestAccess(additionalContext accesscontroller.CanAppendAdditionalContext) *ShopAccessData {
+	if additionalContext == nil {
+		return nil
+	}
+
+	type signedAccess struct {
+		access    ShopAccessData
+		nonce     string
+		writtenAt time.Time
+		clock     int
+	}
+
+	var found []signedAccess
+	for _, entry := range additionalContext.GetLogEntries() {
+		identity := entry.GetIdentity()
+		if identity == nil {
+			continue
+		}
+
+		op, docs, err := parseEntry(entry.GetPayload())
+		if err != nil || op.Op == opDelete {
+			continue
+		}
+
+		for _, doc := range docs {
+			if id, _ := doc["id"].(string); !strings.HasPrefix(id, "acl-") {
+				continue
+			}
+
+			proof, writtenAt, err := documentProof(doc)
+			if err != nil {
+				continue
+			}
+			msg, signer, err := proof.verify(identity.ID, ac.chainID, writtenAt)
+			if err != nil || signer != ac.owner {
+				continue
+			}
+
+			var access ShopAccessData
+			data, err := json.Marshal(doc)
+			if err != nil || json.Unmarshal(data, &access) != nil {
+				continue
+			}
+			found = append(found, signedAccess{access: access, nonce: msg.Nonce, writtenAt: writtenAt, clock: entryClock(entry)})
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	// Keep the earliest time each proof was revoked at, and the earliest entry
+	// that recorded it
+	earliest := map[string]*RevokedDelegation{}
+	for _, f := range found {
+		for _, revoked := range f.access.Revoked {
+			first, ok := earliest[revoked.Nonce]
+			if !ok {
+				earliest[revoked.Nonce] = &RevokedDelegation{Nonce: revoked.Nonce, Revoked: revoked.Revoked, clock: f.clock}
+				continue
+			}
+			if revoked.Revoked.Before(first.Revoked) {
+				first.Revoked = revoked.Revoked
+			}
+			if f.clock > 0 && (first.clock == 0 || f.clock < first.clock) {
+				first.clock = f.clock
+			}
+		}
+	}
+	revocations := &ShopAccessData{}
+	for _, revoked := range earliest {
+		revocations.Revoked = append(revocations.Revoked, *revoked)
+	}
+
+	var latest *ShopAccessData
+	for i := range found {
+		f := &found[i]
+		if revocations.revocation(f.nonce).covers(f.writtenAt, f.clock) {
+			continue
+		}
+		if latest == nil || f.access.Updated.After(latest.Updated) {
+			latest = &f.access
+		}
+	}
+	if latest == nil {
+		latest = &ShopAccessData{}
+	}
+
+	result := *latest
+	result.Revoked = revocations.Revoked
+	return &result
+}
+
+// GetAuthorizedByRole returns the wallets fixed in the manifest for a role
+func (ac *walletAccessController) GetAuthorizedByRole(role string) ([]string, error) {
+	switch role {
+	case ownerCapability, "admin":
+		return []string{ac.owner.Hex()}, nil
+	case writeCapability:
+		writers := []string{ac.owner.Hex()}
+		for _, writer := range ac.writers {
+			writers = append(writers, writer.Hex())
+		}
+		return writers, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Grant is not supported, writers are granted through Manager.GrantWriter
+func (ac *walletAccessController) Grant(ctx context.Context, capability string, keyID string) error {
+	return fmt.Errorf("wallet access controller: use Manager.GrantWriter to grant write access")
+}
+
+// Revoke is not supported, writers are revoked through Manager.RevokeWriter
+func (ac *walletAccessController) Revoke(ctx context.Context, capability string, keyID string) error {
+	return fmt.Errorf("wallet access controller: use Manager.RevokeWriter to revoke write access")
+}
+
+// Load does nothing, the controller is built from its manifest
+func (ac *walletAccessController) Load(ctx context.Context, address string) error {
+	return nil
+}
+
+// Save returns the manifest parameters the controller was built from
+func (ac *walletAccessController) Save(ctx context.Context) (accesscontroller.ManifestParams, error) {
+	writers := make([]string, 0, len(ac.writers))
+	for _, writer := range ac.writers {
+		writers = append(writers, writer.Hex())
+	}
+
+	capabilities := map[string][]string{
+		ownerCapability: {ac.owner.Hex()},
+		writeCapability: writers,
+	}
+	if ac.chainID != 0 {
+		capabilities[chainCapability] = []string{strconv.FormatInt(ac.chainID, 10)}
+	}
+	return accesscontroller.NewSimpleManifestParams(WalletAccessControllerType, capabilities), nil
+}
+
+// Close releases nothing, the controller holds no resources
+func (ac *walletAccessController) Close() error {
+	return nil
+}
+
+// SetLogger sets the controller's logger
+func (ac *walletAccessController) SetLogger(logger *zap.Logger) {
+	ac.logger = logger
+}
+
+// Logger returns the controller's logger
+func (ac *walletAccessController) Logger() *zap.Logger {
+	return ac.logger
+}
+
+// entryOperation mirrors the payload of a docstore log entry
+type entryOperation struct {
+	Key   *string `json:"key,omitempty"`
+	Op    string  `json:"op"`
+	Value []byte  `json:"value"`
+	Docs  []struct {
+		Value []byte `json:"value"`
+	} `json:"docs,omitempty"`
+}
+
+// parseEntry reads the operation of a docstore log entry and the documents it
+// writes. A delete's value is a document naming the deleted key, which carries
+// the writer proof for the delete, see deleteDocument.
+func parseEntry(payload []byte) (*entryOperation, []map[string]interface{}, error) {
+	var op entryOperation
+	if err := json.Unmarshal(payload, &op); err != nil {
+		return nil, nil, fmt.Errorf("unreadable entry payload: %w", err)
+	}
+
+	var values [][]byte
+	switch op.Op {
+	case "PUT":
+		values = append(values, op.Value)
+	case "PUTALL":
+		for _, doc := range op.Docs {
+			values = append(values, doc.Value)
+		}
+	case opDelete:
+		if op.Key == nil || len(op.Value) == 0 {
+			return nil, nil, fmt.Errorf("delete carries no writer proof")
+		}
+		values = append(values, op.Value)
+	default:
+		return nil, nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	docs := make([]map[string]interface{}, 0, len(values))
+	for _, value := range values {
+		doc := map[string]interface{}{}
+		if err := json.Unmarshal(value, &doc); err != nil {
+			return nil, nil, fmt.Errorf("unreadable document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	// The proof must be for this delete, not one copied from another document
+	if op.Op == opDelete {
+		if id, _ := docs[0]["id"].(string); id != *op.Key {
+			return nil, nil, fmt.Errorf("delete of %s carries a document for %q", *op.Key, id)
+		}
+	}
+
+	return &op, docs, nil
+}
+
+// documentProof reads the writer proof attached to a document and the time the
+// document says it was written, which may not be in the future
+func documentProof(doc map[string]interface{}) (*WriterProof, time.Time, error) {
+	raw, ok := doc[writerProofField]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("%w: document has no writer proof", ErrUnauthorizedWriter)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: unreadable writer proof", ErrUnauthorizedWriter)
+	}
+
+	var proof WriterProof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: unreadable writer proof", ErrUnauthorizedWriter)
+	}
+
+	value, _ := doc[writtenAtField].(string)
+	writtenAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: document has no write time", ErrUnauthorizedWriter)
+	}
+	if writtenAt.After(time.Now().Add(clockSkew)) {
+		return nil, time.Time{}, fmt.Errorf("%w: document was written in the future", ErrUnauthorizedWriter)
+	}
+
+	return &proof, writtenAt, nil
+}
+
+// IdentityID returns the ID of this node's OrbitDB identity, which sign-in
+// messages must name for the node to write on a wallet's behalf
+func (m *Manager) IdentityID() string {
+	if m.orbitDB == nil || m.orbitDB.Identity() == nil {
+		return ""
+	}
+	return m.orbitDB.Identity().ID
+}
+
+// SetWriterProof stores the proof this node attaches to the documents it writes.
+// The proof must delegate to this node's identity. It is kept on disk so writes
+// keep working after a restart.
+func (m *Manager) SetWriterProof(proof *WriterProof) error {
+	signer, err := proof.Signer(m.IdentityID(), ens.LoadENSConfig().ChainID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(proof, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal writer proof: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.config.Directory, writerProofFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to save writer proof: %w", err)
+	}
+
+	m.proofMutex.Lock()
+	m.writerProof = proof
+	m.proofMutex.Unlock()
+
+	log.Printf("Node identity %s now writes on behalf of %s", m.IdentityID(), signer.Hex())
+	return nil
+}
+
+// RevokeWriterProof stops this node writing with its current proof, for example
+// when the wallet logs out. Shops owned by the proof's wallet record the
+// revocation in their access document, so replicas reject anything written with
+// the proof from now on, even if it leaked before it expired.
+func (m *Manager) RevokeWriterProof(ctx context.Context) error {
+	m.proofMutex.RLock()
+	proof := m.writerProof
+	m.proofMutex.RUnlock()
+	if proof == nil {
+		return nil
+	}
+
+	signer, err := proof.Signer(m.IdentityID(), 0, time.Now())
+	if err == nil {
+		revocation := RevokedDelegation{Nonce: proof.Nonce(), Revoked: time.Now().UTC()}
+
+		m.dbsMutex.RLock()
+		var owned []string
+		for shopID, docStore := range m.shopDBs {
+			if ac, ok := docStore.AccessController().(*walletAccessController); ok && ac.owner == signer {
+				owned = append(owned, shopID)
+			}
+		}
+		m.dbsMutex.RUnlock()
+
+		for _, shopID := range owned {
+			if err := m.updateAccess(ctx, shopID, func(access *ShopAccessData) {
+				access.Revoked = append(access.Revoked, revocation)
+			}); err != nil {
+				log.Printf("Warning: Failed to record writer proof revocation in shop %s: %v", shopID, err)
+			}
+		}
+	}
+
+	m.proofMutex.Lock()
+	m.writerProof = nil
+	m.proofMutex.Unlock()
+
+	if err := os.Remove(filepath.Join(m.config.Directory, writerProofFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove writer proof: %w", err)
+	}
+	return nil
+}
+
+// loadWriterProof restores a saved proof if it still names this node's identity
+// and hasn't expired
+func (m *Manager) loadWriterProof() {
+	data, err := os.ReadFile(filepath.Join(m.config.Directory, writerProofFile))
+	if err != nil {
+		return
+	}
+
+	var proof WriterProof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		log.Printf("Warning: Ignoring unreadable writer proof: %v", err)
+		return
+	}
+	if _, err := proof.Signer(m.IdentityID(), 0, time.Now()); err != nil {
+		log.Printf("Warning: Ignoring saved writer proof, log in again to write shop data: %v", err)
+		return
+	}
+
+	m.proofMutex.Lock()
+	m.writerProof = &proof
+	m.proofMutex.Unlock()
+}
+
+// signDocument attaches this node's writer proof and the write time to doc
+func (m *Manager) signDocument(ac *walletAccessController, doc map[string]interface{}) error {
+	m.proofMutex.RLock()
+	proof := m.writerProof
+	m.proofMutex.RUnlock()
+
+	if proof == nil {
+		return fmt.Errorf("%w: log in with the shop owner's wallet to write shop data", ErrUnauthorizedWriter)
+	}
+
+	now := time.Now().UTC()
+	if _, err := proof.Signer(m.IdentityID(), ac.chainID, now); err != nil {
+		return fmt.Errorf("%w: log in again to write shop data: %v", ErrUnauthorizedWriter, err)
+	}
+	doc[writerProofField] = proof
+	doc[writtenAtField] = now.Format(time.RFC3339Nano)
+	return nil
+}
+
+// putDocument writes a document to a shop database, signing it when the
+// database checks wallet signatures
+func (m *Manager) putDocument(ctx context.Context, docStore iface.DocumentStore, doc map[string]interface{}) error {
+	if ac, ok := docStore.AccessController().(*walletAccessController); ok {
+		if err := m.signDocument(ac, doc); err != nil {
+			return err
+		}
+	}
+
+	if _, err := docStore.Put(ctx, doc); err != nil {
+		return err
+	}
+	return nil
+}
+
+// deleteDocument removes a document from a shop database. When the database
+// checks wallet signatures the delete carries a signed document naming the key,
+// since a plain docstore delete has nothing to attach a proof to.
+func (m *Manager) deleteDocument(ctx context.Context, docStore iface.DocumentStore, key string) error {
+	ac, ok := docStore.AccessController().(*walletAccessController)
+	if !ok {
+		_, err := docStore.Delete(ctx, key)
+		return err
+	}
+
+	doc := map[string]interface{}{"id": key}
+	if err := m.signDocument(ac, doc); err != nil {
+		return err
+	}
+	value, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delete: %w", err)
+	}
+
+	if _, err := docStore.AddOperation(ctx, operation.NewOperation(&key, opDelete, value), nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetWriters returns the wallets, besides the owner, allowed to write to a shop
+func (m *Manager) GetWriters(ctx context.Context, shopID string) ([]string, error) {
+	docStore, err := m.GetShopDatabase(ctx, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shop database: %w", err)
+	}
+
+	access, err := m.readAccess(ctx, docStore, shopID)
+	if err != nil {
+		return nil, err
+	}
+	if access == nil {
+		return []string{}, nil
+	}
+	return access.Writers, nil
+}
+
+// GrantWriter allows another wallet to write to a shop. Only the owner's node can grant.
+func (m *Manager) GrantWriter(ctx context.Context, shopID string, writer string) error {
+	if !common.IsHexAddress(writer) {
+		return fmt.Errorf("%w: %s", ErrInvalidWriter, writer)
+	}
+	writer = common.HexToAddress(writer).Hex()
+
+	return m.updateAccess(ctx, shopID, func(access *ShopAccessData) {
+		for _, existing := range access.Writers {
+			if existing == writer {
+				return
+			}
+		}
+		access.Writers = append(access.Writers, writer)
+	})
+}
+
+// RevokeWriter removes a wallet's write access to a shop. Only the owner's node can revoke.
+func (m *Manager) RevokeWriter(ctx context.Context, shopID string, writer string) error {
+	if !common.IsHexAddress(writer) {
+		return fmt.Errorf("%w: %s", ErrInvalidWriter, writer)
+	}
+	writer = common.HexToAddress(writer).Hex()
+
+	return m.updateAccess(ctx, shopID, func(access *ShopAccessData) {
+		kept := make([]string, 0, len(access.Writers))
+		for _, existing := range access.Writers {
+			if existing != writer {
+				kept = append(kept, existing)
+			}
+		}
+		access.Writers = kept
+	})
+}
+
+// updateAccess rewrites a shop's access document after applying change to it
+func (m *Manager) updateAccess(ctx context.Context, shopID string, change func(*ShopAccessData)) error {
+	if !m.IsConnected() {
+		return fmt.Errorf("not connected to OrbitDB")
+	}
+
+	docStore, err := m.GetShopDatabase(ctx, shopID)
+	if err != nil {
+		return fmt.Errorf("failed to get shop database: %w", err)
+	}
+
+	access, err := m.readAccess(ctx, docStore, shopID)
+	if err != nil {
+		return err
+	}
+	if access == nil {
+		access = &ShopAccessData{
+			ID:     accessDocID(shopID),
+			ShopID: shopID,
+		}
+	}
+
+	access.SchemaVersion = CurrentSchemaVersion
+	change(access)
+	access.Updated = time.Now()
+
+	data, err := json.Marshal(access)
+	if err != nil {
+		return fmt.Errorf("failed to marshal access document: %w", err)
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to prepare access document: %w", err)
+	}
+
+	if err := m.putDocument(ctx, docStore, doc); err != nil {
+		return fmt.Errorf("failed to store access document: %w", err)
+	}
+
+	log.Printf("Writers of shop %s: %v, %d revoked proofs", shopID, access.Writers, len(access.Revoked))
+	return nil
+}
+
+// readAccess loads a shop's access document, returning nil if there is none
+func (m *Manager) readAccess(ctx context.Context, docStore iface.DocumentStore, shopID string) (*ShopAccessData, error) {
+	doc, err := m.findShopDocument(ctx, docStore, accessDocID(shopID))
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access document: %w", err)
+	}
+	var access ShopAccessData
+	if err := json.Unmarshal(data, &access); err != nil {
+		return nil, fmt.Errorf("failed to read access document: %w", err)
+	}
+
+	return &access, nil
+}
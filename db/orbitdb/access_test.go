@@ -0,0 +1,378 @@
+package orbitdb
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"IndieNode/internal/services/auth"
+
+	"berty.tech/go-ipfs-log/identityprovider"
+	ipfslog "berty.tech/go-ipfs-log/iface"
+	"berty.tech/go-orbit-db/accesscontroller"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testEntry is a log entry as the access controller sees it
+type testEntry struct {
+	payload  []byte
+	identity *identityprovider.Identity
+	clock    int // Lamport time, 0 for entries without a clock
+}
+
+func (e *testEntry) GetPayload() []byte                      { return e.payload }
+func (e *testEntry) GetIdentity() *identityprovider.Identity { return e.identity }
+func (e *testEntry) GetClock() ipfslog.IPFSLogLamportClock   { return testClock{time: e.clock} }
+
+// testClock is the Lamport clock of a test entry, only its time is read
+type testClock struct {
+	ipfslog.IPFSLogLamportClock
+	time int
+}
+
+func (c testClock) GetTime() int { return c.time }
+
+// testLog is the log an entry is appended to
+type testLog []accesscontroller.LogEntry
+
+func (l testLog) GetLogEntries() []accesscontroller.LogEntry { return l }
+
+// trustIdentities accepts every identity, signatures of OrbitDB identities
+// aren't what the wallet access controller checks
+type trustIdentities struct {
+	identityprovider.Interface
+}
+
+func (trustIdentities) VerifyIdentity(*identityprovider.Identity) error { return nil }
+
+// testWallet signs writer proofs
+type testWallet struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func newTestWallet(t *testing.T) *testWallet {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return &testWallet{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// delegate signs a sign-in message delegating writes to identityID
+func (w *testWallet) delegate(t *testing.T, identityID string, change func(m *auth.SIWEMessage)) *WriterProof {
+	t.Helper()
+
+	msg := &auth.SIWEMessage{
+		Domain:         "localhost:3000",
+		Address:        w.address.Hex(),
+		Statement:      auth.SIWEStatement,
+		URI:            "http://localhost:3000",
+		Version:        "1",
+		ChainID:        1,
+		Nonce:          common.Bytes2Hex(crypto.Keccak256([]byte(identityID + w.address.Hex()))[:8]),
+		IssuedAt:       testIssued,
+		ExpirationTime: testIssued.Add(auth.DefaultSessionTTL),
+		Resources:      []string{IdentityResource(identityID)},
+	}
+	if change != nil {
+		change(msg)
+	}
+
+	message := msg.String()
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), w.key)
+	if err != nil {
+		t.Fatalf("failed to sign proof: %v", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return &WriterProof{Message: message, Signature: hexutil.Encode(sig)}
+}
+
+// testIssued is when test proofs were signed, documents are written an hour later
+var testIssued = time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+
+// signedDoc returns a document as putDocument would write it
+func signedDoc(proof *WriterProof, writtenAt time.Time, fields map[string]interface{}) map[string]interface{} {
+	doc := map[string]interface{}{}
+	for key, value := range fields {
+		doc[key] = value
+	}
+	if proof != nil {
+		doc[writerProofField] = proof
+	}
+	if !writtenAt.IsZero() {
+		doc[writtenAtField] = writtenAt.Format(time.RFC3339Nano)
+	}
+	return doc
+}
+
+// entry builds a log entry for a docstore operation, as the docstore encodes it
+func entry(t *testing.T, identityID string, op string, key string, doc map[string]interface{}) accesscontroller.LogEntry {
+	t.Helper()
+
+	var value []byte
+	if doc != nil {
+		var err error
+		if value, err = json.Marshal(doc); err != nil {
+			t.Fatalf("failed to marshal document: %v", err)
+		}
+	}
+	payload, err := operation.NewOperation(&key, op, value).Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal operation: %v", err)
+	}
+	return &testEntry{payload: payload, identity: &identityprovider.Identity{ID: identityID}}
+}
+
+// atClock sets the Lamport time of a test entry, its position in the log
+func atClock(e accesscontroller.LogEntry, clock int) accesscontroller.LogEntry {
+	e.(*testEntry).clock = clock
+	return e
+}
+
+func TestWalletAccessControllerCanAppend(t *testing.T) {
+	const (
+		ownerNode  = "owner-node"
+		writerNode = "writer-node"
+		shopID     = "shop-1"
+	)
+	owner := newTestWallet(t)
+	writer := newTestWallet(t)
+	stranger := newTestWallet(t)
+
+	ac, err := newWalletAccessController(context.Background(), nil, walletAccessManifest(owner.address.Hex(), 1))
+	if err != nil {
+		t.Fatalf("failed to create access controller: %v", err)
+	}
+
+	writtenAt := testIssued.Add(time.Hour)
+	ownerProof := owner.delegate(t, ownerNode, nil)
+	writerProof := writer.delegate(t, writerNode, nil)
+	shopDoc := map[string]interface{}{"id": shopID, "owner": owner.address.Hex(), "name": "Shop"}
+	inventoryDoc := map[string]interface{}{"id": inventoryDocID(shopID)}
+
+	// The owner granted writer, and revoked its own proof for writerNode, in
+	// the first entry of the log
+	revokedProof := owner.delegate(t, writerNode, func(m *auth.SIWEMessage) { m.Nonce = "revoked0" + m.Nonce })
+	grant := atClock(entry(t, ownerNode, "PUT", accessDocID(shopID), signedDoc(ownerProof, testIssued.Add(time.Minute), map[string]interface{}{
+		"id":      accessDocID(shopID),
+		"shopId":  shopID,
+		"writers": []string{writer.address.Hex()},
+		"revoked": []RevokedDelegation{{Nonce: revokedProof.Nonce(), Revoked: writtenAt}},
+		"updated": testIssued.Add(time.Minute),
+	})), 1)
+	// A later access document without the revocation can't undo it
+	regrant := atClock(entry(t, ownerNode, "PUT", accessDocID(shopID), signedDoc(ownerProof, testIssued.Add(2*time.Minute), map[string]interface{}{
+		"id":      accessDocID(shopID),
+		"shopId":  shopID,
+		"writers": []string{writer.address.Hex()},
+		"updated": testIssued.Add(2 * time.Minute),
+	})), 2)
+	// An access document the owner didn't sign grants nothing
+	forgedGrant := entry(t, writerNode, "PUT", accessDocID(shopID), signedDoc(stranger.delegate(t, writerNode, nil), writtenAt, map[string]interface{}{
+		"id":      accessDocID(shopID),
+		"shopId":  shopID,
+		"writers": []string{stranger.address.Hex()},
+		"updated": writtenAt.Add(time.Hour),
+	}))
+	granted := testLog{grant, regrant, forgedGrant}
+
+	tests := []struct {
+		name    string
+		entry   accesscontroller.LogEntry
+		log     testLog
+		allowed bool
+	}{
+		{
+			name:    "owner writes the shop",
+			entry:   entry(t, ownerNode, "PUT", shopID, signedDoc(ownerProof, writtenAt, shopDoc)),
+			allowed: true,
+		},
+		{
+			name:  "document without proof",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(nil, writtenAt, shopDoc)),
+		},
+		{
+			name:  "document without write time",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(ownerProof, time.Time{}, shopDoc)),
+		},
+		{
+			name:  "proof for another identity",
+			entry: entry(t, writerNode, "PUT", shopID, signedDoc(ownerProof, writtenAt, shopDoc)),
+		},
+		{
+			name: "written after the proof expired",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(owner.delegate(t, ownerNode, func(m *auth.SIWEMessage) {
+				m.ExpirationTime = testIssued.Add(30 * time.Minute)
+			}), writtenAt, shopDoc)),
+		},
+		{
+			name:  "written before the proof was issued",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(ownerProof, testIssued.Add(-time.Hour), shopDoc)),
+		},
+		{
+			name:  "written in the future",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(ownerProof, time.Now().Add(time.Hour), shopDoc)),
+		},
+		{
+			name: "login message without expiry",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(owner.delegate(t, ownerNode, func(m *auth.SIWEMessage) {
+				m.ExpirationTime = time.Time{}
+			}), writtenAt, shopDoc)),
+		},
+		{
+			name: "proof signed on another site",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(owner.delegate(t, ownerNode, func(m *auth.SIWEMessage) {
+				m.Domain = "shop.example"
+			}), writtenAt, shopDoc)),
+		},
+		{
+			name: "proof for another chain",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(owner.delegate(t, ownerNode, func(m *auth.SIWEMessage) {
+				m.ChainID = 11155111
+			}), writtenAt, shopDoc)),
+		},
+		{
+			name: "proof signed by another wallet",
+			entry: entry(t, ownerNode, "PUT", shopID, signedDoc(stranger.delegate(t, ownerNode, func(m *auth.SIWEMessage) {
+				m.Address = owner.address.Hex()
+			}), writtenAt, shopDoc)),
+		},
+		{
+			name:  "wallet that wasn't granted",
+			entry: entry(t, writerNode, "PUT", shopID, signedDoc(stranger.delegate(t, writerNode, nil), writtenAt, shopDoc)),
+			log:   granted,
+		},
+		{
+			name:    "granted writer edits the shop",
+			entry:   entry(t, writerNode, "PUT", shopID, signedDoc(writerProof, writtenAt, shopDoc)),
+			log:     granted,
+			allowed: true,
+		},
+		{
+			name:  "writer before being granted",
+			entry: entry(t, writerNode, "PUT", shopID, signedDoc(writerProof, writtenAt, shopDoc)),
+		},
+		{
+			name: "writer changes the owner",
+			entry: entry(t, writerNode, "PUT", shopID, signedDoc(writerProof, writtenAt, map[string]interface{}{
+				"id": shopID, "owner": writer.address.Hex(),
+			})),
+			log: granted,
+		},
+		{
+			name: "writer grants itself",
+			entry: entry(t, writerNode, "PUT", accessDocID(shopID), signedDoc(writerProof, writtenAt, map[string]interface{}{
+				"id": accessDocID(shopID), "writers": []string{writer.address.Hex()},
+			})),
+			log: granted,
+		},
+		{
+			name:    "writer deletes inventory",
+			entry:   entry(t, writerNode, opDelete, inventoryDocID(shopID), signedDoc(writerProof, writtenAt, inventoryDoc)),
+			log:     granted,
+			allowed: true,
+		},
+		{
+			name:  "writer deletes the shop",
+			entry: entry(t, writerNode, opDelete, shopID, signedDoc(writerProof, writtenAt, map[string]interface{}{"id": shopID})),
+			log:   granted,
+		},
+		{
+			name:  "writer deletes the access document",
+			entry: entry(t, writerNode, opDelete, accessDocID(shopID), signedDoc(writerProof, writtenAt, map[string]interface{}{"id": accessDocID(shopID)})),
+			log:   granted,
+		},
+		{
+			name:    "owner deletes the shop",
+			entry:   entry(t, ownerNode, opDelete, shopID, signedDoc(ownerProof, writtenAt, map[string]interface{}{"id": shopID})),
+			allowed: true,
+		},
+		{
+			name:  "delete without proof",
+			entry: entry(t, ownerNode, opDelete, shopID, nil),
+		},
+		{
+			name:  "delete with the proof of another document",
+			entry: entry(t, ownerNode, opDelete, shopID, signedDoc(ownerProof, writtenAt, inventoryDoc)),
+		},
+		{
+			name:    "revoked proof before it was revoked",
+			entry:   entry(t, writerNode, "PUT", shopID, signedDoc(revokedProof, writtenAt.Add(-time.Minute), shopDoc)),
+			log:     granted,
+			allowed: true,
+		},
+		{
+			name:    "revoked proof in an entry concurrent with the revocation",
+			entry:   atClock(entry(t, writerNode, "PUT", shopID, signedDoc(revokedProof, writtenAt.Add(-time.Minute), shopDoc)), 1),
+			log:     granted,
+			allowed: true,
+		},
+		{
+			name:  "revoked proof after it was revoked",
+			entry: entry(t, writerNode, "PUT", shopID, signedDoc(revokedProof, writtenAt, shopDoc)),
+			log:   granted,
+		},
+		{
+			name:  "revoked proof backdated in an entry after the revocation",
+			entry: atClock(entry(t, writerNode, "PUT", shopID, signedDoc(revokedProof, writtenAt.Add(-time.Minute), shopDoc)), 2),
+			log:   granted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ac.CanAppend(tt.entry, trustIdentities{}, tt.log)
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("CanAppend() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnauthorizedWriter) {
+				t.Fatalf("CanAppend() error = %v, want ErrUnauthorizedWriter", err)
+			}
+		})
+	}
+}
+
+func TestWalletAccessManifestChain(t *testing.T) {
+	owner := newTestWallet(t)
+	proof := owner.delegate(t, "node", func(m *auth.SIWEMessage) { m.ChainID = 11155111 })
+	doc := signedDoc(proof, testIssued.Add(time.Hour), map[string]interface{}{"id": "shop-1"})
+
+	// Shops created before the chain was recorded accept proofs for any chain
+	legacy, err := newWalletAccessController(context.Background(), nil, accesscontroller.NewSimpleManifestParams(WalletAccessControllerType, map[string][]string{
+		ownerCapability: {owner.address.Hex()},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create access controller: %v", err)
+	}
+	if err := legacy.CanAppend(entry(t, "node", "PUT", "shop-1", doc), trustIdentities{}, testLog{}); err != nil {
+		t.Errorf("CanAppend() without a chain error = %v", err)
+	}
+
+	mainnet, err := newWalletAccessController(context.Background(), nil, walletAccessManifest(owner.address.Hex(), 1))
+	if err != nil {
+		t.Fatalf("failed to create access controller: %v", err)
+	}
+	if err := mainnet.CanAppend(entry(t, "node", "PUT", "shop-1", doc), trustIdentities{}, testLog{}); !errors.Is(err, ErrUnauthorizedWriter) {
+		t.Errorf("CanAppend() of a testnet proof on mainnet error = %v, want ErrUnauthorizedWriter", err)
+	}
+
+	saved, err := mainnet.Save(context.Background())
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if chains := saved.GetAccess(chainCapability); len(chains) != 1 || chains[0] != "1" {
+		t.Errorf("saved manifest chain = %v, want [1]", chains)
+	}
+}
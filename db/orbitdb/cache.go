@@ -34,29 +34,57 @@ func cloneShop(s *models.Shop) *models.Shop {
 		return nil
 	}
 
-	// Create a copy of the shop
-	clone := &models.Shop{
-		ID:             s.ID,
-		OwnerAddress:   s.OwnerAddress,
-		Name:           s.Name,
-		Description:    s.Description,
-		Location:       s.Location,
-		Email:          s.Email,
-		Phone:          s.Phone,
-		CID:            s.CID,
-		URLName:        s.URLName,
-		Published:      s.Published,
-		PrimaryColor:   s.PrimaryColor,
-		SecondaryColor: s.SecondaryColor,
-		TertiaryColor:  s.TertiaryColor,
-	}
+	// Copy every field, then give the copy its own slices
+	clone := *s
+	clone.Categories = append([]models.Category(nil), s.Categories...)
 
-	// Deep copy items
-	if len(s.Items) > 0 {
+	if s.Items != nil {
 		clone.Items = make([]models.Item, len(s.Items))
-		copy(clone.Items, s.Items)
+		for i, item := range s.Items {
+			item.PhotoPaths = append([]string(nil), item.PhotoPaths...)
+			item.LocalPhotoPaths = append([]string(nil), item.LocalPhotoPaths...)
+			item.Options = cloneOptions(item.Options)
+			item.Variants = cloneVariants(item.Variants)
+			item.Categories = append([]string(nil), item.Categories...)
+			item.Tags = append([]string(nil), item.Tags...)
+			clone.Items[i] = item
+		}
+	}
+
+	return &clone
+}
+
+// cloneOptions makes a deep copy of an item's option groups
+func cloneOptions(options []models.OptionGroup) []models.OptionGroup {
+	if options == nil {
+		return nil
+	}
+	clone := make([]models.OptionGroup, len(options))
+	for i, group := range options {
+		group.Values = append([]string(nil), group.Values...)
+		clone[i] = group
 	}
+	return clone
+}
 
+// cloneVariants makes a deep copy of an item's variants
+func cloneVariants(variants []models.Variant) []models.Variant {
+	if variants == nil {
+		return nil
+	}
+	clone := make([]models.Variant, len(variants))
+	for i, variant := range variants {
+		options := make(map[string]string, len(variant.Options))
+		for name, value := range variant.Options {
+			options[name] = value
+		}
+		variant.Options = options
+		if variant.Price != nil {
+			price := *variant.Price
+			variant.Price = &price
+		}
+		clone[i] = variant
+	}
 	return clone
 }
 
@@ -114,6 +142,16 @@ func (c *ShopCache) Set(id string, shop *models.Shop) {
 	c.lastUsed[id] = time.Now()
 }
 
+// Delete removes a shop from the cache
+func (c *ShopCache) Delete(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.shops, id)
+	delete(c.expiry, id)
+	delete(c.lastUsed, id)
+}
+
 // Clear empties the cache
 func (c *ShopCache) Clear() {
 	c.mutex.Lock()
@@ -0,0 +1,283 @@
+package orbitdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"IndieNode/internal/models"
+
+	"berty.tech/go-orbit-db/iface"
+)
+
+// shopToData converts a shop to the document stored in its OrbitDB docstore.
+// Creation times are kept from existing, which may be nil for a new shop.
+// Local preview paths only make sense on this machine and are not stored.
+func shopToData(shop *models.Shop, existing *ShopData) *ShopData {
+	now := time.Now()
+
+	data := &ShopData{
+		SchemaVersion: CurrentSchemaVersion,
+		ID:            shop.ID,
+		Owner:         shop.OwnerAddress,
+		Name:          shop.Name,
+		Description:   shop.Description,
+		Created:       now,
+		Updated:       now,
+		Content: ShopContent{
+			Theme: ThemeData{
+				PrimaryColor:   rgbaToHex(shop.PrimaryColor),
+				SecondaryColor: rgbaToHex(shop.SecondaryColor),
+				TertiaryColor:  rgbaToHex(shop.TertiaryColor),
+				Template:       shop.Template,
+			},
+			Contact: ContactData{
+				Email:    shop.Email,
+				Phone:    shop.Phone,
+				Location: shop.Location,
+			},
+		},
+		Assets: ShopAssets{
+			LogoCID: shop.LogoPath,
+		},
+		URLName:   shop.URLName,
+		CID:       shop.CID,
+		IPNSName:  shop.IPNSName,
+		ENSName:   shop.ENSName,
+		Published: shop.Published,
+	}
+
+	if len(shop.TemplateOptions) > 0 {
+		data.Content.Theme.TemplateOptions = make(map[string]string, len(shop.TemplateOptions))
+		for name, value := range shop.TemplateOptions {
+			data.Content.Theme.TemplateOptions[name] = value
+		}
+	}
+
+	for _, category := range shop.Categories {
+		data.Content.Categories = append(data.Content.Categories, CategoryData{
+			ID:   category.ID,
+			Name: category.Name,
+		})
+	}
+
+	itemsCreated := make(map[string]time.Time)
+	if existing != nil {
+		if !existing.Created.IsZero() {
+			data.Created = existing.Created
+		}
+		for _, item := range existing.Content.Items {
+			itemsCreated[item.ID] = item.Created
+		}
+	}
+
+	for _, item := range shop.Items {
+		itemData := ItemData{
+			ID:          item.ID,
+			Name:        item.Name,
+			Price:       item.Price,
+			Description: item.Description,
+			ImageCIDs:   append([]string(nil), item.PhotoPaths...),
+			Created:     now,
+			Categories:  append([]string(nil), item.Categories...),
+			Tags:        append([]string(nil), item.Tags...),
+		}
+		for _, group := range item.Options {
+			itemData.Options = append(itemData.Options, OptionGroupData{
+				Name:   group.Name,
+				Values: append([]string(nil), group.Values...),
+			})
+		}
+		for _, variant := range item.Variants {
+			itemData.Variants = append(itemData.Variants, VariantData{
+				ID:       variant.ID,
+				Options:  variant.Options,
+				SKU:      variant.SKU,
+				Price:    variant.Price,
+				ImageCID: variant.PhotoPath,
+			})
+			if variant.PhotoPath != "" {
+				data.Assets.ItemImageCIDs = append(data.Assets.ItemImageCIDs, variant.PhotoPath)
+			}
+		}
+		if created, ok := itemsCreated[item.ID]; ok && !created.IsZero() {
+			itemData.Created = created
+		}
+
+		data.Content.Items = append(data.Content.Items, itemData)
+		data.Assets.ItemImageCIDs = append(data.Assets.ItemImageCIDs, item.PhotoPaths...)
+	}
+
+	return data
+}
+
+// dataToShop converts a stored shop document back to a shop.
+// Stock counts are kept in a separate document, so items and their variants
+// start out unlimited.
+func dataToShop(data *ShopData) *models.Shop {
+	shop := &models.Shop{
+		ID:             data.ID,
+		OwnerAddress:   data.Owner,
+		Name:           data.Name,
+		URLName:        data.URLName,
+		Description:    data.Description,
+		Location:       data.Content.Contact.Location,
+		Email:          data.Content.Contact.Email,
+		Phone:          data.Content.Contact.Phone,
+		PrimaryColor:   hexToRGBA(data.Content.Theme.PrimaryColor),
+		SecondaryColor: hexToRGBA(data.Content.Theme.SecondaryColor),
+		TertiaryColor:  hexToRGBA(data.Content.Theme.TertiaryColor),
+		Template:       data.Content.Theme.Template,
+		LogoPath:       data.Assets.LogoCID,
+		CID:            data.CID,
+		IPNSName:       data.IPNSName,
+		ENSName:        data.ENSName,
+		Published:      data.Published,
+	}
+
+	if len(data.Content.Theme.TemplateOptions) > 0 {
+		shop.TemplateOptions = make(map[string]string, len(data.Content.Theme.TemplateOptions))
+		for name, value := range data.Content.Theme.TemplateOptions {
+			shop.TemplateOptions[name] = value
+		}
+	}
+
+	for _, category := range data.Content.Categories {
+		shop.Categories = append(shop.Categories, models.Category{
+			ID:   category.ID,
+			Name: category.Name,
+		})
+	}
+
+	for _, itemData := range data.Content.Items {
+		item := models.Item{
+			ID:          itemData.ID,
+			Name:        itemData.Name,
+			Price:       itemData.Price,
+			Description: itemData.Description,
+			PhotoPaths:  append([]string(nil), itemData.ImageCIDs...),
+			Inventory:   models.UnlimitedInventory,
+			Categories:  append([]string(nil), itemData.Categories...),
+			Tags:        append([]string(nil), itemData.Tags...),
+		}
+		for _, group := range itemData.Options {
+			item.Options = append(item.Options, models.OptionGroup{
+				Name:   group.Name,
+				Values: append([]string(nil), group.Values...),
+			})
+		}
+		for _, variant := range itemData.Variants {
+			item.Variants = append(item.Variants, models.Variant{
+				ID:        variant.ID,
+				Options:   variant.Options,
+				SKU:       variant.SKU,
+				Price:     variant.Price,
+				PhotoPath: variant.ImageCID,
+				Inventory: models.UnlimitedInventory,
+			})
+		}
+		shop.Items = append(shop.Items, item)
+	}
+
+	return shop
+}
+
+// docToShopData decodes a document returned by a docstore query
+func docToShopData(doc interface{}) (*ShopData, error) {
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	var data ShopData
+	if err := json.Unmarshal(docBytes, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shop data: %w", err)
+	}
+
+	return &data, nil
+}
+
+// shopDataToDoc encodes shop data as a document for a docstore Put
+func shopDataToDoc(data *ShopData) (map[string]interface{}, error) {
+	shopJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal shop data: %w", err)
+	}
+
+	shopDoc := map[string]interface{}{}
+	if err := json.Unmarshal(shopJSON, &shopDoc); err != nil {
+		return nil, fmt.Errorf("failed to prepare shop document: %w", err)
+	}
+
+	return shopDoc, nil
+}
+
+// findShopData looks up the shop document in a shop's docstore, returning nil if there is none.
+// Documents from older schema versions are migrated in memory; they are
+// rewritten the next time the shop is saved.
+func (m *Manager) findShopData(ctx context.Context, docStore iface.DocumentStore, shopID string) (*ShopData, error) {
+	doc, err := m.findShopDocument(ctx, docStore, shopID)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	if from, applied, err := migrateShopDocument(doc); err != nil {
+		// Read what we can, saving is refused until this build is updated
+		log.Printf("Warning: Shop %s: %v", shopID, err)
+	} else if from != CurrentSchemaVersion {
+		log.Printf("Read shop %s from schema version %d (%d changes)", shopID, from, len(applied))
+	}
+
+	return docToShopData(doc)
+}
+
+// findShopDocument returns the raw shop document from a shop's docstore, or nil if there is none
+func (m *Manager) findShopDocument(ctx context.Context, docStore iface.DocumentStore, shopID string) (map[string]interface{}, error) {
+	docs, err := docStore.Query(ctx, func(doc interface{}) (bool, error) {
+		// Match by ID
+		docMap, ok := doc.(map[string]interface{})
+		if !ok {
+			return false, nil
+		}
+
+		id, ok := docMap["id"].(string)
+		return ok && id == shopID, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shop data: %w", err)
+	}
+
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	// Work on a copy so migrations don't touch the store's index
+	return copyDocument(docs[0])
+}
+
+// copyDocument makes a deep copy of a document through JSON
+func copyDocument(doc interface{}) (map[string]interface{}, error) {
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	docMap := map[string]interface{}{}
+	if err := json.Unmarshal(docBytes, &docMap); err != nil {
+		return nil, fmt.Errorf("invalid document format: %w", err)
+	}
+
+	return docMap, nil
+}
+
+// checkSchemaWritable refuses to overwrite documents written by a newer build,
+// which would drop whatever fields it added
+func checkSchemaWritable(existing *ShopData) error {
+	if existing != nil && existing.SchemaVersion > CurrentSchemaVersion {
+		return fmt.Errorf("%w: shop %s uses version %d, this build supports up to %d",
+			ErrUnsupportedSchema, existing.ID, existing.SchemaVersion, CurrentSchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package orbitdb
+
+import (
+	"image/color"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"IndieNode/internal/models"
+)
+
+// storedShop is a shop holding only what the codec stores, so it survives a
+// round trip unchanged
+type storedShop struct {
+	shop *models.Shop
+}
+
+// maxEntries caps the items, variants and list entries of a generated shop
+const maxEntries = 4
+
+// Generate makes a random shop for quick.Check
+func (storedShop) Generate(r *rand.Rand, size int) reflect.Value {
+	shop := &models.Shop{
+		ID:              randString(r, size),
+		OwnerAddress:    randString(r, size),
+		Name:            randString(r, size),
+		URLName:         randString(r, size),
+		Description:     randString(r, size),
+		Location:        randString(r, size),
+		Email:           randString(r, size),
+		Phone:           randString(r, size),
+		PrimaryColor:    randColor(r),
+		SecondaryColor:  randColor(r),
+		TertiaryColor:   randColor(r),
+		Template:        randString(r, size),
+		TemplateOptions: randMap(r, size),
+		LogoPath:        randString(r, size),
+		CID:             randString(r, size),
+		IPNSName:        randString(r, size),
+		ENSName:         randString(r, size),
+		Published:       r.Intn(2) == 0,
+	}
+
+	for i := r.Intn(maxEntries + 1); i > 0; i-- {
+		shop.Categories = append(shop.Categories, models.Category{ID: randString(r, size), Name: randString(r, size)})
+	}
+
+	for i := r.Intn(maxEntries + 1); i > 0; i-- {
+		item := models.Item{
+			ID:          randString(r, size),
+			Name:        randString(r, size),
+			Price:       r.Float64() * 1000,
+			Description: randString(r, size),
+			PhotoPaths:  randStrings(r, size),
+			Inventory:   models.UnlimitedInventory,
+			Categories:  randStrings(r, size),
+			Tags:        randStrings(r, size),
+		}
+		for j := r.Intn(3); j > 0; j-- {
+			item.Options = append(item.Options, models.OptionGroup{Name: randString(r, size), Values: randStrings(r, size)})
+		}
+		for j := r.Intn(maxEntries + 1); j > 0; j-- {
+			variant := models.Variant{
+				ID:        randString(r, size),
+				Options:   randMap(r, size),
+				SKU:       randString(r, size),
+				PhotoPath: randString(r, size),
+				Inventory: models.UnlimitedInventory,
+			}
+			if r.Intn(2) == 0 {
+				price := r.Float64() * 1000
+				variant.Price = &price
+			}
+			item.Variants = append(item.Variants, variant)
+		}
+		shop.Items = append(shop.Items, item)
+	}
+
+	return reflect.ValueOf(storedShop{shop})
+}
+
+// randString returns up to size random runes, including ones JSON escapes
+func randString(r *rand.Rand, size int) string {
+	const runes = "abcXYZ019 -_#/.\"\\<>&\u00e9\u65e5\U0001f6cd\n\t"
+	chars := []rune(runes)
+
+	s := make([]rune, r.Intn(size+1))
+	for i := range s {
+		s[i] = chars[r.Intn(len(chars))]
+	}
+	return string(s)
+}
+
+// randStrings returns up to maxEntries random strings, nil if there are none
+func randStrings(r *rand.Rand, size int) []string {
+	var s []string
+	for i := r.Intn(maxEntries + 1); i > 0; i-- {
+		s = append(s, randString(r, size))
+	}
+	return s
+}
+
+// randMap returns up to maxEntries random entries, nil if there are none
+func randMap(r *rand.Rand, size int) map[string]string {
+	n := r.Intn(maxEntries + 1)
+	if n == 0 {
+		return nil
+	}
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		m[randString(r, size)] = randString(r, size)
+	}
+	return m
+}
+
+// randColor returns a random color, opaque half of the time
+func randColor(r *rand.Rand) color.RGBA {
+	c := color.RGBA{R: uint8(r.Intn(256)), G: uint8(r.Intn(256)), B: uint8(r.Intn(256)), A: 255}
+	if r.Intn(2) == 0 {
+		c.A = uint8(r.Intn(256))
+	}
+	return c
+}
+
+// roundTrip stores shop the way SaveShop does and reads it back the way GetShop does
+func roundTrip(t *testing.T, shop *models.Shop, existing *ShopData) (*models.Shop, *ShopData) {
+	t.Helper()
+
+	doc, err := shopDataToDoc(shopToData(shop, existing))
+	if err != nil {
+		t.Fatalf("shopDataToDoc() error = %v", err)
+	}
+	data, err := docToShopData(doc)
+	if err != nil {
+		t.Fatalf("docToShopData() error = %v", err)
+	}
+	return dataToShop(data), data
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	property := func(s storedShop) bool {
+		got, _ := roundTrip(t, s.shop, nil)
+		if !reflect.DeepEqual(got, s.shop) {
+			t.Logf("round trip of\n%+v\ngave\n%+v", s.shop, got)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCodecRoundTripIsStable(t *testing.T) {
+	// Saving a shop that was just read writes the same document again
+	property := func(s storedShop) bool {
+		first, stored := roundTrip(t, s.shop, nil)
+		_, again := roundTrip(t, first, stored)
+
+		again.Updated = stored.Updated
+		if !reflect.DeepEqual(again, stored) {
+			t.Logf("saving again changed\n%+v\nto\n%+v", stored, again)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCodecDropsLocalPaths(t *testing.T) {
+	price := 12.5
+	shop := &models.Shop{
+		ID:            "shop",
+		LogoPath:      "/ipfs/logo",
+		LocalLogoPath: "/home/me/logo.png",
+		Items: []models.Item{{
+			ID:              "mug",
+			PhotoPaths:      []string{"/ipfs/mug"},
+			LocalPhotoPaths: []string{"/home/me/mug.png"},
+			Inventory:       4,
+			Variants: []models.Variant{
+				{ID: "red", Price: &price, PhotoPath: "/ipfs/red", LocalPhotoPath: "/home/me/red.png", Inventory: 2},
+			},
+		}},
+	}
+
+	got, data := roundTrip(t, shop, nil)
+
+	if got.LocalLogoPath != "" || got.Items[0].LocalPhotoPaths != nil || got.Items[0].Variants[0].LocalPhotoPath != "" {
+		t.Errorf("local paths were stored: %+v", got)
+	}
+	// Stock lives in the inventory document
+	if got.Items[0].Inventory != models.UnlimitedInventory || got.Items[0].Variants[0].Inventory != models.UnlimitedInventory {
+		t.Errorf("stock was stored: %+v", got.Items[0])
+	}
+	if want := []string{"/ipfs/red", "/ipfs/mug"}; !reflect.DeepEqual(data.Assets.ItemImageCIDs, want) {
+		t.Errorf("ItemImageCIDs = %v, want %v", data.Assets.ItemImageCIDs, want)
+	}
+	if data.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", data.SchemaVersion, CurrentSchemaVersion)
+	}
+}
+
+func TestCodecKeepsCreationTimes(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	existing := &ShopData{
+		Created: created,
+		Content: ShopContent{Items: []ItemData{{ID: "mug", Created: created}}},
+	}
+	shop := &models.Shop{ID: "shop", Items: []models.Item{{ID: "mug"}, {ID: "shirt"}}}
+
+	data := shopToData(shop, existing)
+
+	if !data.Created.Equal(created) || !data.Content.Items[0].Created.Equal(created) {
+		t.Errorf("creation times = %s, %s, want %s", data.Created, data.Content.Items[0].Created, created)
+	}
+	if !data.Content.Items[1].Created.After(created) || !data.Updated.After(created) {
+		t.Errorf("new item created %s, updated %s, want now", data.Content.Items[1].Created, data.Updated)
+	}
+}
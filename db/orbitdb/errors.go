@@ -0,0 +1,35 @@
+package orbitdb
+
+import "errors"
+
+var (
+	// ErrShopNotFound is returned when no shop exists with the requested ID
+	ErrShopNotFound = errors.New("shop not found")
+
+	// ErrShopExists is returned when creating a shop whose ID is already taken
+	ErrShopExists = errors.New("shop already exists")
+
+	// ErrInvalidOrder is returned when an order is missing required fields
+	ErrInvalidOrder = errors.New("invalid order")
+
+	// ErrOrderNotFound is returned when no order exists with the requested ID
+	ErrOrderNotFound = errors.New("order not found")
+
+	// ErrDuplicateOrder is returned when an order already exists for a transaction
+	ErrDuplicateOrder = errors.New("order already exists for transaction")
+
+	// ErrItemNotFound is returned when a shop has no item with the requested ID
+	ErrItemNotFound = errors.New("item not found")
+
+	// ErrOutOfStock is returned when an item doesn't have enough stock left for a purchase
+	ErrOutOfStock = errors.New("item out of stock")
+
+	// ErrUnsupportedSchema is returned for shop documents written by a newer version of IndieNode
+	ErrUnsupportedSchema = errors.New("unsupported shop schema version")
+
+	// ErrUnauthorizedWriter is returned for writes not authorised by the shop owner's wallet
+	ErrUnauthorizedWriter = errors.New("writer not authorised for this shop")
+
+	// ErrInvalidWriter is returned when granting or revoking access for a malformed wallet address
+	ErrInvalidWriter = errors.New("invalid writer address")
+)
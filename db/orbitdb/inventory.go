@@ -0,0 +1,351 @@
+package orbitdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"IndieNode/internal/models"
+
+	"berty.tech/go-orbit-db/iface"
+)
+
+// inventoryDocID returns the ID of a shop's inventory document in its docstore
+func inventoryDocID(shopID string) string {
+	return "inventory-" + shopID
+}
+
+// GetInventory returns the stock counts of a shop, or nil if none were saved yet
+func (m *Manager) GetInventory(ctx context.Context, shopID string) (*ShopInventoryData, error) {
+	if !m.IsConnected() {
+		return nil, fmt.Errorf("not connected to OrbitDB")
+	}
+
+	docStore, err := m.GetShopDatabase(ctx, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shop database: %w", err)
+	}
+
+	return m.readInventory(ctx, docStore, shopID)
+}
+
+// DecrementInventory removes quantity units of an item, or of one of its
+// variants if variantID is set, from stock and returns what is left. Items
+// with unlimited stock are left unchanged. Returns ErrOutOfStock without
+// changing anything if there isn't enough stock.
+func (m *Manager) DecrementInventory(ctx context.Context, shopID string, itemID string, variantID string, quantity int64) (int64, error) {
+	if quantity <= 0 {
+		return 0, fmt.Errorf("quantity must be positive")
+	}
+	return m.adjustInventory(ctx, shopID, itemID, variantID, -quantity)
+}
+
+// RestockInventory puts quantity units of an item or variant back into stock,
+// for example when a payment fails
+func (m *Manager) RestockInventory(ctx context.Context, shopID string, itemID string, variantID string, quantity int64) (int64, error) {
+	if quantity <= 0 {
+		return 0, fmt.Errorf("quantity must be positive")
+	}
+	return m.adjustInventory(ctx, shopID, itemID, variantID, quantity)
+}
+
+// adjustInventory changes the stock of an item or variant by delta. The
+// read-modify-write is serialised so concurrent purchases can't both take the
+// last unit.
+func (m *Manager) adjustInventory(ctx context.Context, shopID string, itemID string, variantID string, delta int64) (int64, error) {
+	if !m.IsConnected() {
+		return 0, fmt.Errorf("not connected to OrbitDB")
+	}
+
+	m.inventoryMutex.Lock()
+	defer m.inventoryMutex.Unlock()
+
+	docStore, err := m.GetShopDatabase(ctx, shopID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get shop database: %w", err)
+	}
+
+	inventory, err := m.readInventory(ctx, docStore, shopID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Shops saved before stock was tracked have no inventory, so nothing is limited
+	if inventory == nil {
+		return models.UnlimitedInventory, nil
+	}
+
+	for i := range inventory.Items {
+		item := &inventory.Items[i]
+		if item.ID != itemID {
+			continue
+		}
+
+		// Items with variants keep their stock per variant
+		stock, name := &item.Inventory, itemID
+		if variantID != "" {
+			stock = nil
+			for j := range item.Variants {
+				if item.Variants[j].ID == variantID {
+					stock, name = &item.Variants[j].Inventory, itemID+" ("+variantID+")"
+					break
+				}
+			}
+			if stock == nil {
+				return 0, fmt.Errorf("%w: %s variant %s", ErrItemNotFound, itemID, variantID)
+			}
+		}
+		if *stock == models.UnlimitedInventory {
+			return models.UnlimitedInventory, nil
+		}
+
+		remaining := *stock + delta
+		if remaining < 0 {
+			return *stock, fmt.Errorf("%w: %s has %d left", ErrOutOfStock, name, *stock)
+		}
+
+		*stock = remaining
+		item.Updated = time.Now()
+		inventory.LastUpdated = item.Updated
+
+		if err := m.writeInventory(ctx, docStore, inventory); err != nil {
+			return 0, err
+		}
+
+		// The cached shop carries stock counts too
+		m.invalidateShop(shopID)
+
+		log.Printf("Inventory for %s in shop %s is now %d", name, shopID, remaining)
+		return remaining, nil
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrItemNotFound, itemID)
+}
+
+// StockCounts are the stock counts of a shop's items and variants as a caller
+// loaded them, see SnapshotStock
+type StockCounts map[string]int64
+
+// stockKey identifies an item, or one of its variants if variantID is set, in
+// StockCounts. IDs can't contain the NUL separator, so an item's key never
+// matches one of another item's variants.
+func stockKey(itemID string, variantID string) string {
+	if variantID == "" {
+		return itemID
+	}
+	return itemID + "\x00" + variantID
+}
+
+// SnapshotStock records the stock counts of a shop loaded with GetShop, to be
+// passed to UpdateShop along with the changed shop
+func SnapshotStock(shop *models.Shop) StockCounts {
+	stock := make(StockCounts)
+	for _, item := range shop.Items {
+		stock[stockKey(item.ID, "")] = item.Inventory
+		for _, variant := range item.Variants {
+			stock[stockKey(item.ID, variant.ID)] = variant.Inventory
+		}
+	}
+	return stock
+}
+
+// changedStock returns what the stock of an item or variant should become when
+// the shop is saved with requested. Counts that differ from what the caller
+// loaded are applied to the current count as a change, so sales made since the
+// shop was loaded aren't undone. Counts the caller didn't load, or that switch
+// to or from unlimited, are set as they are.
+func changedStock(current int64, requested int64, loaded StockCounts, key string) int64 {
+	base, ok := loaded[key]
+	if !ok {
+		return current
+	}
+	if requested == base {
+		return current
+	}
+	if requested == models.UnlimitedInventory || base == models.UnlimitedInventory || current == models.UnlimitedInventory {
+		return requested
+	}
+	if stock := current + requested - base; stock > 0 {
+		return stock
+	}
+	return 0
+}
+
+// saveInventory updates the stock counts of a shop's items after it was saved.
+// loaded holds the counts the caller started from, nil if it didn't change any.
+// Items and variants that weren't stocked yet get the shop's counts as they are.
+// The document is only written if something changed.
+func (m *Manager) saveInventory(ctx context.Context, docStore iface.DocumentStore, shop *models.Shop, loaded StockCounts) error {
+	// Sales take the same lock, see adjustInventory
+	m.inventoryMutex.Lock()
+	defer m.inventoryMutex.Unlock()
+
+	existing, err := m.readInventory(ctx, docStore, shop.ID)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]ItemInventory)
+	currentVariants := make(map[string]int64)
+	if existing != nil {
+		for _, item := range existing.Items {
+			current[item.ID] = item
+			for _, variant := range item.Variants {
+				currentVariants[stockKey(item.ID, variant.ID)] = variant.Inventory
+			}
+		}
+	}
+
+	now := time.Now()
+	inventory := &ShopInventoryData{
+		SchemaVersion: CurrentSchemaVersion,
+		ID:            inventoryDocID(shop.ID),
+		ShopID:        shop.ID,
+		OwnerID:       shop.OwnerAddress,
+		LastUpdated:   now,
+	}
+	// Removed items show up as a different count, new ones below
+	changed := existing == nil || len(existing.Items) != len(shop.Items)
+
+	for _, item := range shop.Items {
+		itemInventory := ItemInventory{
+			ID:          item.ID,
+			Name:        item.Name,
+			Price:       item.Price,
+			Description: item.Description,
+			ImageCIDs:   item.PhotoPaths,
+			Inventory:   item.Inventory,
+			Created:     now,
+			Updated:     now,
+		}
+
+		stocked, ok := current[item.ID]
+		if ok {
+			itemInventory.Inventory = changedStock(stocked.Inventory, item.Inventory, loaded, stockKey(item.ID, ""))
+			itemInventory.Created, itemInventory.Updated = stocked.Created, stocked.Updated
+			if itemInventory.Inventory != stocked.Inventory || itemInventory.Name != stocked.Name ||
+				itemInventory.Price != stocked.Price || len(item.Variants) != len(stocked.Variants) {
+				itemInventory.Updated = now
+				changed = true
+			}
+		} else {
+			changed = true
+		}
+
+		for _, variant := range item.Variants {
+			key := stockKey(item.ID, variant.ID)
+			variantInventory := VariantInventory{
+				ID:        variant.ID,
+				SKU:       variant.SKU,
+				Inventory: variant.Inventory,
+			}
+			if count, ok := currentVariants[key]; ok {
+				variantInventory.Inventory = changedStock(count, variant.Inventory, loaded, key)
+				if variantInventory.Inventory != count {
+					itemInventory.Updated = now
+					changed = true
+				}
+			} else {
+				changed = true
+			}
+			itemInventory.Variants = append(itemInventory.Variants, variantInventory)
+		}
+
+		inventory.Items = append(inventory.Items, itemInventory)
+	}
+
+	if !changed {
+		return nil
+	}
+	return m.writeInventory(ctx, docStore, inventory)
+}
+
+// applyInventory copies stock counts from the inventory document onto a shop's items and their variants
+func (m *Manager) applyInventory(ctx context.Context, docStore iface.DocumentStore, shop *models.Shop) error {
+	inventory, err := m.readInventory(ctx, docStore, shop.ID)
+	if err != nil {
+		return err
+	}
+
+	stock := make(map[string]int64)
+	variantStock := make(map[string]map[string]int64)
+	if inventory != nil {
+		for _, item := range inventory.Items {
+			stock[item.ID] = item.Inventory
+			variantStock[item.ID] = make(map[string]int64, len(item.Variants))
+			for _, variant := range item.Variants {
+				variantStock[item.ID][variant.ID] = variant.Inventory
+			}
+		}
+	}
+
+	for i := range shop.Items {
+		item := &shop.Items[i]
+		if count, ok := stock[item.ID]; ok {
+			item.Inventory = count
+		} else {
+			item.Inventory = models.UnlimitedInventory
+		}
+		for j := range item.Variants {
+			if count, ok := variantStock[item.ID][item.Variants[j].ID]; ok {
+				item.Variants[j].Inventory = count
+			} else {
+				item.Variants[j].Inventory = models.UnlimitedInventory
+			}
+		}
+	}
+
+	return nil
+}
+
+// readInventory loads a shop's inventory document, returning nil if there is none
+func (m *Manager) readInventory(ctx context.Context, docStore iface.DocumentStore, shopID string) (*ShopInventoryData, error) {
+	docID := inventoryDocID(shopID)
+	docs, err := docStore.Query(ctx, func(doc interface{}) (bool, error) {
+		docMap, ok := doc.(map[string]interface{})
+		if !ok {
+			return false, nil
+		}
+		id, ok := docMap["id"].(string)
+		return ok && id == docID, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inventory: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	// Round-trip through JSON to get the typed document back
+	data, err := json.Marshal(docs[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory: %w", err)
+	}
+	var inventory ShopInventoryData
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		return nil, fmt.Errorf("failed to read inventory: %w", err)
+	}
+
+	return &inventory, nil
+}
+
+// writeInventory stores an inventory document
+func (m *Manager) writeInventory(ctx context.Context, docStore iface.DocumentStore, inventory *ShopInventoryData) error {
+	data, err := json.Marshal(inventory)
+	if err != nil {
+		return fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to prepare inventory document: %w", err)
+	}
+
+	if err := m.putDocument(ctx, docStore, doc); err != nil {
+		return fmt.Errorf("failed to store inventory: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package orbitdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"IndieNode/internal/models"
+)
+
+func TestChangedStock(t *testing.T) {
+	const unlimited = models.UnlimitedInventory
+
+	tests := []struct {
+		name      string
+		current   int64 // Stock in the inventory document now
+		loaded    int64 // Stock when the caller loaded the shop
+		requested int64
+		notLoaded bool
+		want      int64
+	}{
+		{name: "unchanged keeps sales made since loading", current: 3, loaded: 5, requested: 5, want: 3},
+		{name: "restock is added to current", current: 3, loaded: 5, requested: 10, want: 8},
+		{name: "reduction is taken from current", current: 3, loaded: 5, requested: 4, want: 2},
+		{name: "reduction never goes below zero", current: 1, loaded: 5, requested: 0, want: 0},
+		{name: "not loaded keeps current", current: 3, requested: 10, notLoaded: true, want: 3},
+		{name: "becomes unlimited", current: 3, loaded: 5, requested: unlimited, want: unlimited},
+		{name: "becomes limited", current: unlimited, loaded: unlimited, requested: 7, want: 7},
+		{name: "limited after the current count was reset", current: unlimited, loaded: 5, requested: 7, want: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded := StockCounts{"mug": tt.loaded}
+			if tt.notLoaded {
+				loaded = nil
+			}
+			if got := changedStock(tt.current, tt.requested, loaded, "mug"); got != tt.want {
+				t.Errorf("changedStock(%d, %d) = %d, want %d", tt.current, tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotStock(t *testing.T) {
+	shop := &models.Shop{
+		Items: []models.Item{
+			{ID: "mug", Inventory: 4},
+			{
+				ID:        "shirt",
+				Inventory: models.UnlimitedInventory,
+				Variants: []models.Variant{
+					{ID: "m", Inventory: 2},
+					{ID: "l", Inventory: 0},
+				},
+			},
+		},
+	}
+
+	stock := SnapshotStock(shop)
+	want := map[string]int64{
+		stockKey("mug", ""):    4,
+		stockKey("shirt", ""):  models.UnlimitedInventory,
+		stockKey("shirt", "m"): 2,
+		stockKey("shirt", "l"): 0,
+	}
+	if len(stock) != len(want) {
+		t.Fatalf("SnapshotStock() = %v, want %v", stock, want)
+	}
+	for key, count := range want {
+		if got, ok := stock[key]; !ok || got != count {
+			t.Errorf("SnapshotStock()[%q] = %d, want %d", key, got, count)
+		}
+	}
+}
+
+func TestStockKey(t *testing.T) {
+	// IDs from before they were validated may contain the old separator
+	if stockKey("shirt/m", "") == stockKey("shirt", "m") {
+		t.Errorf("item shirt/m and variant m of shirt share the key %q", stockKey("shirt", "m"))
+	}
+	if stockKey("shirt", "m/l") == stockKey("shirt/m", "l") {
+		t.Errorf("variant m/l of shirt and variant l of shirt/m share the key %q", stockKey("shirt", "m/l"))
+	}
+}
+
+func TestDecrementInventoryConcurrent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an IPFS node")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	m, owner := newTestOwnerManager(t, ctx)
+	shop := &models.Shop{
+		ID:           "clay",
+		Name:         "Clay",
+		OwnerAddress: owner.address.Hex(),
+		Items: []models.Item{
+			{ID: "mug", Name: "Mug", Price: 12, Inventory: 3},
+			{
+				ID:      "shirt",
+				Name:    "T-Shirt",
+				Price:   20,
+				Options: []models.OptionGroup{{Name: "Size", Values: []string{"M"}}},
+				Variants: []models.Variant{
+					{ID: "m", Options: map[string]string{"Size": "M"}, Inventory: 2},
+				},
+			},
+		},
+	}
+	if err := m.StoreShop(shop); err != nil {
+		t.Fatalf("StoreShop() error = %v", err)
+	}
+
+	tests := []struct {
+		itemID    string
+		variantID string
+		stock     int
+	}{
+		{itemID: "mug", stock: 3},
+		{itemID: "shirt", variantID: "m", stock: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.itemID+tt.variantID, func(t *testing.T) {
+			// More buyers than units, all at once
+			const buyers = 8
+			var wg sync.WaitGroup
+			errs := make(chan error, buyers)
+			for i := 0; i < buyers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := m.DecrementInventory(ctx, shop.ID, tt.itemID, tt.variantID, 1)
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			sold, outOfStock := 0, 0
+			for err := range errs {
+				switch {
+				case err == nil:
+					sold++
+				case errors.Is(err, ErrOutOfStock):
+					outOfStock++
+				default:
+					t.Errorf("DecrementInventory() error = %v", err)
+				}
+			}
+			if sold != tt.stock || outOfStock != buyers-tt.stock {
+				t.Errorf("sold %d and refused %d, want %d and %d", sold, outOfStock, tt.stock, buyers-tt.stock)
+			}
+
+			stock := SnapshotStock(mustGetShop(t, ctx, m, shop.ID))
+			if left := stock[stockKey(tt.itemID, tt.variantID)]; left != 0 {
+				t.Errorf("%d left in stock, want 0", left)
+			}
+		})
+	}
+}
+
+// mustGetShop loads a shop with its stock counts
+func mustGetShop(t *testing.T, ctx context.Context, m *Manager, shopID string) *models.Shop {
+	t.Helper()
+
+	shop, err := m.GetShop(ctx, shopID)
+	if err != nil {
+		t.Fatalf("GetShop() error = %v", err)
+	}
+	return shop
+}
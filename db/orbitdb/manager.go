@@ -19,6 +19,7 @@ import (
 	orbitdb "berty.tech/go-orbit-db"
 	"berty.tech/go-orbit-db/iface"
 	"berty.tech/go-orbit-db/stores/documentstore"
+	"github.com/ethereum/go-ethereum/common"
 	iface_ipfs "github.com/ipfs/interface-go-ipfs-core"
 	// TODO: Install OrbitDB dependencies:
 	// go get github.com/berty/go-orbit-db
@@ -33,9 +34,11 @@ func NewManager(ctx context.Context, config *Config, ipfsAPI iface_ipfs.CoreAPI)
 	}
 
 	manager := &Manager{
-		ctx:     ctx,
-		config:  config,
-		shopDBs: make(map[string]iface.DocumentStore), // Initialize database cache
+		ctx:      ctx,
+		config:   config,
+		shopDBs:  make(map[string]iface.DocumentStore), // Initialize database cache
+		orderDBs: make(map[string]iface.EventLogStore),
+		mirrors:  make(map[string]*mirrorState),
 	}
 
 	// Initialize shop data storage
@@ -82,6 +85,12 @@ func (m *Manager) connect(ipfsAPI iface_ipfs.CoreAPI) error {
 	m.orbitDB = orbDB
 	log.Printf("Successfully initialized OrbitDB")
 
+	// Replicas need the controller registered to check entries of shops they open
+	if err := orbDB.RegisterAccessControllerType(newWalletAccessController); err != nil {
+		return fmt.Errorf("failed to register wallet access controller: %w", err)
+	}
+	m.loadWriterProof()
+
 	// Scan for existing shop databases and reconnect to them
 	if err := m.reconnectExistingDatabases(); err != nil {
 		log.Printf("Warning: Some existing shops couldn't be reconnected: %v", err)
@@ -102,6 +111,7 @@ func (m *Manager) Close() error {
 	// Close all open databases
 	m.dbsMutex.Lock()
 	for shopID, db := range m.shopDBs {
+		m.unwatchMirror(shopID)
 		log.Printf("Closing database for shop: %s", shopID)
 		if err := db.Close(); err != nil {
 			log.Printf("Error closing database for shop %s: %v", shopID, err)
@@ -109,6 +119,15 @@ func (m *Manager) Close() error {
 	}
 	// Clear the map
 	m.shopDBs = make(map[string]iface.DocumentStore)
+
+	// Close all order logs
+	for shopID, db := range m.orderDBs {
+		log.Printf("Closing order log for shop: %s", shopID)
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing order log for shop %s: %v", shopID, err)
+		}
+	}
+	m.orderDBs = make(map[string]iface.EventLogStore)
 	m.dbsMutex.Unlock()
 
 	// Close OrbitDB instance
@@ -130,18 +149,40 @@ func (m *Manager) IsConnected() bool {
 	return m.isConnected
 }
 
-// VerifyOwnership checks if the given address is authorized to perform operations
-func (m *Manager) VerifyOwnership(ownerAddress string) bool {
-	// For now, we'll implement a basic ownership check
-	// In a production environment, you might want to add more sophisticated checks
-	// such as verifying signatures or checking against a smart contract
+// ShopExists returns true if shop metadata exists for the given ID
+func (m *Manager) ShopExists(ctx context.Context, shopID string) (bool, error) {
+	metadata, err := m.GetShopMetadata(ctx, shopID)
+	if err != nil {
+		return false, err
+	}
+	return metadata != nil, nil
+}
 
-	if ownerAddress == "" {
-		return false
+// invalidateShop removes a shop from the cache after it changes
+func (m *Manager) invalidateShop(shopID string) {
+	if m.shopCache != nil {
+		m.shopCache.Delete(shopID)
+	}
+}
+
+// VerifyOwnership checks whether address is the owner recorded for a shop.
+// Addresses are compared as Ethereum addresses, so checksum casing doesn't matter.
+func (m *Manager) VerifyOwnership(ctx context.Context, shopID string, address string) (bool, error) {
+	if !common.IsHexAddress(address) {
+		return false, nil
+	}
+
+	shop, err := m.GetShop(ctx, shopID)
+	if err != nil {
+		return false, err
 	}
 
-	// Add your additional ownership verification logic here if needed
-	return true
+	if !common.IsHexAddress(shop.OwnerAddress) {
+		log.Printf("Shop %s has no valid owner address, denying access", shopID)
+		return false, nil
+	}
+
+	return common.HexToAddress(shop.OwnerAddress) == common.HexToAddress(address), nil
 }
 
 // GetDatabasePath returns the current OrbitDB database directory path
@@ -163,96 +204,73 @@ func (m *Manager) StoreShop(shop *models.Shop) error {
 		shop.ID = shop.OwnerAddress
 	}
 
-	// Get or create the document store for this shop
-	docStore, err := m.GetShopDatabase(context.Background(), shop.ID)
-	if err != nil {
-		return fmt.Errorf("failed to get shop database: %w", err)
-	}
-
-	// Convert shop to ShopData for storage
-	shopData := &ShopData{
-		ID:          shop.ID,
-		Owner:       shop.OwnerAddress,
-		Name:        shop.Name,
-		Description: shop.Description,
-		Created:     time.Now(), // Use current time if not provided
-		Updated:     time.Now(),
-		Content: ShopContent{
-			Theme: ThemeData{
-				PrimaryColor:   rgbaToHex(shop.PrimaryColor),
-				SecondaryColor: rgbaToHex(shop.SecondaryColor),
-				TertiaryColor:  rgbaToHex(shop.TertiaryColor),
-			},
-			Contact: ContactData{
-				Email:    shop.Email,
-				Phone:    shop.Phone,
-				Location: shop.Location,
-			},
-		},
-		Assets: ShopAssets{
-			LogoCID: shop.CID, // Use the shop's CID for now
-		},
-		OrbitDBAddress: docStore.Address().String(),
-	}
-
-	// Map items from models.Shop to ShopData
-	for _, item := range shop.Items {
-		itemData := ItemData{
-			ID:          item.ID,
-			Name:        item.Name,
-			Price:       item.Price,
-			Description: item.Description,
-			Created:     time.Now(), // Use current time if not provided
-		}
+	ctx := context.Background()
 
-		// Map photo paths to CIDs (simplified for now)
-		for _, photoPath := range item.PhotoPaths {
-			// In a real implementation, you'd get actual CIDs
-			itemData.ImageCIDs = append(itemData.ImageCIDs, photoPath)
+	// A new shop's database is created with its owner in the access controller,
+	// so the owner has to be recorded first
+	if metadata, err := m.GetShopMetadata(ctx, shop.ID); err != nil {
+		return fmt.Errorf("failed to get shop metadata: %w", err)
+	} else if metadata == nil {
+		if _, err := m.saveShopMetadata(ctx, shop, ""); err != nil {
+			return fmt.Errorf("failed to save shop metadata: %w", err)
 		}
+	}
 
-		shopData.Content.Items = append(shopData.Content.Items, itemData)
+	// Get or create the document store for this shop
+	docStore, err := m.GetShopDatabase(ctx, shop.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get shop database: %w", err)
 	}
 
-	// Convert to JSON for OrbitDB storage
-	shopJSON, err := json.Marshal(shopData)
+	// Keep creation times if the shop was stored before
+	existing, err := m.findShopData(ctx, docStore, shop.ID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal shop data: %w", err)
+		return err
+	}
+	if err := checkSchemaWritable(existing); err != nil {
+		return err
 	}
 
-	// Create the document with the shop ID as document ID
-	shopDoc := map[string]interface{}{}
-	if err := json.Unmarshal(shopJSON, &shopDoc); err != nil {
-		return fmt.Errorf("failed to prepare shop document: %w", err)
+	// Convert shop to ShopData for storage
+	shopData := shopToData(shop, existing)
+	shopData.OrbitDBAddress = docStore.Address().String()
+
+	shopDoc, err := shopDataToDoc(shopData)
+	if err != nil {
+		return err
 	}
 
 	// Store in OrbitDB
-	ctx := context.Background()
-	_, err = docStore.Put(ctx, shopDoc)
-	if err != nil {
+	if err := m.putDocument(ctx, docStore, shopDoc); err != nil {
 		return fmt.Errorf("failed to store shop in OrbitDB: %w", err)
 	}
 
-	// Save metadata separately for easy address retrieval
-	metadata := &ShopMetadata{
-		ID:             shop.ID,
-		Name:           shop.Name,
-		Owner:          shop.OwnerAddress,
-		OrbitDBAddress: docStore.Address().String(),
+	// Save stock counts alongside the shop, those already stocked are left to UpdateShop
+	if err := m.saveInventory(ctx, docStore, shop, nil); err != nil {
+		return err
 	}
 
-	if err := m.SaveShopMetadata(ctx, metadata); err != nil {
+	// Save metadata separately for easy address retrieval
+	metadata, err := m.saveShopMetadata(ctx, shop, docStore.Address().String())
+	if err != nil {
 		return fmt.Errorf("failed to save shop metadata: %w", err)
 	}
 
+	// Drop any stale cached copy
+	m.invalidateShop(shop.ID)
+
 	log.Printf("Successfully stored shop '%s' (ID: %s) in OrbitDB at address: %s",
 		shop.Name, shop.ID, metadata.OrbitDBAddress)
 	return nil
 }
 
-// rgbaToHex converts a color.RGBA to a hex string representation
+// rgbaToHex converts a color.RGBA to a hex string representation.
+// Opaque colors use #rrggbb, anything else keeps its alpha as #rrggbbaa.
 func rgbaToHex(c color.RGBA) string {
-	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
 }
 
 // GetShopCount returns the total number of shops in the database
@@ -339,6 +357,11 @@ func (m *Manager) reconnectExistingDatabases() error {
 			continue
 		}
 
+		// Keep following shops mirrored from other nodes
+		if metadata.Mirror {
+			m.watchMirror(metadata, docStore)
+		}
+
 		// Cache the database
 		m.dbsMutex.Lock()
 		m.shopDBs[shopID] = docStore
@@ -372,6 +395,7 @@ func (m *Manager) GetConnectedDatabases() []DatabaseInfo {
 		info := DatabaseInfo{
 			ShopID:  shopID,
 			Address: db.Address().String(),
+			Mirror:  m.GetMirrorStatus(shopID),
 		}
 		result = append(result, info)
 	}
@@ -383,6 +407,7 @@ func (m *Manager) GetConnectedDatabases() []DatabaseInfo {
 type DatabaseInfo struct {
 	ShopID  string
 	Address string
+	Mirror  *MirrorStatus // Replication status, nil unless the shop is mirrored from another node
 }
 
 // IsOrbitDBInitialized returns whether OrbitDB has been properly initialized
@@ -414,63 +439,31 @@ func (m *Manager) GetShop(ctx context.Context, id string) (*models.Shop, error)
 	// Check if metadata file exists
 	metadataPath := filepath.Join(m.config.Directory, id+"-metadata.json")
 	if _, err := os.Stat(metadataPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("shop not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrShopNotFound, id)
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to access shop metadata: %w", err)
 	}
 
-	// Read metadata
-	metadata, err := m.GetShopMetadata(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read shop metadata: %w", err)
-	}
-
 	// Open the document store
 	docstore, err := m.GetShopDatabase(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open shop database: %w", err)
 	}
 
-	// Query the database for shop information
-	results, err := docstore.Query(ctx, func(doc interface{}) (bool, error) {
-		docMap, ok := doc.(map[string]interface{})
-		if !ok {
-			return false, nil
-		}
-		docType, ok := docMap["type"].(string)
-		if !ok {
-			return false, nil
-		}
-		return docType == "shop", nil
-	})
+	// Query the database for the shop document
+	shopData, err := m.findShopData(ctx, docstore, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query shop database: %w", err)
-	}
-
-	if len(results) == 0 {
-		return nil, fmt.Errorf("shop data not found")
+		return nil, err
 	}
-
-	// Convert the first result to a Shop model
-	shopData, ok := results[0].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid shop data format")
-	}
-
-	shop := &models.Shop{
-		ID:           id,
-		OwnerAddress: shopData["ownerAddress"].(string),
+	if shopData == nil {
+		return nil, fmt.Errorf("%w: no shop data stored for %s", ErrShopNotFound, id)
 	}
 
-	// Use the metadata for the OrbitDB address if needed
-	log.Printf("Using shop metadata with OrbitDB address: %s", metadata.OrbitDBAddress)
+	shop := dataToShop(shopData)
 
-	// Copy fields from shopData to the shop model
-	if name, ok := shopData["name"].(string); ok {
-		shop.Name = name
-	}
-	if description, ok := shopData["description"].(string); ok {
-		shop.Description = description
+	// Stock counts live in their own document
+	if err := m.applyInventory(ctx, docstore, shop); err != nil {
+		return nil, fmt.Errorf("failed to load shop inventory: %w", err)
 	}
 
 	// Store in cache for future requests
@@ -479,10 +472,10 @@ func (m *Manager) GetShop(ctx context.Context, id string) (*models.Shop, error)
 	return shop, nil
 }
 
-// hexToRGBA converts a hex color string to color.RGBA
+// hexToRGBA converts a #rrggbb or #rrggbbaa hex color string to color.RGBA
 func hexToRGBA(hex string) color.RGBA {
 	hex = strings.TrimPrefix(hex, "#")
-	if len(hex) != 6 {
+	if len(hex) != 6 && len(hex) != 8 {
 		return color.RGBA{255, 255, 255, 255} // Default to white
 	}
 
@@ -490,7 +483,12 @@ func hexToRGBA(hex string) color.RGBA {
 	g, _ := strconv.ParseUint(hex[2:4], 16, 8)
 	b, _ := strconv.ParseUint(hex[4:6], 16, 8)
 
-	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255}
+	a := uint64(255)
+	if len(hex) == 8 {
+		a, _ = strconv.ParseUint(hex[6:8], 16, 8)
+	}
+
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: uint8(a)}
 }
 
 // ListShopsOptions provides options for the ListShops method
@@ -516,19 +514,10 @@ func (m *Manager) ListShops(ctx context.Context, options *ListShopsOptions) ([]*
 		options.Limit = 100 // Default limit
 	}
 
-	// Read all files in the shop directory
-	entries, err := os.ReadDir(m.config.Directory)
+	// Every local shop has a metadata file
+	metadataFiles, err := m.localShopIDs()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read shop directory: %w", err)
-	}
-
-	var metadataFiles []string
-	// Look for metadata files
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), "-metadata.json") {
-			shopID := strings.TrimSuffix(entry.Name(), "-metadata.json")
-			metadataFiles = append(metadataFiles, shopID)
-		}
+		return nil, err
 	}
 
 	// Handle pagination
@@ -620,6 +609,74 @@ func (m *Manager) ListShops(ctx context.Context, options *ListShopsOptions) ([]*
 	return shops, nil
 }
 
+// ListItemsOptions provides options for the ListItems method
+type ListItemsOptions struct {
+	Category string // Filter by category ID
+	Tag      string // Filter by tag, ignoring case
+	Limit    int    // Limit the number of results (pagination), 0 for all
+	Offset   int    // Offset for pagination
+}
+
+// ListItems returns a shop's items in category order with optional filtering and pagination
+func (m *Manager) ListItems(ctx context.Context, shopID string, options *ListItemsOptions) ([]models.Item, error) {
+	shop, err := m.GetShop(ctx, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set default options if not provided
+	if options == nil {
+		options = &ListItemsOptions{}
+	}
+
+	return pageItems(filterItems(shop.ItemsByCategory(), options), options), nil
+}
+
+// pageItems returns the page of items selected by the offset and limit of options
+func pageItems(items []models.Item, options *ListItemsOptions) []models.Item {
+	if options.Offset < 0 || options.Offset >= len(items) {
+		return []models.Item{}
+	}
+	endIdx := len(items)
+	// Compared without adding them, a huge limit would overflow
+	if options.Limit > 0 && options.Limit < len(items)-options.Offset {
+		endIdx = options.Offset + options.Limit
+	}
+	return items[options.Offset:endIdx]
+}
+
+// filterItems returns the items matching the category and tag of options
+func filterItems(items []models.Item, options *ListItemsOptions) []models.Item {
+	filtered := []models.Item{}
+	for _, item := range items {
+		if options.Category != "" && !item.InCategory(options.Category) {
+			continue
+		}
+		if options.Tag != "" && !item.HasTag(options.Tag) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+// localShopIDs returns the IDs of all shops with a metadata file in the shop directory
+func (m *Manager) localShopIDs() ([]string, error) {
+	entries, err := os.ReadDir(m.config.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shop directory: %w", err)
+	}
+
+	var shopIDs []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), "-metadata.json") {
+			shopIDs = append(shopIDs, strings.TrimSuffix(entry.Name(), "-metadata.json"))
+		}
+	}
+
+	return shopIDs, nil
+}
+
 // For backward compatibility with existing code
 func (m *Manager) ListAllShops(ctx context.Context) ([]*models.Shop, error) {
 	return m.ListShops(ctx, nil)
@@ -638,7 +695,7 @@ func (m *Manager) DeleteShop(ctx context.Context, shopID string) error {
 	}
 
 	if metadata == nil {
-		return fmt.Errorf("shop not found: %s", shopID)
+		return fmt.Errorf("%w: %s", ErrShopNotFound, shopID)
 	}
 
 	// Get the database
@@ -664,23 +721,22 @@ func (m *Manager) DeleteShop(ctx context.Context, shopID string) error {
 	}
 
 	if len(docs) > 0 {
-		// Get the document hash
-		docMap, ok := docs[0].(map[string]interface{})
-		if !ok {
-			return fmt.Errorf("invalid document format")
-		}
-
-		hash, ok := docMap["_id"].(string)
-		if !ok {
-			return fmt.Errorf("document has no hash (_id)")
+		// Delete the document by its key (the store is indexed on "id")
+		if err := m.deleteDocument(ctx, docStore, shopID); err != nil {
+			return fmt.Errorf("failed to delete shop from OrbitDB: %w", err)
 		}
+	}
 
-		// Delete the document
-		if _, err := docStore.Delete(ctx, hash); err != nil {
-			return fmt.Errorf("failed to delete shop from OrbitDB: %w", err)
+	// Delete the inventory document too
+	if inventory, err := m.readInventory(ctx, docStore, shopID); err == nil && inventory != nil {
+		if err := m.deleteDocument(ctx, docStore, inventoryDocID(shopID)); err != nil {
+			log.Printf("Warning: Failed to delete inventory for shop %s: %v", shopID, err)
 		}
 	}
 
+	// Drop any cached copy
+	m.invalidateShop(shopID)
+
 	// Close and remove the database from cache
 	m.dbsMutex.Lock()
 	if db, exists := m.shopDBs[shopID]; exists {
@@ -689,6 +745,12 @@ func (m *Manager) DeleteShop(ctx context.Context, shopID string) error {
 		}
 		delete(m.shopDBs, shopID)
 	}
+	if db, exists := m.orderDBs[shopID]; exists {
+		if err := db.Close(); err != nil {
+			log.Printf("Warning: Error closing order log for shop %s: %v", shopID, err)
+		}
+		delete(m.orderDBs, shopID)
+	}
 	m.dbsMutex.Unlock()
 
 	// Delete the metadata file
@@ -703,8 +765,11 @@ func (m *Manager) DeleteShop(ctx context.Context, shopID string) error {
 	return nil
 }
 
-// UpdateShop updates an existing shop in OrbitDB
-func (m *Manager) UpdateShop(ctx context.Context, shop *models.Shop) error {
+// UpdateShop updates an existing shop in OrbitDB. loaded is what the shop's
+// stock counts were when the caller loaded it, see SnapshotStock: only counts
+// the caller changed are updated, and by how much they changed, so sales made
+// in the meantime aren't undone. nil leaves the stock of existing items as it is.
+func (m *Manager) UpdateShop(ctx context.Context, shop *models.Shop, loaded StockCounts) error {
 	if !m.IsConnected() {
 		return fmt.Errorf("not connected to OrbitDB")
 	}
@@ -715,127 +780,51 @@ func (m *Manager) UpdateShop(ctx context.Context, shop *models.Shop) error {
 		return fmt.Errorf("failed to get shop database: %w", err)
 	}
 
-	// Query the existing document to get its hash
-	docs, err := docStore.Query(ctx, func(doc interface{}) (bool, error) {
-		// Match by ID
-		docMap, ok := doc.(map[string]interface{})
-		if !ok {
-			return false, nil
-		}
-
-		id, ok := docMap["id"].(string)
-		return ok && id == shop.ID, nil
-	})
-
+	// Load the existing document to keep its creation times
+	existing, err := m.findShopData(ctx, docStore, shop.ID)
 	if err != nil {
-		return fmt.Errorf("failed to query shop data: %w", err)
-	}
-
-	if len(docs) == 0 {
-		return fmt.Errorf("shop not found: %s", shop.ID)
+		return err
 	}
 
-	// Get the document hash
-	docMap, ok := docs[0].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("invalid document format")
+	if existing == nil {
+		return fmt.Errorf("%w: %s", ErrShopNotFound, shop.ID)
 	}
-
-	// We're using Put instead of a direct update by hash, but still verify
-	// the document exists by checking for the _id field
-	if _, ok := docMap["_id"].(string); !ok {
-		return fmt.Errorf("document has no hash (_id)")
+	if err := checkSchemaWritable(existing); err != nil {
+		return err
 	}
 
 	// Convert shop to ShopData
-	shopData := &ShopData{
-		ID:          shop.ID,
-		Owner:       shop.OwnerAddress,
-		Name:        shop.Name,
-		Description: shop.Description,
-		Updated:     time.Now(),
-		Content: ShopContent{
-			Theme: ThemeData{
-				PrimaryColor:   rgbaToHex(shop.PrimaryColor),
-				SecondaryColor: rgbaToHex(shop.SecondaryColor),
-				TertiaryColor:  rgbaToHex(shop.TertiaryColor),
-			},
-			Contact: ContactData{
-				Email:    shop.Email,
-				Phone:    shop.Phone,
-				Location: shop.Location,
-			},
-		},
-		Assets: ShopAssets{
-			LogoCID: shop.CID, // Use the shop's CID for now
-		},
-		OrbitDBAddress: docStore.Address().String(),
-	}
-
-	// Preserve the creation time from the existing document
-	if createdStr, ok := docMap["created"].(string); ok {
-		if created, err := time.Parse(time.RFC3339, createdStr); err == nil {
-			shopData.Created = created
-		} else {
-			shopData.Created = time.Now() // Fallback
-		}
-	} else {
-		shopData.Created = time.Now() // Fallback
-	}
-
-	// Map items from models.Shop to ShopData
-	for _, item := range shop.Items {
-		itemData := ItemData{
-			ID:          item.ID,
-			Name:        item.Name,
-			Price:       item.Price,
-			Description: item.Description,
-			Created:     time.Now(), // Use current time if not provided
-		}
-
-		// Map photo paths to CIDs
-		for _, photoPath := range item.PhotoPaths {
-			itemData.ImageCIDs = append(itemData.ImageCIDs, photoPath)
-		}
-
-		shopData.Content.Items = append(shopData.Content.Items, itemData)
-	}
+	shopData := shopToData(shop, existing)
+	shopData.OrbitDBAddress = docStore.Address().String()
 
 	// Validate shop data
 	if err := m.validateShopData(shopData); err != nil {
 		return fmt.Errorf("invalid shop data: %w", err)
 	}
 
-	// Convert to JSON for OrbitDB storage
-	shopJSON, err := json.Marshal(shopData)
+	updatedDoc, err := shopDataToDoc(shopData)
 	if err != nil {
-		return fmt.Errorf("failed to marshal shop data: %w", err)
-	}
-
-	// Create the document with the shop ID as document ID
-	updatedDoc := map[string]interface{}{}
-	if err := json.Unmarshal(shopJSON, &updatedDoc); err != nil {
-		return fmt.Errorf("failed to prepare shop document: %w", err)
+		return err
 	}
 
 	// Update the document in OrbitDB
-	_, err = docStore.Put(ctx, updatedDoc)
-	if err != nil {
+	if err := m.putDocument(ctx, docStore, updatedDoc); err != nil {
 		return fmt.Errorf("failed to update shop in OrbitDB: %w", err)
 	}
 
-	// Update metadata
-	metadata := &ShopMetadata{
-		ID:             shop.ID,
-		Name:           shop.Name,
-		Owner:          shop.OwnerAddress,
-		OrbitDBAddress: docStore.Address().String(),
+	// Update stock counts
+	if err := m.saveInventory(ctx, docStore, shop, loaded); err != nil {
+		return err
 	}
 
-	if err := m.SaveShopMetadata(ctx, metadata); err != nil {
+	// Update metadata
+	if _, err := m.saveShopMetadata(ctx, shop, docStore.Address().String()); err != nil {
 		return fmt.Errorf("failed to save shop metadata: %w", err)
 	}
 
+	// Drop the stale cached copy
+	m.invalidateShop(shop.ID)
+
 	log.Printf("Successfully updated shop '%s' (ID: %s) in OrbitDB", shop.Name, shop.ID)
 	return nil
 }
@@ -855,7 +844,7 @@ func (m *Manager) AddShopAsset(ctx context.Context, shopID string, assetType str
 	// Update shop assets based on type
 	switch assetType {
 	case "logo":
-		shop.CID = assetCID
+		shop.LogoPath = assetCID
 	case "item":
 		// For item images, we would typically add this to a specific item
 		// But for now, just add to the first item or create one
@@ -866,6 +855,7 @@ func (m *Manager) AddShopAsset(ctx context.Context, shopID string, assetType str
 				Price:       0,
 				Description: "Item description",
 				PhotoPaths:  []string{assetCID},
+				Inventory:   models.UnlimitedInventory,
 			})
 		} else {
 			shop.Items[0].PhotoPaths = append(shop.Items[0].PhotoPaths, assetCID)
@@ -875,7 +865,7 @@ func (m *Manager) AddShopAsset(ctx context.Context, shopID string, assetType str
 	}
 
 	// Update the shop in OrbitDB
-	return m.UpdateShop(ctx, shop)
+	return m.UpdateShop(ctx, shop, nil)
 }
 
 // RemoveShopAsset removes an asset from a shop
@@ -893,8 +883,8 @@ func (m *Manager) RemoveShopAsset(ctx context.Context, shopID string, assetType
 	// Remove asset based on type
 	switch assetType {
 	case "logo":
-		if shop.CID == assetCID {
-			shop.CID = ""
+		if shop.LogoPath == assetCID {
+			shop.LogoPath = ""
 		}
 	case "item":
 		// Remove the CID from all items
@@ -912,7 +902,7 @@ func (m *Manager) RemoveShopAsset(ctx context.Context, shopID string, assetType
 	}
 
 	// Update the shop in OrbitDB
-	return m.UpdateShop(ctx, shop)
+	return m.UpdateShop(ctx, shop, nil)
 }
 
 // validateShopData performs basic validation of shop data
@@ -936,7 +926,7 @@ func (m *Manager) validateShopData(shop *ShopData) error {
 	return nil
 }
 
-// ListShopsByOwner returns all shops owned by the given address
+// ListShopsByOwner returns all shops owned by the given address, ignoring its case
 func (m *Manager) ListShopsByOwner(ctx context.Context, ownerAddress string) ([]*models.Shop, error) {
 	if !m.IsConnected() {
 		return nil, fmt.Errorf("not connected to OrbitDB")
@@ -955,7 +945,7 @@ func (m *Manager) ListShopsByOwner(ctx context.Context, ownerAddress string) ([]
 	// Filter by owner address
 	var ownerShops []*models.Shop
 	for _, shop := range allShops {
-		if shop.OwnerAddress == ownerAddress {
+		if strings.EqualFold(shop.OwnerAddress, ownerAddress) {
 			ownerShops = append(ownerShops, shop)
 		}
 	}
@@ -977,23 +967,13 @@ func (m *Manager) ExportShopData(ctx context.Context, shopID string) ([]byte, er
 	}
 
 	// Query the document store
-	docs, err := docStore.Query(ctx, func(doc interface{}) (bool, error) {
-		// Match by ID
-		docMap, ok := doc.(map[string]interface{})
-		if !ok {
-			return false, nil
-		}
-
-		id, ok := docMap["id"].(string)
-		return ok && id == shopID, nil
-	})
-
+	shopData, err := m.findShopData(ctx, docStore, shopID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query shop data: %w", err)
+		return nil, err
 	}
 
-	if len(docs) == 0 {
-		return nil, fmt.Errorf("shop not found: %s", shopID)
+	if shopData == nil {
+		return nil, fmt.Errorf("%w: %s", ErrShopNotFound, shopID)
 	}
 
 	// Get the shop metadata
@@ -1008,26 +988,9 @@ func (m *Manager) ExportShopData(ctx context.Context, shopID string) ([]byte, er
 		Metadata *ShopMetadata `json:"metadata"`
 	}
 
-	// Convert the document to ShopData
-	docMap, ok := docs[0].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid document format")
-	}
-
-	// Marshal and unmarshal to convert map to ShopData
-	docBytes, err := json.Marshal(docMap)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal document: %w", err)
-	}
-
-	var shopData ShopData
-	if err := json.Unmarshal(docBytes, &shopData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal shop data: %w", err)
-	}
-
 	// Create the export
 	export := ShopExport{
-		ShopData: &shopData,
+		ShopData: shopData,
 		Metadata: metadata,
 	}
 
@@ -1046,10 +1009,11 @@ func (m *Manager) ImportShopData(ctx context.Context, exportData []byte) error {
 		return fmt.Errorf("not connected to OrbitDB")
 	}
 
-	// Define the import structure
+	// Define the import structure. Shop data is decoded after migrating it,
+	// since exports may come from an older schema version.
 	type ShopExport struct {
-		ShopData *ShopData     `json:"shopData"`
-		Metadata *ShopMetadata `json:"metadata"`
+		ShopData map[string]interface{} `json:"shopData"`
+		Metadata *ShopMetadata          `json:"metadata"`
 	}
 
 	// Unmarshal the export data
@@ -1062,6 +1026,15 @@ func (m *Manager) ImportShopData(ctx context.Context, exportData []byte) error {
 		return fmt.Errorf("export data does not contain shop data")
 	}
 
+	if _, _, err := migrateShopDocument(export.ShopData); err != nil {
+		return fmt.Errorf("failed to migrate imported shop: %w", err)
+	}
+
+	importedData, err := docToShopData(export.ShopData)
+	if err != nil {
+		return err
+	}
+
 	// Create options for opening or creating the database
 	docStoreOptions := documentstore.DefaultStoreOptsForMap("id")
 
@@ -1074,13 +1047,19 @@ func (m *Manager) ImportShopData(ctx context.Context, exportData []byte) error {
 	}
 	*dbOptions.StoreType = "docstore"
 
+	accessController, err := m.shopAccessController(importedData.ID, importedData.Owner)
+	if err != nil {
+		return err
+	}
+	dbOptions.AccessController = accessController
+
 	// Attempt to open or create the store
 	dbAddress := ""
 	if export.Metadata != nil && export.Metadata.OrbitDBAddress != "" {
 		dbAddress = export.Metadata.OrbitDBAddress
 		log.Printf("Using existing OrbitDB address: %s", dbAddress)
 	} else {
-		dbAddress = "shop-" + export.ShopData.ID
+		dbAddress = "shop-" + importedData.ID
 		log.Printf("Creating new OrbitDB database: %s", dbAddress)
 	}
 
@@ -1096,46 +1075,37 @@ func (m *Manager) ImportShopData(ctx context.Context, exportData []byte) error {
 		return fmt.Errorf("created store is not a document store")
 	}
 
-	// Update the OrbitDB address in the shop data
-	export.ShopData.OrbitDBAddress = docStore.Address().String()
+	// Normalise the imported data through the same conversion used for stored shops
+	shop := dataToShop(importedData)
+	shopData := shopToData(shop, importedData)
+	shopData.OrbitDBAddress = docStore.Address().String()
 
-	// Marshal shop data for storage
-	shopJSON, err := json.Marshal(export.ShopData)
+	shopDoc, err := shopDataToDoc(shopData)
 	if err != nil {
-		return fmt.Errorf("failed to marshal shop data: %w", err)
-	}
-
-	// Create the document with the shop ID as document ID
-	shopDoc := map[string]interface{}{}
-	if err := json.Unmarshal(shopJSON, &shopDoc); err != nil {
-		return fmt.Errorf("failed to prepare shop document: %w", err)
+		return err
 	}
 
 	// Store in OrbitDB
-	_, err = docStore.Put(ctx, shopDoc)
-	if err != nil {
+	if err := m.putDocument(ctx, docStore, shopDoc); err != nil {
 		return fmt.Errorf("failed to store shop in OrbitDB: %w", err)
 	}
 
 	// Save metadata for the imported shop
-	metadata := &ShopMetadata{
-		ID:             export.ShopData.ID,
-		Name:           export.ShopData.Name,
-		Owner:          export.ShopData.Owner,
-		OrbitDBAddress: docStore.Address().String(),
-	}
-
-	if err := m.SaveShopMetadata(ctx, metadata); err != nil {
+	metadata, err := m.saveShopMetadata(ctx, shop, docStore.Address().String())
+	if err != nil {
 		return fmt.Errorf("failed to save shop metadata: %w", err)
 	}
 
 	// Cache the database
 	m.dbsMutex.Lock()
-	m.shopDBs[export.ShopData.ID] = docStore
+	m.shopDBs[importedData.ID] = docStore
 	m.dbsMutex.Unlock()
 
+	// Drop any stale cached copy
+	m.invalidateShop(shop.ID)
+
 	log.Printf("Successfully imported shop '%s' (ID: %s) into OrbitDB at address: %s",
-		export.ShopData.Name, export.ShopData.ID, metadata.OrbitDBAddress)
+		importedData.Name, importedData.ID, metadata.OrbitDBAddress)
 	return nil
 }
 
@@ -1214,6 +1184,7 @@ func (m *Manager) CloseShopDatabase(shopID string) error {
 	if !exists {
 		return fmt.Errorf("database for shop %s is not open", shopID)
 	}
+	m.unwatchMirror(shopID)
 
 	// Close the database
 	if err := db.Close(); err != nil {
@@ -0,0 +1,43 @@
+package orbitdb
+
+import (
+	"math"
+	"testing"
+
+	"IndieNode/internal/models"
+)
+
+func TestPageItems(t *testing.T) {
+	items := []models.Item{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   string
+	}{
+		{name: "everything", want: "abcd"},
+		{name: "first page", limit: 2, want: "ab"},
+		{name: "last page", offset: 2, limit: 2, want: "cd"},
+		{name: "partial last page", offset: 3, limit: 2, want: "d"},
+		{name: "offset only", offset: 1, want: "bcd"},
+		{name: "limit larger than the items", limit: 10, want: "abcd"},
+		{name: "limit that overflows with the offset", offset: 2, limit: math.MaxInt, want: "cd"},
+		{name: "offset past the end", offset: 4, limit: 2},
+		{name: "negative offset", offset: -1, limit: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := pageItems(items, &ListItemsOptions{Offset: tt.offset, Limit: tt.limit})
+
+			got := ""
+			for _, item := range page {
+				got += item.ID
+			}
+			if got != tt.want || page == nil {
+				t.Errorf("pageItems(offset %d, limit %d) = %q, want %q", tt.offset, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
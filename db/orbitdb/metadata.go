@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"IndieNode/internal/models"
+
 	"berty.tech/go-orbit-db/iface"
 	"berty.tech/go-orbit-db/stores/documentstore"
 )
@@ -18,6 +20,14 @@ type ShopMetadata struct {
 	Name           string `json:"name"`
 	Owner          string `json:"owner"`
 	OrbitDBAddress string `json:"orbitDbAddress"`
+
+	// OrdersDBAddress is the address of the shop's order event log
+	OrdersDBAddress string `json:"ordersDbAddress,omitempty"`
+
+	// Mirror is set for shops replicated from another node, see MirrorShop
+	Mirror     bool     `json:"mirror,omitempty"`
+	SiteCID    string   `json:"siteCid,omitempty"`    // Site pinned for a mirrored shop
+	PinnedCIDs []string `json:"pinnedCids,omitempty"` // Everything pinned for a mirrored shop
 }
 
 // SaveShopMetadata saves just the shop metadata including the OrbitDB address
@@ -26,10 +36,13 @@ func (m *Manager) SaveShopMetadata(ctx context.Context, metadata *ShopMetadata)
 		return fmt.Errorf("not connected to OrbitDB")
 	}
 
-	// Validate
+	// Validate, the ID is part of the file name
 	if metadata.ID == "" {
 		return fmt.Errorf("shop ID is required")
 	}
+	if !models.ValidID(metadata.ID) {
+		return fmt.Errorf("%w: %q", models.ErrInvalidShopID, metadata.ID)
+	}
 
 	// Store metadata in a separate file from the shop data
 	metadataPath := filepath.Join(m.config.Directory, metadata.ID+"-metadata.json")
@@ -45,12 +58,39 @@ func (m *Manager) SaveShopMetadata(ctx context.Context, metadata *ShopMetadata)
 	return nil
 }
 
+// saveShopMetadata writes the metadata for a stored shop, keeping fields
+// like the order log address that are set elsewhere
+func (m *Manager) saveShopMetadata(ctx context.Context, shop *models.Shop, orbitDBAddress string) (*ShopMetadata, error) {
+	metadata, err := m.GetShopMetadata(ctx, shop.ID)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		metadata = &ShopMetadata{}
+	}
+
+	metadata.ID = shop.ID
+	metadata.Name = shop.Name
+	metadata.Owner = shop.OwnerAddress
+	metadata.OrbitDBAddress = orbitDBAddress
+
+	if err := m.SaveShopMetadata(ctx, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
 // GetShopMetadata retrieves shop metadata by shop ID
 func (m *Manager) GetShopMetadata(ctx context.Context, shopID string) (*ShopMetadata, error) {
 	if !m.isConnected {
 		return nil, fmt.Errorf("not connected to OrbitDB")
 	}
 
+	// No shop can be saved under an invalid ID
+	if !models.ValidID(shopID) {
+		return nil, nil
+	}
+
 	// Read metadata file
 	metadataPath := filepath.Join(m.config.Directory, shopID+"-metadata.json")
 	data, err := os.ReadFile(metadataPath)
@@ -109,6 +149,12 @@ func (m *Manager) GetShopDatabase(ctx context.Context, shopID string) (iface.Doc
 			store.Close()
 			return nil, fmt.Errorf("database for shop %s is not a document store", shopID)
 		}
+
+		if metadata.Mirror {
+			m.watchMirror(metadata, db)
+		} else if !m.config.DisableWalletAccess && db.AccessController().Type() != WalletAccessControllerType {
+			log.Printf("Warning: Shop %s was created before wallet access control, only this node's OrbitDB identity can write to it", shopID)
+		}
 	} else {
 		// Create a new database
 		log.Printf("Creating new database for shop %s", shopID)
@@ -125,6 +171,17 @@ func (m *Manager) GetShopDatabase(ctx context.Context, shopID string) (iface.Doc
 		}
 		*dbOptions.StoreType = "docstore"
 
+		// Only the owner's wallet, and writers it grants, may write to the shop
+		owner := ""
+		if metadata != nil {
+			owner = metadata.Owner
+		}
+		accessController, err := m.shopAccessController(shopID, owner)
+		if err != nil {
+			return nil, err
+		}
+		dbOptions.AccessController = accessController
+
 		// Create the new document store
 		store, err := m.orbitDB.Create(ctx, "shop-"+shopID, "docstore", dbOptions)
 		if err != nil {
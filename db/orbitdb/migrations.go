@@ -0,0 +1,254 @@
+package orbitdb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// CurrentSchemaVersion is the schema version of shop documents written by this build.
+// Bump it together with a new entry in shopMigrations whenever ShopData or ItemData
+// changes in a way older documents need converting for.
+const CurrentSchemaVersion = 5
+
+// Migration upgrades a shop document from one schema version to the next
+type Migration struct {
+	From        int                                            // Schema version the migration applies to
+	Description string                                         // Short summary of what the migration changes
+	Apply       func(doc map[string]interface{}) (bool, error) // Reports whether the document changed
+}
+
+// shopMigrations holds the registered migrations, keyed by the version they upgrade from
+var shopMigrations = map[int]Migration{}
+
+func init() {
+	registerMigration(Migration{
+		From:        0,
+		Description: "moved the site CID out of assets.logoCid",
+		Apply:       migrateSiteCID,
+	})
+	registerMigration(Migration{
+		From:        1,
+		Description: "added item options and variants",
+		Apply:       migrateItemVariants,
+	})
+	registerMigration(Migration{
+		From:        2,
+		Description: "added categories and item tags",
+		Apply:       migrateCategories,
+	})
+	registerMigration(Migration{
+		From:        3,
+		Description: "added the shop's ENS name",
+		Apply:       migrateENSName,
+	})
+	registerMigration(Migration{
+		From:        4,
+		Description: "added the template pack and its options",
+		Apply:       migrateTemplate,
+	})
+}
+
+// registerMigration adds a migration to the registry
+func registerMigration(migration Migration) {
+	if _, exists := shopMigrations[migration.From]; exists {
+		panic(fmt.Sprintf("orbitdb: duplicate migration from schema version %d", migration.From))
+	}
+	shopMigrations[migration.From] = migration
+}
+
+// MigrationResult describes what happened to one shop during a bulk migration
+type MigrationResult struct {
+	ShopID      string
+	ShopName    string
+	FromVersion int
+	ToVersion   int
+	Changes     []string // Descriptions of the migrations that were applied
+	Error       error
+}
+
+// schemaVersion returns the schema version of a raw shop document.
+// Documents written before versioning have none and count as version 0.
+func schemaVersion(doc map[string]interface{}) int {
+	switch version := doc["schemaVersion"].(type) {
+	case float64:
+		return int(version)
+	case int:
+		return version
+	default:
+		return 0
+	}
+}
+
+// migrateShopDocument upgrades a raw shop document to CurrentSchemaVersion in place.
+// It returns the version the document started at and the migrations that changed it.
+func migrateShopDocument(doc map[string]interface{}) (int, []string, error) {
+	from := schemaVersion(doc)
+	if from > CurrentSchemaVersion {
+		return from, nil, fmt.Errorf("%w: version %d, this build supports up to %d", ErrUnsupportedSchema, from, CurrentSchemaVersion)
+	}
+
+	var applied []string
+	for version := from; version < CurrentSchemaVersion; version++ {
+		migration, ok := shopMigrations[version]
+		if !ok {
+			return from, applied, fmt.Errorf("no migration registered from schema version %d", version)
+		}
+		changed, err := migration.Apply(doc)
+		if err != nil {
+			return from, applied, fmt.Errorf("failed to migrate from schema version %d: %w", version, err)
+		}
+
+		doc["schemaVersion"] = version + 1
+		if changed {
+			applied = append(applied, migration.Description)
+		}
+	}
+
+	return from, applied, nil
+}
+
+// migrateSiteCID upgrades version 0 documents, which stored the CID of the
+// published site in assets.logoCid and had no field for the logo itself.
+// Logo paths always contain a slash, CIDs never do.
+func migrateSiteCID(doc map[string]interface{}) (bool, error) {
+	if _, ok := doc["cid"]; ok {
+		return false, nil
+	}
+
+	assets, ok := doc["assets"].(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+
+	logoCID, _ := assets["logoCid"].(string)
+	if logoCID == "" || strings.Contains(logoCID, "/") {
+		return false, nil
+	}
+
+	doc["cid"] = logoCID
+	assets["logoCid"] = ""
+	return true, nil
+}
+
+// migrateItemVariants upgrades version 1 documents, whose items had no options
+// or variants. Nothing needs converting; the version is bumped so older builds
+// refuse to save over variants they would drop.
+func migrateItemVariants(doc map[string]interface{}) (bool, error) {
+	return false, nil
+}
+
+// migrateCategories upgrades version 2 documents, which had no categories or
+// item tags. Like migrateItemVariants it only bumps the version.
+func migrateCategories(doc map[string]interface{}) (bool, error) {
+	return false, nil
+}
+
+// migrateENSName upgrades version 3 documents, which had no ENS name. Like
+// migrateItemVariants it only bumps the version, so older builds don't drop the name.
+func migrateENSName(doc map[string]interface{}) (bool, error) {
+	return false, nil
+}
+
+// migrateTemplate upgrades version 4 documents, which had no template pack.
+// Shops without one are generated with the default pack, so only the version is bumped.
+func migrateTemplate(doc map[string]interface{}) (bool, error) {
+	return false, nil
+}
+
+// MigrateAllShops upgrades the documents of every local shop to
+// CurrentSchemaVersion and writes them back. Shops already up to date
+// are reported with the same from and to version.
+func (m *Manager) MigrateAllShops(ctx context.Context) ([]MigrationResult, error) {
+	if !m.IsConnected() {
+		return nil, fmt.Errorf("not connected to OrbitDB")
+	}
+
+	shopIDs, err := m.localShopIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]MigrationResult, 0, len(shopIDs))
+	for _, shopID := range shopIDs {
+		// Mirrored shops are migrated by the node that owns them
+		if metadata, err := m.GetShopMetadata(ctx, shopID); err == nil && metadata != nil && metadata.Mirror {
+			continue
+		}
+
+		result := m.migrateShop(ctx, shopID)
+		if result.Error != nil {
+			log.Printf("Warning: Failed to migrate shop %s: %v", shopID, result.Error)
+		} else if result.FromVersion != result.ToVersion {
+			log.Printf("Migrated shop %s from schema version %d to %d", shopID, result.FromVersion, result.ToVersion)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// migrateShop upgrades and rewrites the document of a single shop
+func (m *Manager) migrateShop(ctx context.Context, shopID string) MigrationResult {
+	result := MigrationResult{ShopID: shopID}
+
+	docStore, err := m.GetShopDatabase(ctx, shopID)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to open shop database: %w", err)
+		return result
+	}
+
+	doc, err := m.findShopDocument(ctx, docStore, shopID)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if doc == nil {
+		result.Error = fmt.Errorf("%w: no shop data stored for %s", ErrShopNotFound, shopID)
+		return result
+	}
+
+	result.ShopName, _ = doc["name"].(string)
+
+	from, applied, err := migrateShopDocument(doc)
+	result.FromVersion = from
+	result.ToVersion = schemaVersion(doc)
+	result.Changes = applied
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	if from == CurrentSchemaVersion {
+		return result
+	}
+
+	// Documents only migrated in memory are converted again on every read
+	if err := m.putDocument(ctx, docStore, doc); err != nil {
+		result.Error = fmt.Errorf("failed to write migrated shop: %w", err)
+		return result
+	}
+
+	m.invalidateShop(shopID)
+	return result
+}
+
+// String summarises a migration result for logs and reports
+func (r MigrationResult) String() string {
+	name := r.ShopID
+	if r.ShopName != "" {
+		name = fmt.Sprintf("%s (%s)", r.ShopName, r.ShopID)
+	}
+
+	switch {
+	case r.Error != nil:
+		return fmt.Sprintf("%s: failed: %v", name, r.Error)
+	case r.FromVersion == r.ToVersion:
+		return fmt.Sprintf("%s: already at schema version %d", name, r.ToVersion)
+	case len(r.Changes) == 0:
+		return fmt.Sprintf("%s: schema version %d -> %d, no data changes", name, r.FromVersion, r.ToVersion)
+	default:
+		return fmt.Sprintf("%s: schema version %d -> %d, %s", name, r.FromVersion, r.ToVersion, strings.Join(r.Changes, "; "))
+	}
+}
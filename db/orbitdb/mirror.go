@@ -0,0 +1,345 @@
+package orbitdb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"IndieNode/internal/models"
+
+	"berty.tech/go-orbit-db/address"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/libp2p/go-libp2p/core/event"
+)
+
+// MirrorStatus describes how far a mirrored shop has replicated
+type MirrorStatus struct {
+	ShopID         string
+	Address        string
+	SiteCID        string
+	Progress       int       // Log entries replicated so far
+	Max            int       // Log entries known to exist
+	PinnedCIDs     int       // Site and asset CIDs pinned on this node
+	LastReplicated time.Time // Zero until the first update arrives from a peer
+	LastError      string
+}
+
+// mirrorState tracks a mirrored shop database while it is open
+type mirrorState struct {
+	store  iface.DocumentStore
+	sub    event.Subscription
+	status MirrorStatus
+}
+
+// MirrorShop replicates another node's shop database from its OrbitDB address,
+// pins its site and assets, and keeps following updates. siteCID may be empty,
+// in which case the site is pinned once the shop document has replicated.
+func (m *Manager) MirrorShop(ctx context.Context, orbitDBAddress string, siteCID string) (*MirrorStatus, error) {
+	if !m.IsConnected() {
+		return nil, fmt.Errorf("not connected to OrbitDB")
+	}
+
+	shopID, err := mirrorShopID(orbitDBAddress)
+	if err != nil {
+		return nil, err
+	}
+	if siteCID != "" {
+		if _, err := cid.Decode(siteCID); err != nil {
+			return nil, fmt.Errorf("invalid site CID %s: %w", siteCID, err)
+		}
+	}
+
+	metadata, err := m.GetShopMetadata(ctx, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shop metadata: %w", err)
+	}
+	if metadata != nil && !metadata.Mirror {
+		return nil, fmt.Errorf("%w: %s is stored on this node", ErrShopExists, shopID)
+	}
+	if metadata == nil {
+		metadata = &ShopMetadata{ID: shopID}
+	}
+	metadata.OrbitDBAddress = orbitDBAddress
+	metadata.Mirror = true
+	if siteCID != "" {
+		metadata.SiteCID = siteCID
+	}
+
+	// Saved first, so GetShopDatabase opens the remote address rather than creating a database
+	if err := m.SaveShopMetadata(ctx, metadata); err != nil {
+		return nil, fmt.Errorf("failed to save shop metadata: %w", err)
+	}
+
+	if _, err := m.GetShopDatabase(ctx, shopID); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Mirroring shop %s from %s", shopID, orbitDBAddress)
+
+	// Pin what is known now, the rest follows as entries replicate
+	m.refreshMirror(ctx, shopID)
+
+	return m.GetMirrorStatus(shopID), nil
+}
+
+// StopMirror closes a mirrored shop database, unpins its content and forgets it
+func (m *Manager) StopMirror(ctx context.Context, shopID string) error {
+	if !m.IsConnected() {
+		return fmt.Errorf("not connected to OrbitDB")
+	}
+
+	metadata, err := m.GetShopMetadata(ctx, shopID)
+	if err != nil {
+		return fmt.Errorf("failed to get shop metadata: %w", err)
+	}
+	if metadata == nil || !metadata.Mirror {
+		return fmt.Errorf("%w: %s is not a mirrored shop", ErrShopNotFound, shopID)
+	}
+
+	if err := m.CloseShopDatabase(shopID); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	for _, c := range metadata.PinnedCIDs {
+		if err := m.ipfs.Pin().Rm(ctx, path.New("/ipfs/"+c)); err != nil {
+			log.Printf("Warning: Failed to unpin %s for mirrored shop %s: %v", c, shopID, err)
+		}
+	}
+
+	metadataPath := filepath.Join(m.config.Directory, shopID+"-metadata.json")
+	if err := os.Remove(metadataPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete shop metadata file: %w", err)
+	}
+	m.invalidateShop(shopID)
+
+	log.Printf("Stopped mirroring shop %s", shopID)
+	return nil
+}
+
+// GetMirrorStatus returns the replication status of a mirrored shop, or nil if
+// the shop isn't a mirror open on this node
+func (m *Manager) GetMirrorStatus(shopID string) *MirrorStatus {
+	m.mirrorsMutex.Lock()
+	defer m.mirrorsMutex.Unlock()
+
+	state, ok := m.mirrors[shopID]
+	if !ok {
+		return nil
+	}
+
+	status := state.status
+	if info := state.store.ReplicationStatus(); info != nil {
+		status.Progress = info.GetProgress()
+		status.Max = info.GetMax()
+	}
+	return &status
+}
+
+// String summarises a mirror's status for the settings database list
+func (s *MirrorStatus) String() string {
+	var b strings.Builder
+	if s.LastReplicated.IsZero() {
+		b.WriteString("waiting for peers")
+	} else {
+		fmt.Fprintf(&b, "replicated %d/%d entries, last update %s", s.Progress, s.Max, s.LastReplicated.Format(time.Kitchen))
+	}
+	fmt.Fprintf(&b, ", %d pinned", s.PinnedCIDs)
+	if s.LastError != "" {
+		b.WriteString(", error: " + s.LastError)
+	}
+	return b.String()
+}
+
+// watchMirror follows replication of a mirrored shop database that was just opened
+func (m *Manager) watchMirror(metadata *ShopMetadata, store iface.DocumentStore) {
+	sub, err := store.EventBus().Subscribe(new(stores.EventReplicated))
+	if err != nil {
+		log.Printf("Warning: Failed to follow mirrored shop %s: %v", metadata.ID, err)
+		return
+	}
+
+	state := &mirrorState{
+		store: store,
+		sub:   sub,
+		status: MirrorStatus{
+			ShopID:     metadata.ID,
+			Address:    metadata.OrbitDBAddress,
+			SiteCID:    metadata.SiteCID,
+			PinnedCIDs: len(metadata.PinnedCIDs),
+		},
+	}
+
+	m.mirrorsMutex.Lock()
+	if previous, ok := m.mirrors[metadata.ID]; ok {
+		previous.sub.Close()
+	}
+	m.mirrors[metadata.ID] = state
+	m.mirrorsMutex.Unlock()
+
+	go func() {
+		for range sub.Out() {
+			m.mirrorsMutex.Lock()
+			state.status.LastReplicated = time.Now()
+			m.mirrorsMutex.Unlock()
+
+			// Cached copies of the shop are stale once a peer's entries arrive
+			m.invalidateShop(metadata.ID)
+			m.refreshMirror(m.ctx, metadata.ID)
+		}
+	}()
+}
+
+// unwatchMirror stops following a mirrored shop database that is being closed
+func (m *Manager) unwatchMirror(shopID string) {
+	m.mirrorsMutex.Lock()
+	defer m.mirrorsMutex.Unlock()
+
+	if state, ok := m.mirrors[shopID]; ok {
+		state.sub.Close()
+		delete(m.mirrors, shopID)
+	}
+}
+
+// refreshMirror pins the site and assets a mirrored shop currently references,
+// and unpins ones it no longer does, such as a site replaced by a republish
+func (m *Manager) refreshMirror(ctx context.Context, shopID string) {
+	err := m.pinMirrorContent(ctx, shopID)
+
+	m.mirrorsMutex.Lock()
+	if state, ok := m.mirrors[shopID]; ok {
+		state.status.LastError = ""
+		if err != nil {
+			state.status.LastError = err.Error()
+		}
+	}
+	m.mirrorsMutex.Unlock()
+
+	if err != nil {
+		log.Printf("Warning: Mirrored shop %s: %v", shopID, err)
+	}
+}
+
+// pinMirrorContent does the pinning for refreshMirror and records the result in the shop's metadata
+func (m *Manager) pinMirrorContent(ctx context.Context, shopID string) error {
+	m.pinMutex.Lock()
+	defer m.pinMutex.Unlock()
+
+	metadata, err := m.GetShopMetadata(ctx, shopID)
+	if err != nil || metadata == nil {
+		return err
+	}
+
+	// Only mirrors still open are refreshed, a late update mustn't reopen a stopped one
+	m.mirrorsMutex.Lock()
+	state, ok := m.mirrors[shopID]
+	m.mirrorsMutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	data, err := m.findShopData(ctx, state.store, shopID)
+	if err != nil {
+		return err
+	}
+
+	siteCID := metadata.SiteCID
+	wanted := []string{}
+	if data != nil {
+		metadata.Name = data.Name
+		metadata.Owner = data.Owner
+		if data.CID != "" {
+			siteCID = data.CID
+		}
+
+		// Assets stored by path are part of the site, only standalone CIDs need pinning
+		for _, asset := range append([]string{data.Assets.LogoCID}, data.Assets.ItemImageCIDs...) {
+			if _, err := cid.Decode(asset); err == nil {
+				wanted = append(wanted, asset)
+			}
+		}
+	}
+	if siteCID != "" {
+		wanted = append(wanted, siteCID)
+	}
+	metadata.SiteCID = siteCID
+
+	pinned := make(map[string]bool, len(metadata.PinnedCIDs))
+	for _, c := range metadata.PinnedCIDs {
+		pinned[c] = true
+	}
+
+	var pinErrors []string
+	keep := make(map[string]bool, len(wanted))
+	for _, c := range wanted {
+		if keep[c] {
+			continue
+		}
+		if !pinned[c] {
+			if err := m.ipfs.Pin().Add(ctx, path.New("/ipfs/"+c)); err != nil {
+				pinErrors = append(pinErrors, fmt.Sprintf("failed to pin %s: %v", c, err))
+				continue
+			}
+			log.Printf("Pinned %s for mirrored shop %s", c, shopID)
+		}
+		keep[c] = true
+	}
+
+	for c := range pinned {
+		if keep[c] {
+			continue
+		}
+		if err := m.ipfs.Pin().Rm(ctx, path.New("/ipfs/"+c)); err != nil {
+			log.Printf("Warning: Failed to unpin %s for mirrored shop %s: %v", c, shopID, err)
+			keep[c] = true
+			continue
+		}
+		log.Printf("Unpinned %s, no longer used by mirrored shop %s", c, shopID)
+	}
+
+	metadata.PinnedCIDs = metadata.PinnedCIDs[:0]
+	for c := range keep {
+		metadata.PinnedCIDs = append(metadata.PinnedCIDs, c)
+	}
+	sort.Strings(metadata.PinnedCIDs)
+	if err := m.SaveShopMetadata(ctx, metadata); err != nil {
+		return err
+	}
+
+	m.mirrorsMutex.Lock()
+	state.status.SiteCID = siteCID
+	state.status.PinnedCIDs = len(metadata.PinnedCIDs)
+	m.mirrorsMutex.Unlock()
+
+	if len(pinErrors) > 0 {
+		return fmt.Errorf("%s", strings.Join(pinErrors, "; "))
+	}
+	return nil
+}
+
+// mirrorShopID returns the shop ID from a shop database address, whose name is "shop-" followed by the ID
+func mirrorShopID(orbitDBAddress string) (string, error) {
+	addr, err := address.Parse(orbitDBAddress)
+	if err != nil {
+		return "", fmt.Errorf("invalid OrbitDB address %s: %w", orbitDBAddress, err)
+	}
+
+	name := addr.GetPath()
+	if !strings.HasPrefix(name, "shop-") || name == "shop-" {
+		return "", fmt.Errorf("%s is not a shop database", orbitDBAddress)
+	}
+
+	// The ID comes from another node, it is used in file names here
+	shopID := strings.TrimPrefix(name, "shop-")
+	if !models.ValidID(shopID) {
+		return "", fmt.Errorf("%w: %q", models.ErrInvalidShopID, shopID)
+	}
+	return shopID, nil
+}
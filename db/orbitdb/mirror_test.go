@@ -0,0 +1,233 @@
+package orbitdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"IndieNode/internal/models"
+	"IndieNode/internal/services/auth"
+	"IndieNode/internal/services/ens"
+
+	"github.com/ipfs/go-libipfs/files"
+	iface_ipfs "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/kubo/core"
+	"github.com/ipfs/kubo/core/coreapi"
+	mock "github.com/ipfs/kubo/core/mock"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+// newTestNodes starts n in-process IPFS nodes connected to each other
+func newTestNodes(t *testing.T, ctx context.Context, n int) []iface_ipfs.CoreAPI {
+	t.Helper()
+
+	mn := mocknet.New()
+	t.Cleanup(func() { mn.Close() })
+
+	apis := make([]iface_ipfs.CoreAPI, 0, n)
+	for i := 0; i < n; i++ {
+		node, err := core.NewNode(ctx, &core.BuildCfg{
+			Online:    true,
+			Host:      mock.MockHostOption(mn),
+			ExtraOpts: map[string]bool{"pubsub": true},
+		})
+		if err != nil {
+			t.Fatalf("failed to start IPFS node: %v", err)
+		}
+		t.Cleanup(func() { node.Close() })
+
+		api, err := coreapi.NewCoreAPI(node)
+		if err != nil {
+			t.Fatalf("failed to create IPFS API: %v", err)
+		}
+		apis = append(apis, api)
+	}
+
+	if err := mn.LinkAll(); err != nil {
+		t.Fatalf("failed to link IPFS nodes: %v", err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatalf("failed to connect IPFS nodes: %v", err)
+	}
+	return apis
+}
+
+// newTestManager opens a shop manager on an IPFS node
+func newTestManager(t *testing.T, ctx context.Context, api iface_ipfs.CoreAPI) *Manager {
+	t.Helper()
+
+	m, err := NewManager(ctx, &Config{Directory: t.TempDir()}, api)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+// eventually polls check until it returns nil or timeout passes
+func eventually(t *testing.T, timeout time.Duration, check func() error) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("gave up after %s: %v", timeout, err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func TestMirrorShop(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts two IPFS nodes")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	apis := newTestNodes(t, ctx, 2)
+	nodeA := newTestManager(t, ctx, apis[0])
+	nodeB := newTestManager(t, ctx, apis[1])
+
+	// A writes on behalf of the owner's wallet
+	owner := newTestWallet(t)
+	proof := owner.delegate(t, nodeA.IdentityID(), func(m *auth.SIWEMessage) {
+		m.ChainID = ens.LoadENSConfig().ChainID
+	})
+	if err := nodeA.SetWriterProof(proof); err != nil {
+		t.Fatalf("SetWriterProof() error = %v", err)
+	}
+
+	site, err := apis[0].Unixfs().Add(ctx, files.NewBytesFile([]byte("<html>Mirrored shop</html>")))
+	if err != nil {
+		t.Fatalf("failed to add site: %v", err)
+	}
+	siteCID := site.Cid().String()
+
+	shop := &models.Shop{
+		ID:           "mirrored-shop",
+		Name:         "Mirrored Shop",
+		OwnerAddress: owner.address.Hex(),
+		CID:          siteCID,
+		Items:        []models.Item{{ID: "mug", Name: "Mug", Price: 12.5, Inventory: 3}},
+	}
+	if err := nodeA.StoreShop(shop); err != nil {
+		t.Fatalf("StoreShop() error = %v", err)
+	}
+	metadata, err := nodeA.GetShopMetadata(ctx, shop.ID)
+	if err != nil || metadata == nil {
+		t.Fatalf("GetShopMetadata() = %v, %v", metadata, err)
+	}
+
+	// B mirrors A's database without knowing the site CID
+	if _, err := nodeB.MirrorShop(ctx, metadata.OrbitDBAddress, ""); err != nil {
+		t.Fatalf("MirrorShop() error = %v", err)
+	}
+
+	eventually(t, time.Minute, func() error {
+		mirrored, err := nodeB.GetShop(ctx, shop.ID)
+		if err != nil {
+			return err
+		}
+		if mirrored.Name != shop.Name || len(mirrored.Items) != 1 || mirrored.Items[0].Inventory != 3 {
+			return fmt.Errorf("mirror has %+v", mirrored)
+		}
+		if status := nodeB.GetMirrorStatus(shop.ID); status == nil || status.SiteCID != siteCID {
+			return fmt.Errorf("mirror status %+v, want site %s", status, siteCID)
+		}
+		return isPinned(ctx, apis[1], siteCID)
+	})
+
+	// Updates on A keep arriving, and a republished site replaces the old pin
+	newSite, err := apis[0].Unixfs().Add(ctx, files.NewBytesFile([]byte("<html>Republished shop</html>")))
+	if err != nil {
+		t.Fatalf("failed to add site: %v", err)
+	}
+	stored, err := nodeA.GetShop(ctx, shop.ID)
+	if err != nil {
+		t.Fatalf("GetShop() error = %v", err)
+	}
+	stored.Name = "Renamed Shop"
+	stored.CID = newSite.Cid().String()
+	if err := nodeA.UpdateShop(ctx, stored, SnapshotStock(stored)); err != nil {
+		t.Fatalf("UpdateShop() error = %v", err)
+	}
+
+	eventually(t, time.Minute, func() error {
+		mirrored, err := nodeB.GetShop(ctx, shop.ID)
+		if err != nil {
+			return err
+		}
+		if mirrored.Name != "Renamed Shop" {
+			return fmt.Errorf("mirror is still named %q", mirrored.Name)
+		}
+		if err := isPinned(ctx, apis[1], stored.CID); err != nil {
+			return err
+		}
+		if isPinned(ctx, apis[1], siteCID) == nil {
+			return fmt.Errorf("old site %s is still pinned", siteCID)
+		}
+		return nil
+	})
+
+	// B can't write to A's shop
+	if err := nodeB.UpdateShop(ctx, stored, nil); err == nil {
+		t.Errorf("UpdateShop() on the mirror succeeded, want an error")
+	}
+}
+
+// isPinned returns nil if c is pinned on the node behind api
+func isPinned(ctx context.Context, api iface_ipfs.CoreAPI, c string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pins, err := api.Pin().Ls(ctx)
+	if err != nil {
+		return err
+	}
+	for pin := range pins {
+		if pin.Err() == nil && pin.Path().Cid().String() == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not pinned", c)
+}
+
+func TestMirrorShopID(t *testing.T) {
+	const root = "/orbitdb/bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi/"
+
+	tests := []struct {
+		name    string
+		address string
+		want    string // Empty if the address must be rejected
+	}{
+		{name: "owner address", address: root + "shop-0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", want: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{name: "named shop", address: root + "shop-clay_and-co", want: "clay_and-co"},
+		{name: "not a shop", address: root + "orders-clay"},
+		{name: "no ID", address: root + "shop-"},
+		{name: "parent directory", address: root + "shop-../../.ssh/id"},
+		{name: "dots", address: root + "shop-.."},
+		{name: "space", address: root + "shop-my shop"},
+		{name: "not an address", address: "shop-clay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mirrorShopID(tt.address)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("mirrorShopID(%q) = %q, want an error", tt.address, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("mirrorShopID(%q) = %q, %v; want %q", tt.address, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package orbitdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"berty.tech/go-orbit-db/iface"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OrderStatus describes where an order is in the payment process
+type OrderStatus string
+
+const (
+	// OrderPending means the payment was sent but hasn't been verified yet
+	OrderPending OrderStatus = "pending"
+	// OrderConfirmed means the payment was verified on-chain
+	OrderConfirmed OrderStatus = "confirmed"
+	// OrderFailed means the payment was rejected or reverted
+	OrderFailed OrderStatus = "failed"
+)
+
+// MaxOrderQuantity is the most units of an item a single order can be for
+const MaxOrderQuantity = 1000
+
+// usedTxFileName is the file in the data directory that lists every
+// transaction that paid for an order on this node
+const usedTxFileName = "used-transactions.json"
+
+// OrderData represents a single sale recorded in a shop's order log
+type OrderData struct {
+	ID        string      `json:"id"`
+	ShopID    string      `json:"shopId"`
+	ItemID    string      `json:"itemId"`
+	VariantID string      `json:"variantId,omitempty"` // Variant bought, for items that have them
+	Quantity  int64       `json:"quantity"`
+	PricePaid string      `json:"pricePaid"`         // Amount the buyer reports paying in the token's base unit (wei for ETH)
+	PriceUSD  float64     `json:"priceUsd"`          // Item price in USD at the time of sale
+	RateUSD   float64     `json:"rateUsd,omitempty"` // USD price of one ETH quoted by this node when the order was recorded
+	Token     string      `json:"token"`             // "ETH" or a token contract address
+	TxHash    string      `json:"txHash"`
+	Buyer     string      `json:"buyer"` // Ethereum address of the buyer
+	Status    OrderStatus `json:"status"`
+	Timestamp time.Time   `json:"timestamp"` // When the order was placed
+	Updated   time.Time   `json:"updated"`   // When this version of the order was written
+}
+
+// GetOrdersDatabase retrieves or creates the order event log for a shop
+func (m *Manager) GetOrdersDatabase(ctx context.Context, shopID string) (iface.EventLogStore, error) {
+	if !m.IsConnected() {
+		return nil, fmt.Errorf("not connected to OrbitDB")
+	}
+
+	// Check if we have a cached database
+	m.dbsMutex.RLock()
+	if db, exists := m.orderDBs[shopID]; exists {
+		m.dbsMutex.RUnlock()
+		return db, nil
+	}
+	m.dbsMutex.RUnlock()
+
+	// Orders belong to an existing shop
+	metadata, err := m.GetShopMetadata(ctx, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shop metadata: %w", err)
+	}
+	if metadata == nil {
+		return nil, fmt.Errorf("%w: %s", ErrShopNotFound, shopID)
+	}
+
+	var db iface.EventLogStore
+	if metadata.OrdersDBAddress != "" {
+		// Reopen the existing order log
+		log.Printf("Reopening order log for shop %s: %s", shopID, metadata.OrdersDBAddress)
+
+		db, err = m.orbitDB.Log(ctx, metadata.OrdersDBAddress, &iface.CreateDBOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open order log for shop %s: %w", shopID, err)
+		}
+	} else {
+		// Create a new order log
+		log.Printf("Creating order log for shop %s", shopID)
+
+		create := true
+		db, err = m.orbitDB.Log(ctx, "orders-"+shopID, &iface.CreateDBOptions{
+			Create: &create,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create order log for shop %s: %w", shopID, err)
+		}
+
+		// Save the address so the same log is reopened next time
+		metadata.OrdersDBAddress = db.Address().String()
+		if err := m.SaveShopMetadata(ctx, metadata); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to save shop metadata: %w", err)
+		}
+
+		log.Printf("Created order log for shop %s at address: %s", shopID, metadata.OrdersDBAddress)
+	}
+
+	// Load the entries
+	if err := db.Load(ctx, -1); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load order log for shop %s: %w", shopID, err)
+	}
+
+	// Cache the database
+	m.dbsMutex.Lock()
+	if m.orderDBs == nil {
+		m.orderDBs = make(map[string]iface.EventLogStore)
+	}
+	m.orderDBs[shopID] = db
+	m.dbsMutex.Unlock()
+
+	return db, nil
+}
+
+// RecordOrder appends an order to the shop's order log.
+// Missing IDs, timestamps and statuses are filled in. Returns ErrDuplicateOrder
+// if any shop on this node already has an order paid by the same transaction.
+func (m *Manager) RecordOrder(ctx context.Context, order *OrderData) error {
+	if order == nil {
+		return fmt.Errorf("order cannot be nil")
+	}
+
+	// Fill in defaults
+	if order.ID == "" {
+		id, err := generateOrderID()
+		if err != nil {
+			return fmt.Errorf("failed to generate order ID: %w", err)
+		}
+		order.ID = id
+	}
+	if order.Timestamp.IsZero() {
+		order.Timestamp = time.Now().UTC()
+	}
+	if order.Status == "" {
+		order.Status = OrderPending
+	}
+	if order.Token == "" {
+		order.Token = "ETH"
+	}
+	if common.IsHexAddress(order.Buyer) {
+		order.Buyer = common.HexToAddress(order.Buyer).Hex()
+	}
+	order.Updated = time.Now().UTC()
+
+	if err := validateOrder(order); err != nil {
+		return err
+	}
+
+	// Checking for an earlier order and appending this one happen under the
+	// same lock, so two requests with the same transaction can't both pass
+	m.ordersMutex.Lock()
+	defer m.ordersMutex.Unlock()
+
+	var used map[string]string
+	if order.TxHash != "" {
+		var err error
+		if used, err = m.usedTransactions(ctx); err != nil {
+			return err
+		}
+		if shopID, ok := used[txKey(order.TxHash)]; ok {
+			return fmt.Errorf("%w %s in shop %s", ErrDuplicateOrder, order.TxHash, shopID)
+		}
+	}
+
+	// Take the stock before the order is written so the last unit can't be sold twice
+	if order.Status != OrderFailed {
+		if _, err := m.DecrementInventory(ctx, order.ShopID, order.ItemID, order.VariantID, order.Quantity); err != nil {
+			return err
+		}
+	}
+
+	if err := m.appendOrder(ctx, order); err != nil {
+		if order.Status != OrderFailed {
+			if _, restockErr := m.RestockInventory(ctx, order.ShopID, order.ItemID, order.VariantID, order.Quantity); restockErr != nil {
+				log.Printf("Warning: Failed to restock %s after failed order: %v", order.ItemID, restockErr)
+			}
+		}
+		return err
+	}
+
+	if order.TxHash != "" {
+		used[txKey(order.TxHash)] = order.ShopID
+		if err := m.saveUsedTransactions(used); err != nil {
+			log.Printf("Warning: Failed to save used transactions: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// usedTransactions returns the shop each transaction that paid for an order on
+// this node went to, keyed by txKey. The index is node-wide so a payment to
+// one owner can't confirm orders in several of their shops, and it outlives
+// deleted shops. Nodes without the index file rebuild it from their order logs.
+// The caller must hold ordersMutex.
+func (m *Manager) usedTransactions(ctx context.Context) (map[string]string, error) {
+	if m.usedTxHashes != nil {
+		return m.usedTxHashes, nil
+	}
+
+	used := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(m.config.Directory, usedTxFileName))
+	if err == nil {
+		if err := json.Unmarshal(data, &used); err != nil {
+			return nil, fmt.Errorf("failed to read used transactions: %w", err)
+		}
+		m.usedTxHashes = used
+		return used, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read used transactions: %w", err)
+	}
+
+	shopIDs, err := m.localShopIDs()
+	if err != nil {
+		return nil, err
+	}
+	for _, shopID := range shopIDs {
+		// Only shops that took orders have a log, ListOrders would create one
+		metadata, err := m.GetShopMetadata(ctx, shopID)
+		if err != nil {
+			return nil, err
+		}
+		if metadata == nil || metadata.Mirror || metadata.OrdersDBAddress == "" {
+			continue
+		}
+
+		orders, err := m.ListOrders(ctx, shopID)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			if order.TxHash != "" {
+				used[txKey(order.TxHash)] = shopID
+			}
+		}
+	}
+
+	if err := m.saveUsedTransactions(used); err != nil {
+		return nil, err
+	}
+	m.usedTxHashes = used
+	return used, nil
+}
+
+// txKey normalises a transaction hash for the used transaction index
+func txKey(txHash string) string {
+	return common.HexToHash(txHash).Hex()
+}
+
+// saveUsedTransactions writes the used transaction index to the data directory
+func (m *Manager) saveUsedTransactions(used map[string]string) error {
+	data, err := json.MarshalIndent(used, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal used transactions: %w", err)
+	}
+
+	// Written to a temporary file first so a crash can't leave half an index
+	path := filepath.Join(m.config.Directory, usedTxFileName)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write used transactions: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write used transactions: %w", err)
+	}
+	return nil
+}
+
+// ListOrders returns the current state of every order in a shop's log, newest first
+func (m *Manager) ListOrders(ctx context.Context, shopID string) ([]*OrderData, error) {
+	db, err := m.GetOrdersDatabase(ctx, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	all := -1
+	entries, err := db.List(ctx, &iface.StreamOptions{Amount: &all})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders for shop %s: %w", shopID, err)
+	}
+
+	// The log is append-only, so an order can appear several times as its
+	// status changes. Keep the most recently written version of each.
+	latest := make(map[string]*OrderData)
+	for _, entry := range entries {
+		var order OrderData
+		if err := json.Unmarshal(entry.GetValue(), &order); err != nil {
+			log.Printf("Skipping unreadable order entry in shop %s: %v", shopID, err)
+			continue
+		}
+
+		if existing, ok := latest[order.ID]; ok && existing.Updated.After(order.Updated) {
+			continue
+		}
+		latest[order.ID] = &order
+	}
+
+	orders := make([]*OrderData, 0, len(latest))
+	for _, order := range latest {
+		orders = append(orders, order)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].Timestamp.After(orders[j].Timestamp)
+	})
+
+	return orders, nil
+}
+
+// UpdateOrderStatus appends a new version of an order with the given status
+func (m *Manager) UpdateOrderStatus(ctx context.Context, shopID string, orderID string, status OrderStatus) error {
+	orders, err := m.ListOrders(ctx, shopID)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if order.ID != orderID {
+			continue
+		}
+		if order.Status == status {
+			return nil
+		}
+
+		updated := *order
+		updated.Status = status
+		updated.Updated = time.Now().UTC()
+		if err := validateOrder(&updated); err != nil {
+			return err
+		}
+		if err := m.appendOrder(ctx, &updated); err != nil {
+			return err
+		}
+
+		// A failed payment releases the stock it was holding
+		if status == OrderFailed {
+			if _, err := m.RestockInventory(ctx, shopID, order.ItemID, order.VariantID, order.Quantity); err != nil && !errors.Is(err, ErrItemNotFound) {
+				return fmt.Errorf("failed to restock item %s: %w", order.ItemID, err)
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+}
+
+// appendOrder writes an order entry to its shop's log
+func (m *Manager) appendOrder(ctx context.Context, order *OrderData) error {
+	db, err := m.GetOrdersDatabase(ctx, order.ShopID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order: %w", err)
+	}
+
+	if _, err := db.Add(ctx, data); err != nil {
+		return fmt.Errorf("failed to record order %s: %w", order.ID, err)
+	}
+
+	log.Printf("Recorded order %s for shop %s (%s)", order.ID, order.ShopID, order.Status)
+	return nil
+}
+
+// validateOrder checks that an order has everything needed to identify the sale
+func validateOrder(order *OrderData) error {
+	if order.ShopID == "" {
+		return fmt.Errorf("%w: shop ID is required", ErrInvalidOrder)
+	}
+	if order.ItemID == "" {
+		return fmt.Errorf("%w: item ID is required", ErrInvalidOrder)
+	}
+	if order.Quantity <= 0 || order.Quantity > MaxOrderQuantity {
+		return fmt.Errorf("%w: quantity must be between 1 and %d", ErrInvalidOrder, MaxOrderQuantity)
+	}
+	if !common.IsHexAddress(order.Buyer) {
+		return fmt.Errorf("%w: invalid buyer address", ErrInvalidOrder)
+	}
+	if order.TxHash != "" && len(common.FromHex(order.TxHash)) != common.HashLength {
+		return fmt.Errorf("%w: invalid transaction hash", ErrInvalidOrder)
+	}
+
+	switch order.Status {
+	case OrderPending, OrderConfirmed, OrderFailed:
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidOrder, order.Status)
+	}
+
+	return nil
+}
+
+// generateOrderID creates a random order ID
+func generateOrderID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,95 @@
+package orbitdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"IndieNode/internal/models"
+	"IndieNode/internal/services/auth"
+	"IndieNode/internal/services/ens"
+)
+
+// newTestOwnerManager opens a manager on a single IPFS node that writes on
+// behalf of a new wallet
+func newTestOwnerManager(t *testing.T, ctx context.Context) (*Manager, *testWallet) {
+	t.Helper()
+
+	m := newTestManager(t, ctx, newTestNodes(t, ctx, 1)[0])
+	owner := newTestWallet(t)
+	proof := owner.delegate(t, m.IdentityID(), func(msg *auth.SIWEMessage) {
+		msg.ChainID = ens.LoadENSConfig().ChainID
+	})
+	if err := m.SetWriterProof(proof); err != nil {
+		t.Fatalf("SetWriterProof() error = %v", err)
+	}
+	return m, owner
+}
+
+func TestRecordOrderRejectsUsedTransactions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an IPFS node")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	m, owner := newTestOwnerManager(t, ctx)
+	for _, id := range []string{"mugs", "vases"} {
+		shop := &models.Shop{
+			ID:           id,
+			Name:         id,
+			OwnerAddress: owner.address.Hex(),
+			Items:        []models.Item{{ID: "item", Name: "Item", Price: 10, Inventory: models.UnlimitedInventory}},
+		}
+		if err := m.StoreShop(shop); err != nil {
+			t.Fatalf("StoreShop() error = %v", err)
+		}
+	}
+
+	const txHash = "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"
+	order := func(shopID string, txHash string) *OrderData {
+		return &OrderData{
+			ShopID:   shopID,
+			ItemID:   "item",
+			Quantity: 1,
+			TxHash:   txHash,
+			Buyer:    "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		}
+	}
+
+	if err := m.RecordOrder(ctx, order("mugs", txHash)); err != nil {
+		t.Fatalf("RecordOrder() error = %v", err)
+	}
+
+	// One payment can't confirm orders in the same shop, or another shop of the owner
+	reuses := []*OrderData{
+		order("mugs", txHash),
+		order("vases", txHash),
+		order("vases", strings.ToUpper(txHash[2:])),
+	}
+	for _, reuse := range reuses {
+		if err := m.RecordOrder(ctx, reuse); !errors.Is(err, ErrDuplicateOrder) {
+			t.Errorf("RecordOrder(%s, %s) error = %v, want %v", reuse.ShopID, reuse.TxHash, err, ErrDuplicateOrder)
+		}
+	}
+
+	// Nodes from before the index rebuild it from their order logs
+	m.ordersMutex.Lock()
+	m.usedTxHashes = nil
+	m.ordersMutex.Unlock()
+	if err := os.Remove(filepath.Join(m.config.Directory, usedTxFileName)); err != nil {
+		t.Fatalf("failed to remove the used transactions: %v", err)
+	}
+	if err := m.RecordOrder(ctx, order("vases", txHash)); !errors.Is(err, ErrDuplicateOrder) {
+		t.Errorf("RecordOrder() after a rebuild error = %v, want %v", err, ErrDuplicateOrder)
+	}
+
+	if err := m.RecordOrder(ctx, order("vases", "0x"+strings.Repeat("ab", 32))); err != nil {
+		t.Errorf("RecordOrder() of a new transaction error = %v", err)
+	}
+}
@@ -13,6 +13,10 @@ import (
 // Config holds shop data storage configuration
 type Config struct {
 	Directory string // Local directory for caching shop data
+
+	// DisableWalletAccess creates shop databases without the wallet access
+	// controller, so any node can write. Only meant for development.
+	DisableWalletAccess bool
 }
 
 // Manager handles shop data storage using IPFS and OrbitDB
@@ -25,12 +29,25 @@ type Manager struct {
 	// OrbitDB fields
 	orbitDB   orbitdb.OrbitDB
 	shopDBs   map[string]iface.DocumentStore // Cache of shop databases
-	dbsMutex  sync.RWMutex                   // Mutex for thread-safe access to shopDBs
+	orderDBs  map[string]iface.EventLogStore // Cache of shop order logs
+	dbsMutex  sync.RWMutex                   // Mutex for thread-safe access to shopDBs and orderDBs
 	shopCache *ShopCache                     // Cache for shop data
+
+	inventoryMutex sync.Mutex        // Serialises stock updates so items can't be oversold
+	ordersMutex    sync.Mutex        // Serialises new orders so a transaction can't pay for two
+	usedTxHashes   map[string]string // Shop each transaction paid for an order in, see usedTransactions
+
+	writerProof *WriterProof // Proof attached to documents this node writes, see SetWriterProof
+	proofMutex  sync.RWMutex
+
+	mirrors      map[string]*mirrorState // Shops replicated from other nodes, by shop ID
+	mirrorsMutex sync.Mutex
+	pinMutex     sync.Mutex // Serialises pin updates of mirrored shops
 }
 
 // ShopData represents the shop structure in OrbitDB
 type ShopData struct {
+	SchemaVersion  int         `json:"schemaVersion"` // See CurrentSchemaVersion
 	ID             string      `json:"id"`
 	Owner          string      `json:"owner"`
 	Name           string      `json:"name"`
@@ -40,19 +57,31 @@ type ShopData struct {
 	Content        ShopContent `json:"content"`
 	Assets         ShopAssets  `json:"assets"`
 	OrbitDBAddress string      `json:"orbitDbAddress"` // OrbitDB address for persistence
+	URLName        string      `json:"urlName,omitempty"`
+	CID            string      `json:"cid,omitempty"`      // IPFS CID of the published site
+	IPNSName       string      `json:"ipnsName,omitempty"` // IPNS name the site is published under
+	ENSName        string      `json:"ensName,omitempty"`  // ENS name whose contenthash follows CID
+	Published      bool        `json:"published,omitempty"`
 }
 
 // ShopContent holds the dynamic content of a shop
 type ShopContent struct {
-	Items   []ItemData  `json:"items"`
-	Theme   ThemeData   `json:"theme"`
-	Contact ContactData `json:"contact"`
+	Items      []ItemData     `json:"items"`
+	Categories []CategoryData `json:"categories,omitempty"` // In the order the site lists them
+	Theme      ThemeData      `json:"theme"`
+	Contact    ContactData    `json:"contact"`
+}
+
+// CategoryData represents a category items can be assigned to
+type CategoryData struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
 }
 
 // ShopAssets holds references to IPFS-stored assets
 type ShopAssets struct {
-	LogoCID       string   `json:"logoCid"`
-	ItemImageCIDs []string `json:"itemImageCids"`
+	LogoCID       string   `json:"logoCid"`       // CID or site path of the shop logo
+	ItemImageCIDs []string `json:"itemImageCids"` // Every item image, for pinning
 }
 
 // ItemData represents a shop item in OrbitDB
@@ -63,13 +92,36 @@ type ItemData struct {
 	Description string    `json:"description"`
 	ImageCIDs   []string  `json:"imageCids"`
 	Created     time.Time `json:"created"`
+
+	Options  []OptionGroupData `json:"options,omitempty"`
+	Variants []VariantData     `json:"variants,omitempty"` // Stock of each variant is kept in the inventory document
+
+	Categories []string `json:"categories,omitempty"` // IDs of the shop's categories
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// OptionGroupData represents a choice buyers make for an item, like its size
+type OptionGroupData struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// VariantData represents one combination of an item's options
+type VariantData struct {
+	ID       string            `json:"id"`
+	Options  map[string]string `json:"options"`
+	SKU      string            `json:"sku,omitempty"`
+	Price    *float64          `json:"price,omitempty"` // Overrides the item's price if set
+	ImageCID string            `json:"imageCid,omitempty"`
 }
 
 // ThemeData represents shop theme configuration
 type ThemeData struct {
-	PrimaryColor   string `json:"primaryColor"`
-	SecondaryColor string `json:"secondaryColor"`
-	TertiaryColor  string `json:"tertiaryColor"`
+	PrimaryColor    string            `json:"primaryColor"`
+	SecondaryColor  string            `json:"secondaryColor"`
+	TertiaryColor   string            `json:"tertiaryColor"`
+	Template        string            `json:"template,omitempty"`        // Template pack the site is generated with
+	TemplateOptions map[string]string `json:"templateOptions,omitempty"` // Values of the pack's options, by option name
 }
 
 // ContactData represents shop contact information
@@ -83,11 +135,12 @@ type ContactData struct {
 
 // ShopInventoryData represents a shop's inventory in OrbitDB
 type ShopInventoryData struct {
-	ID          string          `json:"id"`
-	ShopID      string          `json:"shopId"`
-	OwnerID     string          `json:"ownerId"` // Ethereum address of shop owner
-	Items       []ItemInventory `json:"items"`
-	LastUpdated time.Time       `json:"lastUpdated"`
+	SchemaVersion int             `json:"schemaVersion"`
+	ID            string          `json:"id"`
+	ShopID        string          `json:"shopId"`
+	OwnerID       string          `json:"ownerId"` // Ethereum address of shop owner
+	Items         []ItemInventory `json:"items"`
+	LastUpdated   time.Time       `json:"lastUpdated"`
 }
 
 // ItemInventory represents an item's inventory data
@@ -100,4 +153,13 @@ type ItemInventory struct {
 	Inventory   int64     `json:"inventory"` // -1 represents unlimited
 	Created     time.Time `json:"created"`
 	Updated     time.Time `json:"updated"`
+
+	Variants []VariantInventory `json:"variants,omitempty"` // Stock of items with variants, the item's own is unused
+}
+
+// VariantInventory represents a variant's inventory data
+type VariantInventory struct {
+	ID        string `json:"id"`
+	SKU       string `json:"sku,omitempty"`
+	Inventory int64  `json:"inventory"` // -1 represents unlimited
 }
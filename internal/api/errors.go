@@ -0,0 +1,14 @@
+package api
+
+import "errors"
+
+var (
+	// ErrMissingSignature is returned when a request lacks the signature headers
+	ErrMissingSignature = errors.New("missing request signature")
+
+	// ErrRequestExpired is returned when a signed request's timestamp is outside the allowed window
+	ErrRequestExpired = errors.New("request signature expired")
+
+	// ErrReplayedRequest is returned when a signature has already been used
+	ErrReplayedRequest = errors.New("request signature already used")
+)
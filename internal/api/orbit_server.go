@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/rs/cors"
 
 	"IndieNode/db/orbitdb"
+	"IndieNode/internal/models"
 	"context"
 )
 
@@ -24,6 +27,22 @@ type Server struct {
 	startTime    time.Time
 	requestCount uint64
 	isRunning    bool
+
+	// Signed requests seen within the signature window, for replay protection
+	usedRequests   map[string]time.Time
+	signatureMutex sync.Mutex
+
+	payments PaymentVerifier // Prices and checks order payments, orders are refused if nil
+}
+
+// PaymentVerifier is what the server needs from the payment verifier, see payments.Verifier
+type PaymentVerifier interface {
+	// Quote returns the USD price of one ETH that new orders are recorded with
+	Quote(ctx context.Context) (float64, error)
+
+	// CheckPayment checks that a new order's transaction has been seen and pays
+	// the shop enough, before the order holds any stock
+	CheckPayment(ctx context.Context, order *orbitdb.OrderData, shop *models.Shop) error
 }
 
 // Response represents a standard API response structure
@@ -31,6 +50,9 @@ type Response struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+
+	// ValidationErrors lists invalid fields when a write is rejected
+	ValidationErrors []ValidationError `json:"validationErrors,omitempty"`
 }
 
 // ServerStatus contains information about the API server status
@@ -52,6 +74,7 @@ func NewServer(orbitManager *orbitdb.Manager, port int) *Server {
 		port:         port,
 		startTime:    time.Now(),
 		requestCount: 0,
+		usedRequests: make(map[string]time.Time),
 	}
 
 	// Set up routes
@@ -60,6 +83,11 @@ func NewServer(orbitManager *orbitdb.Manager, port int) *Server {
 	return server
 }
 
+// SetPaymentVerifier sets what prices new orders and checks their payments
+func (s *Server) SetPaymentVerifier(verifier PaymentVerifier) {
+	s.payments = verifier
+}
+
 // GetStatus returns the current server status
 func (s *Server) GetStatus() ServerStatus {
 	var uptime string
@@ -94,11 +122,31 @@ func (s *Server) setupRoutes() {
 	// Root endpoint to check if API is running
 	s.router.HandleFunc("/api", s.handleAPIStatus).Methods("GET")
 
-	// Shop-specific endpoints
+	// Shop-specific endpoints. Writes must be signed by the shop owner's wallet.
 	shopRouter := s.router.PathPrefix("/api/shops").Subrouter()
 	shopRouter.HandleFunc("", s.handleListShops).Methods("GET")
+	shopRouter.HandleFunc("", s.requireSignature(s.handleCreateShop)).Methods("POST")
 	shopRouter.HandleFunc("/{shopId}", s.handleGetShop).Methods("GET")
+	shopRouter.HandleFunc("/{shopId}", s.requireOwner(s.handleReplaceShop)).Methods("PUT")
+	shopRouter.HandleFunc("/{shopId}", s.requireOwner(s.handlePatchShop)).Methods("PATCH")
+	shopRouter.HandleFunc("/{shopId}", s.requireOwner(s.handleDeleteShop)).Methods("DELETE")
+
+	// Item endpoints
 	shopRouter.HandleFunc("/{shopId}/items", s.handleGetShopItems).Methods("GET")
+	shopRouter.HandleFunc("/{shopId}/items", s.requireOwner(s.handleCreateItem)).Methods("POST")
+	shopRouter.HandleFunc("/{shopId}/items/{itemId}", s.handleGetShopItem).Methods("GET")
+	shopRouter.HandleFunc("/{shopId}/items/{itemId}", s.requireOwner(s.handleReplaceItem)).Methods("PUT")
+	shopRouter.HandleFunc("/{shopId}/items/{itemId}", s.requireOwner(s.handlePatchItem)).Methods("PATCH")
+	shopRouter.HandleFunc("/{shopId}/items/{itemId}", s.requireOwner(s.handleDeleteItem)).Methods("DELETE")
+
+	// Order endpoints. Storefronts report purchases, only the owner can read them.
+	shopRouter.HandleFunc("/{shopId}/orders", s.requireOwner(s.handleListOrders)).Methods("GET")
+	shopRouter.HandleFunc("/{shopId}/orders", s.handleCreateOrder).Methods("POST")
+
+	// Writer endpoints. Only the owner can change who may write to the shop database.
+	shopRouter.HandleFunc("/{shopId}/writers", s.requireOwner(s.handleListWriters)).Methods("GET")
+	shopRouter.HandleFunc("/{shopId}/writers", s.requireOwner(s.handleGrantWriter)).Methods("POST")
+	shopRouter.HandleFunc("/{shopId}/writers/{address}", s.requireOwner(s.handleRevokeWriter)).Methods("DELETE")
 
 	log.Printf("API endpoints configured")
 }
@@ -108,8 +156,8 @@ func (s *Server) Start() error {
 	// Configure CORS
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Allow all origins - shop websites could be accessed from various domains
-		AllowedMethods:   []string{"GET", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization", AddressHeader, TimestampHeader, SignatureHeader},
 		AllowCredentials: true,
 		MaxAge:           86400, // 24 hours
 	})
@@ -207,7 +255,8 @@ func (s *Server) handleGetShop(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-// handleGetShopItems returns items for a specific shop
+// handleGetShopItems returns items for a specific shop, optionally filtered by
+// the category and tag query parameters and paged with limit and offset
 func (s *Server) handleGetShopItems(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	shopID := vars["shopId"]
@@ -217,8 +266,23 @@ func (s *Server) handleGetShopItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the shop which includes items
-	shop, err := s.orbitManager.GetShop(r.Context(), shopID)
+	query := r.URL.Query()
+	options := &orbitdb.ListItemsOptions{
+		Category: query.Get("category"),
+		Tag:      query.Get("tag"),
+	}
+	for name, value := range map[string]*int{"limit": &options.Limit, "offset": &options.Offset} {
+		if text := query.Get(name); text != "" {
+			n, err := strconv.Atoi(text)
+			if err != nil || n < 0 {
+				respondWithError(w, http.StatusBadRequest, "Invalid "+name+": must be a non-negative number")
+				return
+			}
+			*value = n
+		}
+	}
+
+	items, err := s.orbitManager.ListItems(r.Context(), shopID, options)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Shop not found: "+err.Error())
 		return
@@ -226,7 +290,7 @@ func (s *Server) handleGetShopItems(w http.ResponseWriter, r *http.Request) {
 
 	response := Response{
 		Success: true,
-		Data:    shop.Items,
+		Data:    newItemResponses(items),
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
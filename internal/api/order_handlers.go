@@ -0,0 +1,165 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/services/payments"
+)
+
+// paymentRetryAfter is how many seconds a storefront waits before reporting an
+// order again whose transaction the node hasn't seen yet
+const paymentRetryAfter = 5
+
+// orderRequest holds the fields a storefront sends when reporting a purchase
+type orderRequest struct {
+	ItemID    string  `json:"itemId"`
+	VariantID string  `json:"variantId"`
+	Quantity  int64   `json:"quantity"`
+	PricePaid string  `json:"pricePaid"`
+	PriceUSD  float64 `json:"priceUsd"` // Ignored, the price is read from the shop
+	Token     string  `json:"token"`
+	TxHash    string  `json:"txHash"`
+	Buyer     string  `json:"buyer"`
+}
+
+// Order Handlers
+
+// handleListOrders returns the order history of a shop. Only the owner can read it.
+func (s *Server) handleListOrders(w http.ResponseWriter, r *http.Request) {
+	shopID := mux.Vars(r)["shopId"]
+
+	orders, err := s.orbitManager.ListOrders(r.Context(), shopID)
+	if err != nil {
+		if errors.Is(err, orbitdb.ErrShopNotFound) {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to list orders: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    orders,
+	})
+}
+
+// handleCreateOrder records a purchase reported by the storefront after payment.
+// Orders always start as pending, since the payment hasn't been confirmed yet.
+// Their price comes from the shop and the ETH rate this node quotes, not from
+// what the buyer reports paying. An order is only recorded, and holds its
+// stock, once its transaction has been seen paying the shop enough, so nodes
+// that can't verify payments don't take orders.
+func (s *Server) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
+	if s.payments == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "This shop can't verify payments, orders are disabled")
+		return
+	}
+
+	shop, ok := s.loadShop(w, r, mux.Vars(r)["shopId"])
+	if !ok {
+		return
+	}
+
+	var req orderRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	var errs []ValidationError
+	if index := findItem(shop, req.ItemID); index < 0 {
+		errs = append(errs, ValidationError{Field: "itemId", Message: "item not found in this shop"})
+	} else if item := shop.Items[index]; item.HasVariants() && item.Variant(req.VariantID) == nil {
+		errs = append(errs, ValidationError{Field: "variantId", Message: "a variant of this item is required"})
+	} else if !item.HasVariants() && req.VariantID != "" {
+		errs = append(errs, ValidationError{Field: "variantId", Message: "this item has no variants"})
+	}
+	if req.Quantity < 0 || req.Quantity > orbitdb.MaxOrderQuantity {
+		errs = append(errs, ValidationError{Field: "quantity", Message: fmt.Sprintf("quantity must be between 1 and %d", orbitdb.MaxOrderQuantity)})
+	}
+	if req.TxHash == "" {
+		errs = append(errs, ValidationError{Field: "txHash", Message: "transaction hash is required"})
+	} else if len(common.FromHex(req.TxHash)) != common.HashLength {
+		errs = append(errs, ValidationError{Field: "txHash", Message: "invalid transaction hash"})
+	}
+	if len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	item := shop.Items[findItem(shop, req.ItemID)]
+	priceUSD := item.Price
+	if variant := item.Variant(req.VariantID); variant != nil {
+		priceUSD = item.VariantPrice(*variant)
+	}
+
+	rateUSD, err := s.payments.Quote(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Failed to quote ETH price: "+err.Error())
+		return
+	}
+
+	order := &orbitdb.OrderData{
+		ShopID:    shop.ID,
+		ItemID:    req.ItemID,
+		VariantID: req.VariantID,
+		Quantity:  req.Quantity,
+		PricePaid: req.PricePaid,
+		PriceUSD:  priceUSD,
+		RateUSD:   rateUSD,
+		Token:     req.Token,
+		TxHash:    req.TxHash,
+		Buyer:     req.Buyer,
+		Status:    orbitdb.OrderPending,
+	}
+
+	// Made-up transactions would hold stock until the verifier gave up on them
+	if err := s.payments.CheckPayment(r.Context(), order, shop); err != nil {
+		switch {
+		case errors.Is(err, payments.ErrTransactionNotFound):
+			w.Header().Set("Retry-After", fmt.Sprint(paymentRetryAfter))
+			respondWithError(w, http.StatusTooEarly, "Transaction "+req.TxHash+" hasn't been seen yet, report the order again shortly")
+		case errors.Is(err, payments.ErrWrongRecipient), errors.Is(err, payments.ErrWrongSender),
+			errors.Is(err, payments.ErrInsufficientValue), errors.Is(err, payments.ErrWrongChain),
+			errors.Is(err, payments.ErrUnsupportedToken), errors.Is(err, payments.ErrUnknownItem):
+			respondWithValidationErrors(w, []ValidationError{{Field: "txHash", Message: err.Error()}})
+		default:
+			respondWithError(w, http.StatusServiceUnavailable, "Failed to check payment: "+err.Error())
+		}
+		return
+	}
+
+	if err := s.orbitManager.RecordOrder(r.Context(), order); err != nil {
+		if errors.Is(err, orbitdb.ErrInvalidOrder) {
+			respondWithValidationErrors(w, []ValidationError{{Message: err.Error()}})
+			return
+		}
+		// A transaction pays for one order only
+		if errors.Is(err, orbitdb.ErrDuplicateOrder) {
+			respondWithError(w, http.StatusConflict, "An order already exists for transaction "+req.TxHash)
+			return
+		}
+		if errors.Is(err, orbitdb.ErrOutOfStock) {
+			respondWithError(w, http.StatusConflict, "Not enough stock: "+err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to record order: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Data:    order,
+	})
+}
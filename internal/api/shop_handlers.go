@@ -0,0 +1,637 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/models"
+)
+
+// maxRequestBodySize limits the size of JSON request bodies
+const maxRequestBodySize = 1 << 20 // 1 MB
+
+// ValidationError describes a single invalid field in a request
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// shopPatch holds the shop fields that can be changed with PATCH.
+// Nil fields are left untouched.
+type shopPatch struct {
+	Name           *string
+	Description    *string
+	Location       *string
+	Email          *string
+	Phone          *string
+	LogoPath       *string
+	PrimaryColor   *color.RGBA
+	SecondaryColor *color.RGBA
+	TertiaryColor  *color.RGBA
+	Categories     *[]models.Category
+}
+
+// itemPatch holds the item fields that can be changed with PATCH.
+// Nil fields are left untouched.
+type itemPatch struct {
+	Name        *string
+	Price       *float64
+	Description *string
+	PhotoPaths  *[]string
+	Inventory   *int64
+	Options     *[]models.OptionGroup
+	Variants    *[]models.Variant
+	Categories  *[]string
+	Tags        *[]string
+}
+
+// itemResponse is an item as returned by the API, with its stock state
+// spelled out for storefronts
+type itemResponse struct {
+	models.Item
+	Variants []variantResponse `json:",omitempty"` // Shadows Item.Variants
+	SoldOut  bool
+}
+
+// variantResponse is a variant as returned by the API. Price is only set if
+// the variant overrides the item's, UnitPrice is what it costs either way.
+type variantResponse struct {
+	models.Variant
+	Label     string
+	UnitPrice float64
+	SoldOut   bool
+}
+
+// newItemResponse wraps an item for an API response
+func newItemResponse(item models.Item) itemResponse {
+	response := itemResponse{Item: item, SoldOut: item.SoldOut()}
+	for _, variant := range item.Variants {
+		response.Variants = append(response.Variants, variantResponse{
+			Variant:   variant,
+			Label:     item.VariantLabel(variant),
+			UnitPrice: item.VariantPrice(variant),
+			SoldOut:   variant.SoldOut(),
+		})
+	}
+	return response
+}
+
+// newItemResponses wraps a shop's items for an API response
+func newItemResponses(items []models.Item) []itemResponse {
+	responses := make([]itemResponse, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, newItemResponse(item))
+	}
+	return responses
+}
+
+// Write Handlers
+
+// handleCreateShop creates a new shop
+func (s *Server) handleCreateShop(w http.ResponseWriter, r *http.Request) {
+	var shop models.Shop
+	if err := decodeJSONBody(w, r, &shop); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	// StoreShop falls back to the owner address as ID, check that ID too
+	shopID := shop.ID
+	if shopID == "" {
+		shopID = shop.OwnerAddress
+	}
+
+	if errs := validateShop(&shop); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	// A shop can only be created for the wallet that signed the request
+	signer, _ := SignerFromContext(r.Context())
+	if !common.IsHexAddress(shop.OwnerAddress) || common.HexToAddress(shop.OwnerAddress) != signer {
+		respondWithError(w, http.StatusForbidden, "OwnerAddress must match the signing wallet")
+		return
+	}
+
+	exists, err := s.orbitManager.ShopExists(r.Context(), shopID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check shop: "+err.Error())
+		return
+	}
+	if exists {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("%v: %s", orbitdb.ErrShopExists, shopID))
+		return
+	}
+
+	if err := s.orbitManager.StoreShop(&shop); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to store shop: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Data:    shop,
+	})
+}
+
+// handleReplaceShop replaces an existing shop
+func (s *Server) handleReplaceShop(w http.ResponseWriter, r *http.Request) {
+	shopID := mux.Vars(r)["shopId"]
+
+	existing, ok := s.loadShop(w, r, shopID)
+	if !ok {
+		return
+	}
+
+	var shop models.Shop
+	if err := decodeJSONBody(w, r, &shop); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	// The path decides which shop is replaced
+	if shop.ID != "" && shop.ID != shopID {
+		respondWithValidationErrors(w, []ValidationError{{Field: "ID", Message: "does not match the shop ID in the URL"}})
+		return
+	}
+	shop.ID = shopID
+
+	errs := validateShop(&shop)
+	if shop.OwnerAddress != "" && !strings.EqualFold(shop.OwnerAddress, existing.OwnerAddress) {
+		errs = append(errs, ValidationError{Field: "OwnerAddress", Message: "cannot be changed"})
+	}
+	if len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	s.updateShop(w, r, &shop, orbitdb.SnapshotStock(existing), http.StatusOK)
+}
+
+// handlePatchShop updates selected fields of an existing shop
+func (s *Server) handlePatchShop(w http.ResponseWriter, r *http.Request) {
+	shopID := mux.Vars(r)["shopId"]
+
+	shop, ok := s.loadShop(w, r, shopID)
+	if !ok {
+		return
+	}
+
+	var patch shopPatch
+	if err := decodeJSONBody(w, r, &patch); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	// Apply the fields that were provided
+	if patch.Name != nil {
+		shop.Name = *patch.Name
+		shop.URLName = "" // Regenerated from the new name by Validate
+	}
+	if patch.Description != nil {
+		shop.Description = *patch.Description
+	}
+	if patch.Location != nil {
+		shop.Location = *patch.Location
+	}
+	if patch.Email != nil {
+		shop.Email = *patch.Email
+	}
+	if patch.Phone != nil {
+		shop.Phone = *patch.Phone
+	}
+	if patch.LogoPath != nil {
+		shop.LogoPath = *patch.LogoPath
+	}
+	if patch.PrimaryColor != nil {
+		shop.PrimaryColor = *patch.PrimaryColor
+	}
+	if patch.SecondaryColor != nil {
+		shop.SecondaryColor = *patch.SecondaryColor
+	}
+	if patch.TertiaryColor != nil {
+		shop.TertiaryColor = *patch.TertiaryColor
+	}
+	if patch.Categories != nil {
+		shop.Categories = *patch.Categories
+	}
+
+	if errs := validateShop(shop); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	s.updateShop(w, r, shop, nil, http.StatusOK)
+}
+
+// handleDeleteShop deletes a shop
+func (s *Server) handleDeleteShop(w http.ResponseWriter, r *http.Request) {
+	shopID := mux.Vars(r)["shopId"]
+
+	if err := s.orbitManager.DeleteShop(r.Context(), shopID); err != nil {
+		if errors.Is(err, orbitdb.ErrShopNotFound) {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete shop: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]string{"id": shopID},
+	})
+}
+
+// handleGetShopItem returns a single item of a shop
+func (s *Server) handleGetShopItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	shop, ok := s.loadShop(w, r, vars["shopId"])
+	if !ok {
+		return
+	}
+
+	index := findItem(shop, vars["itemId"])
+	if index < 0 {
+		respondWithError(w, http.StatusNotFound, "Item not found: "+vars["itemId"])
+		return
+	}
+
+	item := shop.Items[index]
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    newItemResponse(item),
+	})
+}
+
+// handleCreateItem adds an item to a shop
+func (s *Server) handleCreateItem(w http.ResponseWriter, r *http.Request) {
+	shop, ok := s.loadShop(w, r, mux.Vars(r)["shopId"])
+	if !ok {
+		return
+	}
+	stock := orbitdb.SnapshotStock(shop)
+
+	var item models.Item
+	if err := decodeJSONBody(w, r, &item); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	// Items get an ID from their name unless one is given, same as the shop creator
+	if item.ID == "" {
+		item.ID = shop.NewItemID(item.Name)
+	}
+
+	if errs := validateItem(&item, "Item"); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	if findItem(shop, item.ID) >= 0 {
+		respondWithError(w, http.StatusConflict, "Item already exists: "+item.ID)
+		return
+	}
+
+	shop.Items = append(shop.Items, item)
+	if !s.saveShop(w, r, shop, stock) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Data:    item,
+	})
+}
+
+// handleReplaceItem replaces an item of a shop
+func (s *Server) handleReplaceItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	shop, ok := s.loadShop(w, r, vars["shopId"])
+	if !ok {
+		return
+	}
+	stock := orbitdb.SnapshotStock(shop)
+
+	index := findItem(shop, vars["itemId"])
+	if index < 0 {
+		respondWithError(w, http.StatusNotFound, "Item not found: "+vars["itemId"])
+		return
+	}
+
+	var item models.Item
+	if err := decodeJSONBody(w, r, &item); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	// The path decides which item is replaced
+	if item.ID != "" && item.ID != vars["itemId"] {
+		respondWithValidationErrors(w, []ValidationError{{Field: "Item.ID", Message: "does not match the item ID in the URL"}})
+		return
+	}
+	item.ID = vars["itemId"]
+
+	if errs := validateItem(&item, "Item"); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	shop.Items[index] = item
+	if !s.saveShop(w, r, shop, stock) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    item,
+	})
+}
+
+// handlePatchItem updates selected fields of an item
+func (s *Server) handlePatchItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	shop, ok := s.loadShop(w, r, vars["shopId"])
+	if !ok {
+		return
+	}
+	stock := orbitdb.SnapshotStock(shop)
+
+	index := findItem(shop, vars["itemId"])
+	if index < 0 {
+		respondWithError(w, http.StatusNotFound, "Item not found: "+vars["itemId"])
+		return
+	}
+
+	var patch itemPatch
+	if err := decodeJSONBody(w, r, &patch); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	// Apply the fields that were provided
+	item := shop.Items[index]
+	if patch.Name != nil {
+		item.Name = *patch.Name
+	}
+	if patch.Price != nil {
+		item.Price = *patch.Price
+	}
+	if patch.Description != nil {
+		item.Description = *patch.Description
+	}
+	if patch.PhotoPaths != nil {
+		item.PhotoPaths = *patch.PhotoPaths
+	}
+	if patch.Inventory != nil {
+		item.Inventory = *patch.Inventory
+	}
+	if patch.Options != nil {
+		item.Options = *patch.Options
+	}
+	if patch.Variants != nil {
+		item.Variants = *patch.Variants
+	}
+	if patch.Categories != nil {
+		item.Categories = *patch.Categories
+	}
+	if patch.Tags != nil {
+		item.Tags = *patch.Tags
+	}
+
+	if errs := validateItem(&item, "Item"); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	shop.Items[index] = item
+	if !s.saveShop(w, r, shop, stock) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    item,
+	})
+}
+
+// handleDeleteItem removes an item from a shop
+func (s *Server) handleDeleteItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	shop, ok := s.loadShop(w, r, vars["shopId"])
+	if !ok {
+		return
+	}
+	stock := orbitdb.SnapshotStock(shop)
+
+	index := findItem(shop, vars["itemId"])
+	if index < 0 {
+		respondWithError(w, http.StatusNotFound, "Item not found: "+vars["itemId"])
+		return
+	}
+
+	shop.Items = append(shop.Items[:index], shop.Items[index+1:]...)
+	if !s.saveShop(w, r, shop, stock) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]string{"id": vars["itemId"]},
+	})
+}
+
+// Write helpers
+
+// loadShop fetches a shop for modification, writing a 404 or 500 response on failure
+func (s *Server) loadShop(w http.ResponseWriter, r *http.Request, shopID string) (*models.Shop, bool) {
+	if shopID == "" {
+		respondWithError(w, http.StatusBadRequest, "Shop ID is required")
+		return nil, false
+	}
+
+	exists, err := s.orbitManager.ShopExists(r.Context(), shopID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check shop: "+err.Error())
+		return nil, false
+	}
+	if !exists {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("%v: %s", orbitdb.ErrShopNotFound, shopID))
+		return nil, false
+	}
+
+	shop, err := s.orbitManager.GetShop(r.Context(), shopID)
+	if err != nil {
+		if errors.Is(err, orbitdb.ErrShopNotFound) {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return nil, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to load shop: "+err.Error())
+		return nil, false
+	}
+
+	return shop, true
+}
+
+// saveShop validates and writes a shop after an item change, writing an error
+// response on failure. stock is what the shop's stock counts were when it was loaded.
+func (s *Server) saveShop(w http.ResponseWriter, r *http.Request, shop *models.Shop, stock orbitdb.StockCounts) bool {
+	if errs := validateShop(shop); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return false
+	}
+
+	if err := s.orbitManager.UpdateShop(r.Context(), shop, stock); err != nil {
+		if errors.Is(err, orbitdb.ErrUnauthorizedWriter) {
+			respondWithError(w, http.StatusForbidden, err.Error())
+			return false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to update shop: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+// updateShop writes an already validated shop and responds with it, see saveShop for stock
+func (s *Server) updateShop(w http.ResponseWriter, r *http.Request, shop *models.Shop, stock orbitdb.StockCounts, statusCode int) {
+	if err := s.orbitManager.UpdateShop(r.Context(), shop, stock); err != nil {
+		if errors.Is(err, orbitdb.ErrShopNotFound) {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, orbitdb.ErrUnauthorizedWriter) {
+			respondWithError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Failed to update shop: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, statusCode, Response{
+		Success: true,
+		Data:    shop,
+	})
+}
+
+// findItem returns the index of the item with the given ID, or -1
+func findItem(shop *models.Shop, itemID string) int {
+	for i := range shop.Items {
+		if shop.Items[i].ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateShop runs the model validation for a shop and all of its items
+func validateShop(shop *models.Shop) []ValidationError {
+	var errs []ValidationError
+
+	// Items without an ID get one from their name, like items added on their own
+	for i := range shop.Items {
+		if shop.Items[i].ID == "" {
+			shop.Items[i].ID = shop.NewItemID(shop.Items[i].Name)
+		}
+	}
+
+	if err := shop.Validate(); err != nil {
+		errs = append(errs, ValidationError{Field: shopErrorField(err), Message: err.Error()})
+	}
+
+	seen := make(map[string]bool)
+	for i := range shop.Items {
+		prefix := fmt.Sprintf("Items[%d]", i)
+		errs = append(errs, validateItem(&shop.Items[i], prefix)...)
+
+		if id := shop.Items[i].ID; id != "" {
+			if seen[id] {
+				errs = append(errs, ValidationError{Field: prefix + ".ID", Message: "duplicate item ID " + id})
+			}
+			seen[id] = true
+		}
+	}
+
+	return errs
+}
+
+// validateItem runs the model validation for an item, prefixing field names with prefix
+func validateItem(item *models.Item, prefix string) []ValidationError {
+	if err := item.Validate(); err != nil {
+		return []ValidationError{{Field: prefix + "." + itemErrorField(err), Message: err.Error()}}
+	}
+	return nil
+}
+
+// shopErrorField maps a shop validation error to the field it refers to
+func shopErrorField(err error) string {
+	switch {
+	case errors.Is(err, models.ErrInvalidShopID):
+		return "ID"
+	case errors.Is(err, models.ErrEmptyShopName):
+		return "Name"
+	case errors.Is(err, models.ErrEmptyOwnerAddress):
+		return "OwnerAddress"
+	case errors.Is(err, models.ErrEmptyCategoryName), errors.Is(err, models.ErrDuplicateCategory),
+		errors.Is(err, models.ErrUnknownCategory):
+		return "Categories"
+	default:
+		return ""
+	}
+}
+
+// itemErrorField maps an item validation error to the field it refers to
+func itemErrorField(err error) string {
+	switch {
+	case errors.Is(err, models.ErrInvalidItemID):
+		return "ID"
+	case errors.Is(err, models.ErrEmptyItemName):
+		return "Name"
+	case errors.Is(err, models.ErrInvalidPrice):
+		return "Price"
+	case errors.Is(err, models.ErrInvalidInventory):
+		return "Inventory"
+	case errors.Is(err, models.ErrInvalidOption):
+		return "Options"
+	case errors.Is(err, models.ErrInvalidVariant), errors.Is(err, models.ErrDuplicateVariant):
+		return "Variants"
+	case errors.Is(err, models.ErrInvalidTag):
+		return "Tags"
+	default:
+		return ""
+	}
+}
+
+// decodeJSONBody decodes a size-limited JSON body, rejecting unknown fields
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// respondWithValidationErrors writes a 422 response listing the invalid fields
+func respondWithValidationErrors(w http.ResponseWriter, errs []ValidationError) {
+	respondWithJSON(w, http.StatusUnprocessableEntity, Response{
+		Success:          false,
+		Error:            "Validation failed",
+		ValidationErrors: errs,
+	})
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/services/auth"
+)
+
+const (
+	// Headers carrying a wallet signature over the request
+	AddressHeader   = "X-IndieNode-Address"
+	TimestampHeader = "X-IndieNode-Timestamp"
+	SignatureHeader = "X-IndieNode-Signature"
+
+	// signatureWindow is how far a signed request's timestamp may be from the server clock
+	signatureWindow = 5 * time.Minute
+)
+
+// signerContextKey is the context key holding the verified signer address
+type signerContextKey struct{}
+
+// SignerFromContext returns the wallet address that signed the request, if any
+func SignerFromContext(ctx context.Context) (common.Address, bool) {
+	signer, ok := ctx.Value(signerContextKey{}).(common.Address)
+	return signer, ok
+}
+
+// SigningMessage builds the message a wallet signs for an API request.
+// Clients sign this with personal_sign and send the result in SignatureHeader.
+func SigningMessage(method, path string, body []byte, timestamp int64) string {
+	bodyHash := sha256.Sum256(body)
+
+	return "IndieNode API request\n" +
+		"Method: " + strings.ToUpper(method) + "\n" +
+		"Path: " + path + "\n" +
+		"Body SHA-256: " + hex.EncodeToString(bodyHash[:]) + "\n" +
+		"Timestamp: " + strconv.FormatInt(timestamp, 10)
+}
+
+// requireSignature wraps a handler so it only runs for requests carrying a valid
+// wallet signature. The signer is stored in the request context.
+func (s *Server) requireSignature(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		signer, err := s.verifyRequestSignature(w, r)
+		if err != nil {
+			log.Printf("Rejected unsigned request %s %s: %v", r.Method, r.URL.Path, err)
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized: "+err.Error())
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), signerContextKey{}, signer)))
+	}
+}
+
+// requireOwner wraps a handler so it only runs for requests signed by the owner
+// of the shop in the URL
+func (s *Server) requireOwner(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireSignature(func(w http.ResponseWriter, r *http.Request) {
+		shopID := mux.Vars(r)["shopId"]
+		signer, _ := SignerFromContext(r.Context())
+
+		isOwner, err := s.orbitManager.VerifyOwnership(r.Context(), shopID, signer.Hex())
+		if err != nil {
+			if errors.Is(err, orbitdb.ErrShopNotFound) {
+				respondWithError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to verify shop owner: "+err.Error())
+			return
+		}
+		if !isOwner {
+			log.Printf("Rejected %s %s: %s is not the shop owner", r.Method, r.URL.Path, signer.Hex())
+			respondWithError(w, http.StatusForbidden, "Only the shop owner can perform this action")
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// verifyRequestSignature checks the signature headers against the request and
+// returns the signer. The body is read and replaced so handlers can still decode it.
+func (s *Server) verifyRequestSignature(w http.ResponseWriter, r *http.Request) (common.Address, error) {
+	address := r.Header.Get(AddressHeader)
+	timestampHeader := r.Header.Get(TimestampHeader)
+	signature := r.Header.Get(SignatureHeader)
+	if address == "" || timestampHeader == "" || signature == "" {
+		return common.Address{}, ErrMissingSignature
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid timestamp: %w", err)
+	}
+	signedAt := time.Unix(timestamp, 0)
+	if age := time.Since(signedAt); age > signatureWindow || age < -signatureWindow {
+		return common.Address{}, ErrRequestExpired
+	}
+
+	// Read the body so its hash can be checked, then put it back
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+		if err != nil {
+			return common.Address{}, fmt.Errorf("failed to read request body: %w", err)
+		}
+		r.Body.Close()
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	message := SigningMessage(r.Method, r.URL.Path, body, timestamp)
+	if err := auth.VerifySignature(address, message, signature); err != nil {
+		return common.Address{}, err
+	}
+
+	if !s.markRequestUsed(address, message, signedAt) {
+		return common.Address{}, ErrReplayedRequest
+	}
+
+	return common.HexToAddress(address), nil
+}
+
+// markRequestUsed records a signed request so it can't be replayed.
+// Requests are keyed by signer and message rather than by signature bytes,
+// since one message can have several valid encodings of its signature.
+// Returns false if it was already used.
+func (s *Server) markRequestUsed(address, message string, signedAt time.Time) bool {
+	hash := sha256.Sum256([]byte(common.HexToAddress(address).Hex() + "\n" + message))
+	key := hex.EncodeToString(hash[:])
+	now := time.Now()
+
+	s.signatureMutex.Lock()
+	defer s.signatureMutex.Unlock()
+
+	// Requests outside the window are rejected anyway, so forget them
+	for k, expiry := range s.usedRequests {
+		if now.After(expiry) {
+			delete(s.usedRequests, k)
+		}
+	}
+
+	if _, used := s.usedRequests[key]; used {
+		return false
+	}
+	s.usedRequests[key] = signedAt.Add(signatureWindow)
+	return true
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"IndieNode/db/orbitdb"
+)
+
+// writerRequest holds the wallet an owner grants write access to
+type writerRequest struct {
+	Address string `json:"address"`
+}
+
+// Writer Handlers
+
+// handleListWriters returns the wallets, besides the owner, allowed to write to a shop
+func (s *Server) handleListWriters(w http.ResponseWriter, r *http.Request) {
+	shopID := mux.Vars(r)["shopId"]
+
+	writers, err := s.orbitManager.GetWriters(r.Context(), shopID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list writers: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    writers,
+	})
+}
+
+// handleGrantWriter allows another wallet to write to a shop
+func (s *Server) handleGrantWriter(w http.ResponseWriter, r *http.Request) {
+	shopID := mux.Vars(r)["shopId"]
+
+	var req writerRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := s.orbitManager.GrantWriter(r.Context(), shopID, req.Address); err != nil {
+		respondWithWriterError(w, "Failed to grant write access: ", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]string{"address": req.Address},
+	})
+}
+
+// handleRevokeWriter removes a wallet's write access to a shop
+func (s *Server) handleRevokeWriter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.orbitManager.RevokeWriter(r.Context(), vars["shopId"], vars["address"]); err != nil {
+		respondWithWriterError(w, "Failed to revoke write access: ", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]string{"address": vars["address"]},
+	})
+}
+
+// respondWithWriterError maps errors from changing a shop's writers to a status code
+func respondWithWriterError(w http.ResponseWriter, prefix string, err error) {
+	switch {
+	case errors.Is(err, orbitdb.ErrUnauthorizedWriter):
+		respondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, orbitdb.ErrInvalidWriter):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, prefix+err.Error())
+	}
+}
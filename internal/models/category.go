@@ -0,0 +1,230 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Category groups a shop's items, like "Shirts" or "New arrivals". Items can
+// be in several categories.
+type Category struct {
+	ID   string // What items refer to the category by, derived from Name if empty
+	Name string
+}
+
+// Category returns the shop's category with the given ID, or nil if there is none
+func (s *Shop) Category(id string) *Category {
+	for i := range s.Categories {
+		if s.Categories[i].ID == id {
+			return &s.Categories[i]
+		}
+	}
+	return nil
+}
+
+// Tags returns every tag used by the shop's items, sorted. Tags that differ
+// only in case are listed once, spelled as they first appear.
+func (s *Shop) Tags() []string {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, item := range s.Items {
+		for _, tag := range item.Tags {
+			if key := strings.ToLower(tag); !seen[key] {
+				seen[key] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i]) < strings.ToLower(tags[j])
+	})
+	return tags
+}
+
+// ItemsByCategory returns the shop's items in the order of their first
+// category, items without one last. Items keep their order within a category.
+func (s *Shop) ItemsByCategory() []Item {
+	items := make([]Item, 0, len(s.Items))
+	for _, i := range s.CategoryOrder() {
+		items = append(items, s.Items[i])
+	}
+	return items
+}
+
+// CategoryOrder returns the indices of the shop's items in the order
+// ItemsByCategory lists them
+func (s *Shop) CategoryOrder() []int {
+	position := make(map[string]int, len(s.Categories))
+	for i, category := range s.Categories {
+		position[category.ID] = i
+	}
+	rank := func(item Item) int {
+		if len(item.Categories) > 0 {
+			if i, ok := position[item.Categories[0]]; ok {
+				return i
+			}
+		}
+		return len(s.Categories)
+	}
+
+	order := make([]int, len(s.Items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return rank(s.Items[order[i]]) < rank(s.Items[order[j]])
+	})
+	return order
+}
+
+// AddCategory adds a category with the given name to the end of the shop's categories
+func (s *Shop) AddCategory(name string) (*Category, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrEmptyCategoryName
+	}
+
+	names := make(map[string]bool, len(s.Categories))
+	ids := make(map[string]bool, len(s.Categories))
+	for _, category := range s.Categories {
+		names[strings.ToLower(category.Name)] = true
+		ids[category.ID] = true
+	}
+	if names[strings.ToLower(name)] {
+		return nil, ErrDuplicateCategory
+	}
+
+	s.Categories = append(s.Categories, Category{
+		ID:   categoryID(name, len(s.Categories), ids),
+		Name: name,
+	})
+	return &s.Categories[len(s.Categories)-1], nil
+}
+
+// RemoveCategory removes the category with the given ID and takes the shop's items out of it
+func (s *Shop) RemoveCategory(id string) {
+	for i := range s.Categories {
+		if s.Categories[i].ID == id {
+			s.Categories = append(s.Categories[:i], s.Categories[i+1:]...)
+			break
+		}
+	}
+
+	for i := range s.Items {
+		item := &s.Items[i]
+		for j := 0; j < len(item.Categories); j++ {
+			if item.Categories[j] == id {
+				item.Categories = append(item.Categories[:j], item.Categories[j+1:]...)
+				j--
+			}
+		}
+	}
+}
+
+// validateCategories checks the shop's categories and that its items are only
+// in those. Missing category IDs are filled in, without taking the ID another
+// category was given.
+func (s *Shop) validateCategories() error {
+	taken := make(map[string]bool, len(s.Categories))
+	for _, category := range s.Categories {
+		if category.ID != "" {
+			if taken[category.ID] {
+				return ErrDuplicateCategory
+			}
+			taken[category.ID] = true
+		}
+	}
+
+	names := make(map[string]bool, len(s.Categories))
+	ids := make(map[string]bool, len(s.Categories))
+	for i := range s.Categories {
+		category := &s.Categories[i]
+		category.Name = strings.TrimSpace(category.Name)
+		if category.Name == "" {
+			return ErrEmptyCategoryName
+		}
+		if names[strings.ToLower(category.Name)] {
+			return ErrDuplicateCategory
+		}
+		names[strings.ToLower(category.Name)] = true
+
+		if category.ID == "" {
+			category.ID = categoryID(category.Name, i, taken)
+			taken[category.ID] = true
+		}
+		if ids[category.ID] {
+			return ErrDuplicateCategory
+		}
+		ids[category.ID] = true
+	}
+
+	for _, item := range s.Items {
+		for _, id := range item.Categories {
+			if !ids[id] {
+				return fmt.Errorf("%w: %s is in %q", ErrUnknownCategory, item.Name, id)
+			}
+		}
+	}
+	return nil
+}
+
+// categoryID derives an ID for a category from its name that isn't one of
+// taken, falling back to its position for names without usable characters
+func categoryID(name string, index int, taken map[string]bool) string {
+	base := urlSafeName(name)
+	if base == "" {
+		base = fmt.Sprintf("category-%d", index+1)
+	}
+	id := base
+	for n := 2; taken[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return id
+}
+
+// InCategory returns true if the item is in the category with the given ID
+func (i Item) InCategory(id string) bool {
+	for _, category := range i.Categories {
+		if category == id {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag returns true if the item has the tag, ignoring case
+func (i Item) HasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTags trims the item's tags and drops empty and repeated ones
+func (i *Item) normalizeTags() error {
+	var tags []string
+	for _, tag := range i.Tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" {
+			continue
+		}
+		if strings.Contains(tag, ",") {
+			return ErrInvalidTag
+		}
+		duplicate := false
+		for _, existing := range tags {
+			if strings.EqualFold(existing, tag) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			tags = append(tags, tag)
+		}
+	}
+	i.Tags = tags
+	return nil
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateCategories(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories []Category
+		items      []Item
+		wantIDs    []string
+		wantErr    error
+	}{
+		{
+			name:       "IDs from names",
+			categories: []Category{{Name: "Shirts"}, {Name: " New Arrivals "}},
+			wantIDs:    []string{"shirts", "new-arrivals"},
+		},
+		{
+			name:       "names with the same URL name",
+			categories: []Category{{Name: "Mugs!"}, {Name: "Mugs?"}, {Name: "mugs-2"}},
+			wantIDs:    []string{"mugs", "mugs-2", "mugs-2-2"},
+		},
+		{
+			name:       "ID given to a later category",
+			categories: []Category{{Name: "Mugs"}, {ID: "mugs", Name: "Cups"}},
+			wantIDs:    []string{"mugs-2", "mugs"},
+		},
+		{
+			name:       "names without usable characters",
+			categories: []Category{{Name: "☀"}, {Name: "Category 1"}, {Name: "☂"}},
+			wantIDs:    []string{"category-1", "category-1-2", "category-3"},
+		},
+		{
+			name:       "items in categories",
+			categories: []Category{{ID: "cups", Name: "Cups"}, {Name: "Sale"}},
+			items:      []Item{{Name: "Mug", Categories: []string{"sale", "cups"}}},
+			wantIDs:    []string{"cups", "sale"},
+		},
+		{name: "empty name", categories: []Category{{Name: "Mugs"}, {Name: "  "}}, wantErr: ErrEmptyCategoryName},
+		{name: "repeated name", categories: []Category{{Name: "Mugs"}, {Name: "MUGS"}}, wantErr: ErrDuplicateCategory},
+		{name: "repeated ID", categories: []Category{{ID: "mugs", Name: "Mugs"}, {ID: "mugs", Name: "Cups"}}, wantErr: ErrDuplicateCategory},
+		{
+			name:       "item in an unknown category",
+			categories: []Category{{Name: "Mugs"}},
+			items:      []Item{{Name: "Vase", Categories: []string{"vases"}}},
+			wantErr:    ErrUnknownCategory,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := &Shop{Categories: tt.categories, Items: tt.items}
+			err := shop.validateCategories()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateCategories() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			ids := []string{}
+			for _, category := range shop.Categories {
+				if !ValidID(category.ID) {
+					t.Errorf("category %q has ID %q", category.Name, category.ID)
+				}
+				ids = append(ids, category.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("category IDs = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestAddCategory(t *testing.T) {
+	shop := &Shop{Categories: []Category{{ID: "mugs", Name: "Cups"}}}
+
+	tests := []struct {
+		name    string
+		wantID  string
+		wantErr error
+	}{
+		{name: "Mugs", wantID: "mugs-2"},
+		{name: " Vases ", wantID: "vases"},
+		{name: "☀", wantID: "category-4"},
+		{name: "cups", wantErr: ErrDuplicateCategory},
+		{name: "  ", wantErr: ErrEmptyCategoryName},
+	}
+
+	for _, tt := range tests {
+		category, err := shop.AddCategory(tt.name)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("AddCategory(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && category.ID != tt.wantID {
+			t.Errorf("AddCategory(%q) ID = %q, want %q", tt.name, category.ID, tt.wantID)
+		}
+	}
+	if len(shop.Categories) != 4 {
+		t.Errorf("shop has %d categories, want 4", len(shop.Categories))
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr error
+	}{
+		{name: "none"},
+		{name: "trimmed", tags: []string{"  Summer \t Sale ", "gift"}, want: []string{"Summer Sale", "gift"}},
+		{name: "empty", tags: []string{"", "  ", "gift"}, want: []string{"gift"}},
+		{name: "repeated", tags: []string{"Gift", "gift", "GIFT ", "Sale"}, want: []string{"Gift", "Sale"}},
+		{name: "repeated once trimmed", tags: []string{"summer sale", "Summer  Sale"}, want: []string{"summer sale"}},
+		{name: "comma", tags: []string{"gift", "summer,sale"}, wantErr: ErrInvalidTag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := Item{Tags: tt.tags}
+			err := item.normalizeTags()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("normalizeTags() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && strings.Join(item.Tags, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("normalizeTags() = %q, want %q", item.Tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestShopTags(t *testing.T) {
+	shop := &Shop{Items: []Item{
+		{Tags: []string{"summer", "Gift"}},
+		{Tags: []string{"gift", "autumn"}},
+		{Tags: []string{"Summer", "blue"}},
+	}}
+
+	if got, want := strings.Join(shop.Tags(), ","), "autumn,blue,Gift,summer"; got != want {
+		t.Errorf("Tags() = %s, want %s", got, want)
+	}
+}
+
+func TestCategoryOrder(t *testing.T) {
+	shop := &Shop{
+		Categories: []Category{{ID: "new", Name: "New"}, {ID: "shirts", Name: "Shirts"}},
+		Items: []Item{
+			{ID: "tee", Categories: []string{"shirts"}},
+			{ID: "mug"},
+			{ID: "polo", Categories: []string{"new", "shirts"}},
+			{ID: "vase", Categories: []string{"removed", "new"}},
+			{ID: "vest", Categories: []string{"shirts", "new"}},
+		},
+	}
+
+	// Items go by their first category, those without a known one last, in shop order
+	ids := []string{}
+	for _, item := range shop.ItemsByCategory() {
+		ids = append(ids, item.ID)
+	}
+	if got, want := strings.Join(ids, ","), "polo,tee,vest,mug,vase"; got != want {
+		t.Errorf("ItemsByCategory() = %s, want %s", got, want)
+	}
+
+	shop.RemoveCategory("new")
+	ids = ids[:0]
+	for _, item := range shop.ItemsByCategory() {
+		ids = append(ids, item.ID)
+	}
+	if got, want := strings.Join(ids, ","), "tee,polo,vest,mug,vase"; got != want {
+		t.Errorf("ItemsByCategory() after RemoveCategory() = %s, want %s", got, want)
+	}
+	if vase := shop.Items[3]; len(vase.Categories) != 1 || vase.Categories[0] != "removed" {
+		t.Errorf("vase is in %v after RemoveCategory(), want [removed]", vase.Categories)
+	}
+}
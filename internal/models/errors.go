@@ -3,6 +3,12 @@ package models
 import "errors"
 
 var (
+	// ErrInvalidShopID is returned when a shop ID has characters other than letters, digits, hyphens and underscores
+	ErrInvalidShopID = errors.New("shop ID may only contain letters, digits, hyphens and underscores")
+	
+	// ErrInvalidItemID is returned when an item ID has characters other than letters, digits, hyphens and underscores
+	ErrInvalidItemID = errors.New("item ID may only contain letters, digits, hyphens and underscores")
+	
 	// ErrEmptyShopName is returned when a shop name is empty
 	ErrEmptyShopName = errors.New("shop name cannot be empty")
 	
@@ -14,4 +20,31 @@ var (
 	
 	// ErrInvalidPrice is returned when an item price is negative
 	ErrInvalidPrice = errors.New("item price cannot be negative")
+	
+	// ErrInvalidInventory is returned when an item's stock count is negative but not unlimited
+	ErrInvalidInventory = errors.New("item inventory must be -1 (unlimited) or a stock count")
+	
+	// ErrInvalidOption is returned when an item option has no name, no values or a repeated value
+	ErrInvalidOption = errors.New("item options need a unique name and distinct, non-empty values")
+	
+	// ErrInvalidVariant is returned when a variant doesn't pick one value of each of its item's options
+	ErrInvalidVariant = errors.New("item variant must pick one value of each option")
+	
+	// ErrDuplicateVariant is returned when two variants of an item share an ID or options
+	ErrDuplicateVariant = errors.New("item variants must have distinct IDs and options")
+	
+	// ErrTooManyVariants is returned when an item has, or its options combine into, more than MaxVariants variants
+	ErrTooManyVariants = errors.New("item options may combine into at most 100 variants")
+	
+	// ErrInvalidTag is returned when an item tag contains a comma, which separates tags
+	ErrInvalidTag = errors.New("item tags cannot contain commas")
+	
+	// ErrEmptyCategoryName is returned when a shop category has no name
+	ErrEmptyCategoryName = errors.New("category name cannot be empty")
+	
+	// ErrDuplicateCategory is returned when two categories of a shop share an ID or name
+	ErrDuplicateCategory = errors.New("categories must have distinct IDs and names")
+	
+	// ErrUnknownCategory is returned when an item is in a category the shop doesn't have
+	ErrUnknownCategory = errors.New("item category does not exist in the shop")
 )
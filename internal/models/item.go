@@ -1,5 +1,13 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// UnlimitedInventory marks an item whose stock is not tracked
+const UnlimitedInventory int64 = -1
+
 // Item represents a product or service in a shop
 type Item struct {
 	ID              string
@@ -7,16 +15,92 @@ type Item struct {
 	Price           float64
 	Description     string
 	PhotoPaths      []string
-	LocalPhotoPaths []string // For UI preview
+	LocalPhotoPaths []string      // For UI preview
+	Inventory       int64         // Units in stock, UnlimitedInventory if not tracked. Unused if the item has variants.
+	Options         []OptionGroup `json:",omitempty"` // Choices buyers make, such as size or color
+	Variants        []Variant     `json:",omitempty"` // Combinations of Options that can be bought
+	Categories      []string      `json:",omitempty"` // IDs of the shop's categories the item is in
+	Tags            []string      `json:",omitempty"` // Free-form labels buyers can filter by
+}
+
+// UnmarshalJSON decodes an item, treating a missing Inventory as unlimited so
+// items saved before stock was tracked don't show up as sold out
+func (i *Item) UnmarshalJSON(data []byte) error {
+	type plainItem Item
+	item := plainItem{Inventory: UnlimitedInventory}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	*i = Item(item)
+	return nil
+}
+
+// HasUnlimitedInventory returns true if the item's stock is not tracked
+func (i Item) HasUnlimitedInventory() bool {
+	return i.Inventory == UnlimitedInventory
+}
+
+// SoldOut returns true if the item's stock is tracked and none is left. Items
+// with variants are sold out once every variant is.
+func (i Item) SoldOut() bool {
+	if i.HasVariants() {
+		for _, variant := range i.Variants {
+			if !variant.SoldOut() {
+				return false
+			}
+		}
+		return true
+	}
+	return !i.HasUnlimitedInventory() && i.Inventory <= 0
+}
+
+// Slug returns a URL-safe name for the item's page, derived from its name the
+// way Shop.GenerateURLName is, or from its ID if the name has no usable characters
+func (i Item) Slug() string {
+	if slug := urlSafeName(i.Name); slug != "" {
+		return slug
+	}
+	if slug := urlSafeName(i.ID); slug != "" {
+		return slug
+	}
+	return "item"
+}
+
+// NewItemID derives an ID for a new item from its name that none of the shop's
+// items has yet
+func (s *Shop) NewItemID(name string) string {
+	taken := make(map[string]bool, len(s.Items))
+	for _, item := range s.Items {
+		taken[item.ID] = true
+	}
+
+	base := urlSafeName(name)
+	if base == "" {
+		base = "item"
+	}
+	id := base
+	for n := 2; taken[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return id
 }
 
 // Validate performs basic validation on the item data
 func (i *Item) Validate() error {
+	if !ValidID(i.ID) {
+		return ErrInvalidItemID
+	}
 	if i.Name == "" {
 		return ErrEmptyItemName
 	}
 	if i.Price < 0 {
 		return ErrInvalidPrice
 	}
-	return nil
+	if i.Inventory < UnlimitedInventory {
+		return ErrInvalidInventory
+	}
+	if err := i.normalizeTags(); err != nil {
+		return err
+	}
+	return i.validateVariants()
 }
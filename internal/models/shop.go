@@ -19,18 +19,28 @@ type Shop struct {
 	PrimaryColor   color.RGBA
 	SecondaryColor color.RGBA
 	TertiaryColor  color.RGBA
+	Template       string            // Template pack the site is generated with, see templates/<name>/template.json
+	TemplateOptions map[string]string // Values of the template pack's options, by option name
 	LogoPath       string
 	LocalLogoPath  string // For UI preview
 	Items          []Item
+	Categories     []Category `json:",omitempty"` // Item categories, in the order the site lists them
 	CID            string // IPFS Content Identifier
+	IPNSName       string // IPNS name the shop is permanently published under (/ipns/<IPNSName>)
+	ENSName        string // ENS name whose contenthash follows the published CID, e.g. myshop.eth
 	Published       bool
 }
 
 // Validate performs basic validation on the shop data
 // GenerateURLName creates a URL-safe name from the shop name
 func (s *Shop) GenerateURLName() {
+	s.URLName = urlSafeName(s.Name)
+}
+
+// urlSafeName lowercases name and reduces it to letters, digits and single hyphens
+func urlSafeName(name string) string {
 	// Convert to lowercase
-	urlName := strings.ToLower(s.Name)
+	urlName := strings.ToLower(name)
 	
 	// Replace spaces with hyphens
 	urlName = strings.ReplaceAll(urlName, " ", "-")
@@ -44,12 +54,23 @@ func (s *Shop) GenerateURLName() {
 	urlName = reg.ReplaceAllString(urlName, "-")
 	
 	// Trim hyphens from start and end
-	urlName = strings.Trim(urlName, "-")
-	
-	s.URLName = urlName
+	return strings.Trim(urlName, "-")
+}
+
+// idPattern matches valid shop and item IDs. IDs end up in file names,
+// database names and URLs, so they are kept to characters that are safe in all three.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidID returns true if id can be used as a shop or item ID
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
 }
 
 func (s *Shop) Validate() error {
+	// Shops without an ID are stored under their owner address
+	if s.ID != "" && !ValidID(s.ID) {
+		return ErrInvalidShopID
+	}
 	if s.Name == "" {
 		return ErrEmptyShopName
 	}
@@ -59,5 +80,5 @@ func (s *Shop) Validate() error {
 	if s.URLName == "" {
 		s.GenerateURLName()
 	}
-	return nil
+	return s.validateCategories()
 }
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", want: true},
+		{id: "blue-mug_2", want: true},
+		{id: "A", want: true},
+		{id: ""},
+		{id: "Blue Mug"},
+		{id: "../shop"},
+		{id: ".."},
+		{id: "mug/large"},
+		{id: `mug\large`},
+		{id: "mug\x00"},
+		{id: "café"},
+		{id: "mug\n"},
+	}
+
+	for _, tt := range tests {
+		if got := ValidID(tt.id); got != tt.want {
+			t.Errorf("ValidID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestShopValidateID(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr error
+	}{
+		{id: ""}, // Stored under the owner address
+		{id: "clay-and-co"},
+		{id: "clay/../co", wantErr: ErrInvalidShopID},
+		{id: "Clay & Co", wantErr: ErrInvalidShopID},
+	}
+
+	for _, tt := range tests {
+		shop := &Shop{ID: tt.id, Name: "Clay & Co", OwnerAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}
+		if err := shop.Validate(); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Validate() of shop %q error = %v, want %v", tt.id, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewItemID(t *testing.T) {
+	shop := &Shop{Items: []Item{{ID: "mug"}, {ID: "mug-2"}, {ID: "item"}, {ID: "Blue Vase"}}}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Vase", want: "vase"},
+		{name: "Blue Vase", want: "blue-vase"},
+		{name: "Mug", want: "mug-3"},
+		{name: "  MUG! ", want: "mug-3"},
+		{name: "☕", want: "item-2"},
+		{name: "", want: "item-2"},
+	}
+
+	for _, tt := range tests {
+		got := shop.NewItemID(tt.name)
+		if got != tt.want {
+			t.Errorf("NewItemID(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if item := (Item{ID: got, Name: "x"}); item.Validate() != nil {
+			t.Errorf("NewItemID(%q) = %q, which doesn't validate", tt.name, got)
+		}
+	}
+}
+
+func TestItemValidateID(t *testing.T) {
+	for _, id := range []string{"", "Blue Mug", "mug/large"} {
+		item := &Item{ID: id, Name: "Mug"}
+		if err := item.Validate(); !errors.Is(err, ErrInvalidItemID) {
+			t.Errorf("Validate() of item %q error = %v, want %v", id, err, ErrInvalidItemID)
+		}
+	}
+}
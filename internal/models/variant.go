@@ -0,0 +1,235 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxVariants is how many variants an item may have, which caps the
+// combinations of its options
+const MaxVariants = 100
+
+// OptionGroup is a choice buyers make when ordering an item, like its size
+type OptionGroup struct {
+	Name   string
+	Values []string
+}
+
+// Variant is one combination of an item's options, with its own stock and
+// optionally its own price, SKU and photo
+type Variant struct {
+	ID             string            // Identifies the variant in orders and stock, derived from its options if empty
+	Options        map[string]string // Value chosen for each of the item's option groups, by group name
+	SKU            string            `json:",omitempty"`
+	Price          *float64          `json:",omitempty"` // Overrides the item's price, nil to use it
+	PhotoPath      string            `json:",omitempty"`
+	LocalPhotoPath string            `json:",omitempty"` // For UI preview
+	Inventory      int64             // Units in stock, UnlimitedInventory if not tracked
+}
+
+// UnmarshalJSON decodes a variant, treating a missing Inventory as unlimited like Item does
+func (v *Variant) UnmarshalJSON(data []byte) error {
+	type plainVariant Variant
+	variant := plainVariant{Inventory: UnlimitedInventory}
+	if err := json.Unmarshal(data, &variant); err != nil {
+		return err
+	}
+	*v = Variant(variant)
+	return nil
+}
+
+// HasUnlimitedInventory returns true if the variant's stock is not tracked
+func (v Variant) HasUnlimitedInventory() bool {
+	return v.Inventory == UnlimitedInventory
+}
+
+// SoldOut returns true if the variant's stock is tracked and none is left
+func (v Variant) SoldOut() bool {
+	return !v.HasUnlimitedInventory() && v.Inventory <= 0
+}
+
+// HasVariants returns true if the item is bought as one of its variants
+func (i Item) HasVariants() bool {
+	return len(i.Variants) > 0
+}
+
+// Variant returns the variant with the given ID, or nil if the item has none
+func (i Item) Variant(id string) *Variant {
+	for j := range i.Variants {
+		if i.Variants[j].ID == id {
+			return &i.Variants[j]
+		}
+	}
+	return nil
+}
+
+// VariantPrice returns what a variant of the item costs
+func (i Item) VariantPrice(v Variant) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return i.Price
+}
+
+// VariantLabel describes a variant by its option values in the order of the
+// item's option groups, e.g. "M / Red"
+func (i Item) VariantLabel(v Variant) string {
+	values := []string{}
+	for _, group := range i.Options {
+		if value := v.Options[group.Name]; value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return v.ID
+	}
+	return strings.Join(values, " / ")
+}
+
+// GenerateVariants returns a variant for every combination of the item's
+// options. Variants the item already has for a combination are kept as they
+// are, new ones use the item's price and unlimited stock. Returns
+// ErrTooManyVariants if there are more than MaxVariants combinations.
+func (i Item) GenerateVariants() ([]Variant, error) {
+	if len(i.Options) == 0 {
+		return nil, nil
+	}
+
+	// Checked before building them, a few long option lists multiply quickly
+	count := 1
+	for _, group := range i.Options {
+		count *= len(group.Values)
+		if count > MaxVariants {
+			return nil, ErrTooManyVariants
+		}
+	}
+
+	combinations := []map[string]string{{}}
+	for _, group := range i.Options {
+		next := []map[string]string{}
+		for _, combination := range combinations {
+			for _, value := range group.Values {
+				options := make(map[string]string, len(combination)+1)
+				for name, chosen := range combination {
+					options[name] = chosen
+				}
+				options[group.Name] = value
+				next = append(next, options)
+			}
+		}
+		combinations = next
+	}
+
+	variants := make([]Variant, 0, len(combinations))
+	for _, options := range combinations {
+		variant := Variant{Options: options, Inventory: UnlimitedInventory}
+		for _, existing := range i.Variants {
+			if sameOptions(existing.Options, options) {
+				variant = existing
+				break
+			}
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// variantID derives a variant's ID from its option values, falling back to its
+// position for values without usable characters
+func (i Item) variantID(v Variant, index int) string {
+	values := []string{}
+	for _, group := range i.Options {
+		values = append(values, v.Options[group.Name])
+	}
+	if id := urlSafeName(strings.Join(values, " ")); id != "" {
+		return id
+	}
+	return fmt.Sprintf("variant-%d", index+1)
+}
+
+// validateVariants checks the item's options and that each variant picks one
+// of the values of every option. Missing variant IDs are filled in, without
+// taking an ID another variant was given.
+func (i *Item) validateVariants() error {
+	if len(i.Variants) > MaxVariants {
+		return ErrTooManyVariants
+	}
+
+	groups := make(map[string]map[string]bool, len(i.Options))
+	for _, group := range i.Options {
+		if group.Name == "" || len(group.Values) == 0 || groups[group.Name] != nil {
+			return ErrInvalidOption
+		}
+		values := make(map[string]bool, len(group.Values))
+		for _, value := range group.Values {
+			if value == "" || values[value] {
+				return ErrInvalidOption
+			}
+			values[value] = true
+		}
+		groups[group.Name] = values
+	}
+
+	given := make(map[string]bool, len(i.Variants))
+	for _, variant := range i.Variants {
+		if variant.ID != "" {
+			if given[variant.ID] {
+				return ErrDuplicateVariant
+			}
+			given[variant.ID] = true
+		}
+	}
+
+	ids := make(map[string]bool, len(i.Variants))
+	for j := range i.Variants {
+		variant := &i.Variants[j]
+		if len(variant.Options) != len(groups) {
+			return ErrInvalidVariant
+		}
+		for name, value := range variant.Options {
+			if !groups[name][value] {
+				return ErrInvalidVariant
+			}
+		}
+		for k := 0; k < j; k++ {
+			if sameOptions(i.Variants[k].Options, variant.Options) {
+				return ErrDuplicateVariant
+			}
+		}
+
+		if variant.Price != nil && *variant.Price < 0 {
+			return ErrInvalidPrice
+		}
+		if variant.Inventory < UnlimitedInventory {
+			return ErrInvalidInventory
+		}
+
+		if variant.ID == "" {
+			base := i.variantID(*variant, j)
+			variant.ID = base
+			for n := 2; ids[variant.ID] || given[variant.ID]; n++ {
+				variant.ID = fmt.Sprintf("%s-%d", base, n)
+			}
+		}
+		if ids[variant.ID] {
+			return ErrDuplicateVariant
+		}
+		ids[variant.ID] = true
+	}
+
+	return nil
+}
+
+// sameOptions returns true if a and b choose the same value for every option
+func sameOptions(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if b[name] != value {
+			return false
+		}
+	}
+	return true
+}
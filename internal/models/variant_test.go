@@ -0,0 +1,282 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// sizes and colours are the option groups of the test items
+var (
+	sizes   = OptionGroup{Name: "Size", Values: []string{"S", "M"}}
+	colours = OptionGroup{Name: "Colour", Values: []string{"Red", "Blue"}}
+)
+
+func TestValidateVariants(t *testing.T) {
+	price := 12.0
+	negative := -1.0
+
+	tests := []struct {
+		name     string
+		options  []OptionGroup
+		variants []Variant
+		wantErr  error
+	}{
+		{
+			name:    "valid",
+			options: []OptionGroup{sizes, colours},
+			variants: []Variant{
+				{Options: map[string]string{"Size": "S", "Colour": "Red"}, Price: &price, Inventory: 3},
+				{Options: map[string]string{"Size": "M", "Colour": "Red"}, Inventory: UnlimitedInventory},
+			},
+		},
+		{name: "options without variants", options: []OptionGroup{sizes}},
+		{name: "option without a name", options: []OptionGroup{{Values: []string{"S"}}}, wantErr: ErrInvalidOption},
+		{name: "option without values", options: []OptionGroup{{Name: "Size"}}, wantErr: ErrInvalidOption},
+		{name: "empty option value", options: []OptionGroup{{Name: "Size", Values: []string{"S", ""}}}, wantErr: ErrInvalidOption},
+		{name: "repeated option value", options: []OptionGroup{{Name: "Size", Values: []string{"S", "M", "S"}}}, wantErr: ErrInvalidOption},
+		{name: "repeated option name", options: []OptionGroup{sizes, {Name: "Size", Values: []string{"L"}}}, wantErr: ErrInvalidOption},
+		{
+			name:     "variant missing an option",
+			options:  []OptionGroup{sizes, colours},
+			variants: []Variant{{Options: map[string]string{"Size": "S"}}},
+			wantErr:  ErrInvalidVariant,
+		},
+		{
+			name:     "variant with an unknown value",
+			options:  []OptionGroup{sizes},
+			variants: []Variant{{Options: map[string]string{"Size": "XL"}}},
+			wantErr:  ErrInvalidVariant,
+		},
+		{
+			name:     "variant with an unknown option",
+			options:  []OptionGroup{sizes},
+			variants: []Variant{{Options: map[string]string{"Colour": "Red"}}},
+			wantErr:  ErrInvalidVariant,
+		},
+		{
+			name:    "repeated combination",
+			options: []OptionGroup{sizes},
+			variants: []Variant{
+				{ID: "small", Options: map[string]string{"Size": "S"}},
+				{ID: "also-small", Options: map[string]string{"Size": "S"}},
+			},
+			wantErr: ErrDuplicateVariant,
+		},
+		{
+			name:    "repeated ID",
+			options: []OptionGroup{sizes},
+			variants: []Variant{
+				{ID: "shirt", Options: map[string]string{"Size": "S"}},
+				{ID: "shirt", Options: map[string]string{"Size": "M"}},
+			},
+			wantErr: ErrDuplicateVariant,
+		},
+		{
+			name:     "negative price",
+			options:  []OptionGroup{sizes},
+			variants: []Variant{{Options: map[string]string{"Size": "S"}, Price: &negative}},
+			wantErr:  ErrInvalidPrice,
+		},
+		{
+			name:     "negative stock",
+			options:  []OptionGroup{sizes},
+			variants: []Variant{{Options: map[string]string{"Size": "S"}, Inventory: -2}},
+			wantErr:  ErrInvalidInventory,
+		},
+		{
+			name:     "too many variants",
+			options:  []OptionGroup{numberedOption("Number", MaxVariants+1)},
+			variants: numberedVariants("Number", MaxVariants+1),
+			wantErr:  ErrTooManyVariants,
+		},
+		{
+			name:     "as many variants as allowed",
+			options:  []OptionGroup{numberedOption("Number", MaxVariants)},
+			variants: numberedVariants("Number", MaxVariants),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := Item{ID: "shirt", Name: "Shirt", Options: tt.options, Variants: tt.variants}
+			err := item.validateVariants()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateVariants() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for _, variant := range item.Variants {
+				if !ValidID(variant.ID) {
+					t.Errorf("variant %v has ID %q", variant.Options, variant.ID)
+				}
+			}
+		})
+	}
+}
+
+// numberedOption returns an option group with n values
+func numberedOption(name string, n int) OptionGroup {
+	group := OptionGroup{Name: name}
+	for i := 1; i <= n; i++ {
+		group.Values = append(group.Values, fmt.Sprint(i))
+	}
+	return group
+}
+
+// numberedVariants returns a variant for each value of numberedOption(name, n)
+func numberedVariants(name string, n int) []Variant {
+	variants := make([]Variant, 0, n)
+	for i := 1; i <= n; i++ {
+		variants = append(variants, Variant{Options: map[string]string{name: fmt.Sprint(i)}})
+	}
+	return variants
+}
+
+func TestVariantIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []OptionGroup
+		variants []Variant
+		want     []string
+	}{
+		{
+			name:    "from option values",
+			options: []OptionGroup{sizes, colours},
+			variants: []Variant{
+				{Options: map[string]string{"Size": "S", "Colour": "Red"}},
+				{Options: map[string]string{"Size": "M", "Colour": "Blue"}},
+			},
+			want: []string{"s-red", "m-blue"},
+		},
+		{
+			name: "values that join into the same ID",
+			options: []OptionGroup{
+				{Name: "Colour", Values: []string{"Light", "Light Blue"}},
+				{Name: "Shade", Values: []string{"Blue Grey", "Grey"}},
+			},
+			variants: []Variant{
+				{Options: map[string]string{"Colour": "Light", "Shade": "Blue Grey"}},
+				{Options: map[string]string{"Colour": "Light Blue", "Shade": "Grey"}},
+				{Options: map[string]string{"Colour": "Light Blue", "Shade": "Blue Grey"}},
+			},
+			want: []string{"light-blue-grey", "light-blue-grey-2", "light-blue-blue-grey"},
+		},
+		{
+			name:    "values that differ only in punctuation",
+			options: []OptionGroup{{Name: "Fit", Values: []string{"Slim!", "Slim?", "Slim"}}},
+			variants: []Variant{
+				{Options: map[string]string{"Fit": "Slim!"}},
+				{Options: map[string]string{"Fit": "Slim?"}},
+				{Options: map[string]string{"Fit": "Slim"}},
+			},
+			want: []string{"slim", "slim-2", "slim-3"},
+		},
+		{
+			name:    "ID given to a later variant",
+			options: []OptionGroup{sizes},
+			variants: []Variant{
+				{Options: map[string]string{"Size": "S"}},
+				{ID: "s", Options: map[string]string{"Size": "M"}},
+			},
+			want: []string{"s-2", "s"},
+		},
+		{
+			name:    "generated ID taken by a numbered one",
+			options: []OptionGroup{{Name: "Fit", Values: []string{"Slim", "Slim!", "Slim 2"}}},
+			variants: []Variant{
+				{Options: map[string]string{"Fit": "Slim"}},
+				{Options: map[string]string{"Fit": "Slim!"}},
+				{Options: map[string]string{"Fit": "Slim 2"}},
+			},
+			want: []string{"slim", "slim-2", "slim-2-2"},
+		},
+		{
+			name:    "values without usable characters",
+			options: []OptionGroup{{Name: "Mood", Values: []string{"☀", "☂"}}},
+			variants: []Variant{
+				{Options: map[string]string{"Mood": "☀"}},
+				{Options: map[string]string{"Mood": "☂"}},
+			},
+			want: []string{"variant-1", "variant-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := Item{ID: "shirt", Name: "Shirt", Options: tt.options, Variants: tt.variants}
+			if err := item.validateVariants(); err != nil {
+				t.Fatalf("validateVariants() error = %v", err)
+			}
+
+			got := []string{}
+			for _, variant := range item.Variants {
+				got = append(got, variant.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("variant IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateVariants(t *testing.T) {
+	price := 15.0
+	item := Item{
+		ID:       "shirt",
+		Name:     "Shirt",
+		Options:  []OptionGroup{sizes, colours},
+		Variants: []Variant{{ID: "big-blue", Options: map[string]string{"Size": "M", "Colour": "Blue"}, Price: &price, Inventory: 2}},
+	}
+
+	variants, err := item.GenerateVariants()
+	if err != nil {
+		t.Fatalf("GenerateVariants() error = %v", err)
+	}
+	labels := []string{}
+	for _, variant := range variants {
+		labels = append(labels, item.VariantLabel(variant))
+	}
+	if want := "S / Red,S / Blue,M / Red,M / Blue"; strings.Join(labels, ",") != want {
+		t.Errorf("GenerateVariants() = %v, want %s", labels, want)
+	}
+
+	// The existing variant is kept as it was, new ones track no stock
+	if kept := variants[3]; kept.ID != "big-blue" || kept.Price != &price || kept.Inventory != 2 {
+		t.Errorf("existing variant became %+v", kept)
+	}
+	if added := variants[0]; added.ID != "" || added.Price != nil || !added.HasUnlimitedInventory() {
+		t.Errorf("new variant = %+v", added)
+	}
+
+	if variants, err := (Item{}).GenerateVariants(); err != nil || variants != nil {
+		t.Errorf("GenerateVariants() without options = %v, %v; want none", variants, err)
+	}
+
+	tests := []struct {
+		name    string
+		options []OptionGroup
+		wantErr error
+	}{
+		{name: "at the cap", options: []OptionGroup{numberedOption("A", 10), numberedOption("B", 10)}},
+		{name: "over the cap", options: []OptionGroup{numberedOption("A", 10), numberedOption("B", 11)}, wantErr: ErrTooManyVariants},
+		{
+			name:    "many long option lists",
+			options: []OptionGroup{numberedOption("A", 1000), numberedOption("B", 1000), numberedOption("C", 1000)},
+			wantErr: ErrTooManyVariants,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variants, err := Item{Options: tt.options}.GenerateVariants()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateVariants() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(variants) != MaxVariants {
+				t.Errorf("GenerateVariants() made %d variants, want %d", len(variants), MaxVariants)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidSignature is returned when a signature cannot be decoded or recovered
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrInvalidAddress is returned when an address is not a valid Ethereum address
+	ErrInvalidAddress = errors.New("invalid Ethereum address format")
+
+	// ErrInvalidSIWEMessage is returned when a sign-in message is not valid EIP-4361
+	ErrInvalidSIWEMessage = errors.New("invalid sign-in message")
+
+	// ErrDomainMismatch is returned when a sign-in message was issued for another domain
+	ErrDomainMismatch = errors.New("sign-in message domain mismatch")
+
+	// ErrChainIDMismatch is returned when a sign-in message was issued for another chain
+	ErrChainIDMismatch = errors.New("sign-in message chain ID mismatch")
+
+	// ErrMessageExpired is returned when a sign-in message is past its expiration time
+	ErrMessageExpired = errors.New("sign-in message has expired")
+
+	// ErrUnknownNonce is returned when a nonce was never issued or has already been used
+	ErrUnknownNonce = errors.New("sign-in nonce is unknown or already used")
+
+	// ErrNoSession is returned when no persisted session exists
+	ErrNoSession = errors.New("no session found")
+
+	// ErrSessionExpired is returned when a persisted session is past its TTL
+	ErrSessionExpired = errors.New("session has expired")
+
+	// ErrInvalidSession is returned when a session token fails verification
+	ErrInvalidSession = errors.New("invalid session token")
+)
+
+// SignatureMismatchError is returned when a signature was produced by a different
+// address than the one claimed by the caller
+type SignatureMismatchError struct {
+	Claimed   string
+	Recovered string
+}
+
+// Error implements the error interface
+func (e *SignatureMismatchError) Error() string {
+	return fmt.Sprintf("signature was signed by %s, not %s", e.Recovered, e.Claimed)
+}
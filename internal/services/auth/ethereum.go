@@ -1,11 +1,14 @@
 package auth
 
 import (
+	"IndieNode/internal/services/ens"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -13,8 +16,23 @@ import (
 // AuthenticatedUser represents a user that has been authenticated with Ethereum
 type AuthenticatedUser struct {
 	Address  string
-	Message  string
-	LoggedIn bool
+	ChainID  int64
+	Domain   string
+	Nonce    string
+	IssuedAt time.Time
+	// ExpiresAt is when the login session ends; zero means it never expires
+	ExpiresAt time.Time
+	LoggedIn  bool
+
+	// The signed sign-in message, kept so it can serve as a writer proof
+	// for the resources it lists. Empty for restored sessions.
+	SignInMessage   string
+	SignInSignature string
+}
+
+// IsExpired returns true if the user's session has ended
+func (u *AuthenticatedUser) IsExpired() bool {
+	return !u.ExpiresAt.IsZero() && time.Now().After(u.ExpiresAt)
 }
 
 var (
@@ -29,11 +47,27 @@ type Service struct {
 	server      *http.Server
 	serverMutex sync.Mutex
 	isRunning   bool
+
+	// Persisted login sessions, nil if sessions are disabled
+	sessions *SessionStore
+
+	// Sign-In with Ethereum challenge state, guarded by nonceMutex
+	domain     string
+	chainID    int64
+	nonces     map[string]time.Time
+	nonceMutex sync.Mutex
+	resources  []string // Listed in every challenge, see SetSignInResources
 }
 
-// NewService creates a new authentication service
-func NewService() *Service {
-	return &Service{}
+// NewService creates a new authentication service. sessions may be nil, in which
+// case logins only last for the lifetime of the process.
+func NewService(sessions *SessionStore) *Service {
+	return &Service{
+		sessions: sessions,
+		domain:   fmt.Sprintf("localhost:%d", defaultPort),
+		chainID:  ens.LoadENSConfig().ChainID,
+		nonces:   make(map[string]time.Time),
+	}
 }
 
 // GetAuthenticatedUser returns the current authenticated user
@@ -57,8 +91,8 @@ func SetCurrentUser(user *AuthenticatedUser) {
 	currentUser = user
 }
 
-// AuthenticateWithEthereum handles the Ethereum authentication process
-func (s *Service) AuthenticateWithEthereum(address, message, signature string) error {
+// AuthenticateWithEthereum verifies a signed SIWE message and logs in its signer
+func (s *Service) AuthenticateWithEthereum(address, message, signature string) (*AuthenticatedUser, error) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Recovered from panic in AuthenticateWithEthereum: %v", r)
@@ -67,24 +101,104 @@ func (s *Service) AuthenticateWithEthereum(address, message, signature string) e
 
 	// Basic validation
 	if address == "" || message == "" || signature == "" {
-		return fmt.Errorf("address, message, and signature are required")
+		return nil, fmt.Errorf("address, message, and signature are required")
 	}
 
-	// Validate Ethereum address
-	if !common.IsHexAddress(address) {
-		return fmt.Errorf("invalid Ethereum address format")
+	// Parse the sign-in message
+	siwe, err := ParseSIWEMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(siwe.Address, address) {
+		return nil, fmt.Errorf("%w: message is for %s", ErrInvalidSIWEMessage, siwe.Address)
+	}
+
+	// Check domain, chain, expiry and that the nonce is outstanding
+	if err := s.verifyChallenge(siwe); err != nil {
+		return nil, err
+	}
+
+	// Verify the signature was produced by the claimed address
+	if err := VerifySignature(address, message, signature); err != nil {
+		return nil, fmt.Errorf("failed to verify signature: %w", err)
+	}
+
+	// Burn the nonce so the signed message can't be replayed
+	if !s.consumeNonce(siwe.Nonce) {
+		return nil, ErrUnknownNonce
 	}
 
 	// Create new authenticated user
 	user := &AuthenticatedUser{
-		Address:  address,
-		Message:  message,
+		Address:  common.HexToAddress(siwe.Address).Hex(),
+		ChainID:  siwe.ChainID,
+		Domain:   siwe.Domain,
+		Nonce:    siwe.Nonce,
+		IssuedAt: siwe.IssuedAt,
 		LoggedIn: true,
+
+		SignInMessage:   message,
+		SignInSignature: signature,
+	}
+
+	// Persist a session so the login survives restarts
+	if s.sessions != nil {
+		session, err := s.sessions.Issue(user)
+		if err != nil {
+			log.Printf("Warning: Failed to persist session: %v", err)
+		} else {
+			user.ExpiresAt = session.ExpiresAt
+		}
 	}
 
 	// Update the current user
 	SetCurrentUser(user)
 
+	return user, nil
+}
+
+// RestoreSession logs in the user from a persisted session if one is still valid
+func (s *Service) RestoreSession() (*AuthenticatedUser, error) {
+	if s.sessions == nil {
+		return nil, ErrNoSession
+	}
+
+	session, err := s.sessions.Load()
+	if err != nil {
+		if !isSessionMissing(err) {
+			log.Printf("Discarding unusable session: %v", err)
+			_ = s.sessions.Revoke()
+		}
+		return nil, err
+	}
+
+	s.nonceMutex.Lock()
+	domain := s.domain
+	s.nonceMutex.Unlock()
+
+	user := &AuthenticatedUser{
+		Address:   session.Address,
+		ChainID:   session.ChainID,
+		Domain:    domain,
+		IssuedAt:  session.IssuedAt,
+		ExpiresAt: session.ExpiresAt,
+		LoggedIn:  true,
+	}
+	SetCurrentUser(user)
+
+	return user, nil
+}
+
+// Logout revokes the persisted session and clears the current user
+func (s *Service) Logout() error {
+	ClearCurrentUser()
+
+	if s.sessions != nil {
+		if err := s.sessions.Revoke(); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -93,10 +207,10 @@ func ClearCurrentUser() {
 	SetCurrentUser(nil)
 }
 
-// IsAuthenticated returns true if there is a currently authenticated user
+// IsAuthenticated returns true if there is a currently authenticated user whose session hasn't expired
 func IsAuthenticated() bool {
 	user := GetCurrentUser()
-	return user != nil && user.LoggedIn
+	return user != nil && user.LoggedIn && !user.IsExpired()
 }
 
 // IsServerRunning returns true if the authentication server is running
@@ -110,7 +224,9 @@ func (s *Service) IsServerRunning() bool {
 func (s *Service) SetDevModeUser() {
 	user := &AuthenticatedUser{
 		Address:  devModeAddress,
-		Message:  "Dev Mode Authentication",
+		ChainID:  s.chainID,
+		Domain:   "dev-mode",
+		IssuedAt: time.Now(),
 		LoggedIn: true,
 	}
 	SetCurrentUser(user)
@@ -13,7 +13,8 @@ import (
 const defaultPort = 3000
 
 // StartServer starts the authentication server on the specified port
-func (s *Service) StartServer(port int, authCallback func(address, message, signature string)) error {
+// authCallback is called after every sign-in attempt with the user or the reason it failed
+func (s *Service) StartServer(port int, authCallback func(user *AuthenticatedUser, err error)) error {
 	s.serverMutex.Lock()
 	defer s.serverMutex.Unlock()
 
@@ -28,8 +29,15 @@ func (s *Service) StartServer(port int, authCallback func(address, message, sign
 		port = defaultPort
 	}
 
+	// Sign-in messages are bound to the host the login page is served from.
+	// Challenges read it under nonceMutex, set it before the listener starts.
+	s.nonceMutex.Lock()
+	s.domain = fmt.Sprintf("localhost:%d", port)
+	s.nonceMutex.Unlock()
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.serveHTML)
+	mux.HandleFunc("/challenge", s.handleChallenge)
 	mux.HandleFunc("/auth", s.handleAuth(authCallback))
 
 	s.server = &http.Server{
@@ -178,7 +186,7 @@ func (s *Service) serveHTML(w http.ResponseWriter, r *http.Request) {
                     account = await window.ethereum.request({ method: 'eth_requestAccounts' });
                     web3Provider = window.ethereum;
                     
-                    updateStatus('Connected! Preparing sign-in message...', 'success');
+                    updateStatus('Connected! Requesting sign-in message...', 'success');
                     await signMessage();
                 } catch (error) {
                     updateStatus('Failed to connect: ' + error.message, 'error');
@@ -190,14 +198,20 @@ func (s *Service) serveHTML(w http.ResponseWriter, r *http.Request) {
 
         async function signMessage() {
             try {
-                const message = 'Sign in to IndieNode\n\nThis request will not trigger a blockchain transaction or cost any gas fees.';
+                // Ask the server for a fresh SIWE message with a one-time nonce
+                const challengeResponse = await fetch('/challenge?address=' + encodeURIComponent(account[0]));
+                if (!challengeResponse.ok) {
+                    throw new Error(await challengeResponse.text());
+                }
+                const { message } = await challengeResponse.json();
+
                 const signature = await web3Provider.request({
                     method: 'personal_sign',
                     params: [message, account[0]]
                 });
 
-                // Send to backend with absolute URL
-                const response = await fetch('http://localhost:3000/auth', {
+                // Send to the server that issued the challenge
+                const response = await fetch('/auth', {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json',
@@ -210,7 +224,8 @@ func (s *Service) serveHTML(w http.ResponseWriter, r *http.Request) {
                 });
 
                 if (!response.ok) {
-                    throw new Error('Authentication failed');
+                    const result = await response.json().catch(() => ({}));
+                    throw new Error(result.error || 'Authentication failed');
                 }
 
                 updateStatus('Successfully authenticated!', 'success');
@@ -239,8 +254,28 @@ func (s *Service) serveHTML(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(html))
 }
 
-// handleAuth handles the authentication callback
-func (s *Service) handleAuth(callback func(address, message, signature string)) http.HandlerFunc {
+// handleChallenge issues a SIWE message for the address in the query string
+func (s *Service) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	challenge, err := s.NewChallenge(r.URL.Query().Get("address"))
+	if err != nil {
+		log.Printf("Failed to issue challenge: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"message": challenge.String()}); err != nil {
+		log.Printf("Failed to encode challenge: %v", err)
+	}
+}
+
+// handleAuth verifies signed sign-in messages and reports the result to callback
+func (s *Service) handleAuth(callback func(user *AuthenticatedUser, err error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Received auth request from %s", r.RemoteAddr)
 		
@@ -275,22 +310,31 @@ func (s *Service) handleAuth(callback func(address, message, signature string))
 
 		log.Printf("Received auth data for address: %s", auth.Address)
 
-		// Send success response before calling callback
+		// Verify the signed message before telling the browser anything
+		user, authErr := s.AuthenticateWithEthereum(auth.Address, auth.Message, auth.Signature)
+
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
-			log.Printf("Failed to encode response: %v", err)
-			return
+		if authErr != nil {
+			log.Printf("Authentication failed for %s: %v", auth.Address, authErr)
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": authErr.Error()}); err != nil {
+				log.Printf("Failed to encode response: %v", err)
+			}
+		} else {
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+				log.Printf("Failed to encode response: %v", err)
+			}
 		}
-		
+
 		// Ensure response is sent before potentially long-running callback
 		if f, ok := w.(http.Flusher); ok {
 			f.Flush()
 		}
 
-		// Call the callback with the authentication data
+		// Report the result to the callback
 		if callback != nil {
-			callback(auth.Address, auth.Message, auth.Signature)
+			callback(user, authErr)
 		} else {
 			log.Printf("Warning: No callback provided for auth handler")
 		}
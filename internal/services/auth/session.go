@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSessionTTL is how long a wallet login stays valid when no TTL is configured
+	DefaultSessionTTL = 24 * time.Hour
+
+	sessionFileName = "session.json"
+	sessionKeyName  = "session.key"
+	sessionKeySize  = 32
+)
+
+// SessionConfig holds configuration for the session store
+type SessionConfig struct {
+	Directory string
+	TTL       time.Duration
+}
+
+// Session represents a persisted wallet login
+type Session struct {
+	Address   string    `json:"address"`
+	ChainID   int64     `json:"chainId"`
+	IssuedAt  time.Time `json:"issuedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Token     string    `json:"-"`
+}
+
+// IsExpired returns true if the session is past its expiry time
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// SessionStore issues signed session tokens and persists them to disk
+type SessionStore struct {
+	directory string
+	ttl       time.Duration
+	mutex     sync.Mutex
+}
+
+// NewSessionStore creates a session store, creating its directory with owner-only permissions
+func NewSessionStore(config *SessionConfig) (*SessionStore, error) {
+	if config == nil {
+		config = &SessionConfig{}
+	}
+
+	directory := config.Directory
+	if directory == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		directory = filepath.Join(homeDir, "indie_node_auth")
+	}
+
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	return &SessionStore{
+		directory: directory,
+		ttl:       ttl,
+	}, nil
+}
+
+// TTL returns how long newly issued sessions stay valid
+func (s *SessionStore) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue creates a signed session for user and persists it
+func (s *SessionStore) Issue(user *AuthenticatedUser) (*Session, error) {
+	if user == nil || user.Address == "" {
+		return nil, fmt.Errorf("cannot issue a session without an authenticated user")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, err := s.loadKey(true)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	session := &Session{
+		Address:   user.Address,
+		ChainID:   user.ChainID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	token, err := signSession(session, key)
+	if err != nil {
+		return nil, err
+	}
+	session.Token = token
+
+	// Only the token is written; its payload is authenticated by the key
+	data, err := json.Marshal(map[string]string{"token": token})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := writePrivateFile(filepath.Join(s.directory, sessionFileName), data); err != nil {
+		return nil, fmt.Errorf("failed to write session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Load reads the persisted session and verifies its signature and expiry
+func (s *SessionStore) Load() (*Session, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(s.directory, sessionFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoSession
+		}
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var stored struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSession, err)
+	}
+
+	key, err := s.loadKey(false)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := verifySession(stored.Token, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired sessions are removed so they aren't checked again
+	if session.IsExpired() {
+		_ = os.Remove(filepath.Join(s.directory, sessionFileName))
+		return nil, ErrSessionExpired
+	}
+
+	return session, nil
+}
+
+// Revoke deletes the persisted session and rotates the signing key so copies of
+// the token stop verifying
+func (s *SessionStore) Revoke() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.Remove(filepath.Join(s.directory, sessionFileName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove session: %w", err)
+	}
+	if err := os.Remove(filepath.Join(s.directory, sessionKeyName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove session key: %w", err)
+	}
+
+	return nil
+}
+
+// loadKey reads the session signing key, generating it if create is true
+func (s *SessionStore) loadKey(create bool) ([]byte, error) {
+	keyPath := filepath.Join(s.directory, sessionKeyName)
+
+	key, err := os.ReadFile(keyPath)
+	if err == nil && len(key) == sessionKeySize {
+		return key, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read session key: %w", err)
+	}
+	if !create {
+		return nil, ErrNoSession
+	}
+
+	key = make([]byte, sessionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+	if err := writePrivateFile(keyPath, key); err != nil {
+		return nil, fmt.Errorf("failed to write session key: %w", err)
+	}
+
+	return key, nil
+}
+
+// signSession encodes a session as "<payload>.<hmac>"
+func signSession(session *Session, key []byte) (string, error) {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifySession checks a token's signature and decodes its session
+func verifySession(token string, key []byte) (*Session, error) {
+	encoding := base64.RawURLEncoding
+
+	encodedPayload, encodedMAC, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrInvalidSession
+	}
+	payload, err := encoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSession, err)
+	}
+	signature, err := encoding.DecodeString(encodedMAC)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSession, err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidSession
+	}
+
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSession, err)
+	}
+	session.Token = token
+
+	return &session, nil
+}
+
+// writePrivateFile atomically writes data readable only by the current user
+func writePrivateFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// isSessionMissing returns true for errors that just mean nobody is logged in
+func isSessionMissing(err error) bool {
+	return errors.Is(err, ErrNoSession) || errors.Is(err, ErrSessionExpired)
+}
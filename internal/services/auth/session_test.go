@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testUser is the wallet the test sessions are issued for
+var testUser = &AuthenticatedUser{Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ChainID: 1}
+
+// newTestSessionStore returns a session store in a temporary directory
+func newTestSessionStore(t *testing.T, ttl time.Duration) *SessionStore {
+	t.Helper()
+
+	store, err := NewSessionStore(&SessionConfig{Directory: filepath.Join(t.TempDir(), "auth"), TTL: ttl})
+	if err != nil {
+		t.Fatalf("NewSessionStore() error = %v", err)
+	}
+	return store
+}
+
+// writeSessionToken replaces the persisted session with token
+func writeSessionToken(t *testing.T, store *SessionStore, token string) {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]string{"token": token})
+	if err != nil {
+		t.Fatalf("failed to marshal session: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(store.directory, sessionFileName), data, 0600); err != nil {
+		t.Fatalf("failed to write session: %v", err)
+	}
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	store := newTestSessionStore(t, time.Hour)
+
+	issued, err := store.Issue(testUser)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if got := issued.ExpiresAt.Sub(issued.IssuedAt); got != time.Hour {
+		t.Errorf("session lasts %s, want 1h", got)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Address != testUser.Address || loaded.ChainID != testUser.ChainID ||
+		!loaded.IssuedAt.Equal(issued.IssuedAt) || !loaded.ExpiresAt.Equal(issued.ExpiresAt) || loaded.Token != issued.Token {
+		t.Errorf("Load() = %+v, want %+v", loaded, issued)
+	}
+
+	// Only the owner can read the session and its key
+	for _, name := range []string{sessionFileName, sessionKeyName} {
+		info, err := os.Stat(filepath.Join(store.directory, name))
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", name, err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("%s has mode %o, want 600", name, info.Mode().Perm())
+		}
+	}
+
+	// A revoked session doesn't load, even from a copy of the token
+	if err := store.Revoke(); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := store.Load(); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Load() after Revoke() error = %v, want %v", err, ErrNoSession)
+	}
+	if _, err := store.Issue(testUser); err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	writeSessionToken(t, store, issued.Token)
+	if _, err := store.Load(); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Load() of a revoked token error = %v, want %v", err, ErrInvalidSession)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	store := newTestSessionStore(t, time.Hour)
+	if _, err := store.Issue(testUser); err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	key, err := store.loadKey(false)
+	if err != nil {
+		t.Fatalf("loadKey() error = %v", err)
+	}
+	issuedAt := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Second)
+	token, err := signSession(&Session{Address: testUser.Address, ChainID: 1, IssuedAt: issuedAt, ExpiresAt: issuedAt.Add(time.Hour)}, key)
+	if err != nil {
+		t.Fatalf("signSession() error = %v", err)
+	}
+	writeSessionToken(t, store, token)
+
+	if _, err := store.Load(); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("Load() error = %v, want %v", err, ErrSessionExpired)
+	}
+	// The expired session is removed
+	if _, err := os.Stat(filepath.Join(store.directory, sessionFileName)); !os.IsNotExist(err) {
+		t.Errorf("expired session wasn't removed: %v", err)
+	}
+	if _, err := store.Load(); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Load() error = %v, want %v", err, ErrNoSession)
+	}
+}
+
+func TestSessionTampered(t *testing.T) {
+	encoding := base64.RawURLEncoding
+
+	tests := []struct {
+		name   string
+		tamper func(payload, mac string) string
+	}{
+		{
+			name: "other address",
+			tamper: func(payload, mac string) string {
+				data, _ := encoding.DecodeString(payload)
+				data = []byte(strings.Replace(string(data), testUser.Address, "0x0000000000000000000000000000000000000001", 1))
+				return encoding.EncodeToString(data) + "." + mac
+			},
+		},
+		{
+			name: "later expiry",
+			tamper: func(payload, mac string) string {
+				var session map[string]interface{}
+				data, _ := encoding.DecodeString(payload)
+				json.Unmarshal(data, &session)
+				session["expiresAt"] = time.Now().Add(365 * 24 * time.Hour).UTC().Format(time.RFC3339)
+				data, _ = json.Marshal(session)
+				return encoding.EncodeToString(data) + "." + mac
+			},
+		},
+		{
+			name: "other signature",
+			tamper: func(payload, mac string) string {
+				return payload + "." + encoding.EncodeToString(make([]byte, 32))
+			},
+		},
+		{
+			name:   "no signature",
+			tamper: func(payload, mac string) string { return payload },
+		},
+		{
+			name:   "not base64",
+			tamper: func(payload, mac string) string { return "!!!." + mac },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestSessionStore(t, time.Hour)
+			session, err := store.Issue(testUser)
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+
+			payload, mac, _ := strings.Cut(session.Token, ".")
+			writeSessionToken(t, store, tt.tamper(payload, mac))
+
+			if loaded, err := store.Load(); !errors.Is(err, ErrInvalidSession) {
+				t.Errorf("Load() = %+v, %v; want %v", loaded, err, ErrInvalidSession)
+			}
+		})
+	}
+
+	t.Run("not JSON", func(t *testing.T) {
+		store := newTestSessionStore(t, time.Hour)
+		if _, err := store.Issue(testUser); err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(store.directory, sessionFileName), []byte("token"), 0600); err != nil {
+			t.Fatalf("failed to write session: %v", err)
+		}
+		if _, err := store.Load(); !errors.Is(err, ErrInvalidSession) {
+			t.Errorf("Load() error = %v, want %v", err, ErrInvalidSession)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// RecoverAddress recovers the address that produced an EIP-191 personal_sign signature over message
+func RecoverAddress(message, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(strings.TrimSpace(signature))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidSignature, crypto.SignatureLength, len(sig))
+	}
+
+	// Wallets return V as 27/28, go-ethereum expects 0/1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	if sig[crypto.RecoveryIDOffset] > 1 {
+		return common.Address{}, fmt.Errorf("%w: invalid recovery id", ErrInvalidSignature)
+	}
+
+	// personal_sign prefixes the message with "\x19Ethereum Signed Message:\n<len>"
+	hash := accounts.TextHash([]byte(message))
+
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// VerifySignature checks that signature is a personal_sign signature over message by address
+func VerifySignature(address, message, signature string) error {
+	if !common.IsHexAddress(address) {
+		return ErrInvalidAddress
+	}
+
+	recovered, err := RecoverAddress(message, signature)
+	if err != nil {
+		return err
+	}
+
+	// Compare the addresses rather than the strings so checksum casing doesn't matter
+	if recovered != common.HexToAddress(address) {
+		return &SignatureMismatchError{
+			Claimed:   common.HexToAddress(address).Hex(),
+			Recovered: recovered.Hex(),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signMessage signs message like personal_sign, with V as 0/1 or as wallets return it, 27/28
+func signMessage(t *testing.T, message string, walletV bool) (address, signature string) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatalf("failed to sign message: %v", err)
+	}
+	if walletV {
+		sig[crypto.RecoveryIDOffset] += 27
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), hexutil.Encode(sig)
+}
+
+func TestRecoverAddress(t *testing.T) {
+	const message = "Sign in to IndieNode"
+
+	address, walletSig := signMessage(t, message, true)
+	rawAddress, rawSig := signMessage(t, message, false)
+
+	badV, _ := hexutil.Decode(walletSig)
+	badV[crypto.RecoveryIDOffset] = 29
+
+	tests := []struct {
+		name      string
+		signature string
+		want      string // Expected address, empty if an error is expected
+	}{
+		{name: "v of 27/28", signature: walletSig, want: address},
+		{name: "surrounding whitespace", signature: " " + walletSig + "\n", want: address},
+		{name: "v of 0/1", signature: rawSig, want: rawAddress},
+		{name: "invalid recovery id", signature: hexutil.Encode(badV)},
+		{name: "too short", signature: walletSig[:len(walletSig)-2]},
+		{name: "too long", signature: walletSig + "00"},
+		{name: "not hex", signature: "0x" + strings.Repeat("zz", crypto.SignatureLength)},
+		{name: "missing 0x prefix", signature: strings.TrimPrefix(walletSig, "0x")},
+		{name: "empty", signature: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RecoverAddress(message, tt.signature)
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Fatalf("RecoverAddress() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RecoverAddress() error = %v", err)
+			}
+			if got.Hex() != tt.want {
+				t.Errorf("RecoverAddress() = %s, want %s", got.Hex(), tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	const message = "Sign in to IndieNode"
+
+	address, walletSig := signMessage(t, message, true)
+	rawAddress, rawSig := signMessage(t, message, false)
+	otherAddress, _ := signMessage(t, message, true)
+
+	tests := []struct {
+		name      string
+		address   string
+		message   string
+		signature string
+		wantErr   error // nil for a valid signature
+		mismatch  bool  // A SignatureMismatchError is expected
+	}{
+		{name: "valid", address: address, message: message, signature: walletSig},
+		{name: "valid with v of 0/1", address: rawAddress, message: message, signature: rawSig},
+		{name: "lowercase address", address: strings.ToLower(address), message: message, signature: walletSig},
+		{name: "wrong signer", address: otherAddress, message: message, signature: walletSig, mismatch: true},
+		{name: "different message", address: address, message: message + "!", signature: walletSig, mismatch: true},
+		{name: "invalid address", address: "0x1234", message: message, signature: walletSig, wantErr: ErrInvalidAddress},
+		{name: "bad length", address: address, message: message, signature: walletSig[:20], wantErr: ErrInvalidSignature},
+		{name: "bad hex", address: address, message: message, signature: "0xnothex", wantErr: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.address, tt.message, tt.signature)
+
+			var mismatch *SignatureMismatchError
+			switch {
+			case tt.mismatch:
+				if !errors.As(err, &mismatch) {
+					t.Fatalf("VerifySignature() error = %v, want SignatureMismatchError", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VerifySignature() error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("VerifySignature() error = %v", err)
+			}
+		})
+	}
+}
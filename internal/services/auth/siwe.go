@@ -0,0 +1,324 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SIWEStatement is the statement of every sign-in message this app issues
+const SIWEStatement = "Sign in to IndieNode. This request will not trigger a blockchain transaction or cost any gas fees."
+
+const (
+	siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+	siweVersion      = "1"
+
+	// challengeTTL is how long an issued sign-in message stays valid
+	challengeTTL = 5 * time.Minute
+
+	// clockSkew is the tolerance allowed for issued-at times slightly in the future
+	clockSkew = 30 * time.Second
+
+	// MaxDelegationTTL is the longest a sign-in message may delegate resources for
+	MaxDelegationTTL = 30 * 24 * time.Hour
+
+	// minNonceLength is the shortest nonce EIP-4361 allows
+	minNonceLength = 8
+)
+
+// SIWEMessage represents a parsed EIP-4361 Sign-In with Ethereum message
+type SIWEMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+	Resources      []string
+}
+
+// String formats the message as specified by EIP-4361
+func (m *SIWEMessage) String() string {
+	var b strings.Builder
+
+	b.WriteString(m.Domain + siweHeaderSuffix + "\n")
+	b.WriteString(m.Address + "\n")
+	b.WriteString("\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+		b.WriteString("\n")
+	}
+	b.WriteString("URI: " + m.URI + "\n")
+	b.WriteString("Version: " + m.Version + "\n")
+	b.WriteString("Chain ID: " + strconv.FormatInt(m.ChainID, 10) + "\n")
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + m.IssuedAt.UTC().Format(time.RFC3339))
+	if !m.ExpirationTime.IsZero() {
+		b.WriteString("\nExpiration Time: " + m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+
+	return b.String()
+}
+
+// ParseSIWEMessage parses an EIP-4361 formatted message
+func ParseSIWEMessage(message string) (*SIWEMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 3 {
+		return nil, fmt.Errorf("%w: message too short", ErrInvalidSIWEMessage)
+	}
+
+	// Header and address
+	if !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidSIWEMessage)
+	}
+	msg := &SIWEMessage{
+		Domain:  strings.TrimSuffix(lines[0], siweHeaderSuffix),
+		Address: strings.TrimSpace(lines[1]),
+	}
+	if msg.Domain == "" {
+		return nil, fmt.Errorf("%w: missing domain", ErrInvalidSIWEMessage)
+	}
+	if !common.IsHexAddress(msg.Address) {
+		return nil, fmt.Errorf("%w: invalid address", ErrInvalidSIWEMessage)
+	}
+	if lines[2] != "" {
+		return nil, fmt.Errorf("%w: expected blank line after address", ErrInvalidSIWEMessage)
+	}
+
+	// Optional statement, terminated by a blank line
+	rest := lines[3:]
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "URI: ") {
+		if len(rest) < 2 || rest[1] != "" {
+			return nil, fmt.Errorf("%w: expected blank line after statement", ErrInvalidSIWEMessage)
+		}
+		msg.Statement = rest[0]
+		rest = rest[2:]
+	}
+
+	// Key/value fields
+	inResources := false
+	for _, line := range rest {
+		if inResources {
+			if !strings.HasPrefix(line, "- ") {
+				return nil, fmt.Errorf("%w: invalid resource line %q", ErrInvalidSIWEMessage, line)
+			}
+			msg.Resources = append(msg.Resources, strings.TrimPrefix(line, "- "))
+			continue
+		}
+		if line == "Resources:" {
+			inResources = true
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			return nil, fmt.Errorf("%w: invalid field %q", ErrInvalidSIWEMessage, line)
+		}
+
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Version":
+			msg.Version = value
+		case "Chain ID":
+			msg.ChainID, err = strconv.ParseInt(value, 10, 64)
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			msg.ExpirationTime, err = time.Parse(time.RFC3339, value)
+		case "Not Before", "Request ID":
+			// Accepted but not used by IndieNode
+		default:
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSIWEMessage, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s: %v", ErrInvalidSIWEMessage, key, err)
+		}
+	}
+
+	// Required fields
+	if msg.URI == "" || msg.Version == "" || msg.Nonce == "" || msg.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("%w: missing required field", ErrInvalidSIWEMessage)
+	}
+	if msg.Version != siweVersion {
+		return nil, fmt.Errorf("%w: unsupported version %s", ErrInvalidSIWEMessage, msg.Version)
+	}
+
+	return msg, nil
+}
+
+// CheckDelegation checks that a signed message is a delegation this app's sign-in
+// page could have issued and that it is valid at the given time. Unlike a login,
+// the nonce is not checked against issued challenges, since delegations are
+// verified by other nodes long after sign-in. chainID 0 accepts any chain.
+func (m *SIWEMessage) CheckDelegation(chainID int64, at time.Time) error {
+	host := m.Domain
+	if h, _, err := net.SplitHostPort(m.Domain); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("%w: %s is not the local sign-in page", ErrDomainMismatch, m.Domain)
+	}
+	if m.Statement != SIWEStatement {
+		return fmt.Errorf("%w: unexpected statement", ErrInvalidSIWEMessage)
+	}
+	if len(m.Nonce) < minNonceLength || strings.IndexFunc(m.Nonce, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) >= 0 {
+		return fmt.Errorf("%w: invalid nonce", ErrInvalidSIWEMessage)
+	}
+	if chainID != 0 && m.ChainID != chainID {
+		return fmt.Errorf("%w: got %d, expected %d", ErrChainIDMismatch, m.ChainID, chainID)
+	}
+
+	if m.ExpirationTime.IsZero() {
+		return fmt.Errorf("%w: delegation has no expiration time", ErrInvalidSIWEMessage)
+	}
+	if m.ExpirationTime.Sub(m.IssuedAt) > MaxDelegationTTL {
+		return fmt.Errorf("%w: delegation is valid for longer than %s", ErrInvalidSIWEMessage, MaxDelegationTTL)
+	}
+	if at.Before(m.IssuedAt.Add(-clockSkew)) || at.After(m.ExpirationTime) {
+		return ErrMessageExpired
+	}
+
+	return nil
+}
+
+// NewChallenge issues a SIWE message for address with a fresh nonce
+func (s *Service) NewChallenge(address string) (*SIWEMessage, error) {
+	if !common.IsHexAddress(address) {
+		return nil, ErrInvalidAddress
+	}
+
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	s.nonceMutex.Lock()
+	defer s.nonceMutex.Unlock()
+
+	// A message listing resources delegates them for as long as the login lasts,
+	// it must still be signed within challengeTTL
+	now := time.Now().UTC().Truncate(time.Second)
+	expiry := now.Add(challengeTTL)
+	if len(s.resources) > 0 {
+		expiry = now.Add(s.delegationTTL())
+	}
+
+	msg := &SIWEMessage{
+		Domain:         s.domain,
+		Address:        common.HexToAddress(address).Hex(),
+		Statement:      SIWEStatement,
+		URI:            "http://" + s.domain,
+		Version:        siweVersion,
+		ChainID:        s.chainID,
+		Nonce:          nonce,
+		IssuedAt:       now,
+		ExpirationTime: expiry,
+		Resources:      s.resources,
+	}
+
+	// Drop nonces that can no longer be used
+	for n, expiry := range s.nonces {
+		if now.After(expiry) {
+			delete(s.nonces, n)
+		}
+	}
+	s.nonces[nonce] = now.Add(challengeTTL)
+
+	return msg, nil
+}
+
+// delegationTTL returns how long resources granted by signing in stay delegated
+func (s *Service) delegationTTL() time.Duration {
+	ttl := DefaultSessionTTL
+	if s.sessions != nil {
+		ttl = s.sessions.TTL()
+	}
+	if ttl > MaxDelegationTTL {
+		ttl = MaxDelegationTTL
+	}
+	return ttl
+}
+
+// SetSignInResources sets the resources listed in every challenge. Signing the
+// message grants them to this node, for example the OrbitDB identity that
+// writes shop data on the user's behalf.
+func (s *Service) SetSignInResources(resources ...string) {
+	s.nonceMutex.Lock()
+	defer s.nonceMutex.Unlock()
+	s.resources = append([]string(nil), resources...)
+}
+
+// verifyChallenge checks that msg was issued by this server and is still valid
+func (s *Service) verifyChallenge(msg *SIWEMessage) error {
+	now := time.Now()
+
+	s.nonceMutex.Lock()
+	defer s.nonceMutex.Unlock()
+
+	if msg.Domain != s.domain {
+		return fmt.Errorf("%w: got %s, expected %s", ErrDomainMismatch, msg.Domain, s.domain)
+	}
+	if msg.ChainID != s.chainID {
+		return fmt.Errorf("%w: got %d, expected %d", ErrChainIDMismatch, msg.ChainID, s.chainID)
+	}
+	if msg.IssuedAt.After(now.Add(clockSkew)) {
+		return fmt.Errorf("%w: issued in the future", ErrMessageExpired)
+	}
+	if msg.ExpirationTime.IsZero() || now.After(msg.ExpirationTime) {
+		return ErrMessageExpired
+	}
+
+	expiry, ok := s.nonces[msg.Nonce]
+	if !ok {
+		return ErrUnknownNonce
+	}
+	if now.After(expiry) {
+		delete(s.nonces, msg.Nonce)
+		return ErrMessageExpired
+	}
+
+	return nil
+}
+
+// consumeNonce removes a nonce so the message it belongs to can't be replayed.
+// Returns false if the nonce was already used.
+func (s *Service) consumeNonce(nonce string) bool {
+	s.nonceMutex.Lock()
+	defer s.nonceMutex.Unlock()
+
+	if _, ok := s.nonces[nonce]; !ok {
+		return false
+	}
+	delete(s.nonces, nonce)
+	return true
+}
+
+// generateNonce creates a random alphanumeric nonce
+func generateNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckDelegation(t *testing.T) {
+	issued := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	delegation := func(change func(m *SIWEMessage)) *SIWEMessage {
+		m := &SIWEMessage{
+			Domain:         "localhost:3000",
+			Address:        "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			Statement:      SIWEStatement,
+			URI:            "http://localhost:3000",
+			Version:        siweVersion,
+			ChainID:        1,
+			Nonce:          "0123456789abcdef",
+			IssuedAt:       issued,
+			ExpirationTime: issued.Add(DefaultSessionTTL),
+			Resources:      []string{"orbitdb:identity:abc"},
+		}
+		if change != nil {
+			change(m)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		msg     *SIWEMessage
+		chainID int64
+		at      time.Time
+		wantErr error // nil for a valid delegation
+	}{
+		{name: "valid", msg: delegation(nil), chainID: 1, at: issued.Add(time.Hour)},
+		{name: "any chain", msg: delegation(nil), at: issued.Add(time.Hour)},
+		{name: "loopback address", msg: delegation(func(m *SIWEMessage) { m.Domain = "127.0.0.1:3000" }), chainID: 1, at: issued},
+		{name: "within clock skew", msg: delegation(nil), chainID: 1, at: issued.Add(-clockSkew / 2)},
+		{name: "expired", msg: delegation(nil), chainID: 1, at: issued.Add(DefaultSessionTTL + time.Second), wantErr: ErrMessageExpired},
+		{name: "before issued", msg: delegation(nil), chainID: 1, at: issued.Add(-time.Hour), wantErr: ErrMessageExpired},
+		{name: "no expiration", msg: delegation(func(m *SIWEMessage) { m.ExpirationTime = time.Time{} }), chainID: 1, at: issued, wantErr: ErrInvalidSIWEMessage},
+		{name: "too long", msg: delegation(func(m *SIWEMessage) { m.ExpirationTime = issued.Add(MaxDelegationTTL + time.Hour) }), chainID: 1, at: issued, wantErr: ErrInvalidSIWEMessage},
+		{name: "other domain", msg: delegation(func(m *SIWEMessage) { m.Domain = "shop.example" }), chainID: 1, at: issued, wantErr: ErrDomainMismatch},
+		{name: "localhost lookalike", msg: delegation(func(m *SIWEMessage) { m.Domain = "localhost.example:3000" }), chainID: 1, at: issued, wantErr: ErrDomainMismatch},
+		{name: "other chain", msg: delegation(nil), chainID: 11155111, at: issued, wantErr: ErrChainIDMismatch},
+		{name: "other statement", msg: delegation(func(m *SIWEMessage) { m.Statement = "Sign in" }), chainID: 1, at: issued, wantErr: ErrInvalidSIWEMessage},
+		{name: "short nonce", msg: delegation(func(m *SIWEMessage) { m.Nonce = "abc" }), chainID: 1, at: issued, wantErr: ErrInvalidSIWEMessage},
+		{name: "nonce not alphanumeric", msg: delegation(func(m *SIWEMessage) { m.Nonce = "0123-4567-89ab" }), chainID: 1, at: issued, wantErr: ErrInvalidSIWEMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.CheckDelegation(tt.chainID, tt.at)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("CheckDelegation() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckDelegation() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewChallengeDelegationExpiry(t *testing.T) {
+	s := &Service{domain: "localhost:3000", chainID: 1, nonces: make(map[string]time.Time)}
+	const address = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	login, err := s.NewChallenge(address)
+	if err != nil {
+		t.Fatalf("NewChallenge() error = %v", err)
+	}
+	if got := login.ExpirationTime.Sub(login.IssuedAt); got != challengeTTL {
+		t.Errorf("login message valid for %s, want %s", got, challengeTTL)
+	}
+
+	s.SetSignInResources("orbitdb:identity:abc")
+	delegation, err := s.NewChallenge(address)
+	if err != nil {
+		t.Fatalf("NewChallenge() error = %v", err)
+	}
+	if got := delegation.ExpirationTime.Sub(delegation.IssuedAt); got != DefaultSessionTTL {
+		t.Errorf("delegation valid for %s, want %s", got, DefaultSessionTTL)
+	}
+	if err := delegation.CheckDelegation(1, time.Now()); err != nil {
+		t.Errorf("CheckDelegation() of a new challenge error = %v", err)
+	}
+
+	// The challenge itself must still be signed quickly
+	if expiry := s.nonces[delegation.Nonce]; expiry.Sub(delegation.IssuedAt) != challengeTTL {
+		t.Errorf("nonce outstanding for %s, want %s", expiry.Sub(delegation.IssuedAt), challengeTTL)
+	}
+}
@@ -0,0 +1,173 @@
+package ens
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ipfs/go-cid"
+)
+
+// registryABI is the part of the ENS registry needed to find a name's resolver
+const registryABI = `[{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"}]`
+
+// resolverABI is the part of a resolver needed to read and set a name's contenthash
+const resolverABI = `[{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"}],"name":"contenthash","outputs":[{"internalType":"bytes","name":"","type":"bytes"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"bytes32","name":"node","type":"bytes32"},{"internalType":"bytes","name":"hash","type":"bytes"}],"name":"setContenthash","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+
+// ipfsNamespace is the EIP-1577 multicodec prefix of an IPFS contenthash (0xe3 as a varint)
+var ipfsNamespace = []byte{0xe3, 0x01}
+
+// Namehash returns the EIP-137 node of an ENS name such as "myshop.eth"
+func Namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := crypto.Keccak256Hash([]byte(labels[i]))
+		node = crypto.Keccak256Hash(node.Bytes(), label.Bytes())
+	}
+	return node
+}
+
+// EncodeContenthash encodes an IPFS CID as an EIP-1577 contenthash
+func EncodeContenthash(hash string) ([]byte, error) {
+	c, err := cid.Decode(hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CID %s: %w", hash, err)
+	}
+
+	// Contenthashes always carry CIDv1
+	c = cid.NewCidV1(c.Type(), c.Hash())
+	return append(append([]byte{}, ipfsNamespace...), c.Bytes()...), nil
+}
+
+// DecodeContenthash returns the IPFS CID an EIP-1577 contenthash points to
+func DecodeContenthash(data []byte) (string, error) {
+	if len(data) < len(ipfsNamespace) || data[0] != ipfsNamespace[0] || data[1] != ipfsNamespace[1] {
+		return "", fmt.Errorf("contenthash is not an IPFS CID")
+	}
+
+	_, c, err := cid.CidFromBytes(data[len(ipfsNamespace):])
+	if err != nil {
+		return "", fmt.Errorf("invalid contenthash CID: %w", err)
+	}
+	return c.String(), nil
+}
+
+// ContenthashUpdater points ENS names at IPFS content with transactions signed
+// by a key that manages the names
+type ContenthashUpdater struct {
+	client *ethclient.Client
+	config ENSConfig
+	key    *ecdsa.PrivateKey
+}
+
+// NewContenthashUpdater connects to an Ethereum RPC endpoint and creates an updater
+// that signs with the hex encoded private key
+func NewContenthashUpdater(rpcURL string, privateKeyHex string, config ENSConfig) (*ContenthashUpdater, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum RPC: %w", err)
+	}
+
+	return &ContenthashUpdater{
+		client: client,
+		config: config,
+		key:    key,
+	}, nil
+}
+
+// SetContenthash points name at an IPFS CID and waits for the transaction to be mined
+func (u *ContenthashUpdater) SetContenthash(ctx context.Context, name string, hash string) error {
+	contenthash, err := EncodeContenthash(hash)
+	if err != nil {
+		return err
+	}
+
+	node := Namehash(name)
+	resolver, err := u.resolver(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	auth, err := bind.NewKeyedTransactorWithChainID(u.key, big.NewInt(u.config.ChainID))
+	if err != nil {
+		return fmt.Errorf("failed to create transactor: %w", err)
+	}
+	auth.Context = ctx
+
+	tx, err := resolver.Transact(auth, "setContenthash", node, contenthash)
+	if err != nil {
+		return fmt.Errorf("failed to set contenthash of %s: %w", name, err)
+	}
+
+	if _, err := bind.WaitMined(ctx, u.client, tx); err != nil {
+		return fmt.Errorf("failed to mine contenthash transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Contenthash returns the IPFS CID name currently points to
+func (u *ContenthashUpdater) Contenthash(ctx context.Context, name string) (string, error) {
+	node := Namehash(name)
+	resolver, err := u.resolver(ctx, node)
+	if err != nil {
+		return "", err
+	}
+
+	var out []interface{}
+	if err := resolver.Call(&bind.CallOpts{Context: ctx}, &out, "contenthash", node); err != nil {
+		return "", fmt.Errorf("failed to get contenthash of %s: %w", name, err)
+	}
+
+	data := *abi.ConvertType(out[0], new([]byte)).(*[]byte)
+	if len(data) == 0 {
+		return "", nil
+	}
+	return DecodeContenthash(data)
+}
+
+// resolver binds the resolver set for node, or the public resolver if it has none
+func (u *ContenthashUpdater) resolver(ctx context.Context, node common.Hash) (*bind.BoundContract, error) {
+	registry, err := bindContract(common.HexToAddress(u.config.RegistryAddress), registryABI, u.client)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []interface{}
+	if err := registry.Call(&bind.CallOpts{Context: ctx}, &out, "resolver", node); err != nil {
+		return nil, fmt.Errorf("failed to look up resolver: %w", err)
+	}
+
+	address := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	if address == (common.Address{}) {
+		address = common.HexToAddress(u.config.PublicResolverAddress)
+	}
+
+	return bindContract(address, resolverABI, u.client)
+}
+
+// bindContract binds the contract at address with a minimal ABI
+func bindContract(address common.Address, contractABI string, backend bind.ContractBackend) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+	return bind.NewBoundContract(address, parsed, backend, backend, backend), nil
+}
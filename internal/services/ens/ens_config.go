@@ -9,6 +9,7 @@ type ENSConfig struct {
 	ControllerAddress     string
 	PublicResolverAddress string
 	NetworkName           string
+	ChainID               int64
 }
 
 func LoadENSConfig() ENSConfig {
@@ -20,6 +21,7 @@ func LoadENSConfig() ENSConfig {
 			ControllerAddress:     "0xF023fC1C494c8aD7d0A16bCD022a5d229a77F86b", // ETHRegistrarController on Sepolia
 			PublicResolverAddress: "0xDaaF96c344f63131acadD0Ea35170E7892d3dfBA", // Public Resolver on Sepolia
 			NetworkName:           "sepolia",
+			ChainID:               11155111,
 		}
 	}
 
@@ -28,5 +30,6 @@ func LoadENSConfig() ENSConfig {
 		ControllerAddress:     "0x253553366Da8546fC250F225fe3d25d0C782303b", // Mainnet ETHRegistrarController
 		PublicResolverAddress: "0x226159d592E2b063810a10Ebf6dcbADA94Ed68b8", // Mainnet Public Resolver
 		NetworkName:           "mainnet",
+		ChainID:               1,
 	}
 }
@@ -0,0 +1,29 @@
+package payments
+
+import "errors"
+
+var (
+	// ErrTransactionFailed is returned when the payment transaction reverted
+	ErrTransactionFailed = errors.New("payment transaction failed")
+
+	// ErrTransactionNotFound is returned when the payment never appeared on-chain
+	ErrTransactionNotFound = errors.New("payment transaction not found")
+
+	// ErrWrongRecipient is returned when the payment wasn't sent to the shop owner
+	ErrWrongRecipient = errors.New("payment sent to wrong address")
+
+	// ErrWrongSender is returned when the payment didn't come from the order's buyer
+	ErrWrongSender = errors.New("payment sent from wrong address")
+
+	// ErrInsufficientValue is returned when less was paid than the order's items cost
+	ErrInsufficientValue = errors.New("payment value too low")
+
+	// ErrWrongChain is returned when the payment was made on another network
+	ErrWrongChain = errors.New("payment made on wrong chain")
+
+	// ErrUnknownItem is returned when the shop no longer has the item or variant an order is for
+	ErrUnknownItem = errors.New("ordered item not in shop")
+
+	// ErrUnsupportedToken is returned for orders paid with something other than ETH
+	ErrUnsupportedToken = errors.New("unsupported payment token")
+)
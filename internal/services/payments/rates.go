@@ -0,0 +1,136 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/models"
+)
+
+const (
+	// CoinGeckoPriceURL is where storefronts read the ETH price from, see templates/shared/web3.js
+	CoinGeckoPriceURL = "https://api.coingecko.com/api/v3/simple/price?ids=ethereum&vs_currencies=usd"
+
+	// DefaultPriceTolerance is how much less than the quoted amount a payment may
+	// be, since the storefront fetched its own rate shortly before this node did
+	DefaultPriceTolerance = 0.02
+
+	// rateCacheTTL is how long a fetched ETH price is reused
+	rateCacheTTL = time.Minute
+)
+
+// roundingAllowance covers storefronts rounding ETH amounts to 6 decimals
+var roundingAllowance = big.NewInt(5e11)
+
+// RateSource quotes the price of ETH in USD
+type RateSource interface {
+	ETHPriceUSD(ctx context.Context) (float64, error)
+}
+
+// CoinGeckoRates reads the price of ETH from CoinGecko, the source storefronts use
+type CoinGeckoRates struct {
+	URL    string
+	client *http.Client
+
+	mutex   sync.Mutex
+	price   float64
+	fetched time.Time
+}
+
+// NewCoinGeckoRates creates a rate source reading from CoinGeckoPriceURL
+func NewCoinGeckoRates() *CoinGeckoRates {
+	return &CoinGeckoRates{
+		URL:    CoinGeckoPriceURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ETHPriceUSD returns the current price of one ETH in USD
+func (r *CoinGeckoRates) ETHPriceUSD(ctx context.Context) (float64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.price > 0 && time.Since(r.fetched) < rateCacheTTL {
+		return r.price, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create price request: %w", err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch ETH price: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to fetch ETH price: %s", resp.Status)
+	}
+
+	var body struct {
+		Ethereum struct {
+			USD float64 `json:"usd"`
+		} `json:"ethereum"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to parse ETH price: %w", err)
+	}
+	if body.Ethereum.USD <= 0 {
+		return 0, fmt.Errorf("invalid ETH price %v", body.Ethereum.USD)
+	}
+
+	r.price, r.fetched = body.Ethereum.USD, time.Now()
+	return r.price, nil
+}
+
+// OrderPriceUSD returns what one unit of an order's item or variant costs in the shop
+func OrderPriceUSD(shop *models.Shop, order *orbitdb.OrderData) (float64, error) {
+	for _, item := range shop.Items {
+		if item.ID != order.ItemID {
+			continue
+		}
+		if !item.HasVariants() {
+			return item.Price, nil
+		}
+		if variant := item.Variant(order.VariantID); variant != nil {
+			return item.VariantPrice(*variant), nil
+		}
+		return 0, fmt.Errorf("%w: %s variant %s", ErrUnknownItem, order.ItemID, order.VariantID)
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownItem, order.ItemID)
+}
+
+// ExpectedValue returns the least a payment for an order may be, in wei. It is
+// priced from the shop's own item prices and the ETH rate this node quoted when
+// the order was recorded, never from amounts the buyer reported.
+func ExpectedValue(shop *models.Shop, order *orbitdb.OrderData, tolerance float64) (*big.Int, error) {
+	if order.RateUSD <= 0 {
+		return nil, fmt.Errorf("%w: order has no quoted ETH rate", ErrInsufficientValue)
+	}
+	unitPrice, err := OrderPriceUSD(shop, order)
+	if err != nil {
+		return nil, err
+	}
+	if unitPrice <= 0 || order.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: order has no valid price", ErrInsufficientValue)
+	}
+
+	// wei = USD total / USD per ETH * 10^18, less the tolerance
+	total := new(big.Float).Mul(big.NewFloat(unitPrice), new(big.Float).SetInt64(order.Quantity))
+	eth := new(big.Float).Quo(total, big.NewFloat(order.RateUSD))
+	eth.Mul(eth, big.NewFloat(1-tolerance))
+	wei, _ := eth.Mul(eth, big.NewFloat(1e18)).Int(nil)
+
+	wei.Sub(wei, roundingAllowance)
+	if wei.Sign() <= 0 {
+		wei.SetInt64(1)
+	}
+	return wei, nil
+}
@@ -0,0 +1,351 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// DefaultConfirmations is how many blocks a payment needs before its order is confirmed
+	DefaultConfirmations = 3
+
+	// DefaultPollInterval is how often pending orders are checked
+	DefaultPollInterval = 30 * time.Second
+
+	// DefaultNotFoundTimeout is how long an order may reference a transaction that
+	// can't be found before it is marked failed. Orders are only recorded once
+	// their transaction has been seen, so this covers transactions dropped from
+	// the mempool, which must let go of the stock they hold.
+	DefaultNotFoundTimeout = 10 * time.Minute
+)
+
+// ChainReader is the part of an Ethereum client the verifier needs.
+// It is satisfied by *ethclient.Client and by the simulated backend's client.
+type ChainReader interface {
+	ethereum.TransactionReader
+	ethereum.BlockNumberReader
+	ethereum.ChainIDReader
+}
+
+// OrderStore is the part of the OrbitDB manager the verifier needs
+type OrderStore interface {
+	ListAllShops(ctx context.Context) ([]*models.Shop, error)
+	ListOrders(ctx context.Context, shopID string) ([]*orbitdb.OrderData, error)
+	UpdateOrderStatus(ctx context.Context, shopID string, orderID string, status orbitdb.OrderStatus) error
+}
+
+// Config holds payment verification configuration
+type Config struct {
+	RPCURL          string        // Ethereum JSON-RPC endpoint
+	ChainID         int64         // Expected chain ID, 0 accepts the RPC's chain
+	Confirmations   uint64        // Blocks required on top of the payment's block
+	PollInterval    time.Duration // How often pending orders are checked
+	NotFoundTimeout time.Duration // How long stock is held for a transaction that hasn't been seen
+	PriceTolerance  float64       // Fraction a payment may fall short of its quote, see DefaultPriceTolerance
+	Rates           RateSource    // Quotes the ETH price orders are recorded with, CoinGecko if nil
+}
+
+// Verifier checks pending orders against the chain and confirms or fails them
+type Verifier struct {
+	client ChainReader
+	orders OrderStore
+	config Config
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	mutex    sync.Mutex
+}
+
+// NewVerifier connects to the configured RPC endpoint and creates a verifier
+func NewVerifier(config *Config, orders OrderStore) (*Verifier, error) {
+	if config == nil || config.RPCURL == "" {
+		return nil, fmt.Errorf("an Ethereum RPC URL is required")
+	}
+
+	client, err := ethclient.Dial(config.RPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum RPC: %w", err)
+	}
+
+	return NewVerifierWithClient(client, config, orders)
+}
+
+// NewVerifierWithClient creates a verifier that reads the chain through client
+func NewVerifierWithClient(client ChainReader, config *Config, orders OrderStore) (*Verifier, error) {
+	if client == nil {
+		return nil, fmt.Errorf("chain client cannot be nil")
+	}
+	if orders == nil {
+		return nil, fmt.Errorf("order store cannot be nil")
+	}
+
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Confirmations == 0 {
+		cfg.Confirmations = DefaultConfirmations
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.NotFoundTimeout <= 0 {
+		cfg.NotFoundTimeout = DefaultNotFoundTimeout
+	}
+	if cfg.PriceTolerance <= 0 || cfg.PriceTolerance >= 1 {
+		cfg.PriceTolerance = DefaultPriceTolerance
+	}
+	if cfg.Rates == nil {
+		cfg.Rates = NewCoinGeckoRates()
+	}
+
+	return &Verifier{
+		client: client,
+		orders: orders,
+		config: cfg,
+	}, nil
+}
+
+// Start checks pending orders every poll interval until Stop is called
+func (v *Verifier) Start(ctx context.Context) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.stopChan != nil {
+		return
+	}
+	v.stopChan = make(chan struct{})
+	stopChan := v.stopChan
+
+	log.Printf("Starting payment verifier (every %s, %d confirmations)", v.config.PollInterval, v.config.Confirmations)
+
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+
+		ticker := time.NewTicker(v.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := v.CheckPendingOrders(ctx); err != nil {
+				log.Printf("Payment verification error: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background checks and waits for the current one to finish
+func (v *Verifier) Stop() {
+	v.mutex.Lock()
+	if v.stopChan == nil {
+		v.mutex.Unlock()
+		return
+	}
+	close(v.stopChan)
+	v.stopChan = nil
+	v.mutex.Unlock()
+
+	v.wg.Wait()
+	log.Printf("Payment verifier stopped")
+}
+
+// CheckPendingOrders verifies every pending order of every shop once
+func (v *Verifier) CheckPendingOrders(ctx context.Context) error {
+	shops, err := v.orders.ListAllShops(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list shops: %w", err)
+	}
+
+	var checkErrors []error
+	for _, shop := range shops {
+		orders, err := v.orders.ListOrders(ctx, shop.ID)
+		if err != nil {
+			checkErrors = append(checkErrors, fmt.Errorf("failed to list orders for shop %s: %w", shop.ID, err))
+			continue
+		}
+
+		for _, order := range orders {
+			if order.Status != orbitdb.OrderPending {
+				continue
+			}
+
+			status, err := v.VerifyOrder(ctx, order, shop)
+			if status == orbitdb.OrderPending {
+				if err != nil {
+					checkErrors = append(checkErrors, fmt.Errorf("order %s: %w", order.ID, err))
+				}
+				continue
+			}
+
+			if status == orbitdb.OrderFailed {
+				log.Printf("Payment for order %s in shop %s rejected: %v", order.ID, shop.ID, err)
+			} else {
+				log.Printf("Payment for order %s in shop %s confirmed", order.ID, shop.ID)
+			}
+
+			if err := v.orders.UpdateOrderStatus(ctx, shop.ID, order.ID, status); err != nil {
+				checkErrors = append(checkErrors, fmt.Errorf("failed to update order %s: %w", order.ID, err))
+			}
+		}
+	}
+
+	if len(checkErrors) > 0 {
+		return errors.Join(checkErrors...)
+	}
+	return nil
+}
+
+// Quote returns the USD price of one ETH to record with a new order, see ExpectedValue
+func (v *Verifier) Quote(ctx context.Context) (float64, error) {
+	return v.config.Rates.ETHPriceUSD(ctx)
+}
+
+// VerifyOrder checks an order's payment to shop against the chain. It returns
+// OrderPending while the outcome isn't known yet, with an error only if the
+// chain couldn't be read. OrderFailed comes with the reason the payment was rejected.
+func (v *Verifier) VerifyOrder(ctx context.Context, order *orbitdb.OrderData, shop *models.Shop) (orbitdb.OrderStatus, error) {
+	payout, expected, txHash, err := v.paymentTerms(order, shop)
+	if err != nil {
+		return orbitdb.OrderFailed, err
+	}
+
+	tx, isPending, err := v.client.TransactionByHash(ctx, txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		// The transaction may not have propagated yet
+		if time.Since(order.Timestamp) > v.config.NotFoundTimeout {
+			return orbitdb.OrderFailed, ErrTransactionNotFound
+		}
+		return orbitdb.OrderPending, nil
+	}
+	if err != nil {
+		return orbitdb.OrderPending, fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+	if isPending {
+		return orbitdb.OrderPending, nil
+	}
+
+	// Check the transaction itself
+	if err := v.checkTransaction(ctx, tx, order, payout, expected); err != nil {
+		return orbitdb.OrderFailed, err
+	}
+
+	// Check that it succeeded and has enough blocks on top of it
+	receipt, err := v.client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return orbitdb.OrderPending, nil
+		}
+		return orbitdb.OrderPending, fmt.Errorf("failed to fetch receipt: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return orbitdb.OrderFailed, ErrTransactionFailed
+	}
+
+	head, err := v.client.BlockNumber(ctx)
+	if err != nil {
+		return orbitdb.OrderPending, fmt.Errorf("failed to fetch block number: %w", err)
+	}
+	if receipt.BlockNumber == nil || head < receipt.BlockNumber.Uint64() {
+		return orbitdb.OrderPending, nil
+	}
+	if confirmations := head - receipt.BlockNumber.Uint64() + 1; confirmations < v.config.Confirmations {
+		return orbitdb.OrderPending, nil
+	}
+
+	return orbitdb.OrderConfirmed, nil
+}
+
+// CheckPayment checks a new order's transaction before the order is recorded
+// and takes stock. The transaction has to have been seen, pending or mined, and
+// pay the shop at least the order's quoted value. ErrTransactionNotFound means
+// it hasn't reached the RPC node yet and the order can be retried.
+func (v *Verifier) CheckPayment(ctx context.Context, order *orbitdb.OrderData, shop *models.Shop) error {
+	payout, expected, txHash, err := v.paymentTerms(order, shop)
+	if err != nil {
+		return err
+	}
+
+	tx, _, err := v.client.TransactionByHash(ctx, txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return ErrTransactionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+
+	return v.checkTransaction(ctx, tx, order, payout, expected)
+}
+
+// paymentTerms returns where an order's payment must go, the least it may be
+// and the hash of its transaction
+func (v *Verifier) paymentTerms(order *orbitdb.OrderData, shop *models.Shop) (common.Address, *big.Int, common.Hash, error) {
+	if order.Token != "" && !strings.EqualFold(order.Token, "ETH") {
+		return common.Address{}, nil, common.Hash{}, fmt.Errorf("%w: %s", ErrUnsupportedToken, order.Token)
+	}
+	if !common.IsHexAddress(shop.OwnerAddress) {
+		return common.Address{}, nil, common.Hash{}, fmt.Errorf("%w: shop has no valid payout address", ErrWrongRecipient)
+	}
+	expected, err := ExpectedValue(shop, order, v.config.PriceTolerance)
+	if err != nil {
+		return common.Address{}, nil, common.Hash{}, err
+	}
+	if len(common.FromHex(order.TxHash)) != common.HashLength {
+		return common.Address{}, nil, common.Hash{}, fmt.Errorf("%w: invalid transaction hash", ErrTransactionNotFound)
+	}
+	return common.HexToAddress(shop.OwnerAddress), expected, common.HexToHash(order.TxHash), nil
+}
+
+// checkTransaction checks the chain, recipient, sender and value of a payment.
+// expected is the least the payment may be, see ExpectedValue.
+func (v *Verifier) checkTransaction(ctx context.Context, tx *types.Transaction, order *orbitdb.OrderData, payout common.Address, expected *big.Int) error {
+	chainID := big.NewInt(v.config.ChainID)
+	if v.config.ChainID == 0 {
+		var err error
+		if chainID, err = v.client.ChainID(ctx); err != nil {
+			return fmt.Errorf("failed to fetch chain ID: %w", err)
+		}
+	}
+	if tx.ChainId().Sign() != 0 && tx.ChainId().Cmp(chainID) != 0 {
+		return fmt.Errorf("%w: got %s, expected %s", ErrWrongChain, tx.ChainId(), chainID)
+	}
+
+	if tx.To() == nil || *tx.To() != payout {
+		return fmt.Errorf("%w: expected %s", ErrWrongRecipient, payout.Hex())
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWrongSender, err)
+	}
+	if !common.IsHexAddress(order.Buyer) || sender != common.HexToAddress(order.Buyer) {
+		return fmt.Errorf("%w: got %s", ErrWrongSender, sender.Hex())
+	}
+
+	if tx.Value().Cmp(expected) < 0 {
+		return fmt.Errorf("%w: got %s wei, expected at least %s wei", ErrInsufficientValue, tx.Value(), expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,373 @@
+package payments
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// fixedRate quotes the same ETH price every time
+type fixedRate float64
+
+func (r fixedRate) ETHPriceUSD(ctx context.Context) (float64, error) {
+	return float64(r), nil
+}
+
+// noOrders is an empty order store, VerifyOrder doesn't read it
+type noOrders struct{}
+
+func (noOrders) ListAllShops(ctx context.Context) ([]*models.Shop, error) { return nil, nil }
+func (noOrders) ListOrders(ctx context.Context, shopID string) ([]*orbitdb.OrderData, error) {
+	return nil, nil
+}
+func (noOrders) UpdateOrderStatus(ctx context.Context, shopID string, orderID string, status orbitdb.OrderStatus) error {
+	return nil
+}
+
+// testChain is a simulated chain with a funded buyer
+type testChain struct {
+	backend *simulated.Backend
+	client  simulated.Client
+	buyer   *ecdsa.PrivateKey
+}
+
+// revertCode is contract code that reverts every call, so payments to it fail
+var revertCode = []byte{0x60, 0x00, 0x60, 0x00, 0xfd} // PUSH1 0 PUSH1 0 REVERT
+
+func newTestChain(t *testing.T, reverting common.Address) *testChain {
+	t.Helper()
+
+	buyer, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(buyer.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+		reverting:                               {Balance: new(big.Int), Code: revertCode},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	return &testChain{backend: backend, client: backend.Client(), buyer: buyer}
+}
+
+// pay sends value wei from the buyer to to and mines it into a block
+func (c *testChain) pay(t *testing.T, to common.Address, value *big.Int) common.Hash {
+	t.Helper()
+
+	txHash := c.send(t, to, value)
+	c.backend.Commit()
+	return txHash
+}
+
+// send sends value wei from the buyer to to, leaving it in the pending pool
+func (c *testChain) send(t *testing.T, to common.Address, value *big.Int) common.Hash {
+	t.Helper()
+	ctx := context.Background()
+
+	chainID, err := c.client.ChainID(ctx)
+	if err != nil {
+		t.Fatalf("failed to get chain ID: %v", err)
+	}
+	nonce, err := c.client.PendingNonceAt(ctx, crypto.PubkeyToAddress(c.buyer.PublicKey))
+	if err != nil {
+		t.Fatalf("failed to get nonce: %v", err)
+	}
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to get head: %v", err)
+	}
+	tip := big.NewInt(params.GWei)
+
+	tx, err := types.SignNewTx(c.buyer, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip),
+		Gas:       100000, // Estimating would fail for payments that revert
+		To:        &to,
+		Value:     value,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign payment: %v", err)
+	}
+	if err := c.client.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to send payment: %v", err)
+	}
+	return tx.Hash()
+}
+
+func TestVerifyOrder(t *testing.T) {
+	payout := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	reverting := common.HexToAddress("0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359")
+	stranger := common.HexToAddress("0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB")
+
+	// Two mugs at $30 with ETH at $3000 cost 0.02 ETH
+	const rate = 3000
+	price := new(big.Int).Mul(big.NewInt(2), big.NewInt(params.Ether/100))
+	percentOff := func(percent int64) *big.Int {
+		value := new(big.Int).Mul(price, big.NewInt(100-percent))
+		return value.Div(value, big.NewInt(100))
+	}
+
+	tests := []struct {
+		name          string
+		payTo         common.Address
+		owner         common.Address // The shop's payout address, payTo if zero
+		value         *big.Int
+		chainID       int64 // Chain the verifier expects, 0 for the simulated one
+		confirmations uint64
+		buyer         string // Buyer recorded in the order, the payer if empty
+		noQuote       bool
+		wantStatus    orbitdb.OrderStatus
+		wantErr       error
+	}{
+		{name: "exact payment", payTo: payout, value: price, wantStatus: orbitdb.OrderConfirmed},
+		{name: "overpayment", payTo: payout, value: percentOff(-10), wantStatus: orbitdb.OrderConfirmed},
+		{name: "within tolerance", payTo: payout, value: percentOff(1), wantStatus: orbitdb.OrderConfirmed},
+		{name: "awaiting confirmations", payTo: payout, value: price, confirmations: 3, wantStatus: orbitdb.OrderPending},
+		{name: "underpayment", payTo: payout, value: percentOff(50), wantStatus: orbitdb.OrderFailed, wantErr: ErrInsufficientValue},
+		{name: "dust payment", payTo: payout, value: big.NewInt(1), wantStatus: orbitdb.OrderFailed, wantErr: ErrInsufficientValue},
+		{name: "no quoted rate", payTo: payout, value: price, noQuote: true, wantStatus: orbitdb.OrderFailed, wantErr: ErrInsufficientValue},
+		{name: "wrong recipient", payTo: stranger, owner: payout, value: price, wantStatus: orbitdb.OrderFailed, wantErr: ErrWrongRecipient},
+		{name: "wrong chain", payTo: payout, value: price, chainID: 1, wantStatus: orbitdb.OrderFailed, wantErr: ErrWrongChain},
+		{name: "wrong sender", payTo: payout, value: price, buyer: stranger.Hex(), wantStatus: orbitdb.OrderFailed, wantErr: ErrWrongSender},
+		{name: "reverted", payTo: reverting, value: price, wantStatus: orbitdb.OrderFailed, wantErr: ErrTransactionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newTestChain(t, reverting)
+			txHash := chain.pay(t, tt.payTo, tt.value)
+
+			confirmations := tt.confirmations
+			if confirmations == 0 {
+				confirmations = 1
+			}
+			verifier, err := NewVerifierWithClient(chain.client, &Config{
+				ChainID:       tt.chainID,
+				Confirmations: confirmations,
+				Rates:         fixedRate(rate),
+			}, noOrders{})
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+
+			owner := tt.owner
+			if owner == (common.Address{}) {
+				owner = tt.payTo
+			}
+			shop := &models.Shop{
+				ID:           "shop",
+				OwnerAddress: owner.Hex(),
+				Items:        []models.Item{{ID: "mug", Name: "Mug", Price: 30}},
+			}
+
+			buyer := tt.buyer
+			if buyer == "" {
+				buyer = crypto.PubkeyToAddress(chain.buyer.PublicKey).Hex()
+			}
+			order := &orbitdb.OrderData{
+				ID:       "order",
+				ShopID:   shop.ID,
+				ItemID:   "mug",
+				Quantity: 2,
+				// What the buyer claims to have paid has no say in verification
+				PricePaid: tt.value.String(),
+				RateUSD:   rate,
+				Token:     "ETH",
+				TxHash:    txHash.Hex(),
+				Buyer:     buyer,
+				Status:    orbitdb.OrderPending,
+				Timestamp: time.Now(),
+			}
+			if tt.noQuote {
+				order.RateUSD = 0
+			}
+
+			status, err := verifier.VerifyOrder(context.Background(), order, shop)
+			if status != tt.wantStatus {
+				t.Fatalf("VerifyOrder() status = %s, want %s (error: %v)", status, tt.wantStatus, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("VerifyOrder() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyOrderUnknownTransaction(t *testing.T) {
+	chain := newTestChain(t, common.HexToAddress("0xdead"))
+	verifier, err := NewVerifierWithClient(chain.client, &Config{
+		Rates:           fixedRate(3000),
+		NotFoundTimeout: time.Hour,
+	}, noOrders{})
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	buyer := crypto.PubkeyToAddress(chain.buyer.PublicKey)
+	shop := &models.Shop{
+		ID:           "shop",
+		OwnerAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		Items:        []models.Item{{ID: "mug", Name: "Mug", Price: 30}},
+	}
+	order := &orbitdb.OrderData{
+		ShopID:    shop.ID,
+		ItemID:    "mug",
+		Quantity:  1,
+		RateUSD:   3000,
+		TxHash:    common.HexToHash("0x01").Hex(),
+		Buyer:     buyer.Hex(),
+		Timestamp: time.Now(),
+	}
+
+	// A transaction that hasn't propagated yet keeps the order pending
+	if status, err := verifier.VerifyOrder(context.Background(), order, shop); status != orbitdb.OrderPending || err != nil {
+		t.Fatalf("VerifyOrder() = %s, %v, want pending", status, err)
+	}
+
+	// Until the timeout passes
+	order.Timestamp = time.Now().Add(-2 * time.Hour)
+	if status, err := verifier.VerifyOrder(context.Background(), order, shop); status != orbitdb.OrderFailed || !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("VerifyOrder() = %s, %v, want failed with ErrTransactionNotFound", status, err)
+	}
+}
+
+func TestCheckPayment(t *testing.T) {
+	payout := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	stranger := common.HexToAddress("0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB")
+
+	// A $30 mug with ETH at $3000 costs 0.01 ETH
+	price := big.NewInt(params.Ether / 100)
+
+	tests := []struct {
+		name    string
+		payTo   common.Address
+		value   *big.Int
+		mine    bool
+		unsent  bool // The order names a transaction that was never sent
+		wantErr error
+	}{
+		{name: "pending payment", payTo: payout, value: price},
+		{name: "mined payment", payTo: payout, value: price, mine: true},
+		{name: "not seen yet", unsent: true, wantErr: ErrTransactionNotFound},
+		{name: "underpayment", payTo: payout, value: big.NewInt(params.Ether / 200), wantErr: ErrInsufficientValue},
+		{name: "wrong recipient", payTo: stranger, value: price, wantErr: ErrWrongRecipient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newTestChain(t, common.HexToAddress("0xdead"))
+			txHash := common.HexToHash("0x01")
+			if !tt.unsent {
+				txHash = chain.send(t, tt.payTo, tt.value)
+				if tt.mine {
+					chain.backend.Commit()
+				}
+			}
+
+			verifier, err := NewVerifierWithClient(chain.client, &Config{Rates: fixedRate(3000)}, noOrders{})
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+			shop := &models.Shop{
+				ID:           "shop",
+				OwnerAddress: payout.Hex(),
+				Items:        []models.Item{{ID: "mug", Name: "Mug", Price: 30}},
+			}
+			order := &orbitdb.OrderData{
+				ShopID:    shop.ID,
+				ItemID:    "mug",
+				Quantity:  1,
+				RateUSD:   3000,
+				TxHash:    txHash.Hex(),
+				Buyer:     crypto.PubkeyToAddress(chain.buyer.PublicKey).Hex(),
+				Timestamp: time.Now(),
+			}
+
+			err = verifier.CheckPayment(context.Background(), order, shop)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("CheckPayment() error = %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckPayment() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpectedValue(t *testing.T) {
+	variantPrice := 45.0
+	shop := &models.Shop{
+		Items: []models.Item{
+			{ID: "mug", Price: 30},
+			{
+				ID:    "shirt",
+				Price: 20,
+				Options: []models.OptionGroup{
+					{Name: "Size", Values: []string{"M", "XL"}},
+				},
+				Variants: []models.Variant{
+					{ID: "m", Options: map[string]string{"Size": "M"}},
+					{ID: "xl", Options: map[string]string{"Size": "XL"}, Price: &variantPrice},
+				},
+			},
+		},
+	}
+
+	ether := func(eth float64) *big.Int {
+		wei, _ := new(big.Float).Mul(big.NewFloat(eth), big.NewFloat(params.Ether)).Int(nil)
+		return wei.Sub(wei, roundingAllowance)
+	}
+
+	tests := []struct {
+		name    string
+		order   orbitdb.OrderData
+		want    *big.Int
+		wantErr error
+	}{
+		{name: "item", order: orbitdb.OrderData{ItemID: "mug", Quantity: 1, RateUSD: 3000}, want: ether(0.01)},
+		{name: "quantity", order: orbitdb.OrderData{ItemID: "mug", Quantity: 3, RateUSD: 3000}, want: ether(0.03)},
+		{name: "variant with item price", order: orbitdb.OrderData{ItemID: "shirt", VariantID: "m", Quantity: 1, RateUSD: 2000}, want: ether(0.01)},
+		{name: "variant with own price", order: orbitdb.OrderData{ItemID: "shirt", VariantID: "xl", Quantity: 2, RateUSD: 3000}, want: ether(0.03)},
+		{name: "unknown item", order: orbitdb.OrderData{ItemID: "hat", Quantity: 1, RateUSD: 3000}, wantErr: ErrUnknownItem},
+		{name: "unknown variant", order: orbitdb.OrderData{ItemID: "shirt", VariantID: "s", Quantity: 1, RateUSD: 3000}, wantErr: ErrUnknownItem},
+		{name: "no quote", order: orbitdb.OrderData{ItemID: "mug", Quantity: 1}, wantErr: ErrInsufficientValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpectedValue(shop, &tt.order, 0)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ExpectedValue() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExpectedValue() error = %v", err)
+			}
+
+			// Floating point may be off by a few wei
+			diff := new(big.Int).Sub(got, tt.want)
+			if diff.CmpAbs(big.NewInt(1000)) > 0 {
+				t.Errorf("ExpectedValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package pinning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Status is the state of a pin request on a remote pinning service
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusPinning Status = "pinning"
+	StatusPinned  Status = "pinned"
+	StatusFailed  Status = "failed"
+)
+
+// defaultRequestTimeout bounds a single call to a pinning service
+const defaultRequestTimeout = 30 * time.Second
+
+// Pin describes content to pin, as defined by the IPFS Pinning Service API
+type Pin struct {
+	CID     string            `json:"cid"`
+	Name    string            `json:"name,omitempty"`
+	Origins []string          `json:"origins,omitempty"` // Multiaddrs of nodes that already have the content
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// PinStatus is a pinning service's view of a pin request
+type PinStatus struct {
+	RequestID string            `json:"requestid"`
+	Status    Status            `json:"status"`
+	Created   time.Time         `json:"created"`
+	Pin       Pin               `json:"pin"`
+	Delegates []string          `json:"delegates"`
+	Info      map[string]string `json:"info,omitempty"`
+}
+
+// pinResults is the response to a pin listing
+type pinResults struct {
+	Count   int         `json:"count"`
+	Results []PinStatus `json:"results"`
+}
+
+// Client talks to a single IPFS Pinning Service API endpoint
+type Client struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for endpoint, authenticating with token.
+// httpClient may be nil to use a client with a default timeout.
+func NewClient(endpoint string, token string, httpClient *http.Client) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEndpoint, endpoint)
+	}
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultRequestTimeout}
+	}
+
+	return &Client{
+		endpoint:   strings.TrimRight(endpoint, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Add asks the service to pin content
+func (c *Client) Add(ctx context.Context, pin Pin) (*PinStatus, error) {
+	var status PinStatus
+	if err := c.do(ctx, http.MethodPost, "/pins", pin, http.StatusAccepted, &status); err != nil {
+		return nil, fmt.Errorf("failed to request pin of %s: %w", pin.CID, err)
+	}
+	return &status, nil
+}
+
+// Get returns the current status of a pin request
+func (c *Client) Get(ctx context.Context, requestID string) (*PinStatus, error) {
+	var status PinStatus
+	if err := c.do(ctx, http.MethodGet, "/pins/"+url.PathEscape(requestID), nil, http.StatusOK, &status); err != nil {
+		return nil, fmt.Errorf("failed to get pin request %s: %w", requestID, err)
+	}
+	return &status, nil
+}
+
+// List returns pin requests for the given CIDs, or the most recent ones if none are given
+func (c *Client) List(ctx context.Context, cids ...string) ([]PinStatus, error) {
+	path := "/pins"
+	if len(cids) > 0 {
+		path += "?cid=" + url.QueryEscape(strings.Join(cids, ","))
+	}
+
+	var results pinResults
+	if err := c.do(ctx, http.MethodGet, path, nil, http.StatusOK, &results); err != nil {
+		return nil, fmt.Errorf("failed to list pins: %w", err)
+	}
+	return results.Results, nil
+}
+
+// Remove cancels a pin request, unpinning its content
+func (c *Client) Remove(ctx context.Context, requestID string) error {
+	if err := c.do(ctx, http.MethodDelete, "/pins/"+url.PathEscape(requestID), nil, http.StatusAccepted, nil); err != nil {
+		return fmt.Errorf("failed to remove pin request %s: %w", requestID, err)
+	}
+	return nil
+}
+
+// do sends a request and decodes the response into out, which may be nil
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, expected int, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		return newAPIError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// newAPIError reads the error body the Pinning Service API returns with failed requests
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var failure struct {
+		Error struct {
+			Reason  string `json:"reason"`
+			Details string `json:"details"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if json.Unmarshal(data, &failure) == nil {
+		apiErr.Reason = failure.Error.Reason
+		apiErr.Details = failure.Error.Details
+	}
+	if apiErr.Reason == "" {
+		apiErr.Reason = http.StatusText(resp.StatusCode)
+	}
+
+	return apiErr
+}
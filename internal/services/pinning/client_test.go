@@ -0,0 +1,247 @@
+package pinning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestClient starts a server running handler and returns a client for it
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL+"/", "secret", nil)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+// checkRequest fails the test if r isn't method on path with the client's headers
+func checkRequest(t *testing.T, r *http.Request, method, path string) {
+	t.Helper()
+
+	if r.Method != method || r.URL.EscapedPath() != path {
+		t.Errorf("request = %s %s, want %s %s", r.Method, r.URL.EscapedPath(), method, path)
+	}
+	if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+	}
+	if got := r.Header.Get("Accept"); got != "application/json" {
+		t.Errorf("Accept = %q, want application/json", got)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{endpoint: "https://api.pinata.cloud/psa", valid: true},
+		{endpoint: "http://127.0.0.1:5001", valid: true},
+		{endpoint: "ftp://pins.example"},
+		{endpoint: "https://"},
+		{endpoint: "pins.example"},
+		{endpoint: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			_, err := NewClient(tt.endpoint, "token", nil)
+			if tt.valid && err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidEndpoint) {
+				t.Fatalf("NewClient() error = %v, want ErrInvalidEndpoint", err)
+			}
+		})
+	}
+}
+
+func TestClientAdd(t *testing.T) {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		checkRequest(t, r, http.MethodPost, "/pins")
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+
+		var pin Pin
+		if err := json.NewDecoder(r.Body).Decode(&pin); err != nil {
+			t.Errorf("failed to decode pin: %v", err)
+		}
+		if pin.CID != "bafyshop" || pin.Name != "indienode-shop" || len(pin.Origins) != 1 || pin.Meta["shop"] != "shop" {
+			t.Errorf("pin = %+v", pin)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"requestid": "req-1",
+			"status":    "queued",
+			"created":   created,
+			"pin":       pin,
+			"delegates": []string{"/dnsaddr/pins.example"},
+		})
+	})
+
+	status, err := client.Add(context.Background(), Pin{
+		CID:     "bafyshop",
+		Name:    "indienode-shop",
+		Origins: []string{"/ip4/127.0.0.1/tcp/4001"},
+		Meta:    map[string]string{"shop": "shop"},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if status.RequestID != "req-1" || status.Status != StatusQueued || !status.Created.Equal(created) ||
+		status.Pin.CID != "bafyshop" || len(status.Delegates) != 1 {
+		t.Errorf("Add() = %+v", status)
+	}
+}
+
+func TestClientGet(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		checkRequest(t, r, http.MethodGet, "/pins/req%2F1")
+		json.NewEncoder(w).Encode(PinStatus{RequestID: "req/1", Status: StatusPinned, Pin: Pin{CID: "bafyshop"}})
+	})
+
+	status, err := client.Get(context.Background(), "req/1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if status.RequestID != "req/1" || status.Status != StatusPinned || status.Pin.CID != "bafyshop" {
+		t.Errorf("Get() = %+v", status)
+	}
+}
+
+func TestClientList(t *testing.T) {
+	tests := []struct {
+		name      string
+		cids      []string
+		wantQuery string
+	}{
+		{name: "recent", wantQuery: ""},
+		{name: "by CID", cids: []string{"bafya", "bafyb"}, wantQuery: "bafya,bafyb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				checkRequest(t, r, http.MethodGet, "/pins")
+				if got := r.URL.Query().Get("cid"); got != tt.wantQuery {
+					t.Errorf("cid = %q, want %q", got, tt.wantQuery)
+				}
+				json.NewEncoder(w).Encode(pinResults{
+					Count: 2,
+					Results: []PinStatus{
+						{RequestID: "req-1", Status: StatusPinned, Pin: Pin{CID: "bafya"}},
+						{RequestID: "req-2", Status: StatusPinning, Pin: Pin{CID: "bafyb"}},
+					},
+				})
+			})
+
+			pins, err := client.List(context.Background(), tt.cids...)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(pins) != 2 || pins[0].RequestID != "req-1" || pins[1].Status != StatusPinning {
+				t.Errorf("List() = %+v", pins)
+			}
+		})
+	}
+}
+
+func TestClientRemove(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		checkRequest(t, r, http.MethodDelete, "/pins/req-1")
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	if err := client.Remove(context.Background(), "req-1"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantReason    string
+		wantDetails   string
+		wantTemporary bool
+		notAPIError   bool // The response had the expected status but couldn't be decoded
+	}{
+		{
+			name:        "error body",
+			status:      http.StatusUnauthorized,
+			body:        `{"error":{"reason":"UNAUTHORIZED","details":"Access token is invalid"}}`,
+			wantReason:  "UNAUTHORIZED",
+			wantDetails: "Access token is invalid",
+		},
+		{
+			name:          "rate limited without body",
+			status:        http.StatusTooManyRequests,
+			wantReason:    "Too Many Requests",
+			wantTemporary: true,
+		},
+		{
+			name:          "server error with HTML body",
+			status:        http.StatusBadGateway,
+			body:          "<html>Bad Gateway</html>",
+			wantReason:    "Bad Gateway",
+			wantTemporary: true,
+		},
+		{
+			name:       "unexpected success status",
+			status:     http.StatusOK,
+			body:       `{"requestid":"req-1","status":"queued"}`,
+			wantReason: "OK",
+		},
+		{
+			name:        "undecodable response",
+			status:      http.StatusAccepted,
+			body:        `{"requestid":`,
+			notAPIError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := client.Add(context.Background(), Pin{CID: "bafyshop"})
+			if err == nil {
+				t.Fatalf("Add() succeeded, want an error")
+			}
+
+			var apiErr *APIError
+			if tt.notAPIError {
+				if errors.As(err, &apiErr) {
+					t.Fatalf("Add() error = %v, want a decoding error", err)
+				}
+				return
+			}
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Add() error = %v, want an APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Reason != tt.wantReason || apiErr.Details != tt.wantDetails {
+				t.Errorf("APIError = %+v, want %d %q %q", apiErr, tt.status, tt.wantReason, tt.wantDetails)
+			}
+			if apiErr.Temporary() != tt.wantTemporary {
+				t.Errorf("Temporary() = %v, want %v", apiErr.Temporary(), tt.wantTemporary)
+			}
+		})
+	}
+}
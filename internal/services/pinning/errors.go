@@ -0,0 +1,37 @@
+package pinning
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrInvalidEndpoint is returned for pinning service URLs that aren't http or https
+	ErrInvalidEndpoint = errors.New("invalid pinning service endpoint")
+
+	// ErrEndpointExists is returned when adding an endpoint whose name is already taken
+	ErrEndpointExists = errors.New("pinning service already configured")
+
+	// ErrEndpointNotFound is returned when no endpoint has the requested name
+	ErrEndpointNotFound = errors.New("pinning service not found")
+)
+
+// APIError is an error response from a pinning service
+type APIError struct {
+	StatusCode int
+	Reason     string
+	Details    string
+}
+
+func (e *APIError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("pinning service returned %d %s: %s", e.StatusCode, e.Reason, e.Details)
+	}
+	return fmt.Sprintf("pinning service returned %d %s", e.StatusCode, e.Reason)
+}
+
+// Temporary reports whether retrying the request later may succeed
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
@@ -0,0 +1,589 @@
+package pinning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPollInterval is how often queued pins are submitted and polled
+	DefaultPollInterval = time.Minute
+
+	// DefaultMaxAttempts is how many times a pin is tried before it is marked failed
+	DefaultMaxAttempts = 5
+
+	// maxRetryDelay caps the backoff between attempts
+	maxRetryDelay = time.Hour
+
+	endpointsFileName = "endpoints.json"
+	queueFileName     = "queue.json"
+)
+
+// Endpoint is a configured remote pinning service
+type Endpoint struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// Config holds remote pinning configuration
+type Config struct {
+	Directory    string        // Where endpoints and the pin queue are kept, defaults to ~/indie_node_pinning
+	PollInterval time.Duration // How often queued pins are submitted and polled
+	MaxAttempts  int           // Attempts before a pin is marked failed
+	HTTPClient   *http.Client  // Used for every endpoint, nil for a default client
+
+	// Origins returns multiaddrs of this node, passed to services so they can fetch content directly
+	Origins func() []string
+}
+
+// Job tracks one CID of a shop on one pinning service
+type Job struct {
+	ShopID      string    `json:"shopId"`
+	CID         string    `json:"cid"`
+	Endpoint    string    `json:"endpoint"`
+	RequestID   string    `json:"requestId,omitempty"`
+	Status      Status    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"lastError,omitempty"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	Updated     time.Time `json:"updated"`
+
+	// Removing is set once the shop no longer uses the CID, the pin is deleted on the next pass
+	Removing bool `json:"removing,omitempty"`
+}
+
+// Service queues shop content for remote pinning and follows it until pinned
+type Service struct {
+	config    Config
+	endpoints []Endpoint
+	jobs      []*Job
+	mutex     sync.Mutex
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewService creates a pinning service, loading saved endpoints and queued pins
+func NewService(config *Config) (*Service, error) {
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Directory == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		cfg.Directory = filepath.Join(homeDir, "indie_node_pinning")
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+
+	// Tokens are kept here, so only the owner may read it
+	if err := os.MkdirAll(cfg.Directory, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create pinning directory: %w", err)
+	}
+
+	s := &Service{config: cfg}
+	if err := s.load(endpointsFileName, &s.endpoints); err != nil {
+		return nil, err
+	}
+	if err := s.load(queueFileName, &s.jobs); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Endpoints returns the configured pinning services
+func (s *Service) Endpoints() []Endpoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Endpoint(nil), s.endpoints...)
+}
+
+// AddEndpoint configures a pinning service. Content already queued for other
+// services is queued for it too.
+func (s *Service) AddEndpoint(endpoint Endpoint) error {
+	if endpoint.Name == "" {
+		return fmt.Errorf("pinning service name is required")
+	}
+	if _, err := NewClient(endpoint.URL, endpoint.Token, nil); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, existing := range s.endpoints {
+		if existing.Name == endpoint.Name {
+			return fmt.Errorf("%w: %s", ErrEndpointExists, endpoint.Name)
+		}
+	}
+	s.endpoints = append(s.endpoints, endpoint)
+
+	seen := make(map[string]bool)
+	now := time.Now()
+	for _, job := range append([]*Job(nil), s.jobs...) {
+		key := job.ShopID + "/" + job.CID
+		if job.Removing || seen[key] {
+			continue
+		}
+		seen[key] = true
+		s.jobs = append(s.jobs, &Job{
+			ShopID:      job.ShopID,
+			CID:         job.CID,
+			Endpoint:    endpoint.Name,
+			Status:      StatusQueued,
+			NextAttempt: now,
+			Updated:     now,
+		})
+	}
+
+	log.Printf("Added pinning service %s (%s)", endpoint.Name, endpoint.URL)
+	return s.saveLocked()
+}
+
+// RemoveEndpoint forgets a pinning service and its queued pins. Content it
+// already pinned stays pinned there.
+func (s *Service) RemoveEndpoint(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	kept := s.endpoints[:0]
+	found := false
+	for _, endpoint := range s.endpoints {
+		if endpoint.Name == name {
+			found = true
+			continue
+		}
+		kept = append(kept, endpoint)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrEndpointNotFound, name)
+	}
+	s.endpoints = kept
+
+	jobs := s.jobs[:0]
+	for _, job := range s.jobs {
+		if job.Endpoint != name {
+			jobs = append(jobs, job)
+		}
+	}
+	s.jobs = jobs
+
+	log.Printf("Removed pinning service %s", name)
+	return s.saveLocked()
+}
+
+// QueueShop sets the CIDs a shop needs pinned on every configured service, usually
+// its published site and any standalone assets. CIDs queued for the shop before
+// but missing now, such as a previous version of the site, are unpinned.
+func (s *Service) QueueShop(shopID string, cids ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	wanted := make(map[string]bool, len(cids))
+	for _, c := range cids {
+		if c != "" {
+			wanted[c] = true
+		}
+	}
+
+	now := time.Now()
+	queued := make(map[string]bool)
+	for _, job := range s.jobs {
+		if job.ShopID != shopID {
+			continue
+		}
+		if !wanted[job.CID] {
+			if !job.Removing {
+				job.Removing = true
+				job.Attempts = 0
+				job.NextAttempt = now
+			}
+			continue
+		}
+
+		// Wanted again, so keep the pin and give failed ones a fresh start
+		if job.Removing {
+			job.Removing = false
+			job.Attempts = 0
+			job.NextAttempt = now
+		}
+		if job.Status == StatusFailed {
+			job.Status = StatusQueued
+			job.RequestID = ""
+			job.Attempts = 0
+			job.LastError = ""
+			job.NextAttempt = now
+			job.Updated = now
+		}
+		queued[job.Endpoint+"/"+job.CID] = true
+	}
+
+	for c := range wanted {
+		for _, endpoint := range s.endpoints {
+			if queued[endpoint.Name+"/"+c] {
+				continue
+			}
+			s.jobs = append(s.jobs, &Job{
+				ShopID:      shopID,
+				CID:         c,
+				Endpoint:    endpoint.Name,
+				Status:      StatusQueued,
+				NextAttempt: now,
+				Updated:     now,
+			})
+		}
+	}
+
+	if len(s.endpoints) > 0 {
+		log.Printf("Queued %d CIDs of shop %s for remote pinning", len(wanted), shopID)
+	}
+	return s.saveLocked()
+}
+
+// RetryShop resets a shop's failed pins so they are tried again on the next pass
+func (s *Service) RetryShop(shopID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for _, job := range s.jobs {
+		if job.ShopID == shopID && job.Status == StatusFailed {
+			job.Status = StatusQueued
+			job.RequestID = ""
+			job.Attempts = 0
+			job.NextAttempt = now
+		}
+	}
+	return s.saveLocked()
+}
+
+// ShopJobs returns the remote pins of a shop
+func (s *Service) ShopJobs(shopID string) []Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var jobs []Job
+	for _, job := range s.jobs {
+		if job.ShopID == shopID && !job.Removing {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs
+}
+
+// ShopSummary describes a shop's remote pins in a few words, or returns an
+// empty string if nothing is queued for it
+func (s *Service) ShopSummary(shopID string) string {
+	jobs := s.ShopJobs(shopID)
+	if len(jobs) == 0 {
+		return ""
+	}
+
+	counts := make(map[Status]int)
+	for _, job := range jobs {
+		counts[job.Status]++
+	}
+
+	switch {
+	case counts[StatusPinned] == len(jobs):
+		return fmt.Sprintf("Remote pins: %d/%d pinned", counts[StatusPinned], len(jobs))
+	case counts[StatusFailed] > 0:
+		return fmt.Sprintf("Remote pins: %d/%d pinned, %d failed", counts[StatusPinned], len(jobs), counts[StatusFailed])
+	default:
+		return fmt.Sprintf("Remote pins: %d/%d pinned, %d in progress", counts[StatusPinned], len(jobs), len(jobs)-counts[StatusPinned])
+	}
+}
+
+// Start processes the queue every poll interval until Stop is called
+func (s *Service) Start(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopChan != nil {
+		return
+	}
+	s.stopChan = make(chan struct{})
+	stopChan := s.stopChan
+
+	log.Printf("Starting remote pinning (every %s, %d attempts)", s.config.PollInterval, s.config.MaxAttempts)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.ProcessQueue(ctx); err != nil {
+				log.Printf("Remote pinning error: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops background processing and waits for the current pass to finish
+func (s *Service) Stop() {
+	s.mutex.Lock()
+	if s.stopChan == nil {
+		s.mutex.Unlock()
+		return
+	}
+	close(s.stopChan)
+	s.stopChan = nil
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	log.Printf("Remote pinning stopped")
+}
+
+// ProcessQueue submits, polls or removes every pin that is due once
+func (s *Service) ProcessQueue(ctx context.Context) error {
+	now := time.Now()
+
+	s.mutex.Lock()
+	clients := make(map[string]*Client, len(s.endpoints))
+	for _, endpoint := range s.endpoints {
+		client, err := NewClient(endpoint.URL, endpoint.Token, s.config.HTTPClient)
+		if err != nil {
+			log.Printf("Warning: Skipping pinning service %s: %v", endpoint.Name, err)
+			continue
+		}
+		clients[endpoint.Name] = client
+	}
+
+	var due []*Job
+	for _, job := range s.jobs {
+		if s.isDue(job, now) {
+			due = append(due, job)
+		}
+	}
+	s.mutex.Unlock()
+
+	var origins []string
+	if s.config.Origins != nil && len(due) > 0 {
+		origins = s.config.Origins()
+	}
+
+	var processErrors []error
+	for _, job := range due {
+		client, ok := clients[job.Endpoint]
+		if !ok {
+			continue
+		}
+
+		s.mutex.Lock()
+		snapshot := *job
+		s.mutex.Unlock()
+
+		result, err := s.processJob(ctx, client, snapshot, origins)
+
+		s.mutex.Lock()
+		s.applyResult(job, result, err)
+		s.mutex.Unlock()
+
+		if err != nil {
+			processErrors = append(processErrors, fmt.Errorf("%s on %s: %w", job.CID, job.Endpoint, err))
+		}
+	}
+
+	s.mutex.Lock()
+	err := s.saveLocked()
+	s.mutex.Unlock()
+	if err != nil {
+		processErrors = append(processErrors, err)
+	}
+
+	if len(processErrors) > 0 {
+		return errors.Join(processErrors...)
+	}
+	return nil
+}
+
+// isDue reports whether a job needs work on this pass
+func (s *Service) isDue(job *Job, now time.Time) bool {
+	if job.NextAttempt.After(now) {
+		return false
+	}
+	if job.Removing {
+		return true
+	}
+	return job.Status != StatusPinned && job.Status != StatusFailed
+}
+
+// processJob does the network side of a job and returns its updated state
+func (s *Service) processJob(ctx context.Context, client *Client, job Job, origins []string) (Job, error) {
+	var apiErr *APIError
+
+	switch {
+	case job.Removing:
+		if job.RequestID != "" {
+			err := client.Remove(ctx, job.RequestID)
+			if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) {
+				return job, err
+			}
+		}
+		job.RequestID = ""
+
+	case job.RequestID == "":
+		status, err := client.Add(ctx, Pin{
+			CID:     job.CID,
+			Name:    "indienode-" + job.ShopID,
+			Origins: origins,
+			Meta:    map[string]string{"app": "IndieNode", "shop": job.ShopID},
+		})
+		if err != nil {
+			return job, err
+		}
+		job.RequestID = status.RequestID
+		job.Status = status.Status
+
+	default:
+		status, err := client.Get(ctx, job.RequestID)
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			// The service forgot the request, submit it again
+			job.RequestID = ""
+			job.Status = StatusQueued
+			return job, nil
+		}
+		if err != nil {
+			return job, err
+		}
+		job.Status = status.Status
+		if status.Status == StatusFailed {
+			job.RequestID = ""
+			return job, fmt.Errorf("pinning service could not pin %s", job.CID)
+		}
+	}
+
+	return job, nil
+}
+
+// applyResult stores the outcome of processJob, scheduling a retry on failure
+func (s *Service) applyResult(job *Job, result Job, err error) {
+	// The shop may have wanted the CID again while the pin was being removed
+	removing := result.Removing && job.Removing
+
+	now := time.Now()
+	job.RequestID = result.RequestID
+	job.Status = result.Status
+	job.Updated = now
+
+	// Wanted again, so pin it again on the next pass. A pin the removal
+	// failed to delete keeps its request and is polled instead.
+	if result.Removing && !job.Removing {
+		job.Status = StatusQueued
+		job.Attempts = 0
+		job.LastError = ""
+		job.NextAttempt = now
+		return
+	}
+
+	if err == nil {
+		job.LastError = ""
+		job.NextAttempt = time.Time{}
+		if removing {
+			s.dropJob(job)
+		} else if job.Status != StatusPinned {
+			job.NextAttempt = now.Add(s.config.PollInterval)
+		}
+		if job.Status == StatusPinned && !removing {
+			log.Printf("Remote pin of %s for shop %s on %s complete", job.CID, job.ShopID, job.Endpoint)
+		}
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+
+	if job.Attempts >= s.config.MaxAttempts {
+		if removing {
+			log.Printf("Warning: Giving up removing %s from %s: %v", job.CID, job.Endpoint, err)
+			s.dropJob(job)
+			return
+		}
+		job.Status = StatusFailed
+		log.Printf("Warning: Remote pin of %s for shop %s on %s failed: %v", job.CID, job.ShopID, job.Endpoint, err)
+		return
+	}
+
+	// Back off exponentially, services often rate limit
+	delay := s.config.PollInterval << uint(job.Attempts)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	job.NextAttempt = now.Add(delay)
+	if !removing && job.Status == StatusFailed {
+		job.Status = StatusQueued
+	}
+}
+
+// dropJob removes a job from the queue
+func (s *Service) dropJob(job *Job) {
+	for i, existing := range s.jobs {
+		if existing == job {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+// load reads a JSON file from the pinning directory, leaving v unchanged if it doesn't exist
+func (s *Service) load(name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(s.config.Directory, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// saveLocked writes the endpoints and queue. The caller must hold s.mutex.
+func (s *Service) saveLocked() error {
+	files := map[string]interface{}{
+		endpointsFileName: s.endpoints,
+		queueFileName:     s.jobs,
+	}
+
+	for name, v := range files {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(s.config.Directory, name), data, 0600); err != nil {
+			return fmt.Errorf("failed to save %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,446 @@
+package pinning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePinningService is an in-memory IPFS Pinning Service API
+type fakePinningService struct {
+	mutex    sync.Mutex
+	pins     map[string]*PinStatus // By request ID
+	nextID   int
+	failures int      // Requests still to answer with 503
+	requests []string // "METHOD path" of every request
+	onDelete func()   // Called while deleting a pin, may be nil
+}
+
+func newFakePinningService(t *testing.T) (*fakePinningService, *httptest.Server) {
+	t.Helper()
+
+	fake := &fakePinningService{pins: make(map[string]*PinStatus)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakePinningService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	fail := func(status int, reason string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"reason": reason}})
+	}
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		fail(http.StatusUnauthorized, "UNAUTHORIZED")
+		return
+	}
+	if f.failures > 0 {
+		f.failures--
+		fail(http.StatusServiceUnavailable, "UNAVAILABLE")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/pins/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/pins":
+		var pin Pin
+		if err := json.NewDecoder(r.Body).Decode(&pin); err != nil {
+			fail(http.StatusBadRequest, "BAD_REQUEST")
+			return
+		}
+		f.nextID++
+		status := &PinStatus{RequestID: fmt.Sprintf("req-%d", f.nextID), Status: StatusQueued, Created: time.Now(), Pin: pin}
+		f.pins[status.RequestID] = status
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(status)
+
+	case r.Method == http.MethodGet && f.pins[id] != nil:
+		json.NewEncoder(w).Encode(f.pins[id])
+
+	case r.Method == http.MethodDelete && f.pins[id] != nil:
+		if f.onDelete != nil {
+			f.onDelete()
+		}
+		delete(f.pins, id)
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		fail(http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+// setStatus changes the status of every pin request for c
+func (f *fakePinningService) setStatus(c string, status Status) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, pin := range f.pins {
+		if pin.Pin.CID == c {
+			pin.Status = status
+		}
+	}
+}
+
+// forget drops every pin request, as if the service lost them
+func (f *fakePinningService) forget() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.pins = make(map[string]*PinStatus)
+}
+
+// fail answers the next n requests with 503
+func (f *fakePinningService) fail(n int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.failures = n
+}
+
+// takeRequests returns the requests made since the last call
+func (f *fakePinningService) takeRequests() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	requests := f.requests
+	f.requests = nil
+	return requests
+}
+
+// pinnedCIDs returns the CIDs the service holds pin requests for
+func (f *fakePinningService) pinnedCIDs() map[string]bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	cids := make(map[string]bool)
+	for _, pin := range f.pins {
+		cids[pin.Pin.CID] = true
+	}
+	return cids
+}
+
+const testPollInterval = time.Minute
+
+// newTestService creates a service with the fake as its only endpoint
+func newTestService(t *testing.T, server *httptest.Server) *Service {
+	t.Helper()
+
+	s, err := NewService(&Config{
+		Directory:    t.TempDir(),
+		PollInterval: testPollInterval,
+		MaxAttempts:  3,
+		Origins:      func() []string { return []string{"/ip4/127.0.0.1/tcp/4001"} },
+	})
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if err := s.AddEndpoint(Endpoint{Name: "fake", URL: server.URL, Token: "secret"}); err != nil {
+		t.Fatalf("AddEndpoint() error = %v", err)
+	}
+	return s
+}
+
+// makeDue schedules every job for the next pass, as if its retry delay passed
+func (s *Service) makeDue() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, job := range s.jobs {
+		job.NextAttempt = time.Time{}
+	}
+}
+
+// job returns the job for c, failing the test if there isn't exactly one
+func (s *Service) job(t *testing.T, c string) Job {
+	t.Helper()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var found []Job
+	for _, job := range s.jobs {
+		if job.CID == c {
+			found = append(found, *job)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("found %d jobs for %s, want 1", len(found), c)
+	}
+	return found[0]
+}
+
+func checkRequests(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
+
+func TestProcessQueuePinsShop(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakePinningService(t)
+	s := newTestService(t, server)
+
+	if err := s.QueueShop("shop", "bafysite", ""); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+
+	// The pin is submitted, then polled until the service has it
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	checkRequests(t, fake.takeRequests(), "POST /pins")
+	if job := s.job(t, "bafysite"); job.RequestID != "req-1" || job.Status != StatusQueued {
+		t.Fatalf("job after submitting = %+v", job)
+	}
+	if got := s.ShopSummary("shop"); got != "Remote pins: 0/1 pinned, 1 in progress" {
+		t.Errorf("ShopSummary() = %q", got)
+	}
+
+	// Not polled again before the poll interval
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	checkRequests(t, fake.takeRequests())
+
+	fake.setStatus("bafysite", StatusPinned)
+	s.makeDue()
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	checkRequests(t, fake.takeRequests(), "GET /pins/req-1")
+	if job := s.job(t, "bafysite"); job.Status != StatusPinned {
+		t.Fatalf("job after polling = %+v", job)
+	}
+	if got := s.ShopSummary("shop"); got != "Remote pins: 1/1 pinned" {
+		t.Errorf("ShopSummary() = %q", got)
+	}
+
+	// Pinned jobs are left alone
+	s.makeDue()
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	checkRequests(t, fake.takeRequests())
+}
+
+func TestProcessQueueRetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakePinningService(t)
+	s := newTestService(t, server)
+
+	if err := s.QueueShop("shop", "bafysite"); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+	fake.fail(3)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		if err := s.ProcessQueue(ctx); err == nil {
+			t.Fatalf("ProcessQueue() attempt %d succeeded, want an error", attempt)
+		}
+
+		job := s.job(t, "bafysite")
+		if job.Attempts != attempt || job.Status != StatusQueued || !strings.Contains(job.LastError, "UNAVAILABLE") {
+			t.Fatalf("job after attempt %d = %+v", attempt, job)
+		}
+		delay := testPollInterval << uint(attempt)
+		if job.NextAttempt.Before(before.Add(delay)) || job.NextAttempt.After(time.Now().Add(delay)) {
+			t.Errorf("attempt %d retries at %s, want %s later", attempt, job.NextAttempt, delay)
+		}
+
+		// Nothing is sent until the backoff passes
+		if err := s.ProcessQueue(ctx); err != nil {
+			t.Fatalf("ProcessQueue() error = %v", err)
+		}
+		checkRequests(t, fake.takeRequests(), "POST /pins")
+		s.makeDue()
+	}
+
+	// The last attempt marks the pin failed, and it isn't tried again
+	if err := s.ProcessQueue(ctx); err == nil {
+		t.Fatalf("ProcessQueue() succeeded, want an error")
+	}
+	if job := s.job(t, "bafysite"); job.Status != StatusFailed || job.Attempts != 3 {
+		t.Fatalf("job after the last attempt = %+v", job)
+	}
+	if got := s.ShopSummary("shop"); got != "Remote pins: 0/1 pinned, 1 failed" {
+		t.Errorf("ShopSummary() = %q", got)
+	}
+	s.makeDue()
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	checkRequests(t, fake.takeRequests(), "POST /pins")
+
+	// Retrying starts over once the service is back
+	if err := s.RetryShop("shop"); err != nil {
+		t.Fatalf("RetryShop() error = %v", err)
+	}
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() after retry error = %v", err)
+	}
+	if job := s.job(t, "bafysite"); job.Status != StatusQueued || job.RequestID == "" || job.Attempts != 0 {
+		t.Fatalf("job after retry = %+v", job)
+	}
+}
+
+func TestProcessQueueRemovesUnusedPins(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakePinningService(t)
+	s := newTestService(t, server)
+
+	if err := s.QueueShop("shop", "bafyold"); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	fake.takeRequests()
+
+	// A republished site replaces the old one
+	if err := s.QueueShop("shop", "bafynew"); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+	if jobs := s.ShopJobs("shop"); len(jobs) != 1 || jobs[0].CID != "bafynew" {
+		t.Errorf("ShopJobs() = %+v, want only bafynew", jobs)
+	}
+
+	// A failed removal is retried like a pin
+	fake.fail(1)
+	if err := s.ProcessQueue(ctx); err == nil {
+		t.Fatalf("ProcessQueue() succeeded, want the removal to fail")
+	}
+	if job := s.job(t, "bafyold"); !job.Removing || job.Attempts != 1 {
+		t.Fatalf("job after failed removal = %+v", job)
+	}
+
+	s.makeDue()
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if got := fake.pinnedCIDs(); got["bafyold"] || !got["bafynew"] {
+		t.Errorf("service pins %v, want only bafynew", got)
+	}
+	s.mutex.Lock()
+	remaining := len(s.jobs)
+	s.mutex.Unlock()
+	if remaining != 1 {
+		t.Errorf("%d jobs left, want the removed one dropped", remaining)
+	}
+
+	// Pins the service already forgot are dropped too
+	if err := s.QueueShop("shop"); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+	fake.forget()
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if jobs := s.ShopJobs("shop"); len(jobs) != 0 {
+		t.Errorf("ShopJobs() = %+v, want none", jobs)
+	}
+}
+
+func TestProcessQueueRepinsCIDWantedDuringRemoval(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakePinningService(t)
+	s := newTestService(t, server)
+
+	if err := s.QueueShop("shop", "bafyold"); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if err := s.QueueShop("shop", "bafynew"); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+
+	// The shop rolls back to the old version while its pin is being deleted
+	fake.mutex.Lock()
+	fake.onDelete = func() {
+		if err := s.QueueShop("shop", "bafyold", "bafynew"); err != nil {
+			t.Errorf("QueueShop() error = %v", err)
+		}
+	}
+	fake.mutex.Unlock()
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	fake.mutex.Lock()
+	fake.onDelete = nil
+	fake.mutex.Unlock()
+
+	job := s.job(t, "bafyold")
+	if job.Removing || job.Status != StatusQueued || job.RequestID != "" || job.NextAttempt.After(time.Now()) {
+		t.Fatalf("job wanted during its removal = %+v, want it queued to pin now", job)
+	}
+
+	// Pinned again on the next pass, without waiting for a retry
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if got := fake.pinnedCIDs(); !got["bafyold"] || !got["bafynew"] {
+		t.Errorf("service pins %v, want bafyold and bafynew", got)
+	}
+	if job := s.job(t, "bafyold"); job.RequestID == "" {
+		t.Errorf("job after pinning again = %+v, want a request", job)
+	}
+}
+
+func TestProcessQueueResubmitsForgottenPins(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakePinningService(t)
+	s := newTestService(t, server)
+
+	if err := s.QueueShop("shop", "bafysite"); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+
+	fake.forget()
+	s.makeDue()
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if job := s.job(t, "bafysite"); job.RequestID != "" || job.Status != StatusQueued {
+		t.Fatalf("job after the service forgot it = %+v", job)
+	}
+
+	s.makeDue()
+	if err := s.ProcessQueue(ctx); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	fake.takeRequests()
+	if !fake.pinnedCIDs()["bafysite"] {
+		t.Errorf("pin wasn't submitted again")
+	}
+}
+
+func TestServiceKeepsQueueAcrossRestarts(t *testing.T) {
+	_, server := newFakePinningService(t)
+	s := newTestService(t, server)
+
+	if err := s.QueueShop("shop", "bafysite"); err != nil {
+		t.Fatalf("QueueShop() error = %v", err)
+	}
+
+	restarted, err := NewService(&s.config)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if endpoints := restarted.Endpoints(); len(endpoints) != 1 || endpoints[0].Token != "secret" {
+		t.Errorf("Endpoints() = %+v", endpoints)
+	}
+	if jobs := restarted.ShopJobs("shop"); len(jobs) != 1 || jobs[0].CID != "bafysite" || jobs[0].Status != StatusQueued {
+		t.Errorf("ShopJobs() = %+v", jobs)
+	}
+}
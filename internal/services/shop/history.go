@@ -0,0 +1,376 @@
+package shop
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"IndieNode/internal/models"
+)
+
+const (
+	// historyFileName is the publish history kept in each shop's directory
+	historyFileName = "publish_history.json"
+
+	// DefaultKeepVersions is how many published versions of a shop stay pinned
+	DefaultKeepVersions = 10
+)
+
+// ChangeType is the kind of change an item went through between two versions
+type ChangeType string
+
+const (
+	ItemAdded   ChangeType = "added"
+	ItemRemoved ChangeType = "removed"
+	ItemPrice   ChangeType = "price"
+	ItemUpdated ChangeType = "updated"
+)
+
+// ItemChange is one item difference between a version and the one before it
+type ItemChange struct {
+	Type     ChangeType
+	ItemID   string
+	Name     string
+	OldPrice float64 `json:",omitempty"`
+	NewPrice float64 `json:",omitempty"`
+}
+
+// String describes the change in a few words
+func (c ItemChange) String() string {
+	switch c.Type {
+	case ItemAdded:
+		return fmt.Sprintf("Added %s (%.2f)", c.Name, c.NewPrice)
+	case ItemRemoved:
+		return fmt.Sprintf("Removed %s", c.Name)
+	case ItemPrice:
+		return fmt.Sprintf("%s: %.2f -> %.2f", c.Name, c.OldPrice, c.NewPrice)
+	default:
+		return fmt.Sprintf("Updated %s", c.Name)
+	}
+}
+
+// ItemSnapshot is the part of an item a version records so the next one can be diffed against it
+type ItemSnapshot struct {
+	ID          string
+	Name        string
+	Price       float64
+	Description string
+	Variants    []VariantSnapshot `json:",omitempty"`
+	Categories  []string          `json:",omitempty"`
+	Tags        []string          `json:",omitempty"`
+}
+
+// VariantSnapshot is the part of a variant an item snapshot records
+type VariantSnapshot struct {
+	ID    string
+	Label string
+	Price float64
+}
+
+// PublishVersion is one published version of a shop
+type PublishVersion struct {
+	CID         string
+	PublishedAt time.Time
+	Message     string
+	Changes     []ItemChange
+	Items       []ItemSnapshot
+	RollbackOf  string `json:",omitempty"` // CID this version restored, if it was a rollback
+	Unpinned    bool   `json:",omitempty"` // Unpinned by the retention policy, can't be rolled back to
+}
+
+// Short returns the first characters of the version's CID for display
+func (v PublishVersion) Short() string {
+	if len(v.CID) <= 12 {
+		return v.CID
+	}
+	return v.CID[:12]
+}
+
+// versionPins is the part of the IPFS manager that points a shop at a
+// published version and unpins the versions it no longer keeps
+type versionPins interface {
+	PointShopAt(shopDir string, shopPath string, hash string) (string, error)
+	UnpinContent(cid string) error
+	RunGarbageCollection() error
+}
+
+// History returns a shop's published versions, newest first
+func (m *Manager) History(shopName string) ([]PublishVersion, error) {
+	versions, err := m.loadHistory(shopName)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].PublishedAt.After(versions[j].PublishedAt)
+	})
+	return versions, nil
+}
+
+// SetRetention sets how many distinct published versions of each shop stay pinned, 0 keeps them all
+func (m *Manager) SetRetention(keepVersions int) {
+	m.keepVersions = keepVersions
+}
+
+// Rollback makes an earlier published version of a shop current again: shop.json,
+// the shop's metadata, its IPNS name and, if configured, its ENS name are
+// pointed back at that version's CID. The shop's editable data is left as is.
+func (m *Manager) Rollback(shopName string, cid string) (string, error) {
+	versions, err := m.loadHistory(shopName)
+	if err != nil {
+		return "", err
+	}
+
+	var target *PublishVersion
+	for i := range versions {
+		if versions[i].CID == cid {
+			target = &versions[i]
+		}
+	}
+	if target == nil {
+		return "", fmt.Errorf("shop %s has no published version %s", shopName, cid)
+	}
+	if target.Unpinned {
+		return "", fmt.Errorf("version %s was unpinned by the retention policy and may no longer be available", target.Short())
+	}
+
+	current := versions[len(versions)-1]
+	if current.CID == cid {
+		return "", fmt.Errorf("version %s is already the current version", target.Short())
+	}
+
+	shopDir := m.GetShopPath(shopName)
+	url, err := m.pins.PointShopAt(shopDir, filepath.Join(shopDir, "shop.json"), cid)
+	if err != nil {
+		return "", fmt.Errorf("failed to roll back shop %s: %w", shopName, err)
+	}
+
+	rollback := PublishVersion{
+		CID:         cid,
+		PublishedAt: time.Now(),
+		Message:     fmt.Sprintf("Roll back to %s (%s)", target.Short(), target.Message),
+		Changes:     diffItems(current.Items, target.Items),
+		Items:       target.Items,
+		RollbackOf:  cid,
+	}
+	if err := m.recordVersion(shopName, rollback); err != nil {
+		return "", err
+	}
+
+	m.afterPublish(shopName, cid)
+	return url, nil
+}
+
+// recordVersion appends a version to a shop's history and unpins the versions
+// the retention policy no longer keeps
+func (m *Manager) recordVersion(shopName string, version PublishVersion) error {
+	versions, err := m.loadHistory(shopName)
+	if err != nil {
+		return err
+	}
+	versions = append(versions, version)
+
+	if m.keepVersions > 0 {
+		m.applyRetention(versions)
+	}
+
+	return m.saveHistory(shopName, versions)
+}
+
+// applyRetention unpins every version whose CID isn't one of the newest
+// keepVersions distinct CIDs, then collects garbage once. Rollbacks and
+// republishing unchanged content share a CID, so they don't push older
+// content out.
+func (m *Manager) applyRetention(versions []PublishVersion) {
+	kept := make(map[string]bool)
+	for i := len(versions) - 1; i >= 0 && len(kept) < m.keepVersions; i-- {
+		kept[versions[i].CID] = true
+	}
+
+	unpinned := make(map[string]bool)
+	for i := range versions {
+		v := &versions[i]
+		if v.Unpinned || kept[v.CID] {
+			continue
+		}
+		// Older versions may share a CID, which is only pinned once
+		if !unpinned[v.CID] {
+			if err := m.pins.UnpinContent(v.CID); err != nil {
+				fmt.Printf("Warning: failed to unpin old version %s: %v\n", v.CID, err)
+				continue
+			}
+			unpinned[v.CID] = true
+		}
+		v.Unpinned = true
+	}
+
+	if len(unpinned) > 0 {
+		if err := m.pins.RunGarbageCollection(); err != nil {
+			fmt.Printf("Warning: failed to run garbage collection: %v\n", err)
+		}
+	}
+}
+
+// pinnedVersions returns the distinct CIDs of a shop's versions that are still pinned
+func (m *Manager) pinnedVersions(shopName string) []string {
+	versions, err := m.loadHistory(shopName)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	cids := []string{}
+	for _, v := range versions {
+		if v.Unpinned || seen[v.CID] {
+			continue
+		}
+		seen[v.CID] = true
+		cids = append(cids, v.CID)
+	}
+	return cids
+}
+
+// loadHistory reads a shop's versions in the order they were published
+func (m *Manager) loadHistory(shopName string) ([]PublishVersion, error) {
+	data, err := os.ReadFile(filepath.Join(m.GetShopPath(shopName), historyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []PublishVersion{}, nil
+		}
+		return nil, fmt.Errorf("failed to read publish history: %w", err)
+	}
+
+	var versions []PublishVersion
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse publish history: %w", err)
+	}
+	return versions, nil
+}
+
+// saveHistory writes a shop's versions
+func (m *Manager) saveHistory(shopName string, versions []PublishVersion) error {
+	data, err := json.MarshalIndent(versions, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal publish history: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(m.GetShopPath(shopName), historyFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to save publish history: %w", err)
+	}
+	return nil
+}
+
+// snapshotItems records the items of a shop as published
+func snapshotItems(items []models.Item) []ItemSnapshot {
+	snapshot := make([]ItemSnapshot, 0, len(items))
+	for _, item := range items {
+		itemSnapshot := ItemSnapshot{
+			ID:          item.ID,
+			Name:        item.Name,
+			Price:       item.Price,
+			Description: item.Description,
+			Categories:  append([]string(nil), item.Categories...),
+			Tags:        append([]string(nil), item.Tags...),
+		}
+		for _, variant := range item.Variants {
+			itemSnapshot.Variants = append(itemSnapshot.Variants, VariantSnapshot{
+				ID:    variant.ID,
+				Label: item.VariantLabel(variant),
+				Price: item.VariantPrice(variant),
+			})
+		}
+		snapshot = append(snapshot, itemSnapshot)
+	}
+	return snapshot
+}
+
+// diffItems lists the item changes from before to after. Items are matched by
+// ID, or by name for items saved without one.
+func diffItems(before, after []ItemSnapshot) []ItemChange {
+	key := func(item ItemSnapshot) string {
+		if item.ID != "" {
+			return item.ID
+		}
+		return "name:" + item.Name
+	}
+
+	old := make(map[string]ItemSnapshot, len(before))
+	for _, item := range before {
+		old[key(item)] = item
+	}
+
+	changes := []ItemChange{}
+	for _, item := range after {
+		prev, ok := old[key(item)]
+		delete(old, key(item))
+
+		switch {
+		case !ok:
+			changes = append(changes, ItemChange{Type: ItemAdded, ItemID: item.ID, Name: item.Name, NewPrice: item.Price})
+		case prev.Price != item.Price:
+			changes = append(changes, ItemChange{Type: ItemPrice, ItemID: item.ID, Name: item.Name, OldPrice: prev.Price, NewPrice: item.Price})
+		case prev.Name != item.Name || prev.Description != item.Description || !sameVariants(prev.Variants, item.Variants) ||
+			!sameStrings(prev.Categories, item.Categories) || !sameStrings(prev.Tags, item.Tags):
+			changes = append(changes, ItemChange{Type: ItemUpdated, ItemID: item.ID, Name: item.Name})
+		}
+	}
+
+	// Whatever is left was removed, keep the original order
+	for _, item := range before {
+		if _, ok := old[key(item)]; ok {
+			changes = append(changes, ItemChange{Type: ItemRemoved, ItemID: item.ID, Name: item.Name, OldPrice: item.Price})
+		}
+	}
+
+	return changes
+}
+
+// sameVariants returns true if two snapshots of an item have the same variants at the same prices
+func sameVariants(a, b []VariantSnapshot) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameStrings returns true if a and b hold the same strings in the same order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultMessage summarises a version's changes when the publisher gave no message
+func defaultMessage(first bool, changes []ItemChange) string {
+	if first {
+		return "First publish"
+	}
+	if len(changes) == 0 {
+		return "Republish with no item changes"
+	}
+
+	parts := []string{}
+	for i, change := range changes {
+		if i == 3 {
+			parts = append(parts, fmt.Sprintf("%d more", len(changes)-i))
+			break
+		}
+		parts = append(parts, change.String())
+	}
+	return strings.Join(parts, ", ")
+}
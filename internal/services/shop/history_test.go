@@ -0,0 +1,348 @@
+package shop
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"IndieNode/internal/models"
+)
+
+// fakePins records what the publish history asks IPFS to do
+type fakePins struct {
+	unpinned []string
+	failing  map[string]bool // CIDs that fail to unpin
+	gcRuns   int
+	pointed  []string
+}
+
+func (f *fakePins) PointShopAt(shopDir string, shopPath string, hash string) (string, error) {
+	f.pointed = append(f.pointed, hash)
+
+	// Like the IPFS manager, shop.json follows the current version
+	data, err := os.ReadFile(shopPath)
+	if err != nil {
+		return "", err
+	}
+	var shop models.Shop
+	if err := json.Unmarshal(data, &shop); err != nil {
+		return "", err
+	}
+	shop.CID = hash
+	if data, err = json.Marshal(shop); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(shopPath, data, 0644); err != nil {
+		return "", err
+	}
+	return "https://ipfs.example/ipfs/" + hash + "/" + filepath.Base(shopDir) + "/src/index.html", nil
+}
+
+func (f *fakePins) UnpinContent(cid string) error {
+	if f.failing[cid] {
+		return fmt.Errorf("failed to unpin %s", cid)
+	}
+	f.unpinned = append(f.unpinned, cid)
+	return nil
+}
+
+func (f *fakePins) RunGarbageCollection() error {
+	f.gcRuns++
+	return nil
+}
+
+// newTestHistoryManager returns a manager whose IPFS side is a fake
+func newTestHistoryManager(t *testing.T, keepVersions int) (*Manager, *fakePins) {
+	t.Helper()
+
+	m, err := NewManager(filepath.Join(t.TempDir(), "shops"), nil)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	pins := &fakePins{}
+	m.pins = pins
+	m.SetRetention(keepVersions)
+	return m, pins
+}
+
+// versionsOf returns versions published with the given CIDs, oldest first
+func versionsOf(cids ...string) []PublishVersion {
+	versions := make([]PublishVersion, 0, len(cids))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, c := range cids {
+		versions = append(versions, PublishVersion{CID: c, PublishedAt: start.Add(time.Duration(i) * time.Hour)})
+	}
+	return versions
+}
+
+func TestDiffItems(t *testing.T) {
+	mug := ItemSnapshot{ID: "mug", Name: "Mug", Price: 12, Description: "Big"}
+	vase := ItemSnapshot{ID: "vase", Name: "Vase", Price: 40}
+	with := func(item ItemSnapshot, change func(*ItemSnapshot)) ItemSnapshot {
+		change(&item)
+		return item
+	}
+
+	tests := []struct {
+		name   string
+		before []ItemSnapshot
+		after  []ItemSnapshot
+		want   []string
+	}{
+		{name: "unchanged", before: []ItemSnapshot{mug, vase}, after: []ItemSnapshot{mug, vase}},
+		{name: "first publish", after: []ItemSnapshot{mug}, want: []string{"Added Mug (12.00)"}},
+		{name: "removed", before: []ItemSnapshot{mug, vase}, after: []ItemSnapshot{vase}, want: []string{"Removed Mug"}},
+		{
+			name:   "price",
+			before: []ItemSnapshot{mug},
+			after:  []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Price = 15; i.Name = "Large mug" })},
+			want:   []string{"Large mug: 12.00 -> 15.00"},
+		},
+		{
+			name:   "renamed",
+			before: []ItemSnapshot{mug},
+			after:  []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Name = "Large mug" })},
+			want:   []string{"Updated Large mug"},
+		},
+		{
+			name:   "description",
+			before: []ItemSnapshot{mug},
+			after:  []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Description = "Bigger" })},
+			want:   []string{"Updated Mug"},
+		},
+		{
+			name:   "variant price",
+			before: []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Variants = []VariantSnapshot{{ID: "blue", Label: "Blue", Price: 12}} })},
+			after:  []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Variants = []VariantSnapshot{{ID: "blue", Label: "Blue", Price: 14}} })},
+			want:   []string{"Updated Mug"},
+		},
+		{
+			name:   "categories",
+			before: []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Categories = []string{"kitchen"} })},
+			after:  []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Categories = []string{"kitchen", "gifts"} })},
+			want:   []string{"Updated Mug"},
+		},
+		{
+			name:   "tags",
+			before: []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Tags = []string{"blue"} })},
+			after:  []ItemSnapshot{with(mug, func(i *ItemSnapshot) { i.Tags = []string{"green"} })},
+			want:   []string{"Updated Mug"},
+		},
+		{
+			name:   "reordered",
+			before: []ItemSnapshot{mug, vase},
+			after:  []ItemSnapshot{vase, mug},
+		},
+		{
+			name:   "added before removed",
+			before: []ItemSnapshot{mug, vase},
+			after:  []ItemSnapshot{{ID: "bowl", Name: "Bowl", Price: 8}},
+			want:   []string{"Added Bowl (8.00)", "Removed Mug", "Removed Vase"},
+		},
+		{
+			name:   "items without IDs are matched by name",
+			before: []ItemSnapshot{{Name: "Mug", Price: 12}, {Name: "Vase", Price: 40}},
+			after:  []ItemSnapshot{{Name: "Mug", Price: 10}, {Name: "Bowl", Price: 8}},
+			want:   []string{"Mug: 12.00 -> 10.00", "Added Bowl (8.00)", "Removed Vase"},
+		},
+		{
+			name:   "ID given to an item",
+			before: []ItemSnapshot{{Name: "Mug", Price: 12}},
+			after:  []ItemSnapshot{{ID: "mug", Name: "Mug", Price: 12}},
+			want:   []string{"Added Mug (12.00)", "Removed Mug"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := diffItems(tt.before, tt.after)
+			if changes == nil {
+				t.Fatalf("diffItems() = nil, want an empty list")
+			}
+			got := make([]string, 0, len(changes))
+			for _, change := range changes {
+				got = append(got, change.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("diffItems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	tests := []struct {
+		name         string
+		keep         int
+		versions     []PublishVersion
+		unpinned     []string // Already unpinned before
+		failing      []string
+		wantUnpinned []string // Unpinned by this call, in order
+		wantFlagged  []int    // Indexes of the versions marked unpinned afterwards
+	}{
+		{
+			name:     "fewer versions than kept",
+			keep:     3,
+			versions: versionsOf("a", "b", "c"),
+		},
+		{
+			name:         "oldest versions",
+			keep:         2,
+			versions:     versionsOf("a", "b", "c", "d"),
+			wantUnpinned: []string{"a", "b"},
+			wantFlagged:  []int{0, 1},
+		},
+		{
+			name:         "rollback doesn't count as another version",
+			keep:         2,
+			versions:     versionsOf("a", "b", "c", "b"),
+			wantUnpinned: []string{"a"},
+			wantFlagged:  []int{0},
+		},
+		{
+			name:         "republished content is kept",
+			keep:         2,
+			versions:     versionsOf("a", "b", "c", "c", "c"),
+			wantUnpinned: []string{"a"},
+			wantFlagged:  []int{0},
+		},
+		{
+			name:         "older versions sharing a CID are unpinned once",
+			keep:         1,
+			versions:     versionsOf("a", "b", "a", "c"),
+			wantUnpinned: []string{"a", "b"},
+			wantFlagged:  []int{0, 1, 2},
+		},
+		{
+			name:         "already unpinned",
+			keep:         1,
+			versions:     versionsOf("a", "b", "c"),
+			unpinned:     []string{"a"},
+			wantUnpinned: []string{"b"},
+			wantFlagged:  []int{0, 1},
+		},
+		{
+			name:         "failed unpin stays pinned",
+			keep:         1,
+			versions:     versionsOf("a", "b", "c"),
+			failing:      []string{"a"},
+			wantUnpinned: []string{"b"},
+			wantFlagged:  []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, pins := newTestHistoryManager(t, tt.keep)
+			pins.failing = make(map[string]bool)
+			for _, c := range tt.failing {
+				pins.failing[c] = true
+			}
+			for i := range tt.versions {
+				for _, c := range tt.unpinned {
+					if tt.versions[i].CID == c {
+						tt.versions[i].Unpinned = true
+					}
+				}
+			}
+
+			m.applyRetention(tt.versions)
+
+			if strings.Join(pins.unpinned, ",") != strings.Join(tt.wantUnpinned, ",") {
+				t.Errorf("unpinned %v, want %v", pins.unpinned, tt.wantUnpinned)
+			}
+			wantGC := 0
+			if len(tt.wantUnpinned) > 0 {
+				wantGC = 1
+			}
+			if pins.gcRuns != wantGC {
+				t.Errorf("collected garbage %d times, want %d", pins.gcRuns, wantGC)
+			}
+
+			flagged := []int{}
+			for i, v := range tt.versions {
+				if v.Unpinned {
+					flagged = append(flagged, i)
+				}
+			}
+			if fmt.Sprint(flagged) != fmt.Sprint(append([]int{}, tt.wantFlagged...)) {
+				t.Errorf("versions %v are marked unpinned, want %v", flagged, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	m, pins := newTestHistoryManager(t, 2)
+
+	shop := &models.Shop{Name: "Clay", CID: "bafyc", Items: []models.Item{{ID: "mug", Name: "Mug", Price: 15}}}
+	if err := m.SaveShop(shop); err != nil {
+		t.Fatalf("SaveShop() error = %v", err)
+	}
+
+	versions := versionsOf("bafya", "bafyb", "bafyc")
+	versions[0].Unpinned = true
+	versions[1].Message = "Cheaper mug"
+	versions[1].Items = []ItemSnapshot{{ID: "mug", Name: "Mug", Price: 12}}
+	versions[2].Items = []ItemSnapshot{{ID: "mug", Name: "Mug", Price: 15}, {ID: "vase", Name: "Vase", Price: 40}}
+	if err := m.saveHistory("Clay", versions); err != nil {
+		t.Fatalf("saveHistory() error = %v", err)
+	}
+
+	for _, tt := range []struct{ cid, reason string }{
+		{"bafyc", "current version"},
+		{"bafya", "unpinned version"},
+		{"bafyz", "version that was never published"},
+	} {
+		if _, err := m.Rollback("Clay", tt.cid); err == nil {
+			t.Errorf("Rollback() to the %s succeeded", tt.reason)
+		}
+	}
+	if len(pins.pointed) != 0 {
+		t.Fatalf("failed rollbacks pointed the shop at %v", pins.pointed)
+	}
+
+	url, err := m.Rollback("Clay", "bafyb")
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if !strings.Contains(url, "bafyb") || strings.Join(pins.pointed, ",") != "bafyb" {
+		t.Errorf("Rollback() = %s after pointing the shop at %v, want bafyb", url, pins.pointed)
+	}
+
+	history, err := m.loadHistory("Clay")
+	if err != nil {
+		t.Fatalf("loadHistory() error = %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("history has %d versions, want the rollback added", len(history))
+	}
+	rollback := history[3]
+	if rollback.CID != "bafyb" || rollback.RollbackOf != "bafyb" || !strings.Contains(rollback.Message, "Cheaper mug") {
+		t.Errorf("rollback version = %+v", rollback)
+	}
+	var changes []string
+	for _, change := range rollback.Changes {
+		changes = append(changes, change.String())
+	}
+	if want := "Mug: 15.00 -> 12.00, Removed Vase"; strings.Join(changes, ", ") != want {
+		t.Errorf("rollback changes = %q, want %q", changes, want)
+	}
+
+	// Both kept CIDs are still distinct, so nothing more is unpinned
+	if len(pins.unpinned) != 0 {
+		t.Errorf("rollback unpinned %v, want nothing", pins.unpinned)
+	}
+
+	loaded, err := m.LoadShop("Clay")
+	if err != nil {
+		t.Fatalf("LoadShop() error = %v", err)
+	}
+	if loaded.CID != "bafyb" || len(loaded.Items) != 1 || loaded.Items[0].Price != 15 {
+		t.Errorf("shop after rollback has CID %s and items %+v, want bafyb and its editable data kept", loaded.CID, loaded.Items)
+	}
+}
@@ -0,0 +1,512 @@
+package shop
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // GIFs are only read for their size, see process
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// photoWidths are the widths item photos are resized to, photos are never enlarged
+	photoWidths = []int{480, 960, 1600}
+
+	// logoWidths are the widths a shop's logo is resized to
+	logoWidths = []int{256, 512}
+)
+
+const (
+	// thumbnailSize is the width and height of an item photo's square thumbnail
+	thumbnailSize = 320
+
+	// jpegQuality is the quality photos are encoded with
+	jpegQuality = 82
+
+	// webpQuality is the quality cwebp encodes WebP copies with
+	webpQuality = 80
+
+	// maxImagePixels is the most pixels an image can have, checked before it's
+	// decoded since a small file can declare a huge image
+	maxImagePixels = 50_000_000
+)
+
+// ProcessedImage is a photo as published: resized to several widths, with its
+// metadata stripped and file names derived from the original's content, so an
+// unchanged photo gets the same files, and CIDs, every time a shop is published
+type ProcessedImage struct {
+	Src        string // Largest variant, for browsers without srcset support
+	Srcset     string // Every variant with its width, for the srcset attribute
+	WebPSrcset string // WebP copies of the variants, empty if cwebp isn't installed
+	Thumbnail  string // Square thumbnail, empty for logos and images that can't be resized
+	Width      int    // Size of Src, so pages can reserve space for it
+	Height     int
+}
+
+// ImagePipeline processes a shop's images into one directory of its site
+type ImagePipeline struct {
+	dir     string          // Directory the images are written to
+	urlDir  string          // dir relative to the site's root
+	cwebp   string          // Path of the cwebp binary, empty if it isn't installed
+	written map[string]bool // Files in dir the site uses, see Prune
+}
+
+// NewImagePipeline creates a pipeline that writes images to dir, which the
+// site serves at urlDir
+func NewImagePipeline(dir string, urlDir string) *ImagePipeline {
+	cwebp, _ := exec.LookPath("cwebp")
+	return &ImagePipeline{
+		dir:     dir,
+		urlDir:  strings.TrimSuffix(urlDir, "/"),
+		cwebp:   cwebp,
+		written: make(map[string]bool),
+	}
+}
+
+// ProcessPhoto processes an item photo into photoWidths and a thumbnail
+func (p *ImagePipeline) ProcessPhoto(path string) (*ProcessedImage, error) {
+	return p.process(path, photoWidths, thumbnailSize)
+}
+
+// ProcessLogo processes a shop's logo into logoWidths
+func (p *ImagePipeline) ProcessLogo(path string) (*ProcessedImage, error) {
+	return p.process(path, logoWidths, 0)
+}
+
+// Prune removes the files in the pipeline's directory that weren't written or
+// reused since it was created, such as the variants of photos that were removed
+func (p *ImagePipeline) Prune() error {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read image directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || p.written[entry.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(p.dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove unused image %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// imageVariant is one file an image is processed into
+type imageVariant struct {
+	name   string
+	width  int
+	height int
+	square bool // Center cropped to a square before resizing
+}
+
+// process writes an image's variants to the pipeline's directory. Files that
+// already exist are kept as they are, their names come from the original's
+// content so they can't be stale.
+func (p *ImagePipeline) process(path string, widths []int, thumbnail int) (*ProcessedImage, error) {
+	if filepath.Clean(filepath.Dir(path)) == filepath.Clean(p.dir) {
+		// Already one of the pipeline's variants, processing it again would
+		// only lose quality
+		return p.reuse(filepath.Base(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image %s, use a JPEG, PNG or GIF: %w", filepath.Base(path), err)
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, fmt.Errorf("image %s is too large (%dx%d), use one under %d megapixels",
+			filepath.Base(path), config.Width, config.Height, maxImagePixels/1_000_000)
+	}
+	if format == "gif" {
+		// GIFs would lose their animation, publish them as they are
+		return p.copyOriginal(data, hash, config)
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	width, height := config.Width, config.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	ext := ".jpg"
+	if format == "png" {
+		// PNGs are kept as PNGs since they may be transparent
+		ext = ".png"
+	}
+
+	// Every width smaller than the image, and the image's own width if it's
+	// smaller than the largest
+	variants := []imageVariant{}
+	for _, w := range widths {
+		if w >= width {
+			break
+		}
+		variants = append(variants, imageVariant{name: fmt.Sprintf("%s-%d%s", hash, w, ext), width: w, height: scaledHeight(width, height, w)})
+	}
+	if width <= widths[len(widths)-1] {
+		variants = append(variants, imageVariant{name: fmt.Sprintf("%s-%d%s", hash, width, ext), width: width, height: height})
+	}
+	if thumbnail > 0 {
+		size := thumbnail
+		if width < size || height < size {
+			size = min(width, height)
+		}
+		variants = append(variants, imageVariant{name: fmt.Sprintf("%s-thumb%s", hash, ext), width: size, height: size, square: true})
+	}
+
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image directory: %w", err)
+	}
+
+	// Only decode the original if a variant is missing
+	var src *image.RGBA
+	for _, variant := range variants {
+		if p.exists(variant.name) && (p.cwebp == "" || p.exists(webpName(variant.name))) {
+			p.written[variant.name] = true
+			if p.cwebp != "" {
+				p.written[webpName(variant.name)] = true
+			}
+			continue
+		}
+
+		if src == nil {
+			decoded, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode image: %w", err)
+			}
+			src = orient(toRGBA(decoded), orientation)
+		}
+
+		img := src
+		if variant.square {
+			img = cropSquare(img)
+		}
+		if err := p.write(variant.name, resize(img, variant.width, variant.height)); err != nil {
+			return nil, err
+		}
+	}
+
+	processed := &ProcessedImage{}
+	srcset, webpSrcset := []string{}, []string{}
+	for _, variant := range variants {
+		url := p.url(variant.name)
+		if variant.square {
+			processed.Thumbnail = url
+			continue
+		}
+		processed.Src, processed.Width, processed.Height = url, variant.width, variant.height
+		srcset = append(srcset, fmt.Sprintf("%s %dw", url, variant.width))
+		if p.cwebp != "" {
+			webpSrcset = append(webpSrcset, fmt.Sprintf("%s %dw", p.url(webpName(variant.name)), variant.width))
+		}
+	}
+	processed.Srcset = strings.Join(srcset, ", ")
+	processed.WebPSrcset = strings.Join(webpSrcset, ", ")
+
+	return processed, nil
+}
+
+// reuse rebuilds a processed image from the variants in the pipeline's directory
+// that share name's content hash
+func (p *ImagePipeline) reuse(name string) (*ProcessedImage, error) {
+	hash, _, _ := strings.Cut(strings.TrimSuffix(name, filepath.Ext(name)), "-")
+	matches, err := filepath.Glob(filepath.Join(p.dir, hash+"*"))
+	if err != nil || len(matches) == 0 {
+		return nil, fmt.Errorf("image %s doesn't exist", name)
+	}
+
+	processed := &ProcessedImage{}
+	variants := []imageVariant{}
+	webp := make(map[int]string)
+	for _, match := range matches {
+		file := filepath.Base(match)
+		p.written[file] = true
+
+		f, err := os.Open(match)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open image: %w", err)
+		}
+		config, _, err := image.DecodeConfig(f)
+		f.Close()
+
+		switch {
+		case strings.Contains(file, "-thumb"):
+			processed.Thumbnail = p.url(file)
+		case filepath.Ext(file) == ".webp":
+			// The image package can't read WebP, take the width from the name
+			var width int
+			fmt.Sscanf(strings.TrimPrefix(file, hash+"-"), "%d", &width)
+			webp[width] = file
+		case err == nil:
+			variants = append(variants, imageVariant{name: file, width: config.Width, height: config.Height})
+		}
+	}
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("image %s can't be read", name)
+	}
+
+	sort.Slice(variants, func(i, j int) bool {
+		return variants[i].width < variants[j].width
+	})
+	srcset, webpSrcset := []string{}, []string{}
+	for _, variant := range variants {
+		processed.Src, processed.Width, processed.Height = p.url(variant.name), variant.width, variant.height
+		srcset = append(srcset, fmt.Sprintf("%s %dw", p.url(variant.name), variant.width))
+		if file, ok := webp[variant.width]; ok {
+			webpSrcset = append(webpSrcset, fmt.Sprintf("%s %dw", p.url(file), variant.width))
+		}
+	}
+	processed.Srcset = strings.Join(srcset, ", ")
+	if len(webpSrcset) == len(srcset) {
+		processed.WebPSrcset = strings.Join(webpSrcset, ", ")
+	}
+
+	return processed, nil
+}
+
+// copyOriginal publishes a GIF under a name derived from its content
+func (p *ImagePipeline) copyOriginal(data []byte, hash string, config image.Config) (*ProcessedImage, error) {
+	name := hash + ".gif"
+	if !p.exists(name) {
+		if err := os.MkdirAll(p.dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create image directory: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(p.dir, name), data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to copy image: %w", err)
+		}
+	}
+	p.written[name] = true
+
+	return &ProcessedImage{
+		Src:    p.url(name),
+		Width:  config.Width,
+		Height: config.Height,
+	}, nil
+}
+
+// write encodes a variant, which leaves out all of the original's metadata, and
+// its WebP copy if cwebp is installed
+func (p *ImagePipeline) write(name string, img *image.RGBA) error {
+	var buf bytes.Buffer
+	var err error
+	if filepath.Ext(name) == ".png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	path := filepath.Join(p.dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	p.written[name] = true
+
+	if p.cwebp != "" {
+		webp := webpName(name)
+		cmd := exec.Command(p.cwebp, "-quiet", "-metadata", "none", "-q", fmt.Sprint(webpQuality), path, "-o", filepath.Join(p.dir, webp))
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to convert %s to WebP: %s: %w", name, output, err)
+		}
+		p.written[webp] = true
+	}
+
+	return nil
+}
+
+// exists returns true if the pipeline's directory has a file called name
+func (p *ImagePipeline) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(p.dir, name))
+	return err == nil
+}
+
+// url returns the URL of a file in the pipeline's directory relative to the site's root
+func (p *ImagePipeline) url(name string) string {
+	if p.urlDir == "" {
+		return name
+	}
+	return p.urlDir + "/" + name
+}
+
+// webpName returns the name of a variant's WebP copy
+func webpName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".webp"
+}
+
+// scaledHeight returns the height of a width x height image resized to newWidth
+func scaledHeight(width, height, newWidth int) int {
+	return max(1, (height*newWidth+width/2)/width)
+}
+
+// toRGBA converts an image to RGBA with its origin at 0,0
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// orient rotates and flips an image as its EXIF orientation says it should be
+// displayed, since the orientation is lost with the rest of the metadata
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// cropSquare returns the largest square in the center of an image
+func cropSquare(src *image.RGBA) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	size := min(w, h)
+	x0, y0 := (w-size)/2, (h-size)/2
+	return src.SubImage(image.Rect(x0, y0, x0+size, y0+size)).(*image.RGBA)
+}
+
+// resize scales an image down to width x height by averaging the pixels each
+// new pixel covers, which keeps photos sharp without aliasing
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	bounds := src.Rect
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw == width && sh == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := max(y0+1, (y+1)*sh/height)
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := max(x0+1, (x+1)*sw/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					a += uint64(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(b / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, 1 if it has none
+func jpegOrientation(data []byte) int {
+	// Walk the segments before the image data looking for the EXIF one
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			break
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of EXIF data
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
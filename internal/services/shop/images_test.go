@@ -0,0 +1,119 @@
+package shop
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngHeader returns the start of a PNG declaring a width x height image, which
+// is all DecodeConfig reads
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	ihdr[12], ihdr[13] = 8, 6 // 8 bit RGBA
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)-4))
+	buf.Write(ihdr)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return buf.Bytes()
+}
+
+// writeTestImage writes data to name in a temporary directory and returns its path
+func writeTestImage(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestProcessPhotoRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "unknown format", file: "photo.webp", data: []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), wantErr: "unsupported image"},
+		{name: "not an image", file: "photo.jpg", data: []byte("<html>not a photo</html>"), wantErr: "unsupported image"},
+		{name: "empty file", file: "photo.png", wantErr: "unsupported image"},
+		{name: "too many pixels", file: "bomb.png", data: pngHeader(100_000, 100_000), wantErr: "too large"},
+		{name: "too wide", file: "strip.png", data: pngHeader(1<<30, 1), wantErr: "too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "images")
+			pipeline := NewImagePipeline(dir, "images")
+
+			_, err := pipeline.ProcessPhoto(writeTestImage(t, tt.file, tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ProcessPhoto() error = %v, want %q", err, tt.wantErr)
+			}
+			// Nothing is published
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("wrote %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestProcessPhotoCopiesGIF(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 40, 30), []color.Color{color.White, color.Black})
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("failed to encode GIF: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "images")
+	processed, err := NewImagePipeline(dir, "images").ProcessPhoto(writeTestImage(t, "spinner.GIF", buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ProcessPhoto() error = %v", err)
+	}
+
+	if !strings.HasPrefix(processed.Src, "images/") || !strings.HasSuffix(processed.Src, ".gif") || processed.Width != 40 || processed.Height != 30 {
+		t.Errorf("ProcessPhoto() = %+v", processed)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(processed.Src, "images/")))
+	if err != nil || !bytes.Equal(data, buf.Bytes()) {
+		t.Errorf("GIF wasn't copied as it is: %v", err)
+	}
+}
+
+func TestProcessPhotoResizes(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+
+	pipeline := NewImagePipeline(filepath.Join(t.TempDir(), "images"), "images")
+	pipeline.cwebp = "" // Don't depend on cwebp being installed
+	processed, err := pipeline.ProcessPhoto(writeTestImage(t, "photo.png", buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ProcessPhoto() error = %v", err)
+	}
+
+	srcset := strings.Split(processed.Srcset, ", ")
+	if len(srcset) != 3 || !strings.HasSuffix(srcset[0], "-480.png 480w") || !strings.HasSuffix(srcset[2], "-1000.png 1000w") {
+		t.Errorf("Srcset = %q, want 480, 960 and 1000 wide variants", processed.Srcset)
+	}
+	if processed.Width != 1000 || processed.Height != 500 || !strings.HasSuffix(processed.Thumbnail, "-thumb.png") {
+		t.Errorf("ProcessPhoto() = %+v", processed)
+	}
+}
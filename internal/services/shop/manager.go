@@ -1,24 +1,57 @@
 package shop
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
-	"text/template"
+	"sync"
+	"time"
 
+	"IndieNode/db/orbitdb"
 	"IndieNode/internal/models"
 	"IndieNode/internal/services/auth"
 	"IndieNode/ipfs"
+
+	"github.com/ipfs/go-cid"
 )
 
 // Manager handles shop-related operations
 type Manager struct {
-	baseDir string
-	ipfsMgr *ipfs.IPFSManager
+	baseDir   string
+	ipfsMgr   *ipfs.IPFSManager
+	pins      versionPins // ipfsMgr, for the publish history
+	templates *TemplateEngine
+	pinner    Pinner            // Queues published shops for remote pinning, may be nil
+	ens       ContenthashSetter // Points shops' ENS names at their published CID, may be nil
+	store     Store             // Database the shops are saved and published to, may be nil
+
+	keepVersions int // Distinct published versions of a shop that stay pinned, 0 keeps all
+
+	loadedStock map[string]orbitdb.StockCounts // Stock counts each shop was last loaded or saved with, by shop ID
+	stockMutex  sync.Mutex
+}
+
+// Pinner queues published content for pinning on remote services
+type Pinner interface {
+	QueueShop(shopID string, cids ...string) error
+}
+
+// ContenthashSetter points ENS names at IPFS content
+type ContenthashSetter interface {
+	SetContenthash(ctx context.Context, name string, hash string) error
 }
 
+// ensUpdateTimeout bounds how long an ENS update may wait for its transaction to be mined
+const ensUpdateTimeout = 10 * time.Minute
+
+// shopKeyTimeout bounds how long generating a shop waits for its IPNS key
+const shopKeyTimeout = 30 * time.Second
+
 // NewManager creates a new shop manager
 func NewManager(baseDir string, ipfsMgr *ipfs.IPFSManager) (*Manager, error) {
 	// Create shops directory if it doesn't exist
@@ -27,8 +60,12 @@ func NewManager(baseDir string, ipfsMgr *ipfs.IPFSManager) (*Manager, error) {
 	}
 
 	return &Manager{
-		baseDir: baseDir,
-		ipfsMgr: ipfsMgr,
+		baseDir:      baseDir,
+		ipfsMgr:      ipfsMgr,
+		pins:         ipfsMgr,
+		templates:    NewTemplateEngine(filepath.Join(filepath.Dir(baseDir), "templates")),
+		keepVersions: DefaultKeepVersions,
+		loadedStock:  make(map[string]orbitdb.StockCounts),
 	}, nil
 }
 
@@ -107,6 +144,7 @@ func (m *Manager) LoadCurrentShop() (*models.Shop, error) {
 		shop.OwnerAddress = auth.GetCurrentUser().Address
 	}
 
+	m.loadStock(&shop)
 	return &shop, nil
 }
 
@@ -180,10 +218,14 @@ func (m *Manager) LoadShop(shopName string) (*models.Shop, error) {
 		return nil, fmt.Errorf("failed to parse shop data: %w", err)
 	}
 
+	// Orders change the stock after the shop was saved
+	m.loadStock(&shop)
 	return &shop, nil
 }
 
-// SaveShop saves a shop to its directory
+// SaveShop saves a shop to its directory and, if a store is set, to the
+// database, where stock counts changed since the shop was loaded are applied
+// on top of the sales made in the meantime
 func (m *Manager) SaveShop(shop *models.Shop) error {
 	if shop == nil {
 		return fmt.Errorf("shop cannot be nil")
@@ -197,6 +239,9 @@ func (m *Manager) SaveShop(shop *models.Shop) error {
 	if shop.URLName == "" {
 		shop.GenerateURLName()
 	}
+	if err := ensureShopID(shop); err != nil {
+		return err
+	}
 
 	shopDir := filepath.Join(m.baseDir, shop.Name)
 	if err := os.MkdirAll(shopDir, 0755); err != nil {
@@ -213,6 +258,10 @@ func (m *Manager) SaveShop(shop *models.Shop) error {
 		return fmt.Errorf("failed to save shop: %w", err)
 	}
 
+	if err := m.storeShop(shop, false); err != nil {
+		return fmt.Errorf("failed to save shop %s to OrbitDB: %w", shop.Name, err)
+	}
+
 	return nil
 }
 
@@ -221,261 +270,322 @@ func (m *Manager) GetShopPath(shopName string) string {
 	return filepath.Join(m.baseDir, shopName)
 }
 
-// GenerateShop generates the HTML and assets for a shop
-func (m *Manager) GenerateShop(shop *models.Shop) error {
-	if shop == nil {
-		return fmt.Errorf("shop is nil")
+// SetPinner sets where published shops are queued for remote pinning, nil disables it
+func (m *Manager) SetPinner(pinner Pinner) {
+	m.pinner = pinner
+}
+
+// SetENSUpdater sets how shops' ENS names are pointed at their published CID, nil disables it
+func (m *Manager) SetENSUpdater(updater ContenthashSetter) {
+	m.ens = updater
+}
+
+// Publish adds a shop's site to IPFS, records it in the shop's publish history
+// under message, or a summary of its item changes if message is empty, and
+// queues it for remote pinning so it stays available while this node is offline.
+// Returns the site's gateway URL.
+func (m *Manager) Publish(shopName string, message string) (string, error) {
+	shopDir := m.GetShopPath(shopName)
+	url, err := m.ipfsMgr.Publish(filepath.Join(shopDir, "src", "index.html"), filepath.Join(shopDir, "shop.json"))
+	if err != nil {
+		return "", err
 	}
 
-	if shop.Name == "" {
-		return fmt.Errorf("shop name is required")
+	_, siteCID, _, err := m.ipfsMgr.CheckShopPublication(shopDir)
+	if err != nil || siteCID == "" {
+		return "", fmt.Errorf("shop %s has no published CID", shopName)
 	}
 
-	// Get shop directory
-	shopDir := m.GetShopPath(shop.Name)
-	srcDir := filepath.Join(shopDir, "src")
-	assetsDir := filepath.Join(srcDir, "assets")
-	logosDir := filepath.Join(assetsDir, "logos")
-	itemsDir := filepath.Join(srcDir, "items") // Keep original items dir for compatibility
-
-	// Create required directories
-	for _, dir := range []string{srcDir, assetsDir, logosDir, itemsDir} {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
-		}
+	if err := m.recordPublish(shopName, siteCID, message); err != nil {
+		fmt.Printf("Warning: failed to record publish history of shop %s: %v\n", shopName, err)
 	}
 
-	// Copy web3.js file
-	web3JsPath := filepath.Join(filepath.Dir(m.baseDir), "templates", "basic", "web3.js")
-	if err := m.copyFile(web3JsPath, filepath.Join(srcDir, "web3.js")); err != nil {
-		return fmt.Errorf("failed to copy web3.js: %w", err)
+	m.afterPublish(shopName, siteCID)
+	return url, nil
+}
+
+// recordPublish adds a newly published CID to a shop's history, diffing its
+// items against the previous version
+func (m *Manager) recordPublish(shopName string, siteCID string, message string) error {
+	shop, err := m.LoadShop(shopName)
+	if err != nil {
+		return err
 	}
 
-	// Copy shop-api.js file
-	shopApiJsPath := filepath.Join(filepath.Dir(m.baseDir), "templates", "basic", "shop-api.js")
-	if err := m.copyFile(shopApiJsPath, filepath.Join(srcDir, "shop-api.js")); err != nil {
-		return fmt.Errorf("failed to copy shop-api.js: %w", err)
+	versions, err := m.loadHistory(shopName)
+	if err != nil {
+		return err
 	}
 
-	// Generate CSS
-	cssPath := filepath.Join(srcDir, "styles.css")
-	if err := m.generateCSS(shop, cssPath); err != nil {
-		return fmt.Errorf("failed to generate CSS: %w", err)
+	var previous []ItemSnapshot
+	if len(versions) > 0 {
+		previous = versions[len(versions)-1].Items
 	}
+	items := snapshotItems(shop.Items)
+	changes := diffItems(previous, items)
 
-	// Generate index.html
-	indexPath := filepath.Join(srcDir, "index.html")
-	if err := m.generateHTML(shop, indexPath); err != nil {
-		return fmt.Errorf("failed to generate HTML: %w", err)
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = defaultMessage(len(versions) == 0, changes)
 	}
 
-	// Copy logo if exists
-	if shop.LocalLogoPath != "" {
-		ext := filepath.Ext(shop.LocalLogoPath)
-		logoFileName := "logo" + ext
-		targetPath := filepath.Join(logosDir, logoFileName)
-		if err := m.copyFile(shop.LocalLogoPath, targetPath); err != nil {
-			return fmt.Errorf("failed to copy logo: %w", err)
+	return m.recordVersion(shopName, PublishVersion{
+		CID:         siteCID,
+		PublishedAt: time.Now(),
+		Message:     message,
+		Changes:     changes,
+		Items:       items,
+	})
+}
+
+// afterPublish points the shop's stored copy and ENS name at siteCID and
+// updates its remote pins
+func (m *Manager) afterPublish(shopName string, siteCID string) {
+	m.publishToStore(shopName)
+
+	if m.ens != nil {
+		if shop, err := m.LoadShop(shopName); err == nil && shop.ENSName != "" {
+			// Waiting for the transaction to be mined takes a while, don't hold up the caller
+			go func(name string) {
+				ctx, cancel := context.WithTimeout(context.Background(), ensUpdateTimeout)
+				defer cancel()
+				if err := m.ens.SetContenthash(ctx, name, siteCID); err != nil {
+					fmt.Printf("Warning: failed to point %s at %s: %v\n", name, siteCID, err)
+					return
+				}
+				fmt.Printf("Pointed %s at %s\n", name, siteCID)
+			}(shop.ENSName)
 		}
-		// Update LogoPath to be relative to index.html
-		shop.LogoPath = "assets/logos/" + logoFileName
 	}
 
-	// Copy all item images
-	for _, item := range shop.Items {
-		for i, localPath := range item.LocalPhotoPaths {
-			targetPath := filepath.Join(itemsDir, filepath.Base(localPath))
-			if err := m.copyFile(localPath, targetPath); err != nil {
-				return fmt.Errorf("failed to copy item image: %w", err)
-			}
-			// Keep using the existing items/ relative path
-			item.PhotoPaths[i] = "items/" + filepath.Base(localPath)
+	if m.pinner != nil {
+		if err := m.queueRemotePins(shopName); err != nil {
+			fmt.Printf("Warning: failed to queue shop %s for remote pinning: %v\n", shopName, err)
 		}
 	}
-
-	return nil
 }
 
-// DeleteShop deletes a shop and its associated files
-func (m *Manager) DeleteShop(name string) error {
-	shopDir := m.GetShopPath(name)
+// queueRemotePins queues a published shop's site CID, the earlier versions its
+// retention policy keeps, and any assets stored as standalone CIDs rather than
+// inside the site, for remote pinning
+func (m *Manager) queueRemotePins(shopName string) error {
+	published, siteCID, _, err := m.ipfsMgr.CheckShopPublication(m.GetShopPath(shopName))
+	if err != nil {
+		return err
+	}
+	if !published || siteCID == "" {
+		return fmt.Errorf("shop %s has no published CID", shopName)
+	}
 
-	// Try to load shop data to get CID
-	shop, err := m.LoadShop(name)
-	if err == nil && shop.CID != "" {
-		// If shop was published, unpin from IPFS
-		if err := m.ipfsMgr.UnpublishContent(shop.CID); err != nil {
-			// Log the error but continue with deletion
-			fmt.Printf("Warning: failed to unpin content from IPFS: %v\n", err)
+	cids := []string{siteCID}
+	for _, version := range m.pinnedVersions(shopName) {
+		if version != siteCID {
+			cids = append(cids, version)
 		}
 	}
-
-	// Remove the shop directory and all its contents
-	if err := os.RemoveAll(shopDir); err != nil {
-		return fmt.Errorf("failed to delete shop directory: %w", err)
+	if shop, err := m.LoadShop(shopName); err == nil {
+		assets := []string{shop.LogoPath}
+		for _, item := range shop.Items {
+			assets = append(assets, item.PhotoPaths...)
+			for _, variant := range item.Variants {
+				assets = append(assets, variant.PhotoPath)
+			}
+		}
+		for _, asset := range assets {
+			if _, err := cid.Decode(asset); err == nil {
+				cids = append(cids, asset)
+			}
+		}
 	}
 
-	return nil
+	return m.pinner.QueueShop(shopName, cids...)
 }
 
-// generateCSS generates the CSS file from the template
-func (m *Manager) generateCSS(shop *models.Shop, targetPath string) error {
-	// Read the CSS template
-	cssTemplate, err := os.ReadFile(filepath.Join(filepath.Dir(m.baseDir), "templates", "basic", "basic.css"))
-	if err != nil {
-		return fmt.Errorf("failed to read CSS template: %w", err)
+// GenerateShop generates the HTML and assets for a shop
+func (m *Manager) GenerateShop(shop *models.Shop) error {
+	if shop == nil {
+		return fmt.Errorf("shop is nil")
 	}
 
-	// Create a template with the CSS content
-	tmpl, err := template.New("css").Parse(string(cssTemplate))
-	if err != nil {
-		return fmt.Errorf("failed to parse CSS template: %w", err)
+	if shop.Name == "" {
+		return fmt.Errorf("shop name is required")
 	}
 
-	// Create the target CSS file
-	cssFile, err := os.Create(targetPath)
-	if err != nil {
-		return fmt.Errorf("failed to create CSS file: %w", err)
+	// Get shop directory
+	shopDir := m.GetShopPath(shop.Name)
+	srcDir := filepath.Join(shopDir, "src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", srcDir, err)
 	}
-	defer cssFile.Close()
 
-	// Prepare template data with RGB colors
-	data := struct {
-		PrimaryColor   string
-		SecondaryColor string
-		TertiaryColor  string
-	}{
-		PrimaryColor:   fmt.Sprintf("rgb(%d, %d, %d)", shop.PrimaryColor.R, shop.PrimaryColor.G, shop.PrimaryColor.B),
-		SecondaryColor: fmt.Sprintf("rgb(%d, %d, %d)", shop.SecondaryColor.R, shop.SecondaryColor.G, shop.SecondaryColor.B),
-		TertiaryColor:  fmt.Sprintf("rgb(%d, %d, %d)", shop.TertiaryColor.R, shop.TertiaryColor.G, shop.TertiaryColor.B),
+	// Resize the logo into assets/logos and item photos into images. Both
+	// pipelines prune what the shop no longer uses, so removed photos aren't
+	// published again.
+	logos := NewImagePipeline(filepath.Join(srcDir, "assets", "logos"), "assets/logos")
+	var logo *ProcessedImage
+	if shop.LocalLogoPath != "" {
+		processed, err := logos.ProcessLogo(shop.LocalLogoPath)
+		if err != nil {
+			return fmt.Errorf("failed to process logo: %w", err)
+		}
+		// Update LogoPath to be relative to index.html
+		shop.LogoPath = processed.Src
+		logo = processed
+		if err := logos.Prune(); err != nil {
+			return err
+		}
+	} else if shop.LogoPath != "" {
+		logo = &ProcessedImage{Src: shop.LogoPath}
 	}
 
-	// Execute the template with the color data
-	if err := tmpl.Execute(cssFile, data); err != nil {
-		return fmt.Errorf("failed to generate CSS: %w", err)
-	}
+	images := NewImagePipeline(filepath.Join(srcDir, "images"), "images")
+	photos := make(map[string]*ProcessedImage)
+	for _, item := range shop.Items {
+		for i, photoPath := range item.PhotoPaths {
+			// Photos are processed from the original picked in the UI, or
+			// from the copy already in the site for shops generated before
+			source := filepath.Join(srcDir, filepath.FromSlash(photoPath))
+			if i < len(item.LocalPhotoPaths) && item.LocalPhotoPaths[i] != "" {
+				source = item.LocalPhotoPaths[i]
+			} else if _, err := os.Stat(source); err != nil {
+				// Such as a photo stored as a CID
+				continue
+			}
 
-	return nil
-}
+			processed, err := images.ProcessPhoto(source)
+			if err != nil {
+				return fmt.Errorf("failed to process item image: %w", err)
+			}
+			item.PhotoPaths[i] = processed.Src
+			photos[processed.Src] = processed
+		}
+		for i := range item.Variants {
+			variant := &item.Variants[i]
+			if variant.PhotoPath == "" {
+				continue
+			}
+			source := filepath.Join(srcDir, filepath.FromSlash(variant.PhotoPath))
+			if variant.LocalPhotoPath != "" {
+				source = variant.LocalPhotoPath
+			} else if _, err := os.Stat(source); err != nil {
+				continue
+			}
 
-func (m *Manager) generateHTML(shop *models.Shop, targetPath string) error {
-	// Create HTML template
-	html := fmt.Sprintf(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>%s</title>
-    <script src="https://cdn.jsdelivr.net/npm/web3@1.5.2/dist/web3.min.js"></script>
-    <script src="web3.js"></script>
-    <link rel="stylesheet" href="styles.css">
-</head>
-<body>
-    <div class="shop-header">
-        %s
-        <h1>%s</h1>
-        <p>%s</p>
-        <div class="shop-info">
-            %s
-            %s
-        </div>
-    </div>
-    <div class="items-grid">
-        %s
-    </div>
-</body>
-</html>`,
-		shop.Name,
-		m.generateLogoHTML(shop), shop.Name, shop.Description,
-		m.generateLocationHTML(shop), m.generateContactHTML(shop),
-		m.generateItemsHTML(shop))
-
-	// Write the HTML file
-	if err := os.WriteFile(targetPath, []byte(html), 0644); err != nil {
-		return fmt.Errorf("failed to write HTML file: %w", err)
+			processed, err := images.ProcessPhoto(source)
+			if err != nil {
+				return fmt.Errorf("failed to process variant image: %w", err)
+			}
+			variant.PhotoPath = processed.Src
+			photos[processed.Src] = processed
+		}
+	}
+	if err := images.Prune(); err != nil {
+		return err
 	}
 
-	return nil
-}
+	// Photos used to be copied to items/ unprocessed
+	if err := os.RemoveAll(filepath.Join(srcDir, "items")); err != nil {
+		return fmt.Errorf("failed to remove old item images: %w", err)
+	}
 
-func (m *Manager) generateLogoHTML(shop *models.Shop) string {
-	if shop.LogoPath == "" {
-		return ""
+	// Render the shop's template pack into src
+	pack, err := m.templates.Pack(shop.Template)
+	if err != nil {
+		return fmt.Errorf("failed to load template: %w", err)
+	}
+	// The IPNS name is known before the first publish, so even the first
+	// build gets canonical links, absolute share images and a sitemap
+	if shop.IPNSName == "" && m.ipfsMgr != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shopKeyTimeout)
+		name, err := m.ipfsMgr.EnsureShopKey(ctx, filepath.Base(shopDir))
+		cancel()
+		if err != nil {
+			fmt.Printf("Warning: failed to get IPNS key of shop %s, its site won't have permanent URLs: %v\n", shop.Name, err)
+		} else {
+			shop.IPNSName = name
+		}
 	}
-	return fmt.Sprintf(`<img src="assets/logos/%s" alt="%s Logo" class="shop-logo">`,
-		filepath.Base(shop.LogoPath),
-		shop.Name)
-}
 
-func (m *Manager) generateLocationHTML(shop *models.Shop) string {
-	if shop.Location == "" {
-		return ""
+	data := newSiteData(shop, pack, logo, photos)
+	if data.BaseURLs = m.siteURLs(shop); len(data.BaseURLs) > 0 {
+		data.BaseURL = data.BaseURLs[0]
+	}
+	if err := m.templates.Render(pack, data, srcDir); err != nil {
+		return fmt.Errorf("failed to generate site: %w", err)
 	}
-	return fmt.Sprintf(`<p><strong>Location:</strong> %s</p>`, shop.Location)
-}
 
-func (m *Manager) generateItemsHTML(shop *models.Shop) string {
-	var itemsHTML strings.Builder
-	for _, item := range shop.Items {
-		// Generate image HTML
-		var imageHTML string
-		if len(item.PhotoPaths) > 0 {
-			imageHTML = fmt.Sprintf(`<img src="items/%s" alt="%s">`,
-				filepath.Base(item.PhotoPaths[0]),
-				item.Name)
+	// Crawlers look for robots.txt and sitemap.xml at the root of the host,
+	// so they're published at the root of the CID rather than with the pages
+	if err := writeDiscoveryFiles(data, m.rootURLs(shop), filepath.Join(shopDir, ipfs.SiteRootDir)); err != nil {
+		return err
+	}
+	for _, name := range discoveryFiles {
+		// Sites generated before were published with them in src
+		if err := os.Remove(filepath.Join(srcDir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old %s: %w", name, err)
 		}
+	}
 
-		// Generate item card HTML
-		itemHTML := fmt.Sprintf(`
-		<div class="item-card">
-			%s
-			<div class="item-info">
-				<h3>%s</h3>
-				<p class="price">$%.2f</p>
-				<p class="description">%s</p>
-				<button class="eth-buy-button" data-item-id="%s" data-item-price="%.2f">
-					Buy with ETH
-				</button>
-			</div>
-		</div>`,
-			imageHTML,
-			item.Name,
-			item.Price,
-			item.Description,
-			item.ID,
-			item.Price)
-
-		itemsHTML.WriteString(itemHTML)
-	}
-	return itemsHTML.String()
+	return nil
 }
 
-func (m *Manager) generateContactHTML(shop *models.Shop) string {
-	var contact string
-	if shop.Email != "" {
-		contact += fmt.Sprintf(`<p><strong>Email:</strong> %s</p>`, shop.Email)
+// rootURLs returns the permanent URLs of the root of a shop's published CID:
+// through its ENS name if it has one, then through its IPNS name on a public
+// gateway. Empty before the shop has been published under either.
+func (m *Manager) rootURLs(shop *models.Shop) []string {
+	urls := []string{}
+	if shop.ENSName != "" {
+		urls = append(urls, "https://"+shop.ENSName+".limo/")
 	}
-	if shop.Phone != "" {
-		contact += fmt.Sprintf(`<p><strong>Phone:</strong> %s</p>`, shop.Phone)
+	if shop.IPNSName != "" {
+		urls = append(urls, ipfs.DefaultGateways[0]+"/ipns/"+shop.IPNSName+"/")
 	}
-	return contact
+	return urls
 }
 
-func (m *Manager) copyFile(src, dst string) error {
-	// Create destination directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+// siteURLs returns the permanent URLs of a shop's site, in the same order as rootURLs
+func (m *Manager) siteURLs(shop *models.Shop) []string {
+	// Sites are published inside their shop's directory, see ipfs.PointShopAt
+	path := url.PathEscape(filepath.Base(m.GetShopPath(shop.Name))) + "/src/"
+
+	urls := []string{}
+	for _, root := range m.rootURLs(shop) {
+		urls = append(urls, root+path)
 	}
+	return urls
+}
 
-	// Read source file
-	data, err := os.ReadFile(src)
-	if err != nil {
-		return fmt.Errorf("failed to read source file: %w", err)
+// TemplatePacks returns the template packs shops can choose from
+func (m *Manager) TemplatePacks() ([]*TemplatePack, error) {
+	return m.templates.Packs()
+}
+
+// DeleteShop deletes a shop and its associated files
+func (m *Manager) DeleteShop(name string) error {
+	shopDir := m.GetShopPath(name)
+
+	// Try to load shop data to get CID
+	shop, err := m.LoadShop(name)
+	if err == nil && shop.CID != "" {
+		// If shop was published, unpin from IPFS
+		if err := m.ipfsMgr.UnpublishContent(shop.CID); err != nil {
+			// Log the error but continue with deletion
+			fmt.Printf("Warning: failed to unpin content from IPFS: %v\n", err)
+		}
+	}
+
+	// Stop serving the shop from the database
+	if err == nil && shop.ID != "" && m.store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		if err := m.store.DeleteShop(ctx, shop.ID); err != nil && !errors.Is(err, orbitdb.ErrShopNotFound) {
+			fmt.Printf("Warning: failed to delete shop %s from OrbitDB: %v\n", name, err)
+		}
+		cancel()
 	}
 
-	// Write to destination file
-	if err := os.WriteFile(dst, data, 0644); err != nil {
-		return fmt.Errorf("failed to write destination file: %w", err)
+	// Remove the shop directory and all its contents
+	if err := os.RemoveAll(shopDir); err != nil {
+		return fmt.Errorf("failed to delete shop directory: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,117 @@
+package shop
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// allowedTags are the formatting tags merchants can use in descriptions. They're
+// only allowed without attributes, links are handled separately.
+var allowedTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true,
+	"p": true, "br": true, "ul": true, "ol": true, "li": true,
+}
+
+// voidTags are allowed tags that have no closing tag
+var voidTags = map[string]bool{"br": true}
+
+// allowedSchemes are the URL schemes a description's links can use
+var allowedSchemes = []string{"https:", "http:", "mailto:", "ipfs:", "ipns:"}
+
+// escapedTag matches an allowed tag, or a link with nothing but an href, in text
+// that has already been HTML escaped. The href can't contain quotes or angle
+// brackets since those were escaped to entities, and only &amp; is let through.
+var escapedTag = regexp.MustCompile(`(?i)&lt;(/?)([a-z]+)\s*/?&gt;|&lt;a\s+href=&#34;((?:[^\s&]|&amp;)*)&#34;\s*&gt;`)
+
+// sanitizeHTML makes merchant supplied text safe to embed in a page. Everything
+// is escaped except a safe subset of formatting: the tags in allowedTags and
+// links to allowedSchemes, which open with rel="nofollow noopener noreferrer".
+// Stray closing tags are dropped and unclosed tags are closed at the end, so a
+// description can't break the layout around it.
+func sanitizeHTML(text string) template.HTML {
+	escaped := html.EscapeString(text)
+
+	open := []string{}
+	out := escapedTag.ReplaceAllStringFunc(escaped, func(match string) string {
+		parts := escapedTag.FindStringSubmatch(match)
+		closing, tag, href := parts[1] == "/", strings.ToLower(parts[2]), parts[3]
+
+		if tag == "" {
+			if !safeLink(html.UnescapeString(href)) {
+				return match
+			}
+			open = append(open, "a")
+			return `<a href="` + href + `" rel="nofollow noopener noreferrer">`
+		}
+
+		if tag != "a" && !allowedTags[tag] {
+			return match
+		}
+
+		if !closing {
+			if tag == "a" {
+				// Links need an href we can check
+				return match
+			}
+			if !voidTags[tag] {
+				open = append(open, tag)
+			}
+			return "<" + tag + ">"
+		}
+
+		for i := len(open) - 1; i >= 0; i-- {
+			if open[i] != tag {
+				continue
+			}
+			// Close anything left open inside the tag as well
+			closed := ""
+			for j := len(open) - 1; j >= i; j-- {
+				closed += "</" + open[j] + ">"
+			}
+			open = open[:i]
+			return closed
+		}
+		return ""
+	})
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out += "</" + open[i] + ">"
+	}
+
+	return template.HTML(out)
+}
+
+// summaryLength is how many characters plainText keeps
+const summaryLength = 200
+
+// anyTag matches any tag, for removing formatting from text
+var anyTag = regexp.MustCompile(`<[^>]*>`)
+
+// plainText removes the formatting from text and shortens it to a single line
+// of at most summaryLength characters, for places like meta descriptions
+func plainText(text string) string {
+	text = strings.Join(strings.Fields(html.UnescapeString(anyTag.ReplaceAllString(text, " "))), " ")
+	if utf8.RuneCountInString(text) <= summaryLength {
+		return text
+	}
+
+	runes := []rune(text)[:summaryLength-1]
+	if i := strings.LastIndex(string(runes), " "); i > summaryLength/2 {
+		return string(runes)[:i] + "…"
+	}
+	return string(runes) + "…"
+}
+
+// safeLink returns true if href is an absolute URL with an allowed scheme
+func safeLink(href string) bool {
+	lower := strings.ToLower(href)
+	for _, scheme := range allowedSchemes {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package shop
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sanitizeTests are shared by sanitizeHTML and ShopAPI.formatText, which must
+// agree so descriptions look the same on generated pages and in the live catalog
+var sanitizeTests = []struct {
+	name string
+	text string
+	want string
+}{
+	// Plain text and entities
+	{name: "plain text", text: "Hand thrown mug", want: "Hand thrown mug"},
+	{name: "special characters", text: `Fish & chips < 5 > 3 "quoted" 'single'`, want: "Fish &amp; chips &lt; 5 &gt; 3 &#34;quoted&#34; &#39;single&#39;"},
+	{name: "entities stay literal", text: "&lt;script&gt; &amp; &#106;", want: "&amp;lt;script&amp;gt; &amp;amp; &amp;#106;"},
+
+	// Scripts and other tags
+	{name: "script", text: "<script>alert(1)</script>", want: "&lt;script&gt;alert(1)&lt;/script&gt;"},
+	{name: "mixed case script", text: "<ScRiPt>alert(1)</sCrIpT>", want: "&lt;ScRiPt&gt;alert(1)&lt;/sCrIpT&gt;"},
+	{name: "image with handler", text: "<img src=x onerror=alert(1)>", want: "&lt;img src=x onerror=alert(1)&gt;"},
+	{name: "style", text: "<style>body{display:none}</style>", want: "&lt;style&gt;body{display:none}&lt;/style&gt;"},
+	{name: "iframe", text: `<iframe src="https://evil.example"></iframe>`, want: "&lt;iframe src=&#34;https://evil.example&#34;&gt;&lt;/iframe&gt;"},
+
+	// Allowed formatting
+	{name: "formatting", text: "<b>bold</b>, <EM>em</EM> and <u>underlined</u>", want: "<b>bold</b>, <em>em</em> and <u>underlined</u>"},
+	{name: "lists", text: "<ul><li>one</li><li>two</li></ul>", want: "<ul><li>one</li><li>two</li></ul>"},
+	{name: "line breaks", text: "one<br>two<br/>three<BR />four", want: "one<br>two<br>three<br>four"},
+	{name: "closing a line break", text: "one</br>two", want: "onetwo"},
+
+	// Attribute injection
+	{name: "handler on allowed tag", text: `<b onclick="alert(1)">x</b>`, want: "&lt;b onclick=&#34;alert(1)&#34;&gt;x"},
+	{name: "style on allowed tag", text: `<p style="position:fixed">x</p>`, want: "&lt;p style=&#34;position:fixed&#34;&gt;x"},
+	{name: "handler after href", text: `<a href="https://shop.example" onclick="alert(1)">x</a>`, want: "&lt;a href=&#34;https://shop.example&#34; onclick=&#34;alert(1)&#34;&gt;x"},
+	{name: "quote breaking out of href", text: `<a href="https://shop.example/"onmouseover="alert(1)">x</a>`, want: "&lt;a href=&#34;https://shop.example/&#34;onmouseover=&#34;alert(1)&#34;&gt;x"},
+	{name: "unquoted href", text: "<a href=https://shop.example>x</a>", want: "&lt;a href=https://shop.example&gt;x"},
+	{name: "single quoted href", text: "<a href='https://shop.example'>x</a>", want: "&lt;a href=&#39;https://shop.example&#39;&gt;x"},
+	{name: "angle bracket in href", text: `<a href="https://shop.example/<script>">x</a>`, want: "&lt;a href=&#34;https://shop.example/&lt;script&gt;&#34;&gt;x"},
+
+	// Links
+	{name: "https link", text: `<a href="https://shop.example/?a=1&b=2">shop</a>`, want: `<a href="https://shop.example/?a=1&amp;b=2" rel="nofollow noopener noreferrer">shop</a>`},
+	{name: "mailto link", text: `<A HREF="mailto:me@shop.example">mail</A>`, want: `<a href="mailto:me@shop.example" rel="nofollow noopener noreferrer">mail</a>`},
+	{name: "ipfs link", text: `<a href="ipfs://bafybeigdyrzt">site</a>`, want: `<a href="ipfs://bafybeigdyrzt" rel="nofollow noopener noreferrer">site</a>`},
+	{name: "javascript href", text: `<a href="javascript:alert(1)">x</a>`, want: "&lt;a href=&#34;javascript:alert(1)&#34;&gt;x"},
+	{name: "upper case javascript href", text: `<a href="JavaScript:alert(1)">x</a>`, want: "&lt;a href=&#34;JavaScript:alert(1)&#34;&gt;x"},
+	{name: "entity encoded javascript href", text: `<a href="jav&#x61;script:alert(1)">x</a>`, want: "&lt;a href=&#34;jav&amp;#x61;script:alert(1)&#34;&gt;x"},
+	{name: "data href", text: `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, want: "&lt;a href=&#34;data:text/html;base64,PHNjcmlwdD4=&#34;&gt;x"},
+	{name: "relative href", text: `<a href="/admin">x</a>`, want: "&lt;a href=&#34;/admin&#34;&gt;x"},
+	{name: "link without href", text: "<a>x</a>", want: "&lt;a&gt;x"},
+
+	// Unclosed and stray tags
+	{name: "unclosed tags", text: "<b><i>text", want: "<b><i>text</i></b>"},
+	{name: "unclosed link", text: `<a href="https://shop.example">shop`, want: `<a href="https://shop.example" rel="nofollow noopener noreferrer">shop</a>`},
+	{name: "misnested tags", text: "<b><i>x</b> y</i>", want: "<b><i>x</i></b> y"},
+	{name: "stray closing tags", text: "</p></ul>text</div>", want: "text&lt;/div&gt;"},
+	{name: "unterminated tag", text: "<b", want: "&lt;b"},
+	{name: "unterminated link", text: `<a href="https://shop.example"`, want: "&lt;a href=&#34;https://shop.example&#34;"},
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	for _, tt := range sanitizeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(sanitizeHTML(tt.text)); got != tt.want {
+				t.Errorf("sanitizeHTML(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+
+	script, err := os.ReadFile(filepath.Join("..", "..", "..", "templates", sharedTemplateDir, "shop-api.js"))
+	if err != nil {
+		t.Fatalf("failed to read shop-api.js: %v", err)
+	}
+
+	texts := make([]string, len(sanitizeTests))
+	for i, tt := range sanitizeTests {
+		texts[i] = tt.text
+	}
+	input, err := json.Marshal(texts)
+	if err != nil {
+		t.Fatalf("failed to encode texts: %v", err)
+	}
+
+	// Defining the class doesn't touch the DOM, so the script runs as is
+	cmd := exec.Command(node, "-")
+	cmd.Stdin = strings.NewReader(string(script) + `
+const texts = JSON.parse(process.argv[2]);
+process.stdout.write(JSON.stringify(texts.map(text => ShopAPI.formatText(text))));
+`)
+	cmd.Args = append(cmd.Args, string(input))
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to run shop-api.js: %v", err)
+	}
+
+	var got []string
+	if err := json.Unmarshal(output, &got); err != nil || len(got) != len(sanitizeTests) {
+		t.Fatalf("unexpected output %q: %v", output, err)
+	}
+	for i, tt := range sanitizeTests {
+		if got[i] != tt.want {
+			t.Errorf("%s: formatText(%q) = %q, want %q", tt.name, tt.text, got[i], tt.want)
+		}
+	}
+}
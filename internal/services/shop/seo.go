@@ -0,0 +1,207 @@
+package shop
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// schemaContext is the vocabulary the structured data uses
+	schemaContext = "https://schema.org"
+
+	// priceCurrency is the currency item prices are shown in
+	priceCurrency = "USD"
+)
+
+// ldStore is a schema.org Store, the structured data of a shop's catalog pages
+type ldStore struct {
+	Context     string    `json:"@context,omitempty"`
+	Type        string    `json:"@type"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Logo        string    `json:"logo,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	Telephone   string    `json:"telephone,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	MakesOffer  []ldOffer `json:"makesOffer,omitempty"`
+}
+
+// ldProduct is a schema.org Product, the structured data of an item page
+type ldProduct struct {
+	Context     string    `json:"@context,omitempty"`
+	Type        string    `json:"@type"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	SKU         string    `json:"sku,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Image       []string  `json:"image,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Offers      []ldOffer `json:"offers,omitempty"`
+}
+
+// ldOffer is a schema.org Offer, an item or one of its variants for sale at a price
+type ldOffer struct {
+	Type          string     `json:"@type"`
+	Name          string     `json:"name,omitempty"` // Label of the variant offered
+	SKU           string     `json:"sku,omitempty"`
+	Price         string     `json:"price"`
+	PriceCurrency string     `json:"priceCurrency"`
+	Availability  string     `json:"availability"`
+	URL           string     `json:"url,omitempty"`
+	Seller        *ldStore   `json:"seller,omitempty"`
+	ItemOffered   *ldProduct `json:"itemOffered,omitempty"`
+}
+
+// StructuredData returns the schema.org JSON-LD of the page: the item as a
+// Product on item pages, the shop as a Store with its offers on the others.
+// Items with variants have an offer per variant.
+// html/template encodes it as JSON in <script type="application/ld+json">.
+func (d *siteData) StructuredData() interface{} {
+	if d.Product != nil {
+		product := d.product(d.Product)
+		product.Context = schemaContext
+		product.Offers = d.offers(d.Product)
+		seller := &ldStore{Type: "Store", Name: d.Name, URL: d.AbsURL("")}
+		for i := range product.Offers {
+			product.Offers[i].Seller = seller
+		}
+		return product
+	}
+
+	store := &ldStore{
+		Context:     schemaContext,
+		Type:        "Store",
+		Name:        d.Name,
+		Description: plainText(d.Description),
+		URL:         d.AbsURL(""),
+		Email:       d.Email,
+		Telephone:   d.Phone,
+		Address:     d.Location,
+		MakesOffer:  []ldOffer{},
+	}
+	if d.Logo != nil {
+		store.Logo = d.AbsURL(d.Logo.Src)
+	}
+	for i := range d.Items {
+		product := d.product(&d.Items[i])
+		for _, offer := range d.offers(&d.Items[i]) {
+			offer.ItemOffered = product
+			store.MakesOffer = append(store.MakesOffer, offer)
+		}
+	}
+	return store
+}
+
+// product describes an item as a schema.org Product without its offer
+func (d *siteData) product(item *siteItem) *ldProduct {
+	images := []string{}
+	for _, photo := range item.Photos {
+		images = append(images, d.AbsURL(photo.Src))
+	}
+
+	product := &ldProduct{
+		Type:        "Product",
+		Name:        item.Name,
+		Description: plainText(item.Description),
+		SKU:         item.ID,
+		Image:       images,
+		URL:         d.AbsURL(item.URL),
+	}
+	if item.Category != nil {
+		product.Category = item.Category.Name
+	}
+	return product
+}
+
+// offers describes an item's price and availability as schema.org Offers, one
+// for the item or one for each of its variants
+func (d *siteData) offers(item *siteItem) []ldOffer {
+	if len(item.Variants) == 0 {
+		return []ldOffer{d.offer(item, item.Price, item.SoldOut())}
+	}
+
+	offers := make([]ldOffer, 0, len(item.Variants))
+	for _, variant := range item.Variants {
+		offer := d.offer(item, variant.Price, variant.SoldOut())
+		offer.Name = variant.Label
+		offer.SKU = variant.SKU
+		offers = append(offers, offer)
+	}
+	return offers
+}
+
+// offer describes a price and availability of item as a schema.org Offer
+func (d *siteData) offer(item *siteItem, price float64, soldOut bool) ldOffer {
+	availability := "https://schema.org/InStock"
+	if soldOut {
+		availability = "https://schema.org/OutOfStock"
+	}
+
+	return ldOffer{
+		Type:          "Offer",
+		Price:         fmt.Sprintf("%.2f", price),
+		PriceCurrency: priceCurrency,
+		Availability:  availability,
+		URL:           d.AbsURL(item.URL),
+	}
+}
+
+// sitemapURLSet is the root element of a sitemap.xml
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemapURL is one page in a sitemap.xml. Pages have no lastmod since it would
+// change the site's CID on every publish, even when nothing else did.
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+// discoveryFiles are the files writeDiscoveryFiles writes
+var discoveryFiles = []string{"robots.txt", "sitemap.xml"}
+
+// writeDiscoveryFiles writes the sitemap.xml and robots.txt of a site to dir,
+// whose files are published at rootURLs. Sitemaps need absolute URLs, so a site
+// without any has only a robots.txt.
+func writeDiscoveryFiles(data *siteData, rootURLs []string, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	robots := "User-agent: *\nAllow: /\n"
+	for _, root := range rootURLs {
+		robots += "Sitemap: " + strings.TrimSuffix(root, "/") + "/sitemap.xml\n"
+	}
+
+	if len(data.BaseURLs) > 0 {
+		sitemap := sitemapURLSet{}
+		for _, base := range data.BaseURLs {
+			base = strings.TrimSuffix(base, "/") + "/"
+			sitemap.URLs = append(sitemap.URLs, sitemapURL{Loc: base})
+			for _, item := range data.Items {
+				sitemap.URLs = append(sitemap.URLs, sitemapURL{Loc: base + item.URL})
+			}
+		}
+
+		out, err := xml.MarshalIndent(sitemap, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal sitemap: %w", err)
+		}
+		out = append([]byte(xml.Header), append(out, '\n')...)
+		if err := os.WriteFile(filepath.Join(dir, "sitemap.xml"), out, 0644); err != nil {
+			return fmt.Errorf("failed to write sitemap: %w", err)
+		}
+	} else if err := os.Remove(filepath.Join(dir, "sitemap.xml")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old sitemap: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "robots.txt"), []byte(robots), 0644); err != nil {
+		return fmt.Errorf("failed to write robots.txt: %w", err)
+	}
+	return nil
+}
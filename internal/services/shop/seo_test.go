@@ -0,0 +1,277 @@
+package shop
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"IndieNode/internal/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the structured data fixtures in testdata with the current output")
+
+// seoTestBaseURL is where the test shop's site is published
+const seoTestBaseURL = "https://clay.eth.limo/Clay/src/"
+
+// seoTestSite returns the site data of a shop with a plain item, an item with
+// variants and a sold out item
+func seoTestSite(pack *TemplatePack) *siteData {
+	larger := 22.0
+	shop := &models.Shop{
+		Name:         "Clay & Co",
+		OwnerAddress: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		Description:  "<b>Hand thrown</b> pottery,\nmade in Leeds",
+		Email:        "hello@clay.example",
+		Phone:        "+44 113 496 0000",
+		Location:     "Leeds, UK",
+		Categories:   []models.Category{{ID: "mugs", Name: "Mugs"}},
+		Items: []models.Item{
+			{
+				ID:          "mug-1",
+				Name:        "Mug",
+				Price:       12.5,
+				Description: `A <i>big</i> mug, "dishwasher safe"`,
+				PhotoPaths:  []string{"images/0a1b2c3d-960.jpg", "images/4e5f6a7b-960.jpg"},
+				Inventory:   models.UnlimitedInventory,
+				Categories:  []string{"mugs"},
+			},
+			{
+				ID:      "shirt",
+				Name:    "T-Shirt",
+				Price:   20,
+				Options: []models.OptionGroup{{Name: "Size", Values: []string{"S", "M"}}},
+				Variants: []models.Variant{
+					{ID: "s", Options: map[string]string{"Size": "S"}, SKU: "TS-S", Inventory: 0},
+					{ID: "m", Options: map[string]string{"Size": "M"}, SKU: "TS-M", Price: &larger, Inventory: 3},
+				},
+			},
+			{ID: "vase", Name: "Vase", Price: 40, Inventory: 0},
+		},
+	}
+	logo := &ProcessedImage{Src: "assets/logos/8c9d0e1f-256.png", Width: 256, Height: 256}
+
+	data := newSiteData(shop, pack, logo, nil)
+	data.BaseURL = seoTestBaseURL
+	data.BaseURLs = []string{seoTestBaseURL, "https://ipfs.io/ipns/k51qzi5uqu5dlvj2baxnqndepeb86cbk3ng7n3i46uzyxzyqj2xjonzllnv0v8/Clay/src/"}
+	return data
+}
+
+// seoTestPack returns the default template pack
+func seoTestPack(t *testing.T) (*TemplateEngine, *TemplatePack) {
+	t.Helper()
+
+	engine := NewTemplateEngine(filepath.Join("..", "..", "..", "templates"))
+	pack, err := engine.Pack(DefaultTemplate)
+	if err != nil {
+		t.Fatalf("failed to load template pack: %v", err)
+	}
+	return engine, pack
+}
+
+// fixturePath returns the path of a structured data fixture
+func fixturePath(name string) string {
+	return filepath.Join("testdata", "structured_data", name)
+}
+
+// encodeFixture encodes structured data the way the fixtures are stored
+func encodeFixture(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("failed to encode structured data: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// readFixture decodes a fixture into generic JSON values
+func readFixture(t *testing.T, name string) interface{} {
+	t.Helper()
+
+	data, err := os.ReadFile(fixturePath(name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("fixture %s is invalid: %v", name, err)
+	}
+	return v
+}
+
+// structuredDataPages returns the fixture of each page of data with the page's structured data
+func structuredDataPages(data *siteData) map[string]*siteData {
+	pages := map[string]*siteData{"store.json": data}
+	for i := range data.Items {
+		pages["product-"+data.Items[i].Slug+".json"] = data.forItem(&data.Items[i])
+	}
+	return pages
+}
+
+func TestStructuredData(t *testing.T) {
+	_, pack := seoTestPack(t)
+	data := seoTestSite(pack)
+
+	for name, page := range structuredDataPages(data) {
+		t.Run(name, func(t *testing.T) {
+			got := encodeFixture(t, page.StructuredData())
+			if *update {
+				if err := os.WriteFile(fixturePath(name), got, 0644); err != nil {
+					t.Fatalf("failed to update fixture: %v", err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(fixturePath(name))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("structured data differs from %s, run go test -update to see how:\n%s", fixturePath(name), got)
+			}
+		})
+	}
+}
+
+// ldJSON matches the structured data of a rendered page
+var ldJSON = regexp.MustCompile(`(?s)<script type="application/ld\+json">(.*?)</script>`)
+
+func TestRenderedStructuredData(t *testing.T) {
+	engine, _ := seoTestPack(t)
+	packs, err := engine.Packs()
+	if err != nil {
+		t.Fatalf("failed to load template packs: %v", err)
+	}
+
+	for _, pack := range packs {
+		t.Run(pack.Name, func(t *testing.T) {
+			data := seoTestSite(pack)
+			outputDir := t.TempDir()
+			if err := engine.Render(pack, data, outputDir); err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+
+			files := map[string]string{"store.json": "index.html"}
+			if pack.ItemPage != "" {
+				for _, item := range data.Items {
+					files["product-"+item.Slug+".json"] = filepath.FromSlash(item.URL) + "index.html"
+				}
+			}
+			for fixture, page := range files {
+				html, err := os.ReadFile(filepath.Join(outputDir, page))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", page, err)
+				}
+				match := ldJSON.FindSubmatch(html)
+				if match == nil {
+					t.Errorf("%s has no structured data", page)
+					continue
+				}
+
+				// html/template escapes the JSON for the script element, it
+				// must still decode to the fixture
+				var got interface{}
+				if err := json.Unmarshal(match[1], &got); err != nil {
+					t.Errorf("structured data of %s is invalid: %v\n%s", page, err, match[1])
+					continue
+				}
+				if want := readFixture(t, fixture); !reflect.DeepEqual(got, want) {
+					t.Errorf("structured data of %s differs from %s:\n%s", page, fixturePath(fixture), match[1])
+				}
+				if bytes.Contains(match[1], []byte("</")) || bytes.Contains(match[1], []byte("<b>")) {
+					t.Errorf("structured data of %s can close its script element:\n%s", page, match[1])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteDiscoveryFiles(t *testing.T) {
+	const (
+		ensRoot  = "https://clay.eth.limo/"
+		ipnsRoot = "https://ipfs.io/ipns/k51qzi5uqu5dlvj2baxnqndepeb86cbk3ng7n3i46uzyxzyqj2xjonzllnv0v8/"
+	)
+
+	tests := []struct {
+		name        string
+		roots       []string
+		wantRobots  string
+		wantSitemap []string // Empty if there should be no sitemap
+	}{
+		{
+			name:       "ENS and IPNS names",
+			roots:      []string{ensRoot, ipnsRoot},
+			wantRobots: "User-agent: *\nAllow: /\nSitemap: " + ensRoot + "sitemap.xml\nSitemap: " + ipnsRoot + "sitemap.xml\n",
+			wantSitemap: []string{
+				ensRoot + "Clay/src/",
+				ensRoot + "Clay/src/items/mug/",
+				ensRoot + "Clay/src/items/vase/",
+				ipnsRoot + "Clay/src/",
+				ipnsRoot + "Clay/src/items/mug/",
+				ipnsRoot + "Clay/src/items/vase/",
+			},
+		},
+		{
+			name:       "not published yet",
+			wantRobots: "User-agent: *\nAllow: /\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &siteData{Items: []siteItem{{URL: "items/mug/"}, {URL: "items/vase/"}}}
+			for _, root := range tt.roots {
+				data.BaseURLs = append(data.BaseURLs, root+"Clay/src/")
+			}
+
+			dir := filepath.Join(t.TempDir(), ".root")
+			if len(tt.wantSitemap) == 0 {
+				// A sitemap from when the site had URLs is removed
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatalf("failed to create %s: %v", dir, err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "sitemap.xml"), []byte("<urlset/>"), 0644); err != nil {
+					t.Fatalf("failed to write old sitemap: %v", err)
+				}
+			}
+			if err := writeDiscoveryFiles(data, tt.roots, dir); err != nil {
+				t.Fatalf("writeDiscoveryFiles() error = %v", err)
+			}
+
+			robots, err := os.ReadFile(filepath.Join(dir, "robots.txt"))
+			if err != nil || string(robots) != tt.wantRobots {
+				t.Errorf("robots.txt = %q, %v; want %q", robots, err, tt.wantRobots)
+			}
+
+			sitemap, err := os.ReadFile(filepath.Join(dir, "sitemap.xml"))
+			if len(tt.wantSitemap) == 0 {
+				if !os.IsNotExist(err) {
+					t.Errorf("sitemap.xml exists: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to read sitemap.xml: %v", err)
+			}
+			var got []string
+			for _, match := range regexp.MustCompile(`<loc>(.*?)</loc>`).FindAllStringSubmatch(string(sitemap), -1) {
+				got = append(got, match[1])
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantSitemap, "\n") {
+				t.Errorf("sitemap.xml lists\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.wantSitemap, "\n"))
+			}
+			if !strings.Contains(string(sitemap), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`) {
+				t.Errorf("sitemap.xml has no sitemap namespace:\n%s", sitemap)
+			}
+		})
+	}
+}
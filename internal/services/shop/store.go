@@ -0,0 +1,161 @@
+package shop
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/models"
+)
+
+// Store keeps shops in the database the API, orders and mirrors read, see orbitdb.Manager
+type Store interface {
+	ShopExists(ctx context.Context, shopID string) (bool, error)
+	GetShop(ctx context.Context, shopID string) (*models.Shop, error)
+	StoreShop(shop *models.Shop) error
+	UpdateShop(ctx context.Context, shop *models.Shop, loaded orbitdb.StockCounts) error
+	DeleteShop(ctx context.Context, shopID string) error
+}
+
+// storeTimeout bounds how long saving a shop waits for the database
+const storeTimeout = 30 * time.Second
+
+// SetStore sets the database shops are saved and published to, nil keeps them local
+func (m *Manager) SetStore(store Store) {
+	m.store = store
+}
+
+// ensureShopID gives a shop that has none a stable ID: its URL name and a
+// random suffix, so an owner's shops don't share the owner's address as ID
+func ensureShopID(shop *models.Shop) error {
+	if shop.ID != "" {
+		return nil
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate shop ID: %w", err)
+	}
+	prefix := shop.URLName
+	if prefix == "" {
+		prefix = "shop"
+	}
+	shop.ID = prefix + "-" + hex.EncodeToString(suffix)
+	return nil
+}
+
+// loadStock replaces the stock counts of a shop read from shop.json with those
+// in the store, which orders decrement, and remembers them as the counts the
+// next save of the shop started from
+func (m *Manager) loadStock(shop *models.Shop) {
+	if m.store == nil || shop.ID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	stored, err := m.store.GetShop(ctx, shop.ID)
+	if errors.Is(err, orbitdb.ErrShopNotFound) {
+		return
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to load stock of shop %s: %v\n", shop.Name, err)
+		return
+	}
+
+	storedItems := make(map[string]*models.Item, len(stored.Items))
+	for i := range stored.Items {
+		storedItems[stored.Items[i].ID] = &stored.Items[i]
+	}
+	loaded := &models.Shop{}
+	for i := range shop.Items {
+		item := &shop.Items[i]
+		storedItem, ok := storedItems[item.ID]
+		if !ok {
+			continue
+		}
+		item.Inventory = storedItem.Inventory
+		for j := range item.Variants {
+			for _, variant := range storedItem.Variants {
+				if variant.ID == item.Variants[j].ID {
+					item.Variants[j].Inventory = variant.Inventory
+				}
+			}
+		}
+		loaded.Items = append(loaded.Items, *item)
+	}
+
+	m.stockMutex.Lock()
+	m.loadedStock[shop.ID] = orbitdb.SnapshotStock(loaded)
+	m.stockMutex.Unlock()
+}
+
+// storeShop saves a shop to the store, adding it if it's new. Stock counts
+// changed since the shop was loaded are applied as changes, see
+// orbitdb.Manager.UpdateShop; keepStock leaves the stored counts as they are.
+func (m *Manager) storeShop(shop *models.Shop, keepStock bool) error {
+	if m.store == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	exists, err := m.store.ShopExists(ctx, shop.ID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := m.store.StoreShop(shop); err != nil {
+			return err
+		}
+	} else {
+		var loaded orbitdb.StockCounts
+		if !keepStock {
+			m.stockMutex.Lock()
+			loaded = m.loadedStock[shop.ID]
+			m.stockMutex.Unlock()
+		}
+		if err := m.store.UpdateShop(ctx, shop, loaded); err != nil {
+			return err
+		}
+	}
+
+	// The next save starts from the counts just saved
+	if !keepStock {
+		m.stockMutex.Lock()
+		m.loadedStock[shop.ID] = orbitdb.SnapshotStock(shop)
+		m.stockMutex.Unlock()
+	}
+	return nil
+}
+
+// publishToStore points a shop's stored copy at the CID it was just published
+// or rolled back to, so the API and mirrors serve that version
+func (m *Manager) publishToStore(shopName string) {
+	if m.store == nil {
+		return
+	}
+
+	shop, err := m.LoadShop(shopName)
+	if err != nil {
+		fmt.Printf("Warning: failed to load shop %s: %v\n", shopName, err)
+		return
+	}
+
+	// Shops saved before they were stored get their ID and are added
+	if shop.ID == "" {
+		if err := m.SaveShop(shop); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		return
+	}
+	if err := m.storeShop(shop, true); err != nil {
+		fmt.Printf("Warning: failed to publish shop %s to OrbitDB: %v\n", shopName, err)
+	}
+}
@@ -0,0 +1,211 @@
+package shop
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/models"
+	"IndieNode/internal/services/auth"
+	"IndieNode/internal/services/ens"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ipfs/kubo/core"
+	"github.com/ipfs/kubo/core/coreapi"
+	mock "github.com/ipfs/kubo/core/mock"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+// newTestStore opens OrbitDB on an in-process IPFS node, writing on behalf of
+// a new wallet whose address is returned
+func newTestStore(t *testing.T, ctx context.Context) (*orbitdb.Manager, string) {
+	t.Helper()
+
+	mn := mocknet.New()
+	t.Cleanup(func() { mn.Close() })
+	node, err := core.NewNode(ctx, &core.BuildCfg{
+		Online:    true,
+		Host:      mock.MockHostOption(mn),
+		ExtraOpts: map[string]bool{"pubsub": true},
+	})
+	if err != nil {
+		t.Fatalf("failed to start IPFS node: %v", err)
+	}
+	t.Cleanup(func() { node.Close() })
+	api, err := coreapi.NewCoreAPI(node)
+	if err != nil {
+		t.Fatalf("failed to create IPFS API: %v", err)
+	}
+
+	store, err := orbitdb.NewManager(ctx, &orbitdb.Config{Directory: t.TempDir()}, api)
+	if err != nil {
+		t.Fatalf("failed to create OrbitDB manager: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	// The wallet delegates writes to the node the way signing in does
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	issued := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	message := (&auth.SIWEMessage{
+		Domain:         "localhost:3000",
+		Address:        address,
+		Statement:      auth.SIWEStatement,
+		URI:            "http://localhost:3000",
+		Version:        "1",
+		ChainID:        ens.LoadENSConfig().ChainID,
+		Nonce:          "5c504ed432cb5113",
+		IssuedAt:       issued,
+		ExpirationTime: issued.Add(auth.DefaultSessionTTL),
+		Resources:      []string{orbitdb.IdentityResource(store.IdentityID())},
+	}).String()
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatalf("failed to sign proof: %v", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	if err := store.SetWriterProof(&orbitdb.WriterProof{Message: message, Signature: hexutil.Encode(sig)}); err != nil {
+		t.Fatalf("SetWriterProof() error = %v", err)
+	}
+	return store, address
+}
+
+// stockOf returns the stock counts of a stored shop's items and variants, as
+// the API lists them
+func stockOf(t *testing.T, ctx context.Context, store *orbitdb.Manager, shopID string) map[string]int64 {
+	t.Helper()
+
+	items, err := store.ListItems(ctx, shopID, nil)
+	if err != nil {
+		t.Fatalf("ListItems() error = %v", err)
+	}
+	stock := make(map[string]int64)
+	for _, item := range items {
+		stock[item.ID] = item.Inventory
+		for _, variant := range item.Variants {
+			stock[item.ID+"/"+variant.ID] = variant.Inventory
+		}
+	}
+	return stock
+}
+
+// checkStock compares stock counts with want
+func checkStock(t *testing.T, got map[string]int64, want map[string]int64) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Errorf("stock = %v, want %v", got, want)
+		return
+	}
+	for key, count := range want {
+		if got[key] != count {
+			t.Errorf("stock = %v, want %v", got, want)
+			return
+		}
+	}
+}
+
+func TestSaveShopToStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts an IPFS node")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	store, owner := newTestStore(t, ctx)
+	m, err := NewManager(filepath.Join(t.TempDir(), "shops"), nil)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	m.SetStore(store)
+
+	shop := &models.Shop{
+		Name:         "Clay",
+		OwnerAddress: owner,
+		Items: []models.Item{
+			{ID: "mug", Name: "Mug", Price: 12, Inventory: 5},
+			{
+				ID:      "shirt",
+				Name:    "T-Shirt",
+				Price:   20,
+				Options: []models.OptionGroup{{Name: "Size", Values: []string{"S", "M"}}},
+				Variants: []models.Variant{
+					{ID: "s", Options: map[string]string{"Size": "S"}, Inventory: 2},
+					{ID: "m", Options: map[string]string{"Size": "M"}, Inventory: 4},
+				},
+			},
+		},
+	}
+	if err := m.SaveShop(shop); err != nil {
+		t.Fatalf("SaveShop() error = %v", err)
+	}
+	if !models.ValidID(shop.ID) || shop.ID == owner {
+		t.Fatalf("saved shop has ID %q, want one of its own", shop.ID)
+	}
+	checkStock(t, stockOf(t, ctx, store, shop.ID), map[string]int64{"mug": 5, "shirt": 0, "shirt/s": 2, "shirt/m": 4})
+
+	// Saving what the UI loaded keeps the sales made since, and applies its restock
+	loaded, err := m.LoadShop("Clay")
+	if err != nil {
+		t.Fatalf("LoadShop() error = %v", err)
+	}
+	if _, err := store.DecrementInventory(ctx, shop.ID, "mug", "", 2); err != nil {
+		t.Fatalf("DecrementInventory() error = %v", err)
+	}
+	loaded.Description = "Hand thrown pottery"
+	loaded.Items[1].Variants[1].Inventory = 10
+	if err := m.SaveShop(loaded); err != nil {
+		t.Fatalf("SaveShop() error = %v", err)
+	}
+	checkStock(t, stockOf(t, ctx, store, shop.ID), map[string]int64{"mug": 3, "shirt": 0, "shirt/s": 2, "shirt/m": 10})
+
+	// Loading the shop again shows the sale
+	reloaded, err := m.LoadShop("Clay")
+	if err != nil {
+		t.Fatalf("LoadShop() error = %v", err)
+	}
+	if reloaded.Items[0].Inventory != 3 {
+		t.Errorf("loaded stock of mug = %d, want 3", reloaded.Items[0].Inventory)
+	}
+
+	// Publishing points the stored shop at the new CID without touching stock
+	const siteCID = "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"
+	reloaded.CID = siteCID
+	reloaded.Items[0].Inventory = 99
+	data, err := json.Marshal(reloaded)
+	if err != nil {
+		t.Fatalf("failed to marshal shop: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.GetShopPath("Clay"), "shop.json"), data, 0644); err != nil {
+		t.Fatalf("failed to write shop.json: %v", err)
+	}
+	m.afterPublish("Clay", siteCID)
+
+	stored, err := store.GetShop(ctx, shop.ID)
+	if err != nil {
+		t.Fatalf("GetShop() error = %v", err)
+	}
+	if stored.CID != siteCID || stored.Description != "Hand thrown pottery" {
+		t.Errorf("stored shop has CID %q and description %q, want %q and the saved description", stored.CID, stored.Description, siteCID)
+	}
+	checkStock(t, stockOf(t, ctx, store, shop.ID), map[string]int64{"mug": 3, "shirt": 0, "shirt/s": 2, "shirt/m": 10})
+
+	owned, err := store.ListShopsByOwner(ctx, strings.ToLower(owner))
+	if err != nil {
+		t.Fatalf("ListShopsByOwner() error = %v", err)
+	}
+	if len(owned) != 1 || owned[0].ID != shop.ID {
+		t.Errorf("ListShopsByOwner() = %d shops, want the saved shop", len(owned))
+	}
+}
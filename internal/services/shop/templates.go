@@ -0,0 +1,597 @@
+package shop
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"IndieNode/internal/models"
+	"IndieNode/internal/services/ens"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// DefaultTemplate is the template pack used by shops that haven't chosen one
+	DefaultTemplate = "basic"
+
+	// manifestFileName describes a template pack in templates/<name>/
+	manifestFileName = "template.json"
+
+	// sharedTemplateDir holds assets any pack can use, such as the checkout scripts
+	sharedTemplateDir = "shared"
+
+	// itemPagesDir is where item pages go in a site, items/<slug>/index.html
+	itemPagesDir = "items"
+)
+
+// OptionType is the kind of value a template option takes
+type OptionType string
+
+const (
+	OptionText   OptionType = "text"
+	OptionBool   OptionType = "bool"
+	OptionChoice OptionType = "choice"
+)
+
+// TemplateOption is a setting a template pack lets merchants configure
+type TemplateOption struct {
+	Name    string
+	Label   string
+	Type    OptionType
+	Default string
+	Choices []string `json:",omitempty"` // Allowed values of a choice option
+}
+
+// TemplatePage is a file rendered from a template in the pack
+type TemplatePage struct {
+	Source string // Template file in the pack
+	Target string // Output path relative to the site's root
+}
+
+// TemplatePack is a site template discovered in templates/<name>/, described by its template.json
+type TemplatePack struct {
+	Name        string `json:"-"` // Directory name, what models.Shop.Template refers to
+	Title       string
+	Description string
+	Pages       []TemplatePage
+	ItemPage    string   `json:",omitempty"` // Template rendered once per item into items/<slug>/index.html
+	Assets      []string // Files copied as is, from the pack or else the shared directory
+	Options     []TemplateOption
+
+	dir string
+}
+
+// Values returns the pack's option values for a shop, using defaults for options
+// it hasn't set and for choices that aren't allowed
+func (p *TemplatePack) Values(set map[string]string) map[string]string {
+	values := make(map[string]string, len(p.Options))
+	for _, option := range p.Options {
+		value, ok := set[option.Name]
+		if !ok || !option.allows(value) {
+			value = option.Default
+		}
+		values[option.Name] = value
+	}
+	return values
+}
+
+// allows returns true if value is valid for the option
+func (o TemplateOption) allows(value string) bool {
+	switch o.Type {
+	case OptionBool:
+		return value == "true" || value == "false"
+	case OptionChoice:
+		for _, choice := range o.Choices {
+			if choice == value {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// TemplateEngine discovers template packs and renders shop sites with them
+type TemplateEngine struct {
+	dir string
+}
+
+// NewTemplateEngine creates an engine for the packs in dir
+func NewTemplateEngine(dir string) *TemplateEngine {
+	return &TemplateEngine{dir: dir}
+}
+
+// Packs returns every valid template pack, the default first
+func (e *TemplateEngine) Packs() ([]*TemplatePack, error) {
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	packs := []*TemplatePack{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		// Directories without a manifest, such as the shared assets, aren't packs
+		if _, err := os.Stat(filepath.Join(e.dir, entry.Name(), manifestFileName)); err != nil {
+			continue
+		}
+
+		pack, err := e.Pack(entry.Name())
+		if err != nil {
+			fmt.Printf("Warning: skipping template %s: %v\n", entry.Name(), err)
+			continue
+		}
+		packs = append(packs, pack)
+	}
+
+	sort.SliceStable(packs, func(i, j int) bool {
+		if packs[i].Name == DefaultTemplate || packs[j].Name == DefaultTemplate {
+			return packs[i].Name == DefaultTemplate
+		}
+		return packs[i].Title < packs[j].Title
+	})
+	return packs, nil
+}
+
+// Pack loads and validates the template pack called name, or the default pack if name is empty
+func (e *TemplateEngine) Pack(name string) (*TemplatePack, error) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+	if name != filepath.Base(name) || name == sharedTemplateDir {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+
+	dir := filepath.Join(e.dir, name)
+	data, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest of template %s: %w", name, err)
+	}
+
+	var pack TemplatePack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of template %s: %w", name, err)
+	}
+	pack.Name = name
+	pack.dir = dir
+	if pack.Title == "" {
+		pack.Title = name
+	}
+
+	if len(pack.Pages) == 0 {
+		return nil, fmt.Errorf("template %s has no pages", name)
+	}
+	for _, page := range pack.Pages {
+		if _, err := os.Stat(filepath.Join(dir, page.Source)); err != nil {
+			return nil, fmt.Errorf("template %s is missing page %s", name, page.Source)
+		}
+	}
+	if pack.ItemPage != "" {
+		if _, err := os.Stat(filepath.Join(dir, pack.ItemPage)); err != nil {
+			return nil, fmt.Errorf("template %s is missing item page %s", name, pack.ItemPage)
+		}
+	}
+	for _, asset := range pack.Assets {
+		if _, err := e.assetPath(&pack, asset); err != nil {
+			return nil, err
+		}
+	}
+
+	return &pack, nil
+}
+
+// Render copies a pack's assets into outputDir and renders its pages there
+func (e *TemplateEngine) Render(pack *TemplatePack, data *siteData, outputDir string) error {
+	for _, asset := range pack.Assets {
+		src, err := e.assetPath(pack, asset)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(src, filepath.Join(outputDir, asset)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", asset, err)
+		}
+	}
+
+	for _, page := range pack.Pages {
+		if err := renderPage(pack, page, data, filepath.Join(outputDir, page.Target)); err != nil {
+			return fmt.Errorf("failed to render %s: %w", page.Target, err)
+		}
+	}
+
+	// Start from an empty items directory so pages of removed items aren't published
+	if err := os.RemoveAll(filepath.Join(outputDir, itemPagesDir)); err != nil {
+		return fmt.Errorf("failed to remove old item pages: %w", err)
+	}
+	if pack.ItemPage == "" {
+		return nil
+	}
+	for i := range data.Items {
+		page := TemplatePage{Source: pack.ItemPage, Target: data.Items[i].URL + "index.html"}
+		if err := renderPage(pack, page, data.forItem(&data.Items[i]), filepath.Join(outputDir, filepath.FromSlash(page.Target))); err != nil {
+			return fmt.Errorf("failed to render page of %s: %w", data.Items[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// assetPath finds an asset in the pack, falling back to the shared directory
+func (e *TemplateEngine) assetPath(pack *TemplatePack, asset string) (string, error) {
+	for _, dir := range []string{pack.dir, filepath.Join(e.dir, sharedTemplateDir)} {
+		path := filepath.Join(dir, asset)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("template %s is missing asset %s", pack.Name, asset)
+}
+
+// templateFuncs are the helpers available to every pack's pages
+var templateFuncs = map[string]interface{}{
+	"price": func(price float64) string {
+		return fmt.Sprintf("%.2f", price)
+	},
+	"enabled": func(value string) bool {
+		return value == "true"
+	},
+	"join": strings.Join,
+}
+
+// htmlFuncs are the helpers only HTML pages get
+var htmlFuncs = map[string]interface{}{
+	// formatted renders text such as a description with its safe formatting, see sanitizeHTML
+	"formatted": sanitizeHTML,
+	// summary shortens text to a plain line for meta tags, see plainText
+	"summary": plainText,
+}
+
+// pageTemplate is a parsed page, from either html/template or text/template
+type pageTemplate interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// renderPage renders one of a pack's pages to dst. HTML pages use html/template
+// so merchant supplied text is escaped for the context it ends up in. Other
+// pages, such as stylesheets, are plain text and only get styleData, which
+// holds nothing a merchant can type in.
+func renderPage(pack *TemplatePack, page TemplatePage, data *siteData, dst string) error {
+	src := filepath.Join(pack.dir, page.Source)
+
+	var tmpl pageTemplate
+	var pageData interface{}
+	if isHTMLPage(page.Target) {
+		parsed, err := htmltemplate.New(filepath.Base(src)).
+			Funcs(templateFuncs).
+			Funcs(htmlFuncs).
+			ParseFiles(src)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		tmpl, pageData = parsed, data
+	} else {
+		parsed, err := texttemplate.New(filepath.Base(src)).Funcs(templateFuncs).ParseFiles(src)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		tmpl, pageData = parsed, data.styleData(pack)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close()
+
+	return tmpl.Execute(out, pageData)
+}
+
+// isHTMLPage returns true if a page rendered to target is served as HTML
+func isHTMLPage(target string) bool {
+	switch strings.ToLower(filepath.Ext(target)) {
+	case ".html", ".htm":
+		return true
+	default:
+		return false
+	}
+}
+
+// copyFile copies src to dst, creating dst's directory
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read source file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return os.WriteFile(dst, data, 0644)
+}
+
+// siteData is what a pack's pages are rendered with
+type siteData struct {
+	*models.Shop
+	Items   []siteItem      // Shadows Shop.Items with the items' processed photos, in category order
+	Logo    *ProcessedImage // Processed logo, nil if there is none
+	LogoURL string          // Logo relative to the site's root, empty if there is none
+
+	// Categories and tags the site's filters offer, those without items left out
+	Categories []models.Category // Shadows Shop.Categories
+	Tags       []string          // Shadows Shop.Tags
+
+	// first is the index in Items of the shop's first item, what Item falls back to
+	first int
+
+	// Colors in CSS hex format
+	PrimaryColor   string
+	SecondaryColor string
+	TertiaryColor  string
+
+	// Payment details read by web3.js at checkout
+	PayoutAddress string
+	ChainID       int64
+	NetworkName   string
+
+	// Option values of the pack, see TemplatePack.Values
+	Options map[string]string
+
+	// Product is the item an item page is for, nil on the site's other pages
+	Product *siteItem
+
+	// Root is the site's root relative to the page, for <base href> on pages
+	// below it. Relative URLs keep working under a gateway's /ipfs/<cid>/ path.
+	Root string
+
+	// BaseURL is the absolute URL the site is permanently published at, empty
+	// if it has no ENS or IPNS name yet. Used where relative URLs won't do,
+	// such as Open Graph tags.
+	BaseURL string
+
+	// BaseURLs are all of the site's permanent URLs, BaseURL first, listed in its sitemap
+	BaseURLs []string
+}
+
+// styleData is what a pack's non-HTML pages are rendered with. Text options are
+// left out since they could close a CSS rule and add their own.
+type styleData struct {
+	PrimaryColor   string
+	SecondaryColor string
+	TertiaryColor  string
+	Options        map[string]string
+}
+
+// styleData returns the part of d that is safe to render without escaping
+func (d *siteData) styleData(pack *TemplatePack) *styleData {
+	options := make(map[string]string)
+	for _, option := range pack.Options {
+		if option.Type == OptionBool || option.Type == OptionChoice {
+			options[option.Name] = d.Options[option.Name]
+		}
+	}
+
+	return &styleData{
+		PrimaryColor:   d.PrimaryColor,
+		SecondaryColor: d.SecondaryColor,
+		TertiaryColor:  d.TertiaryColor,
+		Options:        options,
+	}
+}
+
+// siteItem is an item with its processed photos
+type siteItem struct {
+	models.Item
+	Photos   []ProcessedImage
+	Images   []string         // URL of each photo's largest variant, for pages that don't use srcset
+	Slug     string           // Item.Slug, numbered if another item has the same one
+	URL      string           // The item's page relative to the site's root, items/<slug>/
+	Variants []siteVariant    // Shadows Item.Variants with their prices resolved
+	Category *models.Category // First of the item's categories, nil if it has none
+}
+
+// siteVariant is a variant of an item with its price resolved and its photo processed
+type siteVariant struct {
+	models.Variant
+	Label    string          // Item.VariantLabel
+	Price    float64         // Shadows Variant.Price with what the variant sells at
+	Photo    *ProcessedImage // nil if the variant has no photo of its own
+	Selected bool            // Picked when the page loads, the first variant in stock
+}
+
+// Selected returns the variant picked when the page loads, or nil if the item has no variants
+func (i *siteItem) Selected() *siteVariant {
+	for j := range i.Variants {
+		if i.Variants[j].Selected {
+			return &i.Variants[j]
+		}
+	}
+	return nil
+}
+
+// UnitPrice returns the price shown for the item, that of the selected variant if it has any
+func (i *siteItem) UnitPrice() float64 {
+	if variant := i.Selected(); variant != nil {
+		return variant.Price
+	}
+	return i.Price
+}
+
+// newSiteData prepares a shop for rendering with pack. photos maps an item's
+// photo paths to the processed images, photos that weren't processed are used
+// as they are.
+func newSiteData(shop *models.Shop, pack *TemplatePack, logo *ProcessedImage, photos map[string]*ProcessedImage) *siteData {
+	// Payments go to the shop owner on the configured network
+	network := ens.LoadENSConfig()
+	payout := payoutAddress(shop)
+	if payout == "" {
+		fmt.Printf("Warning: shop %s has no valid owner address, checkout will be disabled\n", shop.Name)
+	}
+
+	// Slugs are numbered in the shop's order so sorting by category doesn't change item URLs
+	slugs := make([]string, len(shop.Items))
+	taken := make(map[string]bool)
+	for i, item := range shop.Items {
+		slug := item.Slug()
+		for n := 2; taken[slug]; n++ {
+			slug = fmt.Sprintf("%s-%d", item.Slug(), n)
+		}
+		taken[slug] = true
+		slugs[i] = slug
+	}
+
+	items := make([]siteItem, 0, len(shop.Items))
+	used := make(map[string]bool)
+	first := 0
+	for _, index := range shop.CategoryOrder() {
+		item := shop.Items[index]
+		slug := slugs[index]
+		if index == 0 {
+			first = len(items)
+		}
+
+		site := siteItem{
+			Item:   item,
+			Photos: []ProcessedImage{},
+			Images: []string{},
+			Slug:   slug,
+			URL:    itemPagesDir + "/" + slug + "/",
+		}
+		if len(item.Categories) > 0 {
+			site.Category = shop.Category(item.Categories[0])
+		}
+		for _, id := range item.Categories {
+			used[id] = true
+		}
+		for _, photo := range item.PhotoPaths {
+			if photo == "" {
+				continue
+			}
+			processed := ProcessedImage{Src: photo}
+			if p, ok := photos[photo]; ok {
+				processed = *p
+			}
+			site.Photos = append(site.Photos, processed)
+			site.Images = append(site.Images, processed.Src)
+		}
+		for _, variant := range item.Variants {
+			resolved := siteVariant{
+				Variant: variant,
+				Label:   item.VariantLabel(variant),
+				Price:   item.VariantPrice(variant),
+			}
+			if variant.PhotoPath != "" {
+				resolved.Photo = &ProcessedImage{Src: variant.PhotoPath}
+				if p, ok := photos[variant.PhotoPath]; ok {
+					resolved.Photo = p
+				}
+			}
+			site.Variants = append(site.Variants, resolved)
+		}
+		selectVariant(site.Variants)
+		items = append(items, site)
+	}
+
+	categories := []models.Category{}
+	for _, category := range shop.Categories {
+		if used[category.ID] {
+			categories = append(categories, category)
+		}
+	}
+
+	logoURL := ""
+	if logo != nil {
+		logoURL = logo.Src
+	}
+
+	return &siteData{
+		Shop:           shop,
+		Items:          items,
+		Categories:     categories,
+		Tags:           shop.Tags(),
+		first:          first,
+		Logo:           logo,
+		LogoURL:        logoURL,
+		PrimaryColor:   rgbaToHex(shop.PrimaryColor),
+		SecondaryColor: rgbaToHex(shop.SecondaryColor),
+		TertiaryColor:  rgbaToHex(shop.TertiaryColor),
+		PayoutAddress:  payout,
+		ChainID:        network.ChainID,
+		NetworkName:    network.NetworkName,
+		Options:        pack.Values(shop.TemplateOptions),
+	}
+}
+
+// rgbaToHex converts a color.RGBA to CSS hex format
+func rgbaToHex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// payoutAddress returns the checksummed address buyers pay, or "" if the shop
+// owner address isn't a valid Ethereum address
+func payoutAddress(shop *models.Shop) string {
+	if !common.IsHexAddress(shop.OwnerAddress) {
+		return ""
+	}
+	return common.HexToAddress(shop.OwnerAddress).Hex()
+}
+
+// selectVariant marks the variant a page shows first: the first one in stock,
+// or the first one if all are sold out
+func selectVariant(variants []siteVariant) {
+	if len(variants) == 0 {
+		return
+	}
+	for i := range variants {
+		if !variants[i].SoldOut() {
+			variants[i].Selected = true
+			return
+		}
+	}
+	variants[0].Selected = true
+}
+
+// Item returns the item with the given name or ID, or the shop's first item if
+// there is no such item. Used by packs that feature a single product.
+func (d *siteData) Item(nameOrID string) *siteItem {
+	if len(d.Items) == 0 {
+		return nil
+	}
+	for i := range d.Items {
+		if d.Items[i].Name == nameOrID || d.Items[i].ID == nameOrID {
+			return &d.Items[i]
+		}
+	}
+	return &d.Items[d.first]
+}
+
+// forItem returns a copy of d for rendering the page of item
+func (d *siteData) forItem(item *siteItem) *siteData {
+	page := *d
+	page.Product = item
+	page.Root = "../../"
+	return &page
+}
+
+// AbsURL returns the absolute URL of a path relative to the site's root, or the
+// path as is if the site's URL isn't known
+func (d *siteData) AbsURL(path string) string {
+	if d.BaseURL == "" || strings.Contains(path, "://") {
+		return path
+	}
+	return strings.TrimSuffix(d.BaseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
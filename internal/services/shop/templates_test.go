@@ -0,0 +1,122 @@
+package shop
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"IndieNode/internal/models"
+)
+
+func TestPayoutAddress(t *testing.T) {
+	tests := []struct {
+		name  string
+		owner string
+		want  string // Empty if checkout should be disabled
+	}{
+		{name: "checksummed", owner: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", want: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{name: "lowercase", owner: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", want: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{name: "uppercase", owner: "0xFB6916095CA1DF60BB79CE92CE3EA74C37C5D359", want: "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"},
+		{name: "without 0x prefix", owner: "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", want: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{name: "empty", owner: ""},
+		{name: "too short", owner: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea"},
+		{name: "too long", owner: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed00"},
+		{name: "not hex", owner: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz"},
+		{name: "ENS name", owner: "shop.eth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := payoutAddress(&models.Shop{OwnerAddress: tt.owner}); got != tt.want {
+				t.Errorf("payoutAddress(%q) = %q, want %q", tt.owner, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckoutMetaTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		testnet     bool
+		owner       string
+		wantPayout  string
+		wantChainID string
+		wantNetwork string
+	}{
+		{
+			name:        "mainnet",
+			owner:       "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			wantPayout:  "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			wantChainID: "1",
+			wantNetwork: "mainnet",
+		},
+		{
+			name:        "testnet",
+			testnet:     true,
+			owner:       "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			wantPayout:  "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			wantChainID: "11155111",
+			wantNetwork: "sepolia",
+		},
+		{
+			name:        "invalid owner disables checkout",
+			owner:       "not-an-address",
+			wantPayout:  "",
+			wantChainID: "1",
+			wantNetwork: "mainnet",
+		},
+	}
+
+	engine := NewTemplateEngine(filepath.Join("..", "..", "..", "templates"))
+	packs, err := engine.Packs()
+	if err != nil {
+		t.Fatalf("failed to load template packs: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.testnet {
+				t.Setenv("TESTNET_MODE", "true")
+			} else {
+				t.Setenv("TESTNET_MODE", "")
+			}
+
+			shop := &models.Shop{
+				Name:         "Test Shop",
+				OwnerAddress: tt.owner,
+				Items: []models.Item{
+					{ID: "item-1", Name: "Mug", Price: 12.5},
+				},
+			}
+
+			for _, pack := range packs {
+				outputDir := t.TempDir()
+				if err := engine.Render(pack, newSiteData(shop, pack, nil, nil), outputDir); err != nil {
+					t.Fatalf("failed to render %s: %v", pack.Name, err)
+				}
+
+				pages := []string{"index.html"}
+				if pack.ItemPage != "" {
+					pages = append(pages, filepath.Join(itemPagesDir, "mug", "index.html"))
+				}
+				for _, page := range pages {
+					html, err := os.ReadFile(filepath.Join(outputDir, page))
+					if err != nil {
+						t.Fatalf("failed to read %s of %s: %v", page, pack.Name, err)
+					}
+					want := []string{
+						`<meta name="payout-address" content="` + tt.wantPayout + `">`,
+						`<meta name="chain-id" content="` + tt.wantChainID + `">`,
+						`<meta name="network-name" content="` + tt.wantNetwork + `">`,
+					}
+					for _, tag := range want {
+						if !strings.Contains(string(html), tag) {
+							t.Errorf("%s of %s is missing %s", page, pack.Name, tag)
+						}
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package windows
+
+import (
+	"IndieNode/internal/models"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// createCategoriesSection creates the list of the shop's categories, in the
+// order the site shows them, with buttons to add, rename, move and remove them
+func (t *ShopCreatorTab) createCategoriesSection() fyne.CanvasObject {
+	t.categoriesList = widget.NewList(
+		func() int {
+			if t.existingShop == nil {
+				return 0
+			}
+			return len(t.existingShop.Categories)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			upBtn := widget.NewButton("Up", nil)
+			downBtn := widget.NewButton("Down", nil)
+			renameBtn := widget.NewButton("Rename", nil)
+			deleteBtn := widget.NewButton("Delete", nil)
+			buttonBox := container.NewHBox(upBtn, downBtn, renameBtn, deleteBtn)
+			return container.NewBorder(nil, nil, nil, buttonBox, label)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			if t.existingShop == nil || id >= len(t.existingShop.Categories) {
+				return
+			}
+			container := item.(*fyne.Container)
+			label := container.Objects[0].(*widget.Label)
+			buttonBox := container.Objects[1].(*fyne.Container)
+			upBtn := buttonBox.Objects[0].(*widget.Button)
+			downBtn := buttonBox.Objects[1].(*widget.Button)
+			renameBtn := buttonBox.Objects[2].(*widget.Button)
+			deleteBtn := buttonBox.Objects[3].(*widget.Button)
+
+			category := t.existingShop.Categories[id]
+			label.SetText(fmt.Sprintf("%s (%d items)", category.Name, t.categoryItemCount(category.ID)))
+
+			upBtn.OnTapped = func() {
+				t.moveCategory(id, -1)
+			}
+			downBtn.OnTapped = func() {
+				t.moveCategory(id, 1)
+			}
+			renameBtn.OnTapped = func() {
+				t.handleRenameCategory(id)
+			}
+			deleteBtn.OnTapped = func() {
+				t.handleDeleteCategory(id)
+			}
+		},
+	)
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Category Name, e.g. Shirts")
+
+	addBtn := widget.NewButton("Add Category", func() {
+		if t.existingShop == nil {
+			t.existingShop = &models.Shop{}
+		}
+		if _, err := t.existingShop.AddCategory(nameEntry.Text); err != nil {
+			dialog.ShowError(err, t.parent)
+			return
+		}
+		nameEntry.SetText("")
+		t.categoriesList.Refresh()
+	})
+
+	// Give the list room for a few categories, it has no minimum height of its own
+	listContainer := container.NewGridWrap(fyne.NewSize(500, 150), t.categoriesList)
+
+	return container.NewVBox(
+		widget.NewLabel("Items are listed by category, in this order. Assign them when editing an item."),
+		listContainer,
+		container.NewBorder(nil, nil, nil, addBtn, nameEntry),
+	)
+}
+
+// categoryItemCount returns how many of the shop's items are in a category
+func (t *ShopCreatorTab) categoryItemCount(id string) int {
+	count := 0
+	for _, item := range t.existingShop.Items {
+		if item.InCategory(id) {
+			count++
+		}
+	}
+	return count
+}
+
+// moveCategory moves a category up or down the list by delta places
+func (t *ShopCreatorTab) moveCategory(id widget.ListItemID, delta int) {
+	categories := t.existingShop.Categories
+	target := id + delta
+	if target < 0 || target >= len(categories) {
+		return
+	}
+	categories[id], categories[target] = categories[target], categories[id]
+	t.categoriesList.Refresh()
+}
+
+// handleRenameCategory asks for a new name for a category. Its ID stays the
+// same, so items and links to its filter on the site keep working.
+func (t *ShopCreatorTab) handleRenameCategory(id widget.ListItemID) {
+	if t.existingShop == nil || id >= len(t.existingShop.Categories) {
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(t.existingShop.Categories[id].Name)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+	}
+
+	dialog.ShowForm("Rename Category", "Save", "Cancel", items, func(save bool) {
+		if !save {
+			return
+		}
+
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" {
+			dialog.ShowError(models.ErrEmptyCategoryName, t.parent)
+			return
+		}
+		for i, category := range t.existingShop.Categories {
+			if i != id && strings.EqualFold(category.Name, name) {
+				dialog.ShowError(models.ErrDuplicateCategory, t.parent)
+				return
+			}
+		}
+
+		t.existingShop.Categories[id].Name = name
+		t.categoriesList.Refresh()
+	}, t.parent)
+}
+
+// handleDeleteCategory removes a category after confirming, its items stay in the shop
+func (t *ShopCreatorTab) handleDeleteCategory(id widget.ListItemID) {
+	if t.existingShop == nil || id >= len(t.existingShop.Categories) {
+		return
+	}
+
+	category := t.existingShop.Categories[id]
+	message := fmt.Sprintf("Delete the category %s? Its items stay in the shop.", category.Name)
+	dialog.ShowConfirm("Delete Category", message, func(delete bool) {
+		if delete {
+			t.existingShop.RemoveCategory(category.ID)
+			t.categoriesList.Refresh()
+		}
+	}, t.parent)
+}
+
+// newCategoryPicker creates checkboxes for the shop's categories with those of
+// item checked. read returns the IDs of the checked categories.
+func (t *ShopCreatorTab) newCategoryPicker(item models.Item) (picker fyne.CanvasObject, read func() []string) {
+	var categories []models.Category
+	if t.existingShop != nil {
+		categories = t.existingShop.Categories
+	}
+	if len(categories) == 0 {
+		return widget.NewLabel("No categories yet, add them under Optional Settings"), func() []string { return nil }
+	}
+
+	// Category names are unique, so they can stand in for IDs in the check group
+	names := make([]string, 0, len(categories))
+	var checked []string
+	for _, category := range categories {
+		names = append(names, category.Name)
+		if item.InCategory(category.ID) {
+			checked = append(checked, category.Name)
+		}
+	}
+
+	group := widget.NewCheckGroup(names, nil)
+	group.Horizontal = true
+	group.SetSelected(checked)
+
+	return group, func() []string {
+		var ids []string
+		for _, category := range categories {
+			for _, name := range group.Selected {
+				if name == category.Name {
+					ids = append(ids, category.ID)
+				}
+			}
+		}
+		return ids
+	}
+}
+
+// parseTags reads tags separated by commas, Item.Validate trims and dedupes them
+func parseTags(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return strings.Split(text, ",")
+}
@@ -3,6 +3,7 @@ package windows
 import (
 	"IndieNode/internal/services/auth"
 	"fmt"
+	"log"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -20,6 +21,9 @@ type LoginWindow struct {
 	authSvc   *auth.Service
 	onSuccess func()
 
+	// sessionRestored is true when a persisted session made the login unnecessary
+	sessionRestored bool
+
 	// UI elements
 	statusLabel    *widget.Label
 	connectButton  *widget.Button
@@ -34,6 +38,13 @@ func NewLoginWindow(app fyne.App, authSvc *auth.Service, onSuccess func()) *Logi
 		onSuccess: onSuccess,
 	}
 
+	// Skip the MetaMask round-trip while a persisted session is still valid
+	if user, err := authSvc.RestoreSession(); err == nil {
+		log.Printf("Restored session for %s (expires %s)", user.Address, user.ExpiresAt.Format(time.RFC1123))
+		w.sessionRestored = true
+		return w
+	}
+
 	// Create the window
 	w.window = app.NewWindow("Connect with MetaMask")
 	w.window.Resize(fyne.NewSize(400, 500))
@@ -41,9 +52,9 @@ func NewLoginWindow(app fyne.App, authSvc *auth.Service, onSuccess func()) *Logi
 	w.window.CenterOnScreen()
 
 	// Start auth server before creating UI
-	if err := w.authSvc.StartServer(3000, func(address, message, signature string) {
-		// Handle successful authentication
-		if err := w.authSvc.AuthenticateWithEthereum(address, message, signature); err != nil {
+	if err := w.authSvc.StartServer(3000, func(user *auth.AuthenticatedUser, err error) {
+		// The server has already verified the signed SIWE message
+		if err != nil {
 			w.showError("Authentication failed: " + err.Error())
 			return
 		}
@@ -135,13 +146,21 @@ func (w *LoginWindow) showError(message string) {
 	dialog.ShowError(fmt.Errorf(message), w.window)
 }
 
-// Show displays the login window
+// Show displays the login window, or goes straight to onSuccess if a session was restored
 func (w *LoginWindow) Show() {
+	if w.sessionRestored {
+		if w.onSuccess != nil {
+			w.onSuccess()
+		}
+		return
+	}
 	w.window.Show()
 }
 
 // Close closes the login window and stops the auth server
 func (w *LoginWindow) Close() {
 	_ = w.authSvc.StopServer() // Ignore error as we're closing anyway
-	w.window.Close()
+	if w.window != nil {
+		w.window.Close()
+	}
 }
@@ -5,9 +5,11 @@ import (
 	"IndieNode/internal/api"
 	"IndieNode/internal/models"
 	"IndieNode/internal/services/auth"
+	"IndieNode/internal/services/pinning"
 	"IndieNode/internal/services/shop"
 	"IndieNode/ipfs"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -64,6 +66,7 @@ type MainWindow struct {
 	ipfsMgr        *ipfs.IPFSManager
 	authSvc        *auth.Service
 	orbitMgr       *orbitdb.Manager
+	pinningSvc     *pinning.Service
 	apiServer      *api.Server
 	apiPort        int
 	content        *fyne.Container
@@ -72,23 +75,25 @@ type MainWindow struct {
 	createShopTab  *container.TabItem
 	viewShopsTab   *container.TabItem
 	settingsTab    *container.TabItem
+	ordersTab      *container.TabItem
 	welcomeTab     *container.TabItem
 	buttonMap      map[string]*widget.Button
 	closeIntercept func()
 	shopCreator    *ShopCreatorTab
 }
 
-func NewMainWindow(app fyne.App, shopMgr *shop.Manager, ipfsMgr *ipfs.IPFSManager, authSvc *auth.Service, orbitMgr *orbitdb.Manager, apiServer *api.Server, apiPort int) *MainWindow {
+func NewMainWindow(app fyne.App, shopMgr *shop.Manager, ipfsMgr *ipfs.IPFSManager, authSvc *auth.Service, orbitMgr *orbitdb.Manager, pinningSvc *pinning.Service, apiServer *api.Server, apiPort int) *MainWindow {
 	w := &MainWindow{
-		app:       app,
-		window:    app.NewWindow("IndieNode"), // Initialize the window
-		shopMgr:   shopMgr,
-		ipfsMgr:   ipfsMgr,
-		authSvc:   authSvc,
-		orbitMgr:  orbitMgr,
-		apiServer: apiServer,
-		apiPort:   apiPort,
-		buttonMap: make(map[string]*widget.Button),
+		app:        app,
+		window:     app.NewWindow("IndieNode"), // Initialize the window
+		shopMgr:    shopMgr,
+		ipfsMgr:    ipfsMgr,
+		authSvc:    authSvc,
+		orbitMgr:   orbitMgr,
+		pinningSvc: pinningSvc,
+		apiServer:  apiServer,
+		apiPort:    apiPort,
+		buttonMap:  make(map[string]*widget.Button),
 	}
 
 	w.createUI()
@@ -133,12 +138,14 @@ func (w *MainWindow) createUI() {
 	w.shopCreator = shopCreator
 	w.createShopTab = container.NewTabItem("Create Shop", content)
 	w.viewShopsTab = w.createShopList()
-	w.settingsTab = NewSettingsTab(w.window, w.ipfsMgr, w.orbitMgr, w.apiServer, w.apiPort)
+	w.ordersTab = NewOrdersTab(w.window, w.orbitMgr, w.authSvc)
+	w.settingsTab = NewSettingsTab(w.window, w.ipfsMgr, w.orbitMgr, w.pinningSvc, w.apiServer, w.apiPort)
 
 	w.tabs = container.NewAppTabs(
 		w.welcomeTab,
 		w.createShopTab,
 		w.viewShopsTab,
+		w.ordersTab,
 		w.settingsTab,
 	)
 
@@ -199,8 +206,9 @@ func (w *MainWindow) createShopList() *container.TabItem {
 			publishBtn := widget.NewButton("", nil)
 			viewBtn := widget.NewButton("View", nil)
 			editBtn := widget.NewButton("Edit", nil)
+			pinLabel := widget.NewLabel("")
 
-			return container.NewHBox(label, publishBtn, viewBtn, editBtn)
+			return container.NewHBox(label, publishBtn, viewBtn, editBtn, pinLabel)
 		},
 		func(id widget.ListItemID, obj fyne.CanvasObject) {
 			fmt.Printf("UpdateItem called for id: %d\n", id)
@@ -209,11 +217,13 @@ func (w *MainWindow) createShopList() *container.TabItem {
 			publishBtn := containerObj.Objects[1].(*widget.Button)
 			viewBtn := containerObj.Objects[2].(*widget.Button)
 			editBtn := containerObj.Objects[3].(*widget.Button)
+			pinLabel := containerObj.Objects[4].(*widget.Label)
 
 			info := shopInfos[id]
 			fmt.Printf("Setting up shop: %s, isPublished: %v\n", info.name, info.isPublished)
 
 			label.SetText(info.name)
+			pinLabel.SetText(w.remotePinStatus(info.name))
 
 			// Update publish button state using the helper
 			w.updatePublishButtonState(publishBtn, info.name)
@@ -232,18 +242,15 @@ func (w *MainWindow) createShopList() *container.TabItem {
 					return
 				}
 
-				var metadata struct {
-					CID     string `json:"cid"`
-					Gateway string `json:"gateway"`
-				}
+				var metadata ipfs.PublishMetadata
 
 				if err := json.Unmarshal(data, &metadata); err != nil {
 					dialog.ShowError(fmt.Errorf("error parsing metadata: %w", err), w.window)
 					return
 				}
 
-				// Use the gateway URL from metadata for the copy button
-				gatewayURL := metadata.Gateway
+				// Prefer the permanent IPNS URL for the copy button
+				gatewayURL := metadata.ShareURL()
 
 				// Create the copy button with the correct URL
 				urlContainer := container.NewHBox(
@@ -374,6 +381,14 @@ func (w *MainWindow) createShopList() *container.TabItem {
 	return container.NewTabItem("View Shops", paddedContent)
 }
 
+// remotePinStatus summarises a shop's remote pins for the shop list
+func (w *MainWindow) remotePinStatus(shopName string) string {
+	if w.pinningSvc == nil {
+		return ""
+	}
+	return w.pinningSvc.ShopSummary(shopName)
+}
+
 func (w *MainWindow) refreshShopList() {
 	fmt.Println("=== Starting refreshShopList ===")
 	// Get updated shops list
@@ -422,8 +437,10 @@ func (w *MainWindow) refreshShopList() {
 		publishBtn := widget.NewButton("", nil)
 		viewBtn := widget.NewButton("View", nil)
 		editBtn := widget.NewButton("Edit", nil)
+		historyBtn := widget.NewButton("History", nil)
+		pinLabel := widget.NewLabel("")
 
-		return container.NewHBox(label, publishBtn, viewBtn, editBtn)
+		return container.NewHBox(label, publishBtn, viewBtn, editBtn, historyBtn, pinLabel)
 	}
 
 	list.UpdateItem = func(id widget.ListItemID, obj fyne.CanvasObject) {
@@ -433,11 +450,14 @@ func (w *MainWindow) refreshShopList() {
 		publishBtn := containerObj.Objects[1].(*widget.Button)
 		viewBtn := containerObj.Objects[2].(*widget.Button)
 		editBtn := containerObj.Objects[3].(*widget.Button)
+		historyBtn := containerObj.Objects[4].(*widget.Button)
+		pinLabel := containerObj.Objects[5].(*widget.Label)
 
 		info := shopInfos[id]
 		fmt.Printf("Setting up shop: %s, isPublished: %v\n", info.name, info.isPublished)
 
 		label.SetText(info.name)
+		pinLabel.SetText(w.remotePinStatus(info.name))
 
 		// Store or retrieve button from map
 		if existingBtn, ok := w.buttonMap[info.name]; ok {
@@ -490,10 +510,8 @@ func (w *MainWindow) refreshShopList() {
 				}
 
 				fmt.Printf("Publishing shop: %s\n", info.name)
-				htmlPath := shopDir + "/src/index.html"
-				shopPath := shopDir + "/shop.json"
 
-				url, err := w.ipfsMgr.Publish(htmlPath, shopPath)
+				url, err := w.shopMgr.Publish(info.name, "")
 				if err != nil {
 					dialog.ShowError(fmt.Errorf("failed to publish shop: %w", err), w.window)
 					return
@@ -534,18 +552,15 @@ func (w *MainWindow) refreshShopList() {
 				return
 			}
 
-			var metadata struct {
-				CID     string `json:"cid"`
-				Gateway string `json:"gateway"`
-			}
+			var metadata ipfs.PublishMetadata
 
 			if err := json.Unmarshal(data, &metadata); err != nil {
 				dialog.ShowError(fmt.Errorf("error parsing metadata: %w", err), w.window)
 				return
 			}
 
-			// Use the gateway URL from metadata for the copy button
-			gatewayURL := metadata.Gateway
+			// Prefer the permanent IPNS URL for the copy button
+			gatewayURL := metadata.ShareURL()
 			urlContainer := container.NewHBox(
 				widget.NewLabel(gatewayURL),
 				widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
@@ -588,6 +603,11 @@ func (w *MainWindow) refreshShopList() {
 			d.Show()
 		}
 
+		// Configure history button
+		historyBtn.OnTapped = func() {
+			w.showPublishHistory(info.name)
+		}
+
 		// Configure edit button
 		editBtn.OnTapped = func() {
 			shop, err := w.shopMgr.LoadShop(info.name)
@@ -668,18 +688,15 @@ func (w *MainWindow) showPublishSuccessDialog(url string, cid string) {
 		return
 	}
 
-	var metadata struct {
-		CID     string `json:"cid"`
-		Gateway string `json:"gateway"`
-	}
+	var metadata ipfs.PublishMetadata
 
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		dialog.ShowError(fmt.Errorf("error parsing metadata: %w", err), w.window)
 		return
 	}
 
-	// Use the gateway URL from metadata
-	gatewayURL := metadata.Gateway
+	// Prefer the permanent IPNS URL so the QR code survives republishing
+	gatewayURL := metadata.ShareURL()
 	fmt.Printf("Using Gateway URL: %s\n", gatewayURL)
 
 	// Generate QR code with the gateway URL from metadata
@@ -725,6 +742,24 @@ func (w *MainWindow) showPublishSuccessDialog(url string, cid string) {
 func (w *MainWindow) createMainMenu() {
 	w.mainMenu = fyne.NewMainMenu(
 		fyne.NewMenu("File",
+			fyne.NewMenuItem("Log Out", func() {
+				dialog.ShowConfirm("Log Out", "End your session? You will need to sign in with MetaMask next time.", func(confirmed bool) {
+					if !confirmed {
+						return
+					}
+					// Revoke the node's writer proof so it stops authorising writes
+					if w.orbitMgr != nil {
+						if err := w.orbitMgr.RevokeWriterProof(context.Background()); err != nil {
+							fmt.Printf("Warning: Failed to revoke writer proof: %v\n", err)
+						}
+					}
+					if err := w.authSvc.Logout(); err != nil {
+						dialog.ShowError(err, w.window)
+						return
+					}
+					w.window.Close()
+				}, w.window)
+			}),
 			fyne.NewMenuItem("Quit", func() {
 				w.window.Close()
 			}),
@@ -745,6 +780,67 @@ func (w *MainWindow) Close() {
 	w.window.Close()
 }
 
+// showPublishHistory lists a shop's published versions and lets one be rolled back to
+func (w *MainWindow) showPublishHistory(shopName string) {
+	versions, err := w.shopMgr.History(shopName)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to load publish history: %w", err), w.window)
+		return
+	}
+	if len(versions) == 0 {
+		dialog.ShowInformation("Publish History", "This shop hasn't been published yet.", w.window)
+		return
+	}
+
+	var d dialog.Dialog
+	list := container.NewVBox()
+	for i, version := range versions {
+		version := version
+
+		title := fmt.Sprintf("%s  %s  %s", version.PublishedAt.Format("2006-01-02 15:04"), version.Short(), version.Message)
+		entry := container.NewVBox(widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: i == 0}))
+		for _, change := range version.Changes {
+			entry.Add(widget.NewLabel("    " + change.String()))
+		}
+
+		switch {
+		case i == 0:
+			entry.Add(widget.NewLabel("    Current version"))
+		case version.Unpinned:
+			entry.Add(widget.NewLabel("    Unpinned by the retention policy"))
+		case version.CID == versions[0].CID:
+			// Same content as the current version, nothing to roll back to
+		default:
+			entry.Add(container.NewHBox(widget.NewButton("Roll Back", func() {
+				dialog.ShowConfirm("Roll Back",
+					fmt.Sprintf("Point %s, its IPNS name and ENS name back at version %s?", shopName, version.Short()),
+					func(confirmed bool) {
+						if !confirmed {
+							return
+						}
+						url, err := w.shopMgr.Rollback(shopName, version.CID)
+						if err != nil {
+							dialog.ShowError(err, w.window)
+							return
+						}
+						d.Hide()
+						w.refreshShopList()
+						w.showPublishSuccessDialog(url, version.CID)
+					}, w.window)
+			})))
+		}
+
+		list.Add(entry)
+		list.Add(widget.NewSeparator())
+	}
+
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(600, 400))
+
+	d = dialog.NewCustom("Publish History: "+shopName, "Close", scroll, w.window)
+	d.Show()
+}
+
 // updatePublishButtonState updates the state and behavior of a publish button based on shop publication status
 func (w *MainWindow) updatePublishButtonState(publishBtn *widget.Button, shopName string) {
 	shopDir := w.shopMgr.GetShopPath(shopName)
@@ -760,11 +856,7 @@ func (w *MainWindow) updatePublishButtonState(publishBtn *widget.Button, shopNam
 	} else {
 		publishBtn.SetText("Publish")
 		publishBtn.OnTapped = func() {
-			// Existing publish logic
-			htmlPath := filepath.Join(shopDir, "src", "index.html")
-			shopPath := filepath.Join(shopDir, "shop.json")
-
-			url, err := w.ipfsMgr.Publish(htmlPath, shopPath)
+			url, err := w.shopMgr.Publish(shopName, "")
 			if err != nil {
 				dialog.ShowError(fmt.Errorf("failed to publish shop: %w", err), w.window)
 				return
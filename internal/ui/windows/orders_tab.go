@@ -0,0 +1,199 @@
+package windows
+
+import (
+	"IndieNode/db/orbitdb"
+	"IndieNode/internal/services/auth"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// orderColumns are the headers of the order history table
+var orderColumns = []string{"Date", "Item", "Qty", "Paid", "Buyer", "Status", "Transaction"}
+
+// OrdersTab shows the order history of the logged in user's shops
+type OrdersTab struct {
+	window      fyne.Window
+	orbitMgr    *orbitdb.Manager
+	authSvc     *auth.Service
+	shopSelect  *widget.Select
+	statusLabel *widget.Label
+	table       *widget.Table
+	shopIDs     map[string]string // Shop name to shop ID
+	orders      []*orbitdb.OrderData
+}
+
+// NewOrdersTab creates the order history tab
+func NewOrdersTab(window fyne.Window, orbitMgr *orbitdb.Manager, authSvc *auth.Service) *container.TabItem {
+	t := &OrdersTab{
+		window:      window,
+		orbitMgr:    orbitMgr,
+		authSvc:     authSvc,
+		statusLabel: widget.NewLabel("Select a shop to view its orders"),
+		shopIDs:     make(map[string]string),
+	}
+
+	t.shopSelect = widget.NewSelect(nil, func(name string) {
+		t.loadOrders()
+	})
+	t.shopSelect.PlaceHolder = "Select a shop"
+
+	t.table = widget.NewTable(
+		func() (int, int) {
+			return len(t.orders), len(orderColumns)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			cell.(*widget.Label).SetText(t.cellText(id))
+		},
+	)
+	t.table.ShowHeaderRow = true
+	t.table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	t.table.UpdateHeader = func(id widget.TableCellID, cell fyne.CanvasObject) {
+		cell.(*widget.Label).SetText(orderColumns[id.Col])
+	}
+	for col, width := range []float32{150, 120, 50, 120, 130, 90, 130} {
+		t.table.SetColumnWidth(col, width)
+	}
+
+	refreshButton := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), func() {
+		t.refreshShops()
+		t.loadOrders()
+	})
+
+	header := container.NewBorder(nil, nil, widget.NewLabel("Shop:"), refreshButton, t.shopSelect)
+	content := container.NewBorder(
+		container.NewVBox(header, t.statusLabel, widget.NewSeparator()),
+		nil, nil, nil,
+		t.table,
+	)
+
+	t.refreshShops()
+
+	return container.NewTabItem("Orders", content)
+}
+
+// refreshShops reloads the shops owned by the logged in user into the selector
+func (t *OrdersTab) refreshShops() {
+	user := t.authSvc.GetAuthenticatedUser()
+	if user == nil || t.orbitMgr == nil || !t.orbitMgr.IsConnected() {
+		t.shopSelect.Options = nil
+		t.shopSelect.Refresh()
+		return
+	}
+
+	shops, err := t.orbitMgr.ListShopsByOwner(context.Background(), user.Address)
+	if err != nil {
+		t.statusLabel.SetText(fmt.Sprintf("Failed to load shops: %v", err))
+		return
+	}
+
+	t.shopIDs = make(map[string]string)
+	names := make([]string, 0, len(shops))
+	for _, shop := range shops {
+		t.shopIDs[shop.Name] = shop.ID
+		names = append(names, shop.Name)
+	}
+	sort.Strings(names)
+
+	t.shopSelect.Options = names
+	t.shopSelect.Refresh()
+}
+
+// loadOrders fetches the orders of the selected shop
+func (t *OrdersTab) loadOrders() {
+	shopID, ok := t.shopIDs[t.shopSelect.Selected]
+	if !ok {
+		return
+	}
+
+	t.statusLabel.SetText("Loading orders...")
+
+	go func() {
+		orders, err := t.orbitMgr.ListOrders(context.Background(), shopID)
+		if err != nil {
+			t.statusLabel.SetText("Failed to load orders")
+			dialog.ShowError(fmt.Errorf("failed to load orders: %w", err), t.window)
+			return
+		}
+
+		t.orders = orders
+		t.table.Refresh()
+
+		// Summarise confirmed sales
+		confirmed := 0
+		total := new(big.Int)
+		for _, order := range orders {
+			if order.Status != orbitdb.OrderConfirmed {
+				continue
+			}
+			confirmed++
+			if paid, ok := new(big.Int).SetString(order.PricePaid, 10); ok {
+				total.Add(total, paid)
+			}
+		}
+		t.statusLabel.SetText(fmt.Sprintf("%d orders, %d confirmed, %s ETH received", len(orders), confirmed, formatWei(total.String())))
+	}()
+}
+
+// cellText returns the text shown in a table cell
+func (t *OrdersTab) cellText(id widget.TableCellID) string {
+	if id.Row >= len(t.orders) {
+		return ""
+	}
+	order := t.orders[id.Row]
+
+	switch id.Col {
+	case 0:
+		return order.Timestamp.Local().Format(time.DateTime)
+	case 1:
+		if order.VariantID != "" {
+			return order.ItemID + " (" + order.VariantID + ")"
+		}
+		return order.ItemID
+	case 2:
+		return fmt.Sprintf("%d", order.Quantity)
+	case 3:
+		if order.Token != "" && order.Token != "ETH" {
+			return order.PricePaid + " " + shortenHex(order.Token)
+		}
+		return formatWei(order.PricePaid) + " ETH"
+	case 4:
+		return shortenHex(order.Buyer)
+	case 5:
+		return string(order.Status)
+	case 6:
+		return shortenHex(order.TxHash)
+	}
+	return ""
+}
+
+// formatWei converts a wei amount to an ETH string with up to 6 decimals
+func formatWei(wei string) string {
+	amount, ok := new(big.Float).SetString(wei)
+	if !ok {
+		return wei
+	}
+	eth := new(big.Float).Quo(amount, big.NewFloat(1e18))
+	return eth.Text('f', 6)
+}
+
+// shortenHex abbreviates a long hex string like an address or hash
+func shortenHex(value string) string {
+	if len(value) <= 12 {
+		return value
+	}
+	return value[:6] + "..." + value[len(value)-4:]
+}
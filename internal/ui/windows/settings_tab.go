@@ -4,13 +4,13 @@ import (
 	"IndieNode/db/orbitdb"
 	"IndieNode/internal/api"
 	"IndieNode/internal/services/auth"
+	"IndieNode/internal/services/pinning"
 	"IndieNode/ipfs"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -27,6 +27,7 @@ type Settings struct {
 	window             fyne.Window
 	ipfsMgr            *ipfs.IPFSManager
 	orbitMgr           *orbitdb.Manager
+	pinningSvc         *pinning.Service
 	apiServer          *api.Server
 	apiPort            int
 	statusLabel        *widget.Label
@@ -53,11 +54,12 @@ type Settings struct {
 	stopUpdateChan chan bool
 }
 
-func NewSettingsTab(window fyne.Window, ipfsMgr *ipfs.IPFSManager, orbitMgr *orbitdb.Manager, apiServer *api.Server, apiPort int) *container.TabItem {
+func NewSettingsTab(window fyne.Window, ipfsMgr *ipfs.IPFSManager, orbitMgr *orbitdb.Manager, pinningSvc *pinning.Service, apiServer *api.Server, apiPort int) *container.TabItem {
 	s := &Settings{
 		window:             window,
 		ipfsMgr:            ipfsMgr,
 		orbitMgr:           orbitMgr,
+		pinningSvc:         pinningSvc,
 		apiServer:          apiServer,
 		apiPort:            apiPort,
 		statusLabel:        widget.NewLabel("Checking IPFS status..."),
@@ -172,32 +174,19 @@ func (s *Settings) createUI() {
 				}
 
 				// Get list of pins first
-				cmd := exec.Command(s.ipfsMgr.BinaryPath, "pin", "ls", "--type", "recursive")
-				cmd.Env = append(os.Environ(), fmt.Sprintf("IPFS_PATH=%s", s.ipfsMgr.DataPath))
-				output, err := cmd.CombinedOutput()
+				pins, err := s.ipfsMgr.ListPins()
 				if err != nil {
 					dialog.ShowError(fmt.Errorf("Error getting pins: %v", err), s.window)
 					return
 				}
 
-				pins := strings.Split(strings.TrimSpace(string(output)), "\n")
-				if len(pins) == 0 || (len(pins) == 1 && pins[0] == "") {
+				if len(pins) == 0 {
 					dialog.ShowInformation("Clear Pins", "No pins found to clear", s.window)
 					return
 				}
 
 				// Unpin each item
-				for _, line := range pins {
-					if line == "" {
-						continue
-					}
-					// Extract CID from the line
-					parts := strings.Fields(line)
-					if len(parts) == 0 {
-						continue
-					}
-					cid := parts[0]
-
+				for _, cid := range pins {
 					err := s.ipfsMgr.UnpublishContent(cid)
 					if err != nil {
 						dialog.ShowError(fmt.Errorf("Error unpinning %s: %v", cid, err), s.window)
@@ -220,34 +209,17 @@ func (s *Settings) createUI() {
 			return
 		}
 
-		// Use ipfs command with proper environment
-		cmd := exec.Command(s.ipfsMgr.BinaryPath, "pin", "ls", "--type", "recursive")
-		cmd.Env = append(os.Environ(), fmt.Sprintf("IPFS_PATH=%s", s.ipfsMgr.DataPath))
-		output, err := cmd.CombinedOutput()
+		pins, err := s.ipfsMgr.ListPins()
 		if err != nil {
-			dialog.ShowError(fmt.Errorf("Error getting pins: %v (output: %s)", err, string(output)), s.window)
+			dialog.ShowError(fmt.Errorf("Error getting pins: %v", err), s.window)
 			return
 		}
 
-		// Parse the output
-		pins := strings.Split(strings.TrimSpace(string(output)), "\n")
-		numPins := 0
-		if len(pins) > 0 && pins[0] != "" {
-			numPins = len(pins)
-		}
-
 		// Create scrollable content
 		var info strings.Builder
-		info.WriteString(fmt.Sprintf("Number of pinned items: %d\n\n", numPins))
-		for _, line := range pins {
-			if line != "" {
-				// Extract just the CID from the line (removes the "recursive" suffix)
-				parts := strings.Fields(line)
-				if len(parts) > 0 {
-					cid := parts[0]
-					info.WriteString(fmt.Sprintf("CID: %s\n", cid))
-				}
-			}
+		info.WriteString(fmt.Sprintf("Number of pinned items: %d\n\n", len(pins)))
+		for _, cid := range pins {
+			info.WriteString(fmt.Sprintf("CID: %s\n", cid))
 		}
 
 		// Create a text widget with the content
@@ -395,6 +367,16 @@ func (s *Settings) createUI() {
 					fmt.Sprintf("Address: %s", dbInfo.Address),
 					fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}))
 
+				// Show replication progress for shops mirrored from other nodes
+				if dbInfo.Mirror != nil {
+					dbListContainer.Add(container.NewHBox(
+						widget.NewLabel(fmt.Sprintf("Mirror: %s", dbInfo.Mirror)),
+						widget.NewButtonWithIcon("Stop Mirroring", theme.CancelIcon(), func() {
+							s.stopMirror(dbInfo.ShopID)
+						}),
+					))
+				}
+
 				// Add API endpoint for this shop
 				if s.apiServer != nil {
 					apiEndpoint := fmt.Sprintf("http://localhost:%d/api/shops/%s", s.apiPort, dbInfo.ShopID)
@@ -445,6 +427,12 @@ func (s *Settings) createUI() {
 			widget.NewButtonWithIcon("Export All", theme.DocumentSaveIcon(), func() {
 				s.exportAllShops()
 			}),
+			widget.NewButtonWithIcon("Migrate Shops", theme.UploadIcon(), func() {
+				s.migrateAllShops()
+			}),
+			widget.NewButtonWithIcon("Mirror Shop", theme.DownloadIcon(), func() {
+				s.mirrorShop()
+			}),
 		)
 		orbitDBInfoWidgets = append(orbitDBInfoWidgets, dbManagementBtns)
 	} else {
@@ -471,6 +459,38 @@ func (s *Settings) createUI() {
 	))
 	s.content.Add(storageCard)
 
+	// Remote Pinning section
+	if s.pinningSvc != nil {
+		pinningCard := widget.NewCard("Remote Pinning", "", nil)
+		pinningInfo := container.NewVBox()
+
+		endpoints := s.pinningSvc.Endpoints()
+		if len(endpoints) == 0 {
+			pinningInfo.Add(widget.NewLabel("No pinning services configured"))
+		}
+		for _, endpoint := range endpoints {
+			name := endpoint.Name
+			removeBtn := widget.NewButtonWithIcon("Remove", theme.DeleteIcon(), func() {
+				s.removePinningEndpoint(name)
+			})
+			pinningInfo.Add(container.NewHBox(
+				widget.NewLabel(fmt.Sprintf("%s: %s", endpoint.Name, endpoint.URL)),
+				removeBtn,
+			))
+		}
+
+		addEndpointBtn := widget.NewButtonWithIcon("Add Pinning Service", theme.ContentAddIcon(), func() {
+			s.addPinningEndpoint()
+		})
+
+		pinningCard.SetContent(container.NewVBox(
+			pinningInfo,
+			widget.NewSeparator(),
+			addEndpointBtn,
+		))
+		s.content.Add(pinningCard)
+	}
+
 	// Initial updates
 	s.updateIPFSStatus()
 	s.updateAddressLabel()
@@ -688,7 +708,9 @@ func (s *Settings) updateIPFSStatus() {
 }
 
 func (s *Settings) updatePathLabel() {
-	if s.ipfsMgr.BinaryPath == "" {
+	if s.ipfsMgr.Mode == ipfs.EmbeddedIPFS {
+		s.pathLabel.SetText(fmt.Sprintf("IPFS: Embedded node (%s)", s.ipfsMgr.DataPath))
+	} else if s.ipfsMgr.BinaryPath == "" {
 		s.pathLabel.SetText("IPFS Status: Not installed")
 	} else {
 		s.pathLabel.SetText(fmt.Sprintf("IPFS Path: %s", s.ipfsMgr.BinaryPath))
@@ -717,7 +739,7 @@ func (s *Settings) updateAddressLabel() {
 }
 
 func (s *Settings) updateInstallButtonVisibility(daemonButton *widget.Button) {
-	if s.ipfsMgr.BinaryPath != "" {
+	if s.ipfsMgr.BinaryPath != "" || s.ipfsMgr.Mode == ipfs.EmbeddedIPFS {
 		s.installButton.Hide()
 		s.daemonContainer.Show()
 	} else {
@@ -898,6 +920,154 @@ func (s *Settings) reloadAllDatabases() {
 		s.window)
 }
 
+// migrateAllShops upgrades every local shop to the current schema version
+func (s *Settings) migrateAllShops() {
+	if s.orbitMgr == nil || !s.orbitMgr.IsConnected() {
+		dialog.ShowError(fmt.Errorf("not connected to OrbitDB"), s.window)
+		return
+	}
+
+	results, err := s.orbitMgr.MigrateAllShops(context.Background())
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to migrate shops: %w", err), s.window)
+		return
+	}
+
+	if len(results) == 0 {
+		dialog.ShowInformation("Migrate Shops", "No local shops to migrate", s.window)
+		return
+	}
+
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		lines = append(lines, result.String())
+	}
+
+	report := widget.NewLabel(strings.Join(lines, "\n"))
+	report.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(report)
+	scroll.SetMinSize(fyne.NewSize(500, 200))
+
+	dialog.ShowCustom(fmt.Sprintf("Migrated to Schema Version %d", orbitdb.CurrentSchemaVersion), "Close", scroll, s.window)
+	s.createUI() // Refresh UI
+}
+
+// mirrorShop asks for another node's shop database address and site CID, then replicates it here
+func (s *Settings) mirrorShop() {
+	if s.orbitMgr == nil || !s.orbitMgr.IsConnected() {
+		dialog.ShowError(fmt.Errorf("not connected to OrbitDB"), s.window)
+		return
+	}
+
+	addressEntry := widget.NewEntry()
+	addressEntry.SetPlaceHolder("/orbitdb/.../shop-0x...")
+	siteCIDEntry := widget.NewEntry()
+	siteCIDEntry.SetPlaceHolder("Optional, found once the shop replicates")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("OrbitDB Address", addressEntry),
+		widget.NewFormItem("Site CID", siteCIDEntry),
+	}
+
+	form := dialog.NewForm("Mirror Shop", "Mirror", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		status, err := s.orbitMgr.MirrorShop(context.Background(),
+			strings.TrimSpace(addressEntry.Text), strings.TrimSpace(siteCIDEntry.Text))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to mirror shop: %w", err), s.window)
+			return
+		}
+
+		dialog.ShowInformation("Mirror Shop",
+			fmt.Sprintf("Now mirroring shop %s: %s", status.ShopID, status), s.window)
+		s.createUI() // Refresh UI
+	}, s.window)
+	form.Resize(fyne.NewSize(500, 200))
+	form.Show()
+}
+
+// stopMirror stops replicating a mirrored shop and unpins its content
+func (s *Settings) stopMirror(shopID string) {
+	if s.orbitMgr == nil || !s.orbitMgr.IsConnected() {
+		dialog.ShowError(fmt.Errorf("not connected to OrbitDB"), s.window)
+		return
+	}
+
+	dialog.ShowConfirm("Stop Mirroring",
+		fmt.Sprintf("Stop mirroring shop %s and unpin its site?", shopID),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			if err := s.orbitMgr.StopMirror(context.Background(), shopID); err != nil {
+				dialog.ShowError(fmt.Errorf("failed to stop mirroring: %w", err), s.window)
+				return
+			}
+
+			s.createUI() // Refresh UI
+		},
+		s.window)
+}
+
+// addPinningEndpoint configures a remote pinning service that published shops are pinned to
+func (s *Settings) addPinningEndpoint() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. Pinata")
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://api.pinata.cloud/psa")
+	tokenEntry := widget.NewPasswordEntry()
+	tokenEntry.SetPlaceHolder("Access token")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Endpoint URL", urlEntry),
+		widget.NewFormItem("Token", tokenEntry),
+	}
+
+	form := dialog.NewForm("Add Pinning Service", "Add", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		endpoint := pinning.Endpoint{
+			Name:  strings.TrimSpace(nameEntry.Text),
+			URL:   strings.TrimSpace(urlEntry.Text),
+			Token: strings.TrimSpace(tokenEntry.Text),
+		}
+		if err := s.pinningSvc.AddEndpoint(endpoint); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to add pinning service: %w", err), s.window)
+			return
+		}
+
+		s.createUI() // Refresh UI
+	}, s.window)
+	form.Resize(fyne.NewSize(500, 250))
+	form.Show()
+}
+
+// removePinningEndpoint stops pinning published shops to a remote pinning service
+func (s *Settings) removePinningEndpoint(name string) {
+	dialog.ShowConfirm("Remove Pinning Service",
+		fmt.Sprintf("Remove pinning service %s? Content already pinned there is left in place.", name),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			if err := s.pinningSvc.RemoveEndpoint(name); err != nil {
+				dialog.ShowError(fmt.Errorf("failed to remove pinning service: %w", err), s.window)
+				return
+			}
+
+			s.createUI() // Refresh UI
+		},
+		s.window)
+}
+
 // exportAllShops exports all shops to a directory
 func (s *Settings) exportAllShops() {
 	if s.orbitMgr == nil || !s.orbitMgr.IsConnected() {
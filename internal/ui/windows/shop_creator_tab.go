@@ -37,13 +37,21 @@ type ShopCreatorTab struct {
 	locationEntry        *widget.Entry
 	emailEntry           *widget.Entry
 	phoneEntry           *widget.Entry
+	ensEntry             *widget.Entry
+	templateSelect       *widget.Select
+	templateDescription  *widget.Label
+	templateOptionsForm  *fyne.Container
+	templatePacks        []*shop.TemplatePack
+	templateOptionValues map[string]func() string // Reads each option of the selected pack from its widget
 	logoPath             string
 	logoPreviewContainer *fyne.Container
 	itemsList            *widget.List
+	categoriesList       *widget.List
 	itemsListContainer   *fyne.Container
 	itemNameEntry        *widget.Entry
 	itemDescEntry        *widget.Entry
 	itemPriceEntry       *widget.Entry
+	itemStockEntry       *widget.Entry
 	itemImagesContainer  *fyne.Container
 	currentItemImages    []ImageMapping
 	previewContainer     *fyne.Container
@@ -67,12 +75,14 @@ func NewShopCreatorTab(parent fyne.Window, shopMgr *shop.Manager, ipfsMgr *ipfs.
 		locationEntry:        widget.NewEntry(),
 		emailEntry:           widget.NewEntry(),
 		phoneEntry:           widget.NewEntry(),
+		ensEntry:             widget.NewEntry(),
 		logoPreviewContainer: container.NewVBox(),
 		previewContainer:     container.NewVBox(),
 		currentImages:        make([]ImageMapping, 0),
 		itemNameEntry:        widget.NewEntry(),
 		itemDescEntry:        widget.NewEntry(),
 		itemPriceEntry:       widget.NewEntry(),
+		itemStockEntry:       widget.NewEntry(),
 		itemImagesContainer:  container.NewVBox(),
 		currentItemImages:    make([]ImageMapping, 0),
 	}
@@ -121,6 +131,11 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 		t.phoneEntry.SetText(t.existingShop.Phone)
 	}
 
+	t.ensEntry.SetPlaceHolder("ENS Name, e.g. myshop.eth (Optional)")
+	if t.existingShop != nil {
+		t.ensEntry.SetText(t.existingShop.ENSName)
+	}
+
 	// Color pickers
 	primaryColorPicker := components.NewColorButton("Background Color", color.RGBA{R: 0xff, G: 0xfc, B: 0xe9, A: 0xff}, t.parent, func(c color.Color) {
 		if t.existingShop == nil {
@@ -182,6 +197,9 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 	// Logo upload button
 	logoUploadBtn := widget.NewButton("Upload Logo", t.handleLogoUpload)
 
+	templatePicker := t.createTemplatePicker()
+	categoriesSection := t.createCategoriesSection()
+
 	// Optional settings in accordion
 	optionalSettings := widget.NewAccordion(
 		widget.NewAccordionItem("Optional Settings", container.NewVBox(
@@ -190,6 +208,7 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 				t.descriptionContainer,
 				t.locationEntry,
 				t.phoneEntry,
+				t.ensEntry,
 			),
 			widget.NewSeparator(),
 			container.NewVBox(
@@ -198,6 +217,16 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 				t.logoPreviewContainer,
 			),
 			widget.NewSeparator(),
+			container.NewVBox(
+				widget.NewLabel("Site Template"),
+				templatePicker,
+			),
+			widget.NewSeparator(),
+			container.NewVBox(
+				widget.NewLabel("Categories"),
+				categoriesSection,
+			),
+			widget.NewSeparator(),
 			container.NewVBox(
 				widget.NewLabel("Theme Colors"),
 				container.NewGridWithColumns(3,
@@ -223,6 +252,7 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 	t.itemDescEntry.SetPlaceHolder("Item Description")
 	t.itemDescEntry.MultiLine = true
 	t.itemPriceEntry.SetPlaceHolder("Price (e.g. 9.99)")
+	t.itemStockEntry.SetPlaceHolder("Stock (leave empty for unlimited)")
 
 	// Initialize items list
 	t.itemsList = widget.NewList(
@@ -249,7 +279,8 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 			editBtn := buttonBox.Objects[0].(*widget.Button)
 			deleteBtn := buttonBox.Objects[1].(*widget.Button)
 
-			label.SetText(fmt.Sprintf("%s - $%.2f", t.existingShop.Items[id].Name, t.existingShop.Items[id].Price))
+			shopItem := t.existingShop.Items[id]
+			label.SetText(fmt.Sprintf("%s - $%.2f (%s)", shopItem.Name, shopItem.Price, stockLabel(shopItem)))
 
 			editBtn.OnTapped = func() {
 				t.handleEditItem(id)
@@ -292,6 +323,7 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 		t.itemNameEntry.SetText("")
 		t.itemDescEntry.SetText("")
 		t.itemPriceEntry.SetText("")
+		t.itemStockEntry.SetText("")
 		t.currentItemImages = nil
 		t.itemImagesContainer.Objects = nil
 		t.itemImagesContainer.Refresh()
@@ -309,6 +341,12 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 			return
 		}
 
+		stock, err := parseStock(t.itemStockEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, t.parent)
+			return
+		}
+
 		var photoPaths, localPhotoPaths []string
 		for _, img := range t.currentItemImages {
 			photoPaths = append(photoPaths, img.RelativePath)
@@ -320,12 +358,13 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 		}
 
 		t.existingShop.Items = append(t.existingShop.Items, models.Item{
-			ID:              t.itemNameEntry.Text, // Using name as ID for now
+			ID:              t.existingShop.NewItemID(t.itemNameEntry.Text),
 			Name:            t.itemNameEntry.Text,
 			Description:     t.itemDescEntry.Text,
 			Price:           price,
 			PhotoPaths:      photoPaths,
 			LocalPhotoPaths: localPhotoPaths,
+			Inventory:       stock,
 		})
 
 		// Clear the form
@@ -351,10 +390,24 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 	})
 
 	publishBtn := widget.NewButton("Generate & Publish to IPFS", func() {
-		if err := t.generateAndPublish(); err != nil {
-			dialog.ShowError(err, t.parent)
-			return
+		// Ask what changed, like a commit message, for the shop's publish history
+		messageEntry := widget.NewEntry()
+		messageEntry.SetPlaceHolder("Optional, summarises item changes if empty")
+
+		items := []*widget.FormItem{
+			widget.NewFormItem("Message", messageEntry),
 		}
+
+		form := dialog.NewForm("Publish Shop", "Publish", "Cancel", items, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := t.generateAndPublish(messageEntry.Text); err != nil {
+				dialog.ShowError(err, t.parent)
+			}
+		}, t.parent)
+		form.Resize(fyne.NewSize(500, 150))
+		form.Show()
 	})
 
 	submitBtn := widget.NewButton("Save Shop", t.handleSubmit)
@@ -404,6 +457,7 @@ func (t *ShopCreatorTab) createContent() fyne.CanvasObject {
 		t.itemNameEntry,
 		t.itemDescEntry,
 		t.itemPriceEntry,
+		t.itemStockEntry,
 		itemImageBtn,
 		t.itemImagesContainer,
 		layout.NewSpacer(),
@@ -453,6 +507,132 @@ func (t *ShopCreatorTab) createEditContent() fyne.CanvasObject {
 	return mainContainer
 }
 
+// createTemplatePicker creates the template pack selector and the form for the selected pack's options
+func (t *ShopCreatorTab) createTemplatePicker() fyne.CanvasObject {
+	t.templateDescription = widget.NewLabel("")
+	t.templateDescription.Wrapping = fyne.TextWrapWord
+	t.templateOptionsForm = container.NewVBox()
+
+	packs, err := t.shopMgr.TemplatePacks()
+	if err != nil {
+		fmt.Printf("Warning: failed to load template packs: %v\n", err)
+	}
+	t.templatePacks = packs
+
+	titles := []string{}
+	for _, pack := range packs {
+		titles = append(titles, pack.Title)
+	}
+
+	t.templateSelect = widget.NewSelect(titles, func(title string) {
+		for _, pack := range t.templatePacks {
+			if pack.Title == title {
+				t.showTemplateOptions(pack)
+				return
+			}
+		}
+	})
+
+	name := ""
+	if t.existingShop != nil {
+		name = t.existingShop.Template
+	}
+	t.selectTemplate(name)
+
+	return container.NewVBox(t.templateSelect, t.templateDescription, t.templateOptionsForm)
+}
+
+// selectTemplate selects the pack called name, or the default pack if there is no such pack
+func (t *ShopCreatorTab) selectTemplate(name string) {
+	if t.templateSelect == nil || len(t.templatePacks) == 0 {
+		return
+	}
+	if name == "" {
+		name = shop.DefaultTemplate
+	}
+
+	selected := t.templatePacks[0]
+	for _, pack := range t.templatePacks {
+		if pack.Name == name {
+			selected = pack
+		}
+	}
+
+	// SetSelected only calls back when the selection changes, but the options
+	// need refilling with this shop's values either way
+	t.templateSelect.SetSelected(selected.Title)
+	t.showTemplateOptions(selected)
+}
+
+// showTemplateOptions fills the options form for pack with the shop's saved values
+func (t *ShopCreatorTab) showTemplateOptions(pack *shop.TemplatePack) {
+	var saved map[string]string
+	if t.existingShop != nil && t.existingShop.Template == pack.Name {
+		saved = t.existingShop.TemplateOptions
+	}
+	values := pack.Values(saved)
+
+	t.templateDescription.SetText(pack.Description)
+	t.templateOptionsForm.Objects = nil
+	t.templateOptionValues = make(map[string]func() string)
+
+	form := widget.NewForm()
+	for _, option := range pack.Options {
+		label := option.Label
+		if label == "" {
+			label = option.Name
+		}
+
+		switch option.Type {
+		case shop.OptionBool:
+			check := widget.NewCheck("", nil)
+			check.SetChecked(values[option.Name] == "true")
+			form.Append(label, check)
+			t.templateOptionValues[option.Name] = func() string {
+				return strconv.FormatBool(check.Checked)
+			}
+		case shop.OptionChoice:
+			choice := widget.NewSelect(option.Choices, nil)
+			choice.SetSelected(values[option.Name])
+			form.Append(label, choice)
+			t.templateOptionValues[option.Name] = func() string {
+				return choice.Selected
+			}
+		default:
+			entry := widget.NewEntry()
+			entry.SetText(values[option.Name])
+			form.Append(label, entry)
+			t.templateOptionValues[option.Name] = func() string {
+				return strings.TrimSpace(entry.Text)
+			}
+		}
+	}
+
+	if len(pack.Options) > 0 {
+		t.templateOptionsForm.Add(form)
+	}
+	t.templateOptionsForm.Refresh()
+}
+
+// applyTemplateSelection stores the selected pack and its option values in the shop
+func (t *ShopCreatorTab) applyTemplateSelection() {
+	if t.existingShop == nil || t.templateSelect == nil {
+		return
+	}
+
+	for _, pack := range t.templatePacks {
+		if pack.Title != t.templateSelect.Selected {
+			continue
+		}
+		t.existingShop.Template = pack.Name
+		t.existingShop.TemplateOptions = make(map[string]string, len(t.templateOptionValues))
+		for name, value := range t.templateOptionValues {
+			t.existingShop.TemplateOptions[name] = value()
+		}
+		return
+	}
+}
+
 func (t *ShopCreatorTab) handleLogoUpload() {
 	fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
 		if err != nil {
@@ -490,6 +670,8 @@ func (t *ShopCreatorTab) handleSubmit() {
 	t.existingShop.Location = t.locationEntry.Text
 	t.existingShop.Email = t.emailEntry.Text
 	t.existingShop.Phone = t.phoneEntry.Text
+	t.existingShop.ENSName = strings.TrimSpace(t.ensEntry.Text)
+	t.applyTemplateSelection()
 	// Generate URL-safe name
 	t.existingShop.GenerateURLName()
 
@@ -515,11 +697,13 @@ func (t *ShopCreatorTab) handleSubmit() {
 	t.locationEntry.SetText("")
 	t.emailEntry.SetText("")
 	t.phoneEntry.SetText("")
+	t.ensEntry.SetText("")
 	t.logoPath = ""
 	t.logoPreviewContainer.Objects = nil
 	t.logoPreviewContainer.Refresh()
 	t.existingShop = nil
 	t.itemsList.Refresh()
+	t.categoriesList.Refresh()
 }
 
 func (t *ShopCreatorTab) handleEditItem(id widget.ListItemID) {
@@ -543,6 +727,10 @@ func (t *ShopCreatorTab) handleEditItem(id widget.ListItemID) {
 	priceEntry.SetText(fmt.Sprintf("%.2f", item.Price))
 	priceEntry.SetPlaceHolder("Price")
 
+	stockEntry := widget.NewEntry()
+	stockEntry.SetText(formatStock(item.Inventory))
+	stockEntry.SetPlaceHolder("Stock (leave empty for unlimited)")
+
 	var itemImages []ImageMapping
 	imagePreview := container.NewVBox()
 
@@ -585,12 +773,25 @@ func (t *ShopCreatorTab) handleEditItem(id widget.ListItemID) {
 		fd.Show()
 	})
 
+	categoryPicker, readCategories := t.newCategoryPicker(item)
+
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetText(strings.Join(item.Tags, ", "))
+	tagsEntry.SetPlaceHolder("Tags, separated by commas (Optional)")
+
+	variants := newVariantEditor(t.parent, item)
+
 	content := container.NewVBox(
 		nameEntry,
 		descEntry,
 		priceEntry,
+		stockEntry,
 		selectImageBtn,
 		imagePreview,
+		widget.NewLabel("Categories"),
+		categoryPicker,
+		tagsEntry,
+		variants.content(),
 	)
 
 	dialog.ShowCustomConfirm("Edit Item", "Save", "Cancel", content, func(save bool) {
@@ -601,22 +802,54 @@ func (t *ShopCreatorTab) handleEditItem(id widget.ListItemID) {
 				return
 			}
 
+			stock, err := parseStock(stockEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, t.parent)
+				return
+			}
+
+			options, itemVariants, err := variants.read()
+			if err != nil {
+				dialog.ShowError(err, t.parent)
+				return
+			}
+
 			var photoPaths, localPhotoPaths []string
 			for _, img := range itemImages {
 				photoPaths = append(photoPaths, img.RelativePath)
 				localPhotoPaths = append(localPhotoPaths, img.OriginalPath)
 			}
 
-			t.existingShop.Items[id] = models.Item{
-				ID:              nameEntry.Text, // Using name as ID for now
+			// Items from before IDs were checked were identified by their name
+			itemID := item.ID
+			if !models.ValidID(itemID) {
+				itemID = t.existingShop.NewItemID(nameEntry.Text)
+			}
+
+			edited := models.Item{
+				ID:              itemID,
 				Name:            nameEntry.Text,
 				Description:     descEntry.Text,
 				Price:           price,
 				PhotoPaths:      photoPaths,
 				LocalPhotoPaths: localPhotoPaths,
+				Inventory:       stock,
+				Options:         options,
+				Variants:        itemVariants,
+				Categories:      readCategories(),
+				Tags:            parseTags(tagsEntry.Text),
+			}
+
+			// Also fills in the IDs of new variants
+			if err := edited.Validate(); err != nil {
+				dialog.ShowError(err, t.parent)
+				return
 			}
 
+			t.existingShop.Items[id] = edited
+
 			t.itemsList.Refresh()
+			t.categoriesList.Refresh()
 		}
 	}, t.parent)
 }
@@ -630,6 +863,7 @@ func (t *ShopCreatorTab) handleDeleteItem(id widget.ListItemID) {
 		if delete {
 			t.existingShop.Items = append(t.existingShop.Items[:id], t.existingShop.Items[id+1:]...)
 			t.itemsList.Refresh()
+			t.categoriesList.Refresh()
 		}
 	}, t.parent)
 }
@@ -649,6 +883,8 @@ func (t *ShopCreatorTab) generateShop() error {
 	t.existingShop.Location = t.locationEntry.Text
 	t.existingShop.Email = t.emailEntry.Text
 	t.existingShop.Phone = t.phoneEntry.Text
+	t.existingShop.ENSName = strings.TrimSpace(t.ensEntry.Text)
+	t.applyTemplateSelection()
 
 	// Set logo paths
 	if t.logoPath != "" {
@@ -670,7 +906,7 @@ func (t *ShopCreatorTab) generateShop() error {
 	return nil
 }
 
-func (t *ShopCreatorTab) generateAndPublish() error {
+func (t *ShopCreatorTab) generateAndPublish(message string) error {
 	// First generate the shop
 	if err := t.generateShop(); err != nil {
 		return err
@@ -685,18 +921,13 @@ func (t *ShopCreatorTab) generateAndPublish() error {
 	progress := dialog.NewProgress("Publishing", "Publishing shop to IPFS...", t.parent)
 	progress.Show()
 
-	// Get shop path
-	shopPath := t.shopMgr.GetShopPath(t.existingShop.Name)
-	htmlPath := filepath.Join(shopPath, "src", "index.html")
-	shopJsonPath := filepath.Join(shopPath, "shop.json")
-
 	// Store shop reference for use in goroutine
 	shopName := t.existingShop.Name
 
 	// Run IPFS publishing in a goroutine to avoid blocking the UI
 	go func() {
-		// Publish to IPFS
-		url, err := t.ipfsMgr.Publish(htmlPath, shopJsonPath)
+		// Publish to IPFS and queue for remote pinning
+		url, err := t.shopMgr.Publish(shopName, message)
 
 		// Capture the result
 		finalURL := ""
@@ -795,6 +1026,42 @@ func parseURL(urlStr string) *url.URL {
 	return u
 }
 
+// parseStock reads a stock count from an entry, where empty means unlimited
+func parseStock(text string) (int64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return models.UnlimitedInventory, nil
+	}
+
+	stock, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || stock < 0 {
+		return 0, fmt.Errorf("invalid stock: must be a whole number of 0 or more")
+	}
+	return stock, nil
+}
+
+// formatStock shows a stock count in an entry, leaving it empty for unlimited
+func formatStock(stock int64) string {
+	if stock == models.UnlimitedInventory {
+		return ""
+	}
+	return strconv.FormatInt(stock, 10)
+}
+
+// stockLabel describes an item's stock for the items list
+func stockLabel(item models.Item) string {
+	switch {
+	case item.SoldOut():
+		return "sold out"
+	case item.HasVariants():
+		return fmt.Sprintf("%d variants", len(item.Variants))
+	case item.HasUnlimitedInventory():
+		return "unlimited stock"
+	default:
+		return fmt.Sprintf("%d in stock", item.Inventory)
+	}
+}
+
 // handleDeleteShop handles the deletion of the current shop
 func (t *ShopCreatorTab) handleDeleteShop() {
 	if t.existingShop == nil {
@@ -822,6 +1089,7 @@ func (t *ShopCreatorTab) handleDeleteShop() {
 			t.locationEntry.SetText("")
 			t.emailEntry.SetText("")
 			t.phoneEntry.SetText("")
+			t.ensEntry.SetText("")
 			t.logoPath = ""
 			t.currentImages = nil
 			if t.logoPreviewContainer != nil {
@@ -849,6 +1117,8 @@ func (t *ShopCreatorTab) LoadExistingShop(shop *models.Shop) {
 	t.locationEntry.SetText(shop.Location)
 	t.emailEntry.SetText(shop.Email)
 	t.phoneEntry.SetText(shop.Phone)
+	t.ensEntry.SetText(shop.ENSName)
+	t.selectTemplate(shop.Template)
 
 	// Update delete button visibility
 	if t.deleteBtn != nil {
@@ -875,4 +1145,7 @@ func (t *ShopCreatorTab) LoadExistingShop(shop *models.Shop) {
 	if t.itemsList != nil {
 		t.itemsList.Refresh()
 	}
+	if t.categoriesList != nil {
+		t.categoriesList.Refresh()
+	}
 }
@@ -0,0 +1,241 @@
+package windows
+
+import (
+	"IndieNode/internal/models"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// variantEditor edits an item's options and the variants they make up, as
+// part of the item dialog
+type variantEditor struct {
+	parent       fyne.Window
+	optionsEntry *widget.Entry
+	rows         *fyne.Container
+	item         models.Item // Options and variants as last shown
+	fields       []*variantFields
+}
+
+// variantFields are the entries of one variant's row
+type variantFields struct {
+	variant    models.Variant
+	skuEntry   *widget.Entry
+	priceEntry *widget.Entry
+	stockEntry *widget.Entry
+	photoLabel *widget.Label
+}
+
+// newVariantEditor creates an editor for the options and variants of item
+func newVariantEditor(parent fyne.Window, item models.Item) *variantEditor {
+	e := &variantEditor{
+		parent:       parent,
+		optionsEntry: widget.NewEntry(),
+		rows:         container.NewVBox(),
+		item:         item,
+	}
+	e.optionsEntry.MultiLine = true
+	e.optionsEntry.SetText(formatOptions(item.Options))
+	e.optionsEntry.SetPlaceHolder("One option per line, e.g. Size: S, M, L")
+	e.showVariants(item.Variants)
+	return e
+}
+
+// content returns the editor's widgets
+func (e *variantEditor) content() fyne.CanvasObject {
+	updateBtn := widget.NewButton("Update Variants", func() {
+		if err := e.update(); err != nil {
+			dialog.ShowError(err, e.parent)
+		}
+	})
+
+	return container.NewVBox(
+		widget.NewLabel("Options (leave empty if the item comes in one version)"),
+		e.optionsEntry,
+		updateBtn,
+		e.rows,
+	)
+}
+
+// update rebuilds the variant rows from the options entry, keeping what was
+// entered for combinations that still exist
+func (e *variantEditor) update() error {
+	options, err := parseOptions(e.optionsEntry.Text)
+	if err != nil {
+		return err
+	}
+	variants, err := e.readRows()
+	if err != nil {
+		return err
+	}
+
+	updated := e.item
+	updated.Options = options
+	updated.Variants = variants
+	generated, err := updated.GenerateVariants()
+	if err != nil {
+		return err
+	}
+
+	e.item = updated
+	e.showVariants(generated)
+	return nil
+}
+
+// read returns the options and variants as entered, updating the rows first
+// in case the options were changed without doing so
+func (e *variantEditor) read() ([]models.OptionGroup, []models.Variant, error) {
+	if err := e.update(); err != nil {
+		return nil, nil, err
+	}
+	variants, err := e.readRows()
+	if err != nil {
+		return nil, nil, err
+	}
+	return e.item.Options, variants, nil
+}
+
+// showVariants replaces the rows with one for each variant
+func (e *variantEditor) showVariants(variants []models.Variant) {
+	e.rows.Objects = nil
+	e.fields = nil
+
+	if len(variants) > 0 {
+		e.rows.Add(container.NewGridWithColumns(5,
+			widget.NewLabel("Variant"),
+			widget.NewLabel("SKU"),
+			widget.NewLabel("Price"),
+			widget.NewLabel("Stock"),
+			widget.NewLabel("Photo"),
+		))
+	}
+
+	for _, variant := range variants {
+		fields := &variantFields{
+			variant:    variant,
+			skuEntry:   widget.NewEntry(),
+			priceEntry: widget.NewEntry(),
+			stockEntry: widget.NewEntry(),
+			photoLabel: widget.NewLabel(filepath.Base(variant.PhotoPath)),
+		}
+		fields.skuEntry.SetText(variant.SKU)
+		if variant.Price != nil {
+			fields.priceEntry.SetText(fmt.Sprintf("%.2f", *variant.Price))
+		}
+		fields.priceEntry.SetPlaceHolder("Item price")
+		fields.stockEntry.SetText(formatStock(variant.Inventory))
+		fields.stockEntry.SetPlaceHolder("Unlimited")
+		if variant.PhotoPath == "" {
+			fields.photoLabel.SetText("")
+		}
+
+		photoBtn := widget.NewButton("Choose", func() {
+			e.choosePhoto(fields)
+		})
+
+		e.rows.Add(container.NewGridWithColumns(5,
+			widget.NewLabel(e.item.VariantLabel(variant)),
+			fields.skuEntry,
+			fields.priceEntry,
+			fields.stockEntry,
+			container.NewBorder(nil, nil, nil, photoBtn, fields.photoLabel),
+		))
+		e.fields = append(e.fields, fields)
+	}
+
+	e.rows.Refresh()
+}
+
+// choosePhoto lets the user pick a photo for one variant
+func (e *variantEditor) choosePhoto(fields *variantFields) {
+	fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, e.parent)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		sourcePath := reader.URI().Path()
+		fields.variant.LocalPhotoPath = sourcePath
+		fields.variant.PhotoPath = fmt.Sprintf("items/%s", filepath.Base(sourcePath))
+		fields.photoLabel.SetText(filepath.Base(sourcePath))
+	}, e.parent)
+	fd.SetFilter(storage.NewExtensionFileFilter([]string{".png", ".jpg", ".jpeg"}))
+	fd.Show()
+}
+
+// readRows returns the variants with what was entered in their rows
+func (e *variantEditor) readRows() ([]models.Variant, error) {
+	variants := make([]models.Variant, 0, len(e.fields))
+	for _, fields := range e.fields {
+		variant := fields.variant
+		label := e.item.VariantLabel(variant)
+
+		variant.SKU = strings.TrimSpace(fields.skuEntry.Text)
+
+		variant.Price = nil
+		if text := strings.TrimSpace(fields.priceEntry.Text); text != "" {
+			price, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid price for %s: must be a number", label)
+			}
+			variant.Price = &price
+		}
+
+		stock, err := parseStock(fields.stockEntry.Text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", label, err)
+		}
+		variant.Inventory = stock
+
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// parseOptions reads option groups from text with one "Name: value, value" per line
+func parseOptions(text string) ([]models.OptionGroup, error) {
+	var options []models.OptionGroup
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, values, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid option %q: use Name: value, value", line)
+		}
+
+		group := models.OptionGroup{Name: strings.TrimSpace(name)}
+		for _, value := range strings.Split(values, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				group.Values = append(group.Values, value)
+			}
+		}
+		if len(group.Values) == 0 {
+			return nil, fmt.Errorf("option %s needs at least one value", group.Name)
+		}
+		options = append(options, group)
+	}
+	return options, nil
+}
+
+// formatOptions shows option groups in the options entry, see parseOptions
+func formatOptions(options []models.OptionGroup) string {
+	lines := make([]string, 0, len(options))
+	for _, group := range options {
+		lines = append(lines, group.Name+": "+strings.Join(group.Values, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
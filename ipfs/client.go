@@ -1,6 +1,7 @@
 package ipfs
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,9 +11,22 @@ import (
 	"time"
 
 	shell "github.com/ipfs/go-ipfs-api"
+	icore "github.com/ipfs/interface-go-ipfs-core"
 )
 
 func (m *IPFSManager) AddFile(path string) (string, error) {
+	if m.Mode == EmbeddedIPFS {
+		if !m.IsDaemonRunning() {
+			return "", fmt.Errorf("embedded IPFS node is not running")
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		return m.addFileEmbedded(file)
+	}
+
 	if m.Shell == nil {
 		return "", fmt.Errorf("IPFS shell not initialized")
 	}
@@ -90,13 +104,24 @@ func (m *IPFSManager) announceAndVerifyCID(hash string, maxRetries int) error {
 	return fmt.Errorf("failed to verify CID %s is being provided after %d attempts", hash, maxRetries)
 }
 
-func (m *IPFSManager) AddDirectory(path string) (string, error) {
+// AddDirectory adds and pins a directory wrapped in a parent directory, with
+// rootFiles beside it in the parent, and returns the parent's CID
+func (m *IPFSManager) AddDirectory(path string, rootFiles ...string) (string, error) {
+	if m.Mode == EmbeddedIPFS {
+		if !m.IsDaemonRunning() {
+			return "", fmt.Errorf("embedded IPFS node is not running")
+		}
+		return m.addDirectoryEmbedded(path, rootFiles)
+	}
+
 	if m.BinaryPath == "" {
 		return "", fmt.Errorf("IPFS binary not found")
 	}
 
-	// Add directory to IPFS using command, removed -Q flag to get full output
-	cmd := exec.Command(m.BinaryPath, "add", "-r", "--wrap-with-directory", path)
+	// Add directory to IPFS using command, removed -Q flag to get full output.
+	// Everything named on the command line is wrapped in the same directory.
+	args := append([]string{"add", "-r", "--wrap-with-directory", path}, rootFiles...)
+	cmd := exec.Command(m.BinaryPath, args...)
 	cmd.Env = append(os.Environ(), fmt.Sprintf("IPFS_PATH=%s", m.DataPath))
 	output, err := cmd.Output()
 	if err != nil {
@@ -150,16 +175,25 @@ func (m *IPFSManager) AddDirectory(path string) (string, error) {
 }
 
 func (m *IPFSManager) GetGatewayURL(hash string) string {
+	fmt.Printf("[DEBUG] Getting gateway URL for hash: %s\n", hash)
+	return m.gatewayBaseURL() + "/ipfs/" + hash
+}
+
+// GetIPNSGatewayURL returns the gateway URL for an IPNS name
+func (m *IPFSManager) GetIPNSGatewayURL(name string) string {
+	return m.gatewayBaseURL() + "/ipns/" + name
+}
+
+// gatewayBaseURL returns the base URL of the first healthy gateway
+func (m *IPFSManager) gatewayBaseURL() string {
 	m.gatewayLock.Lock()
 	defer m.gatewayLock.Unlock()
 
-	fmt.Printf("[DEBUG] Getting gateway URL for hash: %s\n", hash)
-
 	// Initialize gateways if needed
 	if len(m.gateways) == 0 {
 		fmt.Printf("[DEBUG] No gateways found, initializing...\n")
 		gateways := DefaultGateways
-		if m.Shell != nil && m.IsDaemonRunning() {
+		if m.Mode != EmbeddedIPFS && m.Shell != nil && m.IsDaemonRunning() {
 			fmt.Printf("[DEBUG] Daemon is running, adding local gateway\n")
 			// Add local gateway first if daemon is running
 			gateways = append([]string{"http://127.0.0.1:8080"}, gateways...)
@@ -185,13 +219,13 @@ func (m *IPFSManager) GetGatewayURL(hash string) string {
 			gateway.LastUsed = time.Now()
 			fmt.Printf("[DEBUG] Using healthy gateway: %s\n", gateway.URL)
 			// Don't add /src/index.html here - let the caller handle the full path
-			return strings.TrimRight(gateway.URL, "/") + "/ipfs/" + hash
+			return strings.TrimRight(gateway.URL, "/")
 		}
 	}
 
 	// If no healthy gateway found, use ipfs.io as fallback
 	fmt.Printf("[DEBUG] No healthy gateways found, using ipfs.io\n")
-	return "https://ipfs.io/ipfs/" + hash
+	return "https://ipfs.io"
 }
 
 func (m *IPFSManager) Publish(htmlPath string, shopPath string) (string, error) {
@@ -203,8 +237,13 @@ func (m *IPFSManager) Publish(htmlPath string, shopPath string) (string, error)
 	shopDir := filepath.Dir(filepath.Dir(htmlPath))
 	fmt.Printf("Publishing shop from directory: %s\n", shopDir)
 
-	// Use IPFS to add the entire shop directory
-	hash, err := m.AddDirectory(shopDir)
+	// Use IPFS to add the entire shop directory, with files such as robots.txt
+	// at the root of the CID where crawlers look for them
+	rootFiles, err := filepath.Glob(filepath.Join(shopDir, SiteRootDir, "*"))
+	if err != nil {
+		return "", fmt.Errorf("error listing site root files: %v", err)
+	}
+	hash, err := m.AddDirectory(shopDir, rootFiles...)
 	if err != nil {
 		return "", fmt.Errorf("error adding directory to IPFS: %v", err)
 	}
@@ -215,28 +254,43 @@ func (m *IPFSManager) Publish(htmlPath string, shopPath string) (string, error)
 	fmt.Printf("Base gateway URL: %s\n", baseURL)
 
 	// List directory contents to verify structure
-	cmd := exec.Command(m.BinaryPath, "ls", hash)
-	output, err := cmd.Output()
-	if err == nil {
-		fmt.Printf("Directory contents:\n%s\n", string(output))
+	if m.Mode != EmbeddedIPFS {
+		cmd := exec.Command(m.BinaryPath, "ls", hash)
+		output, err := cmd.Output()
+		if err == nil {
+			fmt.Printf("Directory contents:\n%s\n", string(output))
+		}
 	}
 
+	return m.PointShopAt(shopDir, shopPath, hash)
+}
+
+// PointShopAt makes hash the current version of a shop: it rewrites the shop's
+// metadata and shop.json and points its IPNS name at hash. Returns the site's gateway URL.
+func (m *IPFSManager) PointShopAt(shopDir string, shopPath string, hash string) (string, error) {
 	// Get the shop directory name from the path
 	shopDirName := filepath.Base(shopDir)
 
 	// Construct the gateway URL with the shop directory name
-	finalURL := baseURL + "/" + shopDirName + "/src/index.html"
+	finalURL := m.GetGatewayURL(hash) + "/" + shopDirName + "/src/index.html"
 	fmt.Printf("Final URL: %s\n", finalURL)
 
 	// Create metadata file
-	metadata := struct {
-		CID     string `json:"cid"`
-		Gateway string `json:"gateway"`
-	}{
+	metadata := PublishMetadata{
 		CID:     hash,
 		Gateway: finalURL, // Save the constructed gateway URL
 	}
 
+	// Point the shop's IPNS name at the new CID so its permanent URL stays the same
+	ipnsName, err := m.PublishIPNS(context.Background(), shopDirName, hash)
+	if err != nil {
+		fmt.Printf("Warning: Failed to update IPNS record, shop is only reachable by CID: %v\n", err)
+	} else {
+		metadata.IPNSName = ipnsName
+		metadata.IPNSGateway = m.GetIPNSGatewayURL(ipnsName) + "/" + shopDirName + "/src/index.html"
+		fmt.Printf("Permanent URL: %s\n", metadata.IPNSGateway)
+	}
+
 	// Save metadata to file
 	metadataPath := filepath.Join(shopDir, "ipfs_metadata.json")
 	metadataJSON, err := json.MarshalIndent(metadata, "", "    ")
@@ -249,8 +303,8 @@ func (m *IPFSManager) Publish(htmlPath string, shopPath string) (string, error)
 	}
 	fmt.Printf("Saved metadata to: %s\n", metadataPath)
 
-	// Update the shop.json with the new CID
-	err = updateShopCID(shopPath, hash)
+	// Update the shop.json with the new CID and IPNS name
+	err = updateShopCID(shopPath, hash, metadata.IPNSName)
 	if err != nil {
 		return "", fmt.Errorf("error updating shop.json: %v", err)
 	}
@@ -259,7 +313,7 @@ func (m *IPFSManager) Publish(htmlPath string, shopPath string) (string, error)
 	return finalURL, nil
 }
 
-func updateShopCID(shopPath string, cid string) error {
+func updateShopCID(shopPath string, cid string, ipnsName string) error {
 	// Load the existing JSON
 	jsonData, err := os.ReadFile(shopPath)
 	if err != nil {
@@ -275,6 +329,11 @@ func updateShopCID(shopPath string, cid string) error {
 	// Update the CID field
 	shopData["CID"] = cid
 
+	// Only overwrite the IPNS name when the record was actually published
+	if ipnsName != "" {
+		shopData["IPNSName"] = ipnsName
+	}
+
 	// Write the updated JSON back to the file
 	updatedJSON, err := json.MarshalIndent(shopData, "", "    ")
 	if err != nil {
@@ -321,10 +380,7 @@ func (m *IPFSManager) CheckShopPublication(shopDir string) (isPublished bool, ci
 	}
 
 	// Parse the metadata
-	var metadata struct {
-		CID     string `json:"cid"`
-		Gateway string `json:"gateway"`
-	}
+	var metadata PublishMetadata
 
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		return false, "", "", fmt.Errorf("error parsing metadata: %w", err)
@@ -334,6 +390,13 @@ func (m *IPFSManager) CheckShopPublication(shopDir string) (isPublished bool, ci
 }
 
 func (m *IPFSManager) RunGarbageCollection() error {
+	if m.Mode == EmbeddedIPFS {
+		if !m.IsDaemonRunning() {
+			return fmt.Errorf("embedded IPFS node is not running")
+		}
+		return m.garbageCollectEmbedded()
+	}
+
 	if m.Shell == nil {
 		return fmt.Errorf("IPFS shell not initialized")
 	}
@@ -349,17 +412,9 @@ func (m *IPFSManager) RunGarbageCollection() error {
 }
 
 func (m *IPFSManager) UnpublishContent(cid string) error {
-	if m.BinaryPath == "" {
-		return fmt.Errorf("IPFS binary not found")
-	}
-
-	// Unpin the content using CLI command
-	cmd := exec.Command(m.BinaryPath, "pin", "rm", cid)
-	cmd.Env = append(os.Environ(), fmt.Sprintf("IPFS_PATH=%s", m.DataPath))
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to unpin content: %w (output: %s)", err, string(output))
+	if err := m.UnpinContent(cid); err != nil {
+		return err
 	}
-	fmt.Printf("Successfully unpinned content with CID: %s\n", cid)
 
 	// Run garbage collection after unpinning
 	if err := m.RunGarbageCollection(); err != nil {
@@ -370,11 +425,70 @@ func (m *IPFSManager) UnpublishContent(cid string) error {
 	return nil
 }
 
+// UnpinContent unpins cid without collecting garbage, so several CIDs can be
+// unpinned before a single RunGarbageCollection
+func (m *IPFSManager) UnpinContent(cid string) error {
+	if m.Mode == EmbeddedIPFS {
+		if !m.IsDaemonRunning() {
+			return fmt.Errorf("embedded IPFS node is not running")
+		}
+		if err := m.unpinEmbedded(cid); err != nil {
+			return fmt.Errorf("failed to unpin content: %w", err)
+		}
+	} else {
+		if m.BinaryPath == "" {
+			return fmt.Errorf("IPFS binary not found")
+		}
+
+		// Unpin the content using CLI command
+		cmd := exec.Command(m.BinaryPath, "pin", "rm", cid)
+		cmd.Env = append(os.Environ(), fmt.Sprintf("IPFS_PATH=%s", m.DataPath))
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to unpin content: %w (output: %s)", err, string(output))
+		}
+	}
+	fmt.Printf("Successfully unpinned content with CID: %s\n", cid)
+
+	return nil
+}
+
+// ListPins returns the CIDs of all recursively pinned content
+func (m *IPFSManager) ListPins() ([]string, error) {
+	if m.Mode == EmbeddedIPFS {
+		if !m.IsDaemonRunning() {
+			return nil, fmt.Errorf("embedded IPFS node is not running")
+		}
+		return m.listPinsEmbedded()
+	}
+
+	cmd := exec.Command(m.BinaryPath, "pin", "ls", "--type", "recursive")
+	cmd.Env = append(os.Environ(), fmt.Sprintf("IPFS_PATH=%s", m.DataPath))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pins: %w (output: %s)", err, string(output))
+	}
+
+	// Each line is "<cid> recursive"
+	cids := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) > 0 {
+			cids = append(cids, parts[0])
+		}
+	}
+	return cids, nil
+}
+
 func (m *IPFSManager) GetIPFSNode() (interface{}, error) {
 	if !m.IsDaemonRunning() {
 		return nil, fmt.Errorf("IPFS daemon is not running")
 	}
 
+	// The embedded node has a complete CoreAPI of its own
+	if m.Mode == EmbeddedIPFS {
+		return m.embedded.api, nil
+	}
+
 	// Create a new shell instance
 	api := shell.NewShell("localhost:5001")
 
@@ -391,6 +505,21 @@ func (m *IPFSManager) GetIPFSNode() (interface{}, error) {
 	return coreAPI, nil
 }
 
+// coreAPI returns the CoreAPI of the embedded node, or one bridged over the daemon's shell
+func (m *IPFSManager) coreAPI() (icore.CoreAPI, error) {
+	if m.Mode == EmbeddedIPFS {
+		if m.embedded == nil {
+			return nil, fmt.Errorf("embedded IPFS node is not running")
+		}
+		return m.embedded.api, nil
+	}
+
+	if m.Shell == nil {
+		return nil, fmt.Errorf("IPFS shell is not initialized")
+	}
+	return &IPFSCoreAPI{shell: m.Shell}, nil
+}
+
 func (m *IPFSManager) GetGateways() []GatewayStatus {
 	m.gatewayLock.RLock()
 	defer m.gatewayLock.RUnlock()
@@ -6,10 +6,11 @@ import (
 	"io"
 	"strings"
 
-	"github.com/ipfs/boxo/files"
 	"github.com/ipfs/go-cid"
 	shell "github.com/ipfs/go-ipfs-api"
 	format "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-libipfs/files"
+	ipfspath "github.com/ipfs/go-path"
 	icore "github.com/ipfs/interface-go-ipfs-core"
 	"github.com/ipfs/interface-go-ipfs-core/options"
 	"github.com/ipfs/interface-go-ipfs-core/path"
@@ -279,22 +280,22 @@ func (api *IPFSCoreAPI) Pin() icore.PinAPI {
 
 // Name returns the NameAPI interface
 func (api *IPFSCoreAPI) Name() icore.NameAPI {
-	return nil // Returning nil for now, will fix in follow-up PR
+	return &NameAPI{shell: api.shell}
 }
 
 // Object returns the ObjectAPI interface
 func (api *IPFSCoreAPI) Object() icore.ObjectAPI {
-	return nil // Returning nil for now, will fix in follow-up PR
+	return &ObjectAPI{shell: api.shell, api: api}
 }
 
 // Swarm returns the SwarmAPI interface
 func (api *IPFSCoreAPI) Swarm() icore.SwarmAPI {
-	return nil // Returning nil for now, will fix in follow-up PR
+	return &SwarmAPI{shell: api.shell}
 }
 
 // PubSub returns the PubSubAPI interface
 func (api *IPFSCoreAPI) PubSub() icore.PubSubAPI {
-	return nil // Returning nil for now, will fix in follow-up PR
+	return &PubSubAPI{shell: api.shell}
 }
 
 // Key returns the KeyAPI interface
@@ -322,24 +323,79 @@ func (api *KeyAPI) Self(ctx context.Context) (icore.Key, error) {
 	}
 
 	// Return the ID as a Key
-	return &Key{id: peerID}, nil
+	return &Key{name: "self", id: peerID}, nil
 }
 
-// Stub implementations for the rest of the KeyAPI interface
+// Generate creates a new key in the daemon's keystore
 func (api *KeyAPI) Generate(ctx context.Context, name string, opts ...options.KeyGenerateOption) (icore.Key, error) {
-	return nil, fmt.Errorf("not implemented")
+	settings, err := options.KeyGenerateOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	keyOpts := []shell.KeyOpt{shell.KeyGen.Type(settings.Algorithm)}
+	if settings.Size > 0 {
+		keyOpts = append(keyOpts, shell.KeyGen.Size(settings.Size))
+	}
+
+	key, err := api.shell.KeyGen(ctx, name, keyOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	return newKey(key.Name, key.Id)
 }
 
+// List lists the keys in the daemon's keystore
 func (api *KeyAPI) List(ctx context.Context) ([]icore.Key, error) {
-	return nil, fmt.Errorf("not implemented")
+	keys, err := api.shell.KeyList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	result := make([]icore.Key, 0, len(keys))
+	for _, k := range keys {
+		key, err := newKey(k.Name, k.Id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+	}
+
+	return result, nil
 }
 
+// Rename renames a key in the daemon's keystore
 func (api *KeyAPI) Rename(ctx context.Context, oldName string, newName string, opts ...options.KeyRenameOption) (icore.Key, bool, error) {
-	return nil, false, fmt.Errorf("not implemented")
+	settings, err := options.KeyRenameOptions(opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	out, err := api.shell.KeyRename(ctx, oldName, newName, settings.Force)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to rename key: %w", err)
+	}
+
+	key, err := newKey(out.Now, out.Id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return key, out.Overwrite, nil
 }
 
+// Remove deletes a key from the daemon's keystore
 func (api *KeyAPI) Remove(ctx context.Context, name string) (icore.Key, error) {
-	return nil, fmt.Errorf("not implemented")
+	keys, err := api.shell.KeyRm(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove key: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("key %s was not removed", name)
+	}
+
+	return newKey(keys[0].Name, keys[0].Id)
 }
 
 func (api *KeyAPI) Import(ctx context.Context, name string, pem []byte, password string) (icore.Key, error) {
@@ -348,11 +404,24 @@ func (api *KeyAPI) Import(ctx context.Context, name string, pem []byte, password
 
 // Key implements the Key interface
 type Key struct {
-	id peer.ID
+	name string
+	id   peer.ID
+}
+
+// newKey creates a Key from the name and ID returned by the daemon
+func newKey(name, id string) (*Key, error) {
+	peerID, err := peer.Decode(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key ID %s: %w", id, err)
+	}
+	return &Key{name: name, id: peerID}, nil
 }
 
 func (k *Key) Name() string {
-	return "self"
+	if k.name == "" {
+		return "self"
+	}
+	return k.name
 }
 
 func (k *Key) Path() path.Path {
@@ -365,22 +434,22 @@ func (k *Key) ID() peer.ID {
 
 // Routing returns the RoutingAPI interface
 func (api *IPFSCoreAPI) Routing() icore.RoutingAPI {
-	return nil // Returning nil for now, will fix in follow-up PR
+	return &RoutingAPI{shell: api.shell}
 }
 
 // Unixfs returns the UnixfsAPI interface
 func (api *IPFSCoreAPI) Unixfs() icore.UnixfsAPI {
-	return nil // Returning nil for now, will fix in follow-up PR
+	return &UnixfsAPI{shell: api.shell}
 }
 
 // Files returns the FilesAPI interface
 func (api *IPFSCoreAPI) Files() icore.UnixfsAPI {
-	return nil // Returning nil for now, will fix in follow-up PR
+	return api.Unixfs()
 }
 
 // Dht returns the DhtAPI interface
 func (api *IPFSCoreAPI) Dht() icore.DhtAPI {
-	return nil // Returning nil for now, will fix in follow-up PR
+	return &DhtAPI{shell: api.shell, api: api}
 }
 
 // Add imports the data from the reader into IPFS
@@ -426,12 +495,36 @@ func (api *IPFSCoreAPI) ResolvePath(ctx context.Context, p path.Path) (path.Reso
 		cidStr = cidStr[5:]
 	}
 
-	c, err := cid.Decode(cidStr)
+	if c, err := cid.Decode(cidStr); err == nil {
+		return path.IpfsPath(c), nil
+	}
+
+	// Paths into a DAG and IPNS names are resolved by the daemon
+	var out struct {
+		Cid struct {
+			Cid string `json:"/"`
+		}
+		RemPath string
+	}
+	if err := api.shell.Request("dag/resolve", p.String()).Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", p, err)
+	}
+
+	c, err := cid.Decode(out.Cid.Cid)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid CID %s: %w", out.Cid.Cid, err)
+	}
+
+	// The root is only known for /ipfs/ paths, an IPNS name resolves straight to c
+	ipath := ipfspath.Path(p.String())
+	root := c
+	if p.Namespace() == "ipfs" {
+		if rootCid, _, err := ipfspath.SplitAbsPath(ipath); err == nil {
+			root = rootCid
+		}
 	}
 
-	return path.IpfsPath(c), nil
+	return path.NewResolvedPath(ipath, c, root, out.RemPath), nil
 }
 
 // ResolveNode resolves the node
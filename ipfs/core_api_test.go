@@ -0,0 +1,178 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// rpcCall is a request received by the fake daemon
+type rpcCall struct {
+	command string            // Such as "object/stat"
+	args    []string          // The arg query parameters, in order
+	options map[string]string // Every other query parameter
+	body    []byte            // The file sent as a multipart body, if any
+}
+
+// newTestAPI starts a fake daemon whose RPC calls are answered by handle and
+// returns an API client for it, along with a function returning the calls so far
+func newTestAPI(t *testing.T, handle func(w http.ResponseWriter, r *http.Request, call rpcCall)) (*IPFSCoreAPI, func() []rpcCall) {
+	t.Helper()
+
+	var (
+		calls []rpcCall
+		mutex sync.Mutex
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, "/api/v0/") {
+			t.Errorf("request = %s %s, want POST /api/v0/...", r.Method, r.URL.Path)
+		}
+
+		call := rpcCall{
+			command: strings.TrimPrefix(r.URL.Path, "/api/v0/"),
+			options: make(map[string]string),
+		}
+		for key, values := range r.URL.Query() {
+			if key == "arg" {
+				call.args = values
+			} else {
+				call.options[key] = values[0]
+			}
+		}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			reader, err := r.MultipartReader()
+			if err != nil {
+				t.Errorf("failed to read multipart body: %v", err)
+			} else if part, err := reader.NextPart(); err == nil {
+				call.body, _ = io.ReadAll(part)
+			}
+		}
+		mutex.Lock()
+		calls = append(calls, call)
+		mutex.Unlock()
+
+		handle(w, r, call)
+	}))
+	t.Cleanup(server.Close)
+
+	return &IPFSCoreAPI{shell: shell.NewShell(server.URL)}, func() []rpcCall {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]rpcCall(nil), calls...)
+	}
+}
+
+// writeJSON writes each value as a line of a JSON response, the way the daemon streams output
+func writeJSON(t *testing.T, w http.ResponseWriter, values ...interface{}) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			t.Errorf("failed to encode response: %v", err)
+		}
+	}
+}
+
+// writeError answers with a daemon error
+func writeError(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]interface{}{"Message": message, "Code": 0, "Type": "error"})
+}
+
+// testCid returns a CID derived from data
+func testCid(t *testing.T, data string) cid.Cid {
+	t.Helper()
+
+	hash, err := mh.Sum([]byte(data), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatalf("failed to hash %q: %v", data, err)
+	}
+	return cid.NewCidV1(cid.DagProtobuf, hash)
+}
+
+// checkCall fails the test unless call is command with args
+func checkCall(t *testing.T, call rpcCall, command string, args ...string) {
+	t.Helper()
+
+	if call.command != command || strings.Join(call.args, " ") != strings.Join(args, " ") {
+		t.Errorf("call = %s %q, want %s %q", call.command, call.args, command, args)
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	root := testCid(t, "root")
+	leaf := testCid(t, "leaf")
+
+	tests := []struct {
+		name          string
+		path          string
+		wantResolve   bool // Whether the daemon is asked to resolve the path
+		remPath       string
+		wantCid       cid.Cid
+		wantRoot      cid.Cid
+		wantRemainder string
+	}{
+		{name: "bare CID", path: "/ipfs/" + root.String(), wantCid: root, wantRoot: root},
+		{name: "path into a DAG", path: "/ipfs/" + root.String() + "/shop/index.html", wantResolve: true, wantCid: leaf, wantRoot: root},
+		{name: "path into a node", path: "/ipfs/" + root.String() + "/items", wantResolve: true, remPath: "items", wantCid: leaf, wantRoot: root, wantRemainder: "items"},
+		{name: "IPNS name", path: "/ipns/shop.example/index.html", wantResolve: true, wantCid: leaf, wantRoot: leaf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+				writeJSON(t, w, map[string]interface{}{
+					"Cid":     map[string]string{"/": leaf.String()},
+					"RemPath": tt.remPath,
+				})
+			})
+
+			resolved, err := api.ResolvePath(context.Background(), path.New(tt.path))
+			if err != nil {
+				t.Fatalf("ResolvePath() error = %v", err)
+			}
+
+			if tt.wantResolve {
+				if len(calls()) != 1 {
+					t.Fatalf("made %d calls, want 1", len(calls()))
+				}
+				checkCall(t, calls()[0], "dag/resolve", tt.path)
+			} else if len(calls()) != 0 {
+				t.Errorf("made %d calls, want none", len(calls()))
+			}
+
+			// The resolved path still names what was asked for
+			if resolved.String() != tt.path {
+				t.Errorf("String() = %s, want %s", resolved.String(), tt.path)
+			}
+			if resolved.Cid() != tt.wantCid || resolved.Root() != tt.wantRoot || resolved.Remainder() != tt.wantRemainder {
+				t.Errorf("ResolvePath() = cid %s, root %s, remainder %q; want %s, %s, %q",
+					resolved.Cid(), resolved.Root(), resolved.Remainder(), tt.wantCid, tt.wantRoot, tt.wantRemainder)
+			}
+		})
+	}
+}
+
+func TestResolvePathError(t *testing.T) {
+	api, _ := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeError(w, "no link named \"missing\"")
+	})
+
+	_, err := api.ResolvePath(context.Background(), path.New("/ipns/shop.example/missing"))
+	if err == nil || !strings.Contains(err.Error(), "no link named") {
+		t.Fatalf("ResolvePath() error = %v, want the daemon's error", err)
+	}
+}
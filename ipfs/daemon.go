@@ -16,6 +16,14 @@ func (m *IPFSManager) Initialize() error {
 		return nil
 	}
 
+	if m.Mode == EmbeddedIPFS {
+		if err := m.initEmbeddedRepo(); err != nil {
+			return err
+		}
+		m.initialized = true
+		return nil
+	}
+
 	// Create data directory if it doesn't exist
 	if err := os.MkdirAll(m.DataPath, 0755); err != nil {
 		return fmt.Errorf("failed to create IPFS data directory: %v", err)
@@ -76,6 +84,15 @@ func (m *IPFSManager) StartDaemon() error {
 	fmt.Printf("[DEBUG] Reinitializing gateways...\n")
 	m.ReinitializeGateways()
 
+	if m.Mode == EmbeddedIPFS {
+		if err := m.startEmbedded(); err != nil {
+			m.Status = DaemonStopped
+			return err
+		}
+		m.Status = DaemonRunning
+		return nil
+	}
+
 	// Configure ports:
 	// API port (5001) - for sending commands to IPFS
 	// Gateway port (8080) - for accessing content
@@ -170,6 +187,12 @@ func (m *IPFSManager) StopDaemon() error {
 
 	m.Status = DaemonStopping
 
+	if m.Mode == EmbeddedIPFS {
+		err := m.stopEmbedded()
+		m.Status = DaemonStopped
+		return err
+	}
+
 	// Simply set Shell to nil, no need to close it
 	m.Shell = nil
 
@@ -190,6 +213,10 @@ func (m *IPFSManager) StopDaemon() error {
 }
 
 func (m *IPFSManager) InitializeExistingDaemon() error {
+	if m.Mode == EmbeddedIPFS {
+		return fmt.Errorf("the embedded IPFS node does not attach to an existing daemon")
+	}
+
 	sh := shell.NewShell("localhost:5001")
 	if sh == nil {
 		return fmt.Errorf("failed to create shell connection to IPFS daemon")
@@ -207,6 +234,10 @@ func (m *IPFSManager) InitializeExistingDaemon() error {
 }
 
 func (m *IPFSManager) IsDaemonRunning() bool {
+	if m.Mode == EmbeddedIPFS {
+		return m.Status == DaemonRunning && m.embedded != nil && m.embedded.node.IsOnline
+	}
+
 	if m.Status != DaemonRunning || m.Shell == nil {
 		return false
 	}
@@ -217,6 +248,13 @@ func (m *IPFSManager) IsDaemonRunning() bool {
 }
 
 func (m *IPFSManager) GetNodeInfo() (string, []string, error) {
+	if m.Mode == EmbeddedIPFS {
+		if !m.IsDaemonRunning() {
+			return "", nil, fmt.Errorf("embedded IPFS node is not running")
+		}
+		return m.embeddedNodeInfo()
+	}
+
 	if !m.IsDaemonRunning() || m.Shell == nil {
 		return "", nil, fmt.Errorf("IPFS daemon is not running")
 	}
@@ -230,6 +268,10 @@ func (m *IPFSManager) GetNodeInfo() (string, []string, error) {
 }
 
 func (m *IPFSManager) ConfigureGateway() error {
+	if m.Mode == EmbeddedIPFS {
+		return nil // The embedded node doesn't serve a gateway
+	}
+
 	cmd := exec.Command(m.BinaryPath, "config", "Addresses.Gateway", "/ip4/127.0.0.1/tcp/8080")
 	cmd.Env = append(os.Environ(), fmt.Sprintf("IPFS_PATH=%s", m.DataPath))
 	if output, err := cmd.CombinedOutput(); err != nil {
@@ -0,0 +1,301 @@
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-libipfs/files"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	kubo "github.com/ipfs/kubo"
+	"github.com/ipfs/kubo/config"
+	"github.com/ipfs/kubo/core"
+	"github.com/ipfs/kubo/core/coreapi"
+	"github.com/ipfs/kubo/core/corerepo"
+	"github.com/ipfs/kubo/core/node/libp2p"
+	"github.com/ipfs/kubo/plugin/loader"
+	"github.com/ipfs/kubo/repo/fsrepo"
+)
+
+// EmbeddedDataDir is the repo directory of the embedded node, kept apart from
+// the app-specific daemon's repo so both modes can be switched between
+const EmbeddedDataDir = "embedded-data"
+
+// embeddedNode is an IPFS node running inside this process
+type embeddedNode struct {
+	node   *core.IpfsNode
+	api    icore.CoreAPI
+	cancel context.CancelFunc
+}
+
+var (
+	// Plugins such as the flatfs and leveldb datastores can only be injected once per process
+	pluginsOnce sync.Once
+	pluginsErr  error
+)
+
+// loadPlugins loads kubo's built-in plugins, which opening a repo depends on
+func loadPlugins(repoPath string) error {
+	pluginsOnce.Do(func() {
+		plugins, err := loader.NewPluginLoader(filepath.Join(repoPath, "plugins"))
+		if err != nil {
+			pluginsErr = fmt.Errorf("failed to load plugins: %w", err)
+			return
+		}
+		if err := plugins.Initialize(); err != nil {
+			pluginsErr = fmt.Errorf("failed to initialize plugins: %w", err)
+			return
+		}
+		if err := plugins.Inject(); err != nil {
+			pluginsErr = fmt.Errorf("failed to inject plugins: %w", err)
+		}
+	})
+	return pluginsErr
+}
+
+// initEmbeddedRepo creates the embedded node's repo on first use
+func (m *IPFSManager) initEmbeddedRepo() error {
+	if err := os.MkdirAll(m.DataPath, 0755); err != nil {
+		return fmt.Errorf("failed to create IPFS data directory: %v", err)
+	}
+
+	if err := loadPlugins(m.DataPath); err != nil {
+		return err
+	}
+
+	if fsrepo.IsInitialized(m.DataPath) {
+		return nil
+	}
+
+	cfg, err := config.Init(io.Discard, 2048)
+	if err != nil {
+		return fmt.Errorf("failed to create IPFS config: %w", err)
+	}
+
+	// Nothing is served over HTTP, the app talks to the node directly
+	cfg.Addresses.API = config.Strings{}
+	cfg.Addresses.Gateway = config.Strings{}
+	cfg.Addresses.Swarm = []string{
+		"/ip4/0.0.0.0/tcp/4001",
+		"/ip4/0.0.0.0/tcp/8081/ws",
+		"/ip4/0.0.0.0/udp/4001/quic-v1",
+		"/ip4/0.0.0.0/udp/4001/quic-v1/webtransport",
+	}
+	cfg.Swarm.EnableHolePunching = config.True
+	cfg.Swarm.RelayClient.Enabled = config.True
+	cfg.Swarm.RelayService.Enabled = config.True
+
+	if err := fsrepo.Init(m.DataPath, cfg); err != nil {
+		return fmt.Errorf("failed to initialize IPFS repo: %w", err)
+	}
+	fmt.Printf("Initialized embedded IPFS repo at %s\n", m.DataPath)
+
+	return nil
+}
+
+// startEmbedded opens the repo and brings the in-process node online
+func (m *IPFSManager) startEmbedded() error {
+	repo, err := fsrepo.Open(m.DataPath)
+	if err != nil {
+		return fmt.Errorf("failed to open IPFS repo: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	node, err := core.NewNode(ctx, &core.BuildCfg{
+		Online:  true,
+		Routing: libp2p.DHTOption,
+		Repo:    repo,
+		ExtraOpts: map[string]bool{
+			"pubsub": true, // OrbitDB replicates over pubsub
+			"ipnsps": true,
+		},
+	})
+	if err != nil {
+		cancel()
+		repo.Close()
+		return fmt.Errorf("failed to start embedded IPFS node: %w", err)
+	}
+
+	api, err := coreapi.NewCoreAPI(node)
+	if err != nil {
+		cancel()
+		node.Close()
+		return fmt.Errorf("failed to create CoreAPI: %w", err)
+	}
+
+	m.embedded = &embeddedNode{node: node, api: api, cancel: cancel}
+	fmt.Printf("Embedded IPFS node started. Node ID: %s\n", node.Identity)
+	return nil
+}
+
+// stopEmbedded shuts down the in-process node and releases its repo
+func (m *IPFSManager) stopEmbedded() error {
+	if m.embedded == nil {
+		return nil
+	}
+
+	err := m.embedded.node.Close()
+	m.embedded.cancel()
+	m.embedded = nil
+	if err != nil {
+		return fmt.Errorf("failed to stop embedded IPFS node: %w", err)
+	}
+	return nil
+}
+
+// embeddedNodeInfo returns the node's peer ID and its addresses with the peer ID appended
+func (m *IPFSManager) embeddedNodeInfo() (string, []string, error) {
+	node := m.embedded.node
+	id := node.Identity.String()
+
+	addrs := []string{}
+	for _, addr := range node.PeerHost.Addrs() {
+		addrs = append(addrs, addr.String()+"/p2p/"+id)
+	}
+
+	return id, addrs, nil
+}
+
+// addDirectoryEmbedded adds and pins a directory, wrapped in a parent directory
+// with rootFiles like `ipfs add -r --wrap-with-directory`, and announces it to the DHT
+func (m *IPFSManager) addDirectoryEmbedded(dirPath string, rootFiles []string) (string, error) {
+	stat, err := os.Stat(dirPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to add directory %s to IPFS: %w", dirPath, err)
+	}
+
+	dir, err := files.NewSerialFile(dirPath, false, stat)
+	if err != nil {
+		return "", fmt.Errorf("failed to read directory %s: %w", dirPath, err)
+	}
+	defer dir.Close()
+
+	entries := map[string]files.Node{
+		filepath.Base(dirPath): dir,
+	}
+	for _, filePath := range rootFiles {
+		stat, err := os.Stat(filePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to add file %s to IPFS: %w", filePath, err)
+		}
+		file, err := files.NewSerialFile(filePath, false, stat)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
+		}
+		defer file.Close()
+		entries[filepath.Base(filePath)] = file
+	}
+	wrapped := files.NewMapDirectory(entries)
+
+	ctx := context.Background()
+	resolved, err := m.embedded.api.Unixfs().Add(ctx, wrapped, options.Unixfs.Pin(true))
+	if err != nil {
+		return "", fmt.Errorf("failed to add directory %s to IPFS: %w", dirPath, err)
+	}
+	hash := resolved.Cid().String()
+	fmt.Printf("Successfully added and pinned directory with hash: %s\n", hash)
+
+	fmt.Printf("[DHT] Starting mandatory DHT announcement process...\n")
+	if err := m.announceEmbedded(ctx, resolved, 5); err != nil {
+		return "", fmt.Errorf("failed to announce and verify CID in DHT: %w", err)
+	}
+	fmt.Printf("[DHT] Successfully completed mandatory DHT announcement process\n")
+
+	return hash, nil
+}
+
+// announceEmbedded provides a CID on the DHT and checks that a provider can be found
+func (m *IPFSManager) announceEmbedded(ctx context.Context, p path.Resolved, maxRetries int) error {
+	dht := m.embedded.api.Dht()
+
+	for i := 0; i < maxRetries; i++ {
+		fmt.Printf("[DHT] Attempt %d/%d: Announcing CID to DHT...\n", i+1, maxRetries)
+
+		if err := dht.Provide(ctx, p); err != nil {
+			fmt.Printf("[DHT] Warning: Failed to announce CID %s (attempt %d/%d): %v\n",
+				p.Cid(), i+1, maxRetries, err)
+			time.Sleep(time.Second * 5)
+			continue
+		}
+
+		findCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		providers, err := dht.FindProviders(findCtx, p, options.Dht.NumProviders(1))
+		if err != nil {
+			cancel()
+			fmt.Printf("[DHT] Warning: Failed to verify CID providers %s (attempt %d/%d): %v\n",
+				p.Cid(), i+1, maxRetries, err)
+			time.Sleep(time.Second * 5)
+			continue
+		}
+
+		found := false
+		for provider := range providers {
+			fmt.Printf("[DHT] Found provider: %s\n", provider.ID)
+			found = true
+		}
+		cancel()
+
+		if found {
+			fmt.Printf("[DHT] Successfully verified CID %s is being provided by peers\n", p.Cid())
+			return nil
+		}
+
+		fmt.Printf("[DHT] No providers found for CID %s (attempt %d/%d), retrying...\n",
+			p.Cid(), i+1, maxRetries)
+		time.Sleep(time.Second * 5)
+	}
+
+	return fmt.Errorf("failed to verify CID %s is being provided after %d attempts", p.Cid(), maxRetries)
+}
+
+// addFileEmbedded adds and pins a single file
+func (m *IPFSManager) addFileEmbedded(file *os.File) (string, error) {
+	resolved, err := m.embedded.api.Unixfs().Add(context.Background(), files.NewReaderFile(file), options.Unixfs.Pin(true))
+	if err != nil {
+		return "", err
+	}
+	return resolved.Cid().String(), nil
+}
+
+// listPinsEmbedded returns the CIDs of all recursive pins
+func (m *IPFSManager) listPinsEmbedded() ([]string, error) {
+	pins, err := m.embedded.api.Pin().Ls(context.Background(), options.Pin.Ls.Recursive())
+	if err != nil {
+		return nil, err
+	}
+
+	cids := []string{}
+	for pin := range pins {
+		if err := pin.Err(); err != nil {
+			return nil, err
+		}
+		cids = append(cids, pin.Path().Cid().String())
+	}
+	return cids, nil
+}
+
+// unpinEmbedded removes a recursive pin
+func (m *IPFSManager) unpinEmbedded(hash string) error {
+	c, err := cid.Decode(hash)
+	if err != nil {
+		return fmt.Errorf("invalid CID %s: %w", hash, err)
+	}
+	return m.embedded.api.Pin().Rm(context.Background(), path.IpfsPath(c))
+}
+
+// garbageCollectEmbedded removes unpinned blocks from the repo
+func (m *IPFSManager) garbageCollectEmbedded() error {
+	return corerepo.GarbageCollect(m.embedded.node, context.Background())
+}
+
+// embeddedVersion returns the version of kubo the embedded node is built from
+func embeddedVersion() string {
+	return kubo.CurrentVersionNumber + " (embedded)"
+}
@@ -14,6 +14,21 @@ import (
 )
 
 func NewIPFSManager(config *Config) (*IPFSManager, error) {
+	mode := IPFSMode("")
+	if config != nil {
+		mode = config.Mode
+	}
+
+	switch mode {
+	case "", SystemIPFS:
+	case AppSpecificIPFS:
+		return newAppSpecificManager(config)
+	case EmbeddedIPFS:
+		return newEmbeddedManager(config)
+	default:
+		return nil, fmt.Errorf("unknown IPFS mode: %s", mode)
+	}
+
 	// Try to find system IPFS first
 	systemPath, err := exec.LookPath("ipfs")
 	if err == nil {
@@ -44,8 +59,17 @@ func NewIPFSManager(config *Config) (*IPFSManager, error) {
 		fmt.Printf("No system IPFS found: %v\n", err)
 	}
 
+	if mode == SystemIPFS {
+		return nil, fmt.Errorf("no compatible system IPFS found (need %s or later)", MinCompatibleVersion)
+	}
+
 	// If system IPFS not found or not compatible, set up app-specific IPFS
 	fmt.Printf("Falling back to app-specific IPFS\n")
+	return newAppSpecificManager(config)
+}
+
+// newAppSpecificManager uses the kubo binary IndieNode downloads into ~/indie_node_ipfs
+func newAppSpecificManager(config *Config) (*IPFSManager, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
@@ -93,6 +117,29 @@ func NewIPFSManager(config *Config) (*IPFSManager, error) {
 	return manager, nil
 }
 
+// newEmbeddedManager runs IPFS inside the process, so no binary is downloaded
+// and nothing listens on the API port
+func newEmbeddedManager(config *Config) (*IPFSManager, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	manager := &IPFSManager{
+		DataPath: filepath.Join(homeDir, "indie_node_ipfs", EmbeddedDataDir),
+		Mode:     EmbeddedIPFS,
+		gateways: make([]GatewayStatus, 0),
+	}
+
+	if config != nil {
+		for _, gateway := range config.CustomGateways {
+			manager.AddCustomGateway(gateway)
+		}
+	}
+
+	return manager, nil
+}
+
 func detectIPFSPath(binaryPath string) (string, error) {
 	if envPath := os.Getenv("IPFS_PATH"); envPath != "" {
 		fmt.Printf("Using IPFS_PATH from environment: %s\n", envPath)
@@ -123,6 +170,9 @@ func detectIPFSPath(binaryPath string) (string, error) {
 }
 
 func (m *IPFSManager) EnsureInstalled() error {
+	if m.Mode == EmbeddedIPFS {
+		return nil // Nothing to download
+	}
 	if _, err := os.Stat(m.BinaryPath); os.IsNotExist(err) {
 		return m.downloadIPFS()
 	}
@@ -130,6 +180,10 @@ func (m *IPFSManager) EnsureInstalled() error {
 }
 
 func (m *IPFSManager) GetIPFSVersion() (string, error) {
+	if m.Mode == EmbeddedIPFS {
+		return embeddedVersion(), nil
+	}
+
 	// Try to get the IPFS path
 	ipfsPath, err := exec.LookPath("ipfs")
 	if err != nil {
@@ -151,6 +205,10 @@ func (m *IPFSManager) GetIPFSVersion() (string, error) {
 }
 
 func (m *IPFSManager) IsIPFSDownloaded() (bool, string) {
+	if m.Mode == EmbeddedIPFS {
+		return true, embeddedVersion()
+	}
+
 	// Check if binary actually exists at the path
 	if _, err := os.Stat(m.BinaryPath); err == nil {
 		version, err := m.GetIPFSVersion()
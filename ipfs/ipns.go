@@ -0,0 +1,131 @@
+package ipfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+const (
+	// shopKeyPrefix namespaces shop keys in the daemon's keystore
+	shopKeyPrefix = "indienode-shop-"
+
+	// ipnsLifetime is how long a published record stays valid; the daemon republishes it before then
+	ipnsLifetime = 48 * time.Hour
+)
+
+var shopKeyInvalidChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// ShopKeyName returns the keystore name used for a shop's IPNS key. A short
+// hash of the directory name keeps names that only differ in dropped
+// characters, such as "Shop!" and "Shop", from sharing a key.
+func ShopKeyName(shopDirName string) string {
+	sum := sha256.Sum256([]byte(shopDirName))
+	return legacyShopKeyName(shopDirName) + "-" + hex.EncodeToString(sum[:4])
+}
+
+// legacyShopKeyName returns the keystore name shop keys had before ShopKeyName
+// added the hash
+func legacyShopKeyName(shopDirName string) string {
+	name := strings.ToLower(strings.ReplaceAll(shopDirName, " ", "-"))
+	name = shopKeyInvalidChars.ReplaceAllString(name, "")
+	return shopKeyPrefix + name
+}
+
+// EnsureShopKey returns the IPNS name of a shop's key, generating the key if the
+// shop doesn't have one yet. The name is in the same form PublishIPNS returns.
+func (m *IPFSManager) EnsureShopKey(ctx context.Context, shopDirName string) (string, error) {
+	api, err := m.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	keyAPI := api.Key()
+	keyName := ShopKeyName(shopDirName)
+
+	// Reuse the existing key so the IPNS name never changes
+	keys, err := keyAPI.List(ctx)
+	if err != nil {
+		return "", err
+	}
+	legacyName := legacyShopKeyName(shopDirName)
+	hasLegacy := false
+	for _, key := range keys {
+		if key.Name() == keyName {
+			return icore.FormatKey(key), nil
+		}
+		hasLegacy = hasLegacy || key.Name() == legacyName
+	}
+
+	// A key from before the name had a hash is renamed so the shop keeps its
+	// IPNS name; a name without any valid characters was never unique
+	if hasLegacy && legacyName != shopKeyPrefix {
+		key, _, err := keyAPI.Rename(ctx, legacyName, keyName)
+		if err != nil {
+			return "", err
+		}
+		fmt.Printf("Renamed IPNS key %s to %s\n", legacyName, keyName)
+		return icore.FormatKey(key), nil
+	}
+
+	key, err := keyAPI.Generate(ctx, keyName, options.Key.Type(options.Ed25519Key))
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("Generated IPNS key %s for shop %s\n", keyName, shopDirName)
+
+	return icore.FormatKey(key), nil
+}
+
+// PublishIPNS points a shop's IPNS name at hash and returns the name
+func (m *IPFSManager) PublishIPNS(ctx context.Context, shopDirName string, hash string) (string, error) {
+	c, err := cid.Decode(hash)
+	if err != nil {
+		return "", fmt.Errorf("invalid CID %s: %w", hash, err)
+	}
+
+	if _, err := m.EnsureShopKey(ctx, shopDirName); err != nil {
+		return "", fmt.Errorf("failed to get shop key: %w", err)
+	}
+
+	api, err := m.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := api.Name().Publish(ctx, path.IpfsPath(c),
+		options.Name.Key(ShopKeyName(shopDirName)),
+		options.Name.ValidTime(ipnsLifetime),
+		options.Name.AllowOffline(true),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Published /ipns/%s -> %s\n", entry.Name(), entry.Value())
+	return entry.Name(), nil
+}
+
+// ResolveIPNS returns the path a shop's IPNS name currently points to
+func (m *IPFSManager) ResolveIPNS(ctx context.Context, name string) (string, error) {
+	api, err := m.coreAPI()
+	if err != nil {
+		return "", err
+	}
+
+	p, err := api.Name().Resolve(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	return p.String(), nil
+}
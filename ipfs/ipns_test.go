@@ -0,0 +1,111 @@
+package ipfs
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/libp2p/go-libp2p/core/test"
+)
+
+func TestShopKeyName(t *testing.T) {
+	valid := regexp.MustCompile(`^indienode-shop-[a-z0-9-]*-[0-9a-f]{8}$`)
+
+	names := []string{"My Shop", "my-shop", "Shop", "Shop!", "Shop?", "!!!", "???", "Café"}
+	seen := make(map[string]string)
+	for _, dirName := range names {
+		name := ShopKeyName(dirName)
+		if !valid.MatchString(name) {
+			t.Errorf("ShopKeyName(%q) = %s, not a valid key name", dirName, name)
+		}
+		if name != ShopKeyName(dirName) {
+			t.Errorf("ShopKeyName(%q) isn't stable", dirName)
+		}
+		if other, ok := seen[name]; ok {
+			t.Errorf("ShopKeyName(%q) = ShopKeyName(%q) = %s", dirName, other, name)
+		}
+		seen[name] = dirName
+	}
+}
+
+func TestEnsureShopKey(t *testing.T) {
+	existing := test.RandPeerIDFatal(t)
+	generated := test.RandPeerIDFatal(t)
+	keyName := ShopKeyName("My Shop")
+
+	tests := []struct {
+		name      string
+		dirName   string
+		keys      []map[string]string
+		wantName  string
+		wantCalls []string // After key/list
+	}{
+		{
+			name:     "existing key",
+			dirName:  "My Shop",
+			keys:     []map[string]string{{"Name": "self", "Id": generated.String()}, {"Name": keyName, "Id": existing.String()}},
+			wantName: icore.FormatKeyID(existing),
+		},
+		{
+			name:      "key without a hash",
+			dirName:   "My Shop",
+			keys:      []map[string]string{{"Name": "indienode-shop-my-shop", "Id": existing.String()}},
+			wantName:  icore.FormatKeyID(existing),
+			wantCalls: []string{"key/rename indienode-shop-my-shop " + keyName},
+		},
+		{
+			name:      "new key",
+			dirName:   "My Shop",
+			keys:      []map[string]string{{"Name": "indienode-shop-other", "Id": existing.String()}},
+			wantName:  icore.FormatKeyID(generated),
+			wantCalls: []string{"key/gen " + keyName},
+		},
+		{
+			name:      "name without valid characters",
+			dirName:   "!!!",
+			keys:      []map[string]string{{"Name": "indienode-shop-", "Id": existing.String()}},
+			wantName:  icore.FormatKeyID(generated),
+			wantCalls: []string{"key/gen " + ShopKeyName("!!!")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+				switch call.command {
+				case "key/list":
+					writeJSON(t, w, map[string]interface{}{"Keys": tt.keys})
+				case "key/rename":
+					writeJSON(t, w, map[string]interface{}{"Was": call.args[0], "Now": call.args[1], "Id": existing.String()})
+				default:
+					writeJSON(t, w, map[string]string{"Name": call.args[0], "Id": generated.String()})
+				}
+			})
+			m := &IPFSManager{Mode: SystemIPFS, Shell: api.shell}
+
+			name, err := m.EnsureShopKey(context.Background(), tt.dirName)
+			if err != nil {
+				t.Fatalf("EnsureShopKey() error = %v", err)
+			}
+
+			// The same form of the name that name/publish returns
+			if name != tt.wantName {
+				t.Errorf("EnsureShopKey() = %s, want %s", name, tt.wantName)
+			}
+			got := calls()
+			if len(got) != len(tt.wantCalls)+1 {
+				t.Fatalf("made %d calls, want %d", len(got), len(tt.wantCalls)+1)
+			}
+			for i, want := range tt.wantCalls {
+				command, args, _ := strings.Cut(want, " ")
+				checkCall(t, got[i+1], command, strings.Split(args, " ")...)
+				if command == "key/gen" && got[i+1].options["type"] != "ed25519" {
+					t.Errorf("key type = %q, want ed25519", got[i+1].options["type"])
+				}
+			}
+		})
+	}
+}
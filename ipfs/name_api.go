@@ -0,0 +1,91 @@
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	shell "github.com/ipfs/go-ipfs-api"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// NameAPI implements the NameAPI interface over the daemon's name/publish and name/resolve commands
+type NameAPI struct {
+	shell *shell.Shell
+}
+
+// IpnsEntry implements the IpnsEntry interface
+type IpnsEntry struct {
+	name  string
+	value path.Path
+}
+
+// Name returns the IPNS name the entry was published under
+func (e *IpnsEntry) Name() string {
+	return e.name
+}
+
+// Value returns the path the IPNS name points to
+func (e *IpnsEntry) Value() path.Path {
+	return e.value
+}
+
+// Publish points the IPNS name of the configured key at p
+func (api *NameAPI) Publish(ctx context.Context, p path.Path, opts ...options.NamePublishOption) (icore.IpnsEntry, error) {
+	settings, err := options.NamePublishOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.shell.Request("name/publish", p.String()).
+		Option("key", settings.Key).
+		Option("lifetime", settings.ValidTime.String()).
+		Option("allow-offline", settings.AllowOffline).
+		Option("resolve", false)
+	if settings.TTL != nil {
+		req.Option("ttl", settings.TTL.String())
+	}
+
+	var out struct {
+		Name  string
+		Value string
+	}
+	if err := req.Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to publish IPNS record: %w", err)
+	}
+
+	return &IpnsEntry{name: out.Name, value: path.New(out.Value)}, nil
+}
+
+// Resolve returns the path an IPNS name currently points to
+func (api *NameAPI) Resolve(ctx context.Context, name string, opts ...options.NameResolveOption) (path.Path, error) {
+	settings, err := options.NameResolveOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.shell.Request("name/resolve", strings.TrimPrefix(name, "/ipns/")).
+		Option("nocache", !settings.Cache)
+
+	var out struct {
+		Path string
+	}
+	if err := req.Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("%w: %v", icore.ErrResolveFailed, err)
+	}
+
+	return path.New(out.Path), nil
+}
+
+// Search resolves a name and delivers the result on a channel
+func (api *NameAPI) Search(ctx context.Context, name string, opts ...options.NameResolveOption) (<-chan icore.IpnsResult, error) {
+	ch := make(chan icore.IpnsResult, 1)
+	go func() {
+		defer close(ch)
+		p, err := api.Resolve(ctx, name, opts...)
+		ch <- icore.IpnsResult{Path: p, Err: err}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,258 @@
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	shell "github.com/ipfs/go-ipfs-api"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-libipfs/files"
+	"github.com/ipfs/go-merkledag"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// ObjectAPI implements the ObjectAPI interface over the daemon's object commands
+type ObjectAPI struct {
+	shell *shell.Shell
+	api   *IPFSCoreAPI
+}
+
+// New creates an empty dag-pb node, or an empty unixfs directory
+func (api *ObjectAPI) New(ctx context.Context, opts ...options.ObjectNewOption) (format.Node, error) {
+	settings, err := options.ObjectNewOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.shell.Request("object/new")
+	if settings.Type == "unixfs-dir" {
+		req.Arguments(settings.Type)
+	}
+
+	rp, err := api.execHash(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create object: %w", err)
+	}
+	return api.Get(ctx, rp)
+}
+
+// Put stores a node read from src, encoded as JSON or protobuf
+func (api *ObjectAPI) Put(ctx context.Context, src io.Reader, opts ...options.ObjectPutOption) (path.Resolved, error) {
+	settings, err := options.ObjectPutOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.shell.Request("object/put").
+		Option("inputenc", settings.InputEnc).
+		Option("datafieldenc", settings.DataType).
+		Option("pin", settings.Pin)
+
+	rp, err := api.execHash(ctx, withFileBody(req, files.NewReaderFile(src)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to put object: %w", err)
+	}
+	return rp, nil
+}
+
+// Get returns the dag-pb node at p
+func (api *ObjectAPI) Get(ctx context.Context, p path.Path) (format.Node, error) {
+	rp, err := api.api.ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// The raw block is needed here, cat would return the unixfs file contents
+	output, err := send(ctx, api.shell.Request("block/get", rp.Cid().String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %w", rp.Cid(), err)
+	}
+	defer output.Close()
+
+	raw, err := io.ReadAll(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", rp.Cid(), err)
+	}
+
+	block, err := blocks.NewBlockWithCid(raw, rp.Cid())
+	if err != nil {
+		return nil, err
+	}
+	return merkledag.DecodeProtobufBlock(block)
+}
+
+// Data returns the data field of the node at p
+func (api *ObjectAPI) Data(ctx context.Context, p path.Path) (io.Reader, error) {
+	output, err := send(ctx, api.shell.Request("object/data", p.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data of %s: %w", p, err)
+	}
+	defer output.Close()
+
+	// Read it all so the response isn't left open for the caller to close
+	data, err := io.ReadAll(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data of %s: %w", p, err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// Links returns the links of the node at p
+func (api *ObjectAPI) Links(ctx context.Context, p path.Path) ([]*format.Link, error) {
+	var out struct {
+		Links []struct {
+			Name string
+			Hash string
+			Size uint64
+		}
+	}
+	if err := api.shell.Request("object/links", p.String()).Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to get links of %s: %w", p, err)
+	}
+
+	links := make([]*format.Link, 0, len(out.Links))
+	for _, l := range out.Links {
+		c, err := cid.Decode(l.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CID %s: %w", l.Hash, err)
+		}
+		links = append(links, &format.Link{Name: l.Name, Size: l.Size, Cid: c})
+	}
+	return links, nil
+}
+
+// Stat returns size information about the node at p
+func (api *ObjectAPI) Stat(ctx context.Context, p path.Path) (*icore.ObjectStat, error) {
+	var out struct {
+		Hash           string
+		NumLinks       int
+		BlockSize      int
+		LinksSize      int
+		DataSize       int
+		CumulativeSize int
+	}
+	if err := api.shell.Request("object/stat", p.String()).Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", p, err)
+	}
+
+	c, err := cid.Decode(out.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CID %s: %w", out.Hash, err)
+	}
+
+	return &icore.ObjectStat{
+		Cid:            c,
+		NumLinks:       out.NumLinks,
+		BlockSize:      out.BlockSize,
+		LinksSize:      out.LinksSize,
+		DataSize:       out.DataSize,
+		CumulativeSize: out.CumulativeSize,
+	}, nil
+}
+
+// AddLink returns a copy of base with a link named name pointing at child
+func (api *ObjectAPI) AddLink(ctx context.Context, base path.Path, name string, child path.Path, opts ...options.ObjectAddLinkOption) (path.Resolved, error) {
+	settings, err := options.ObjectAddLinkOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.shell.Request("object/patch/add-link", base.String(), name, child.String()).
+		Option("create", settings.Create)
+
+	rp, err := api.execHash(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add link %s: %w", name, err)
+	}
+	return rp, nil
+}
+
+// RmLink returns a copy of base without the link named link
+func (api *ObjectAPI) RmLink(ctx context.Context, base path.Path, link string) (path.Resolved, error) {
+	rp, err := api.execHash(ctx, api.shell.Request("object/patch/rm-link", base.String(), link))
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove link %s: %w", link, err)
+	}
+	return rp, nil
+}
+
+// AppendData returns a copy of the node at p with r appended to its data
+func (api *ObjectAPI) AppendData(ctx context.Context, p path.Path, r io.Reader) (path.Resolved, error) {
+	req := api.shell.Request("object/patch/append-data", p.String())
+	rp, err := api.execHash(ctx, withFileBody(req, files.NewReaderFile(r)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to append data to %s: %w", p, err)
+	}
+	return rp, nil
+}
+
+// SetData returns a copy of the node at p with its data replaced by r
+func (api *ObjectAPI) SetData(ctx context.Context, p path.Path, r io.Reader) (path.Resolved, error) {
+	req := api.shell.Request("object/patch/set-data", p.String())
+	rp, err := api.execHash(ctx, withFileBody(req, files.NewReaderFile(r)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set data of %s: %w", p, err)
+	}
+	return rp, nil
+}
+
+// Diff lists the changes between two nodes
+func (api *ObjectAPI) Diff(ctx context.Context, a path.Path, b path.Path) ([]icore.ObjectChange, error) {
+	type link struct {
+		Cid string `json:"/"`
+	}
+	var out struct {
+		Changes []struct {
+			Type   icore.ChangeType
+			Path   string
+			Before *link
+			After  *link
+		}
+	}
+	if err := api.shell.Request("object/diff", a.String(), b.String()).Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to diff %s and %s: %w", a, b, err)
+	}
+
+	changes := make([]icore.ObjectChange, 0, len(out.Changes))
+	for _, ch := range out.Changes {
+		change := icore.ObjectChange{Type: ch.Type, Path: ch.Path}
+		if ch.Before != nil {
+			c, err := cid.Decode(ch.Before.Cid)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CID %s: %w", ch.Before.Cid, err)
+			}
+			change.Before = path.IpfsPath(c)
+		}
+		if ch.After != nil {
+			c, err := cid.Decode(ch.After.Cid)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CID %s: %w", ch.After.Cid, err)
+			}
+			change.After = path.IpfsPath(c)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// execHash sends an object command that responds with the hash of a node
+func (api *ObjectAPI) execHash(ctx context.Context, req *shell.RequestBuilder) (path.Resolved, error) {
+	var out struct {
+		Hash string
+	}
+	if err := req.Exec(ctx, &out); err != nil {
+		return nil, err
+	}
+
+	c, err := cid.Decode(out.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CID %s: %w", out.Hash, err)
+	}
+	return path.IpfsPath(c), nil
+}
@@ -0,0 +1,241 @@
+package ipfs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+func TestObjectGet(t *testing.T) {
+	child := testCid(t, "child")
+	node := merkledag.NodeWithData([]byte("shop data"))
+	if err := node.AddRawLink("items", &format.Link{Cid: child, Size: 10}); err != nil {
+		t.Fatalf("failed to add link: %v", err)
+	}
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		w.Write(node.RawData())
+	})
+
+	got, err := api.Object().Get(context.Background(), path.IpfsPath(node.Cid()))
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	checkCall(t, calls()[0], "block/get", node.Cid().String())
+	protoNode, ok := got.(*merkledag.ProtoNode)
+	if !ok {
+		t.Fatalf("Get() = %T, want a dag-pb node", got)
+	}
+	if string(protoNode.Data()) != "shop data" || len(protoNode.Links()) != 1 || protoNode.Links()[0].Cid != child {
+		t.Errorf("Get() = %+v", protoNode)
+	}
+}
+
+func TestObjectGetCorruptBlock(t *testing.T) {
+	node := merkledag.NodeWithData([]byte("shop data"))
+	api, _ := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		w.Write([]byte("not the block that was asked for"))
+	})
+
+	if _, err := api.Object().Get(context.Background(), path.IpfsPath(node.Cid())); err == nil {
+		t.Fatalf("Get() of a block that doesn't match its CID succeeded")
+	}
+}
+
+func TestObjectData(t *testing.T) {
+	c := testCid(t, "node")
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		w.Write([]byte("shop data"))
+	})
+
+	r, err := api.Object().Data(context.Background(), path.IpfsPath(c))
+	if err != nil {
+		t.Fatalf("Data() error = %v", err)
+	}
+	data, _ := io.ReadAll(r)
+
+	checkCall(t, calls()[0], "object/data", "/ipfs/"+c.String())
+	if string(data) != "shop data" {
+		t.Errorf("Data() = %q", data)
+	}
+}
+
+func TestObjectLinks(t *testing.T) {
+	c := testCid(t, "node")
+	child := testCid(t, "child")
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, map[string]interface{}{
+			"Hash": c.String(),
+			"Links": []map[string]interface{}{
+				{"Name": "index.html", "Hash": child.String(), "Size": 120},
+			},
+		})
+	})
+
+	links, err := api.Object().Links(context.Background(), path.IpfsPath(c))
+	if err != nil {
+		t.Fatalf("Links() error = %v", err)
+	}
+
+	checkCall(t, calls()[0], "object/links", "/ipfs/"+c.String())
+	if len(links) != 1 || links[0].Name != "index.html" || links[0].Cid != child || links[0].Size != 120 {
+		t.Errorf("Links() = %+v", links)
+	}
+}
+
+func TestObjectStat(t *testing.T) {
+	c := testCid(t, "node")
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, map[string]interface{}{
+			"Hash":           c.String(),
+			"NumLinks":       2,
+			"BlockSize":      90,
+			"LinksSize":      80,
+			"DataSize":       10,
+			"CumulativeSize": 2048,
+		})
+	})
+
+	stat, err := api.Object().Stat(context.Background(), path.IpfsPath(c))
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+
+	checkCall(t, calls()[0], "object/stat", "/ipfs/"+c.String())
+	want := icore.ObjectStat{Cid: c, NumLinks: 2, BlockSize: 90, LinksSize: 80, DataSize: 10, CumulativeSize: 2048}
+	if *stat != want {
+		t.Errorf("Stat() = %+v, want %+v", *stat, want)
+	}
+}
+
+func TestObjectPatch(t *testing.T) {
+	base := testCid(t, "base")
+	child := testCid(t, "child")
+	patched := testCid(t, "patched")
+
+	tests := []struct {
+		name        string
+		patch       func(icore.ObjectAPI) (path.Resolved, error)
+		wantCommand string
+		wantArgs    []string
+		wantOptions map[string]string
+		wantBody    string
+	}{
+		{
+			name: "add link",
+			patch: func(api icore.ObjectAPI) (path.Resolved, error) {
+				return api.AddLink(context.Background(), path.IpfsPath(base), "shop/index.html", path.IpfsPath(child), options.Object.Create(true))
+			},
+			wantCommand: "object/patch/add-link",
+			wantArgs:    []string{"/ipfs/" + base.String(), "shop/index.html", "/ipfs/" + child.String()},
+			wantOptions: map[string]string{"create": "true"},
+		},
+		{
+			name: "remove link",
+			patch: func(api icore.ObjectAPI) (path.Resolved, error) {
+				return api.RmLink(context.Background(), path.IpfsPath(base), "index.html")
+			},
+			wantCommand: "object/patch/rm-link",
+			wantArgs:    []string{"/ipfs/" + base.String(), "index.html"},
+		},
+		{
+			name: "append data",
+			patch: func(api icore.ObjectAPI) (path.Resolved, error) {
+				return api.AppendData(context.Background(), path.IpfsPath(base), strings.NewReader("more"))
+			},
+			wantCommand: "object/patch/append-data",
+			wantArgs:    []string{"/ipfs/" + base.String()},
+			wantBody:    "more",
+		},
+		{
+			name: "set data",
+			patch: func(api icore.ObjectAPI) (path.Resolved, error) {
+				return api.SetData(context.Background(), path.IpfsPath(base), strings.NewReader("replaced"))
+			},
+			wantCommand: "object/patch/set-data",
+			wantArgs:    []string{"/ipfs/" + base.String()},
+			wantBody:    "replaced",
+		},
+		{
+			name: "put",
+			patch: func(api icore.ObjectAPI) (path.Resolved, error) {
+				return api.Put(context.Background(), strings.NewReader(`{"Data":"shop"}`), options.Object.DataType("text"), options.Object.Pin(true))
+			},
+			wantCommand: "object/put",
+			wantOptions: map[string]string{"inputenc": "json", "datafieldenc": "text", "pin": "true"},
+			wantBody:    `{"Data":"shop"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+				writeJSON(t, w, map[string]string{"Hash": patched.String()})
+			})
+
+			got, err := tt.patch(api.Object())
+			if err != nil {
+				t.Fatalf("patch error = %v", err)
+			}
+			if got.Cid() != patched {
+				t.Errorf("patch = %s, want %s", got.Cid(), patched)
+			}
+
+			call := calls()[0]
+			checkCall(t, call, tt.wantCommand, tt.wantArgs...)
+			for key, want := range tt.wantOptions {
+				if call.options[key] != want {
+					t.Errorf("option %s = %q, want %q", key, call.options[key], want)
+				}
+			}
+			if string(call.body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", call.body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestObjectDiff(t *testing.T) {
+	a := testCid(t, "a")
+	b := testCid(t, "b")
+	before := testCid(t, "before")
+	after := testCid(t, "after")
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, map[string]interface{}{
+			"Changes": []map[string]interface{}{
+				{"Type": icore.DiffAdd, "Path": "new.html", "After": map[string]string{"/": after.String()}},
+				{"Type": icore.DiffMod, "Path": "index.html", "Before": map[string]string{"/": before.String()}, "After": map[string]string{"/": after.String()}},
+				{"Type": icore.DiffRemove, "Path": "old.html", "Before": map[string]string{"/": before.String()}},
+			},
+		})
+	})
+
+	changes, err := api.Object().Diff(context.Background(), path.IpfsPath(a), path.IpfsPath(b))
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	checkCall(t, calls()[0], "object/diff", "/ipfs/"+a.String(), "/ipfs/"+b.String())
+	if len(changes) != 3 {
+		t.Fatalf("Diff() = %+v, want 3 changes", changes)
+	}
+	if changes[0].Type != icore.DiffAdd || changes[0].Path != "new.html" || changes[0].Before != nil || changes[0].After.Cid() != after {
+		t.Errorf("added = %+v", changes[0])
+	}
+	if changes[1].Type != icore.DiffMod || changes[1].Before.Cid() != before || changes[1].After.Cid() != after {
+		t.Errorf("modified = %+v", changes[1])
+	}
+	if changes[2].Type != icore.DiffRemove || changes[2].Before.Cid() != before || changes[2].After != nil {
+		t.Errorf("removed = %+v", changes[2])
+	}
+}
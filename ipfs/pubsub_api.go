@@ -0,0 +1,230 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/ipfs/go-libipfs/files"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mbase "github.com/multiformats/go-multibase"
+)
+
+// PubSubAPI implements the PubSubAPI interface over the daemon's pubsub commands,
+// which OrbitDB uses to replicate stores between peers
+type PubSubAPI struct {
+	shell *shell.Shell
+}
+
+// Ls lists the topics this node is subscribed to
+func (api *PubSubAPI) Ls(ctx context.Context) ([]string, error) {
+	var out struct {
+		Strings []string
+	}
+	if err := api.shell.Request("pubsub/ls").Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to list pubsub topics: %w", err)
+	}
+
+	topics := make([]string, 0, len(out.Strings))
+	for _, encoded := range out.Strings {
+		topic, err := decodeMultibase(encoded)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, string(topic))
+	}
+	return topics, nil
+}
+
+// Peers lists the peers this node is pubsubbing with, optionally on a single topic
+func (api *PubSubAPI) Peers(ctx context.Context, opts ...options.PubSubPeersOption) ([]peer.ID, error) {
+	settings, err := options.PubSubPeersOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := api.shell.Request("pubsub/peers")
+	if settings.Topic != "" {
+		req.Arguments(encodeTopic(settings.Topic))
+	}
+
+	var out struct {
+		Strings []string
+	}
+	if err := req.Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to list pubsub peers: %w", err)
+	}
+
+	peers := make([]peer.ID, 0, len(out.Strings))
+	for _, id := range out.Strings {
+		p, err := peer.Decode(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode peer ID %s: %w", id, err)
+		}
+		peers = append(peers, p)
+	}
+	return peers, nil
+}
+
+// Publish sends a message to a topic
+func (api *PubSubAPI) Publish(ctx context.Context, topic string, data []byte) error {
+	req := api.shell.Request("pubsub/pub", encodeTopic(topic))
+	if err := withFileBody(req, files.NewBytesFile(data)).Exec(ctx, nil); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Subscribe receives messages published to a topic until the subscription is closed
+func (api *PubSubAPI) Subscribe(ctx context.Context, topic string, opts ...options.PubSubSubscribeOption) (icore.PubSubSubscription, error) {
+	if _, err := options.PubSubSubscribeOptions(opts...); err != nil {
+		return nil, err
+	}
+
+	output, err := send(ctx, api.shell.Request("pubsub/sub", encodeTopic(topic)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+
+	sub := &pubSubSubscription{
+		output:   output,
+		messages: make(chan pubSubResult),
+		done:     make(chan struct{}),
+	}
+	go sub.read()
+
+	return sub, nil
+}
+
+// pubSubResult is a decoded message or the error that ended the subscription
+type pubSubResult struct {
+	message *PubSubMessage
+	err     error
+}
+
+// pubSubSubscription decodes the daemon's stream of messages in the background
+// so that Next can give up when its context is cancelled
+type pubSubSubscription struct {
+	output    io.ReadCloser
+	messages  chan pubSubResult
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// read decodes messages until the stream ends or the subscription is closed
+func (s *pubSubSubscription) read() {
+	defer close(s.messages)
+
+	dec := json.NewDecoder(s.output)
+	for {
+		var out struct {
+			From     string
+			Data     string
+			Seqno    string
+			TopicIDs []string
+		}
+
+		result := pubSubResult{}
+		if err := dec.Decode(&out); err != nil {
+			result.err = err
+		} else {
+			result.message, result.err = decodePubSubMessage(out.From, out.Data, out.Seqno, out.TopicIDs)
+		}
+
+		select {
+		case s.messages <- result:
+		case <-s.done:
+			return
+		}
+		if result.err != nil {
+			return
+		}
+	}
+}
+
+// Next returns the next message published to the topic
+func (s *pubSubSubscription) Next(ctx context.Context) (icore.PubSubMessage, error) {
+	select {
+	case result, ok := <-s.messages:
+		if !ok {
+			return nil, io.EOF
+		}
+		if result.err != nil {
+			return nil, result.err
+		}
+		return result.message, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close ends the subscription
+func (s *pubSubSubscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.output.Close()
+	})
+	return err
+}
+
+// PubSubMessage implements the PubSubMessage interface
+type PubSubMessage struct {
+	from   peer.ID
+	data   []byte
+	seq    []byte
+	topics []string
+}
+
+func (m *PubSubMessage) From() peer.ID    { return m.from }
+func (m *PubSubMessage) Data() []byte     { return m.data }
+func (m *PubSubMessage) Seq() []byte      { return m.seq }
+func (m *PubSubMessage) Topics() []string { return m.topics }
+
+// decodePubSubMessage decodes a message's fields, which the daemon wraps in multibase
+func decodePubSubMessage(from, data, seqno string, topicIDs []string) (*PubSubMessage, error) {
+	sender, err := peer.Decode(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sender %s: %w", from, err)
+	}
+
+	message := &PubSubMessage{from: sender}
+	if message.data, err = decodeMultibase(data); err != nil {
+		return nil, err
+	}
+	if message.seq, err = decodeMultibase(seqno); err != nil {
+		return nil, err
+	}
+	for _, encoded := range topicIDs {
+		topic, err := decodeMultibase(encoded)
+		if err != nil {
+			return nil, err
+		}
+		message.topics = append(message.topics, string(topic))
+	}
+
+	return message, nil
+}
+
+// encodeTopic wraps a topic in multibase, as the daemon expects in URLs
+func encodeTopic(topic string) string {
+	encoded, _ := mbase.Encode(mbase.Base64url, []byte(topic))
+	return encoded
+}
+
+// decodeMultibase decodes a multibase-wrapped field
+func decodeMultibase(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	_, data, err := mbase.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode multibase field: %w", err)
+	}
+	return data, nil
+}
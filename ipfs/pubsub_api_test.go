@@ -0,0 +1,197 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/libp2p/go-libp2p/core/test"
+	mbase "github.com/multiformats/go-multibase"
+)
+
+// Topics are paths, which must not reach the daemon unencoded
+const testTopic = "/orbitdb/zdpu/shop"
+
+// mbaseEncode wraps data in multibase the way the daemon does
+func mbaseEncode(t *testing.T, data string) string {
+	t.Helper()
+
+	encoded, err := mbase.Encode(mbase.Base64url, []byte(data))
+	if err != nil {
+		t.Fatalf("failed to encode %q: %v", data, err)
+	}
+	return encoded
+}
+
+// checkTopicArg fails the test unless arg is the multibase encoding of topic
+func checkTopicArg(t *testing.T, arg, topic string) {
+	t.Helper()
+
+	_, decoded, err := mbase.Decode(arg)
+	if err != nil || string(decoded) != topic {
+		t.Errorf("topic arg %q decodes to %q, %v; want %q", arg, decoded, err, topic)
+	}
+}
+
+func TestPubSubLs(t *testing.T) {
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, map[string][]string{"Strings": {mbaseEncode(t, testTopic), mbaseEncode(t, "other")}})
+	})
+
+	topics, err := api.PubSub().Ls(context.Background())
+	if err != nil {
+		t.Fatalf("Ls() error = %v", err)
+	}
+
+	checkCall(t, calls()[0], "pubsub/ls")
+	if len(topics) != 2 || topics[0] != testTopic || topics[1] != "other" {
+		t.Errorf("Ls() = %q", topics)
+	}
+}
+
+func TestPubSubPeers(t *testing.T) {
+	p := test.RandPeerIDFatal(t)
+
+	tests := []struct {
+		name  string
+		topic string
+	}{
+		{name: "all topics"},
+		{name: "one topic", topic: testTopic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+				writeJSON(t, w, map[string][]string{"Strings": {p.String()}})
+			})
+
+			peers, err := api.PubSub().Peers(context.Background(), options.PubSub.Topic(tt.topic))
+			if err != nil {
+				t.Fatalf("Peers() error = %v", err)
+			}
+
+			call := calls()[0]
+			if call.command != "pubsub/peers" {
+				t.Errorf("command = %s, want pubsub/peers", call.command)
+			}
+			if tt.topic == "" && len(call.args) != 0 {
+				t.Errorf("args = %q, want none", call.args)
+			}
+			if tt.topic != "" {
+				if len(call.args) != 1 {
+					t.Fatalf("args = %q, want the topic", call.args)
+				}
+				checkTopicArg(t, call.args[0], tt.topic)
+			}
+			if len(peers) != 1 || peers[0] != p {
+				t.Errorf("Peers() = %v, want [%s]", peers, p)
+			}
+		})
+	}
+}
+
+func TestPubSubPublish(t *testing.T) {
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {})
+
+	// Binary data is sent as is, only the topic is encoded
+	data := "{\"heads\":[]}\x00\xff"
+	if err := api.PubSub().Publish(context.Background(), testTopic, []byte(data)); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	call := calls()[0]
+	if call.command != "pubsub/pub" || len(call.args) != 1 {
+		t.Fatalf("call = %s %q, want pubsub/pub with the topic", call.command, call.args)
+	}
+	checkTopicArg(t, call.args[0], testTopic)
+	if string(call.body) != data {
+		t.Errorf("body = %q, want %q", call.body, data)
+	}
+}
+
+func TestPubSubSubscribe(t *testing.T) {
+	sender := test.RandPeerIDFatal(t)
+	done := make(chan struct{})
+	defer close(done)
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		for _, data := range []string{"first", "second"} {
+			writeJSON(t, w, map[string]interface{}{
+				"from":     sender.String(),
+				"data":     mbaseEncode(t, data),
+				"seqno":    mbaseEncode(t, "seq-"+data),
+				"topicIDs": []string{mbaseEncode(t, testTopic)},
+			})
+		}
+		w.(http.Flusher).Flush()
+
+		// Keep the stream open like the daemon does
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	})
+
+	ctx := context.Background()
+	sub, err := api.PubSub().Subscribe(ctx, testTopic)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	call := calls()[0]
+	if call.command != "pubsub/sub" || len(call.args) != 1 {
+		t.Fatalf("call = %s %q, want pubsub/sub with the topic", call.command, call.args)
+	}
+	checkTopicArg(t, call.args[0], testTopic)
+
+	for _, want := range []string{"first", "second"} {
+		msg, err := sub.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if msg.From() != sender || string(msg.Data()) != want || string(msg.Seq()) != "seq-"+want ||
+			len(msg.Topics()) != 1 || msg.Topics()[0] != testTopic {
+			t.Errorf("Next() = from %s, data %q, seq %q, topics %q", msg.From(), msg.Data(), msg.Seq(), msg.Topics())
+		}
+	}
+
+	// Next gives up when its context ends, without ending the subscription
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := sub.Next(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Next() with nothing published error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if _, err := sub.Next(ctx); err == nil {
+		t.Errorf("Next() after Close() succeeded")
+	}
+}
+
+func TestPubSubSubscribeBadMessage(t *testing.T) {
+	api, _ := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, map[string]interface{}{
+			"from": test.RandPeerIDFatal(t).String(),
+			"data": "!not multibase",
+		})
+	})
+
+	sub, err := api.PubSub().Subscribe(context.Background(), testTopic)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer sub.Close()
+
+	if _, err := sub.Next(context.Background()); err == nil {
+		t.Errorf("Next() of a message with undecodable data succeeded")
+	}
+}
@@ -0,0 +1,167 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/ipfs/go-libipfs/files"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+)
+
+// RoutingAPI implements the RoutingAPI interface over the daemon's routing/get and routing/put commands
+type RoutingAPI struct {
+	shell *shell.Shell
+}
+
+// Get returns the value stored under key, such as an /ipns/ record
+func (api *RoutingAPI) Get(ctx context.Context, key string) ([]byte, error) {
+	output, err := send(ctx, api.shell.Request("routing/get", key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	defer output.Close()
+
+	var event routing.QueryEvent
+	if err := json.NewDecoder(output).Decode(&event); err != nil {
+		return nil, fmt.Errorf("failed to decode routing/get output: %w", err)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(event.Extra)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode value of %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Put stores value under key
+func (api *RoutingAPI) Put(ctx context.Context, key string, value []byte) error {
+	req := api.shell.Request("routing/put", key).Option("allow-offline", true)
+	if err := withFileBody(req, files.NewBytesFile(value)).Exec(ctx, nil); err != nil {
+		return fmt.Errorf("failed to put %s: %w", key, err)
+	}
+	return nil
+}
+
+// DhtAPI implements the DhtAPI interface over the daemon's routing commands
+type DhtAPI struct {
+	shell *shell.Shell
+	api   *IPFSCoreAPI
+}
+
+// FindPeer looks up the addresses of a peer
+func (api *DhtAPI) FindPeer(ctx context.Context, p peer.ID) (peer.AddrInfo, error) {
+	var found peer.AddrInfo
+	err := api.queryEvents(ctx, api.shell.Request("routing/findpeer", p.String()), func(event *routing.QueryEvent) bool {
+		if event.Type == routing.FinalPeer && len(event.Responses) > 0 && event.Responses[0] != nil {
+			found = *event.Responses[0]
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return peer.AddrInfo{}, fmt.Errorf("failed to find peer %s: %w", p, err)
+	}
+	if found.ID == "" {
+		return peer.AddrInfo{}, fmt.Errorf("peer %s not found", p)
+	}
+	return found, nil
+}
+
+// FindProviders streams the peers that provide the content at p
+func (api *DhtAPI) FindProviders(ctx context.Context, p path.Path, opts ...options.DhtFindProvidersOption) (<-chan peer.AddrInfo, error) {
+	settings, err := options.DhtFindProvidersOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := api.api.ResolvePath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := send(ctx, api.shell.Request("routing/findprovs", rp.Cid().String()).
+		Option("num-providers", settings.NumProviders))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find providers of %s: %w", p, err)
+	}
+
+	ch := make(chan peer.AddrInfo)
+	go func() {
+		defer close(ch)
+		defer output.Close()
+
+		dec := json.NewDecoder(output)
+		for {
+			var event routing.QueryEvent
+			if err := dec.Decode(&event); err != nil {
+				return
+			}
+			if event.Type != routing.Provider {
+				continue
+			}
+			for _, provider := range event.Responses {
+				if provider == nil {
+					continue
+				}
+				select {
+				case ch <- *provider:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Provide announces that this node provides the content at p
+func (api *DhtAPI) Provide(ctx context.Context, p path.Path, opts ...options.DhtProvideOption) error {
+	settings, err := options.DhtProvideOptions(opts...)
+	if err != nil {
+		return err
+	}
+
+	rp, err := api.api.ResolvePath(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	req := api.shell.Request("routing/provide", rp.Cid().String()).Option("recursive", settings.Recursive)
+	if err := api.queryEvents(ctx, req, func(*routing.QueryEvent) bool { return true }); err != nil {
+		return fmt.Errorf("failed to provide %s: %w", p, err)
+	}
+	return nil
+}
+
+// queryEvents passes each event a routing command streams to handle until it
+// returns false. Errors from individual peers are events too and don't end the query.
+func (api *DhtAPI) queryEvents(ctx context.Context, req *shell.RequestBuilder, handle func(*routing.QueryEvent) bool) error {
+	output, err := send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	dec := json.NewDecoder(output)
+	for {
+		var event routing.QueryEvent
+		if err := dec.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if !handle(&event) {
+			return nil
+		}
+	}
+}
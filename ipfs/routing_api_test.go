@@ -0,0 +1,139 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"github.com/libp2p/go-libp2p/core/test"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// testAddrInfo returns a random peer listening on addr
+func testAddrInfo(t *testing.T, addr string) *peer.AddrInfo {
+	t.Helper()
+	return &peer.AddrInfo{ID: test.RandPeerIDFatal(t), Addrs: []ma.Multiaddr{ma.StringCast(addr)}}
+}
+
+func TestRoutingGet(t *testing.T) {
+	record := []byte("\x0a\x22signed ipns record")
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, routing.QueryEvent{Type: routing.Value, Extra: base64.StdEncoding.EncodeToString(record)})
+	})
+
+	key := "/ipns/" + test.RandPeerIDFatal(t).String()
+	value, err := api.Routing().Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	checkCall(t, calls()[0], "routing/get", key)
+	if string(value) != string(record) {
+		t.Errorf("Get() = %q, want %q", value, record)
+	}
+}
+
+func TestRoutingPut(t *testing.T) {
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {})
+
+	key := "/ipns/" + test.RandPeerIDFatal(t).String()
+	if err := api.Routing().Put(context.Background(), key, []byte("signed ipns record")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	call := calls()[0]
+	checkCall(t, call, "routing/put", key)
+	if call.options["allow-offline"] != "true" {
+		t.Errorf("allow-offline = %q, want true", call.options["allow-offline"])
+	}
+	if string(call.body) != "signed ipns record" {
+		t.Errorf("body = %q", call.body)
+	}
+}
+
+func TestDhtFindPeer(t *testing.T) {
+	target := testAddrInfo(t, "/ip4/203.0.113.7/tcp/4001")
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w,
+			routing.QueryEvent{Type: routing.SendingQuery, ID: test.RandPeerIDFatal(t)},
+			routing.QueryEvent{Type: routing.QueryError, Extra: "dial backoff"},
+			routing.QueryEvent{Type: routing.FinalPeer, Responses: []*peer.AddrInfo{target}},
+		)
+	})
+
+	found, err := api.Dht().FindPeer(context.Background(), target.ID)
+	if err != nil {
+		t.Fatalf("FindPeer() error = %v", err)
+	}
+
+	checkCall(t, calls()[0], "routing/findpeer", target.ID.String())
+	if found.ID != target.ID || len(found.Addrs) != 1 || !found.Addrs[0].Equal(target.Addrs[0]) {
+		t.Errorf("FindPeer() = %v, want %v", found, target)
+	}
+}
+
+func TestDhtFindPeerNotFound(t *testing.T) {
+	api, _ := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, routing.QueryEvent{Type: routing.QueryError, Extra: "routing: not found"})
+	})
+
+	if _, err := api.Dht().FindPeer(context.Background(), test.RandPeerIDFatal(t)); err == nil {
+		t.Fatalf("FindPeer() of a peer that wasn't found succeeded")
+	}
+}
+
+func TestDhtFindProviders(t *testing.T) {
+	c := testCid(t, "site")
+	first := testAddrInfo(t, "/ip4/203.0.113.7/tcp/4001")
+	second := testAddrInfo(t, "/ip6/2001:db8::1/udp/4001/quic")
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w,
+			routing.QueryEvent{Type: routing.QueryError, Extra: "dial backoff"},
+			routing.QueryEvent{Type: routing.Provider, Responses: []*peer.AddrInfo{first}},
+			routing.QueryEvent{Type: routing.PeerResponse, Responses: []*peer.AddrInfo{testAddrInfo(t, "/ip4/198.51.100.1/tcp/4001")}},
+			routing.QueryEvent{Type: routing.Provider, Responses: []*peer.AddrInfo{second}},
+		)
+	})
+
+	providers, err := api.Dht().FindProviders(context.Background(), path.IpfsPath(c), options.Dht.NumProviders(5))
+	if err != nil {
+		t.Fatalf("FindProviders() error = %v", err)
+	}
+
+	var found []peer.ID
+	for provider := range providers {
+		found = append(found, provider.ID)
+	}
+
+	call := calls()[0]
+	checkCall(t, call, "routing/findprovs", c.String())
+	if call.options["num-providers"] != "5" {
+		t.Errorf("num-providers = %q, want 5", call.options["num-providers"])
+	}
+	if len(found) != 2 || found[0] != first.ID || found[1] != second.ID {
+		t.Errorf("FindProviders() = %v, want [%s %s]", found, first.ID, second.ID)
+	}
+}
+
+func TestDhtProvide(t *testing.T) {
+	c := testCid(t, "site")
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, routing.QueryEvent{Type: routing.QueryError, Extra: "dial backoff"})
+	})
+
+	if err := api.Dht().Provide(context.Background(), path.IpfsPath(c), options.Dht.Recursive(true)); err != nil {
+		t.Fatalf("Provide() error = %v", err)
+	}
+
+	call := calls()[0]
+	checkCall(t, call, "routing/provide", c.String())
+	if call.options["recursive"] != "true" {
+		t.Errorf("recursive = %q, want true", call.options["recursive"])
+	}
+}
@@ -0,0 +1,166 @@
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	shell "github.com/ipfs/go-ipfs-api"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// SwarmAPI implements the SwarmAPI interface over the daemon's swarm commands
+type SwarmAPI struct {
+	shell *shell.Shell
+}
+
+// Connect dials a peer at each of its addresses, or through routing if it has none
+func (api *SwarmAPI) Connect(ctx context.Context, pi peer.AddrInfo) error {
+	idPart := "/p2p/" + pi.ID.String()
+
+	addrs := []string{}
+	for _, addr := range pi.Addrs {
+		addrs = append(addrs, addr.String()+idPart)
+	}
+	if len(addrs) == 0 {
+		addrs = append(addrs, idPart)
+	}
+
+	if err := api.shell.Request("swarm/connect").Arguments(addrs...).Exec(ctx, nil); err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", pi.ID, err)
+	}
+	return nil
+}
+
+// Disconnect closes the connection at addr
+func (api *SwarmAPI) Disconnect(ctx context.Context, addr ma.Multiaddr) error {
+	if err := api.shell.Request("swarm/disconnect", addr.String()).Exec(ctx, nil); err != nil {
+		return fmt.Errorf("failed to disconnect from %s: %w", addr, err)
+	}
+	return nil
+}
+
+// Peers lists the peers this node is connected to
+func (api *SwarmAPI) Peers(ctx context.Context) ([]icore.ConnectionInfo, error) {
+	var out struct {
+		Peers []struct {
+			Addr      string
+			Peer      string
+			Latency   string
+			Direction network.Direction
+			Streams   []struct {
+				Protocol string
+			}
+		}
+	}
+	err := api.shell.Request("swarm/peers").
+		Option("streams", true).
+		Option("latency", true).
+		Option("direction", true).
+		Exec(ctx, &out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list swarm peers: %w", err)
+	}
+
+	conns := make([]icore.ConnectionInfo, 0, len(out.Peers))
+	for _, p := range out.Peers {
+		id, err := peer.Decode(p.Peer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode peer ID %s: %w", p.Peer, err)
+		}
+		addr, err := ma.NewMultiaddr(p.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse address %s: %w", p.Addr, err)
+		}
+
+		conn := &ConnectionInfo{id: id, addr: addr, direction: p.Direction}
+		if p.Latency != "" && p.Latency != "n/a" {
+			conn.latency, _ = time.ParseDuration(p.Latency)
+		}
+		for _, stream := range p.Streams {
+			conn.streams = append(conn.streams, protocol.ID(stream.Protocol))
+		}
+		conns = append(conns, conn)
+	}
+
+	return conns, nil
+}
+
+// KnownAddrs returns every address this node knows for each peer
+func (api *SwarmAPI) KnownAddrs(ctx context.Context) (map[peer.ID][]ma.Multiaddr, error) {
+	var out struct {
+		Addrs map[string][]string
+	}
+	if err := api.shell.Request("swarm/addrs").Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to list known addresses: %w", err)
+	}
+
+	known := make(map[peer.ID][]ma.Multiaddr, len(out.Addrs))
+	for id, addrs := range out.Addrs {
+		p, err := peer.Decode(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode peer ID %s: %w", id, err)
+		}
+		parsed, err := parseMultiaddrs(addrs)
+		if err != nil {
+			return nil, err
+		}
+		known[p] = parsed
+	}
+
+	return known, nil
+}
+
+// LocalAddrs returns the addresses this node announces
+func (api *SwarmAPI) LocalAddrs(ctx context.Context) ([]ma.Multiaddr, error) {
+	var out struct {
+		Strings []string
+	}
+	if err := api.shell.Request("swarm/addrs/local").Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to list local addresses: %w", err)
+	}
+	return parseMultiaddrs(out.Strings)
+}
+
+// ListenAddrs returns the addresses this node listens on
+func (api *SwarmAPI) ListenAddrs(ctx context.Context) ([]ma.Multiaddr, error) {
+	var out struct {
+		Strings []string
+	}
+	if err := api.shell.Request("swarm/addrs/listen").Exec(ctx, &out); err != nil {
+		return nil, fmt.Errorf("failed to list listen addresses: %w", err)
+	}
+	return parseMultiaddrs(out.Strings)
+}
+
+// ConnectionInfo implements the ConnectionInfo interface
+type ConnectionInfo struct {
+	id        peer.ID
+	addr      ma.Multiaddr
+	direction network.Direction
+	latency   time.Duration
+	streams   []protocol.ID
+}
+
+func (c *ConnectionInfo) ID() peer.ID                     { return c.id }
+func (c *ConnectionInfo) Address() ma.Multiaddr           { return c.addr }
+func (c *ConnectionInfo) Direction() network.Direction    { return c.direction }
+func (c *ConnectionInfo) Latency() (time.Duration, error) { return c.latency, nil }
+func (c *ConnectionInfo) Streams() ([]protocol.ID, error) { return c.streams, nil }
+
+// parseMultiaddrs parses the addresses the daemon returns as strings
+func parseMultiaddrs(addrs []string) ([]ma.Multiaddr, error) {
+	parsed := make([]ma.Multiaddr, 0, len(addrs))
+	for _, addr := range addrs {
+		a, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse address %s: %w", addr, err)
+		}
+		parsed = append(parsed, a)
+	}
+	return parsed, nil
+}
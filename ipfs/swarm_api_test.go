@@ -0,0 +1,171 @@
+package ipfs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/test"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestSwarmConnect(t *testing.T) {
+	id := test.RandPeerIDFatal(t)
+
+	tests := []struct {
+		name     string
+		addrs    []ma.Multiaddr
+		wantArgs []string
+	}{
+		{
+			name:     "with addresses",
+			addrs:    []ma.Multiaddr{ma.StringCast("/ip4/203.0.113.7/tcp/4001"), ma.StringCast("/ip4/203.0.113.7/udp/4001/quic")},
+			wantArgs: []string{"/ip4/203.0.113.7/tcp/4001/p2p/" + id.String(), "/ip4/203.0.113.7/udp/4001/quic/p2p/" + id.String()},
+		},
+		{
+			name:     "through routing",
+			wantArgs: []string{"/p2p/" + id.String()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {})
+
+			if err := api.Swarm().Connect(context.Background(), peer.AddrInfo{ID: id, Addrs: tt.addrs}); err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+			checkCall(t, calls()[0], "swarm/connect", tt.wantArgs...)
+		})
+	}
+}
+
+func TestSwarmConnectError(t *testing.T) {
+	api, _ := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeError(w, "failure: dial backoff")
+	})
+
+	if err := api.Swarm().Connect(context.Background(), peer.AddrInfo{ID: test.RandPeerIDFatal(t)}); err == nil {
+		t.Fatalf("Connect() succeeded, want the daemon's error")
+	}
+}
+
+func TestSwarmDisconnect(t *testing.T) {
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {})
+
+	addr := ma.StringCast("/ip4/203.0.113.7/tcp/4001/p2p/" + test.RandPeerIDFatal(t).String())
+	if err := api.Swarm().Disconnect(context.Background(), addr); err != nil {
+		t.Fatalf("Disconnect() error = %v", err)
+	}
+	checkCall(t, calls()[0], "swarm/disconnect", addr.String())
+}
+
+func TestSwarmPeers(t *testing.T) {
+	first := test.RandPeerIDFatal(t)
+	second := test.RandPeerIDFatal(t)
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, map[string]interface{}{
+			"Peers": []map[string]interface{}{
+				{
+					"Addr":      "/ip4/203.0.113.7/tcp/4001",
+					"Peer":      first.String(),
+					"Latency":   "12.5ms",
+					"Direction": network.DirOutbound,
+					"Streams":   []map[string]string{{"Protocol": "/meshsub/1.1.0"}, {"Protocol": "/ipfs/bitswap/1.2.0"}},
+				},
+				{
+					"Addr":      "/ip4/198.51.100.1/udp/4001/quic",
+					"Peer":      second.String(),
+					"Latency":   "n/a",
+					"Direction": network.DirInbound,
+				},
+			},
+		})
+	})
+
+	conns, err := api.Swarm().Peers(context.Background())
+	if err != nil {
+		t.Fatalf("Peers() error = %v", err)
+	}
+
+	call := calls()[0]
+	checkCall(t, call, "swarm/peers")
+	for _, option := range []string{"streams", "latency", "direction"} {
+		if call.options[option] != "true" {
+			t.Errorf("%s = %q, want true", option, call.options[option])
+		}
+	}
+
+	if len(conns) != 2 {
+		t.Fatalf("Peers() returned %d connections, want 2", len(conns))
+	}
+	latency, _ := conns[0].Latency()
+	streams, _ := conns[0].Streams()
+	if conns[0].ID() != first || conns[0].Address().String() != "/ip4/203.0.113.7/tcp/4001" ||
+		conns[0].Direction() != network.DirOutbound || latency != 12500*time.Microsecond ||
+		len(streams) != 2 || streams[0] != "/meshsub/1.1.0" {
+		t.Errorf("first connection = %s %s %s %s %v", conns[0].ID(), conns[0].Address(), conns[0].Direction(), latency, streams)
+	}
+	latency, _ = conns[1].Latency()
+	if conns[1].ID() != second || conns[1].Direction() != network.DirInbound || latency != 0 {
+		t.Errorf("second connection = %s %s %s", conns[1].ID(), conns[1].Direction(), latency)
+	}
+}
+
+func TestSwarmAddrs(t *testing.T) {
+	id := test.RandPeerIDFatal(t)
+	addrs := []string{"/ip4/203.0.113.7/tcp/4001", "/ip6/2001:db8::1/udp/4001/quic"}
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		if call.command == "swarm/addrs" {
+			writeJSON(t, w, map[string]interface{}{"Addrs": map[string][]string{id.String(): addrs}})
+			return
+		}
+		writeJSON(t, w, map[string][]string{"Strings": addrs})
+	})
+	ctx := context.Background()
+
+	known, err := api.Swarm().KnownAddrs(ctx)
+	if err != nil {
+		t.Fatalf("KnownAddrs() error = %v", err)
+	}
+	if len(known) != 1 || len(known[id]) != 2 || known[id][1].String() != addrs[1] {
+		t.Errorf("KnownAddrs() = %v", known)
+	}
+
+	local, err := api.Swarm().LocalAddrs(ctx)
+	if err != nil {
+		t.Fatalf("LocalAddrs() error = %v", err)
+	}
+	listen, err := api.Swarm().ListenAddrs(ctx)
+	if err != nil {
+		t.Fatalf("ListenAddrs() error = %v", err)
+	}
+	for name, got := range map[string][]ma.Multiaddr{"LocalAddrs": local, "ListenAddrs": listen} {
+		if len(got) != 2 || got[0].String() != addrs[0] || got[1].String() != addrs[1] {
+			t.Errorf("%s() = %v, want %v", name, got, addrs)
+		}
+	}
+
+	got := calls()
+	if len(got) != 3 {
+		t.Fatalf("made %d calls, want 3", len(got))
+	}
+	checkCall(t, got[0], "swarm/addrs")
+	checkCall(t, got[1], "swarm/addrs/local")
+	checkCall(t, got[2], "swarm/addrs/listen")
+}
+
+func TestSwarmAddrsInvalid(t *testing.T) {
+	api, _ := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w, map[string][]string{"Strings": {"/ip4/not-an-ip/tcp/4001"}})
+	})
+
+	if _, err := api.Swarm().ListenAddrs(context.Background()); err == nil {
+		t.Fatalf("ListenAddrs() with an invalid address succeeded")
+	}
+}
@@ -12,6 +12,11 @@ const (
 	IPFSVersion          = "v0.33.2"
 	IPFSDataDir          = ".ipfs"
 	MinCompatibleVersion = "v0.20.0" // Minimum IPFS version we support
+
+	// SiteRootDir is the directory of a shop whose files are published at the
+	// root of its CID, beside the shop's directory, such as robots.txt. It's
+	// hidden, so adding the shop's directory leaves it out.
+	SiteRootDir = ".root"
 )
 
 type DaemonStatus int
@@ -28,6 +33,7 @@ type IPFSMode string
 const (
 	SystemIPFS      IPFSMode = "system"
 	AppSpecificIPFS IPFSMode = "app-specific"
+	EmbeddedIPFS    IPFSMode = "embedded" // In-process node, no binary or API port needed
 )
 
 var DefaultGateways = []string{
@@ -47,13 +53,31 @@ type IPFSManager struct {
 	initialized bool
 	gateways    []GatewayStatus
 	gatewayLock sync.RWMutex
+	embedded    *embeddedNode // Set while the embedded node is running
 }
 
 type Config struct {
+	Mode             IPFSMode // Empty to use system IPFS if compatible, else app-specific
 	CustomBinaryPath string
 	CustomGateways   []string
 }
 
+// PublishMetadata is the contents of a shop's ipfs_metadata.json
+type PublishMetadata struct {
+	CID         string `json:"cid"`
+	Gateway     string `json:"gateway"`
+	IPNSName    string `json:"ipnsName,omitempty"`
+	IPNSGateway string `json:"ipnsGateway,omitempty"`
+}
+
+// ShareURL returns the permanent IPNS URL if the shop has one, otherwise the CID URL
+func (pm *PublishMetadata) ShareURL() string {
+	if pm.IPNSGateway != "" {
+		return pm.IPNSGateway
+	}
+	return pm.Gateway
+}
+
 type GatewayStatus struct {
 	URL      string
 	Healthy  bool
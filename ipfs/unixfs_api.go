@@ -0,0 +1,283 @@
+package ipfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/ipfs/go-libipfs/files"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// UnixfsAPI implements the UnixfsAPI interface over the daemon's add, ls and cat commands
+type UnixfsAPI struct {
+	shell *shell.Shell
+}
+
+// Add imports a file or directory into IPFS and returns the path of its root
+func (api *UnixfsAPI) Add(ctx context.Context, file files.Node, opts ...options.UnixfsAddOption) (path.Resolved, error) {
+	settings, _, err := options.UnixfsAddOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	hashName, ok := mh.Codes[settings.MhType]
+	if !ok {
+		return nil, fmt.Errorf("unknown multihash type %d", settings.MhType)
+	}
+
+	req := api.shell.Request("add").
+		Option("hash", hashName).
+		Option("chunker", settings.Chunker).
+		Option("cid-version", settings.CidVersion).
+		Option("inline", settings.Inline).
+		Option("inline-limit", settings.InlineLimit).
+		Option("pin", settings.Pin).
+		Option("only-hash", settings.OnlyHash).
+		Option("fscache", settings.FsCache).
+		Option("nocopy", settings.NoCopy).
+		Option("silent", settings.Silent).
+		Option("progress", settings.Progress)
+	if settings.RawLeavesSet {
+		req.Option("raw-leaves", settings.RawLeaves)
+	}
+	if settings.Layout == options.TrickleLayout {
+		req.Option("trickle", true)
+	}
+
+	output, err := send(ctx, withFileBody(req, file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add file: %w", err)
+	}
+	defer output.Close()
+
+	// add streams an event per file, the root comes last
+	var root string
+	dec := json.NewDecoder(output)
+	for {
+		var event struct {
+			Name  string
+			Hash  string
+			Bytes int64
+			Size  string
+		}
+		if err := dec.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode add output: %w", err)
+		}
+
+		if event.Hash != "" {
+			root = event.Hash
+		}
+
+		if settings.Events != nil {
+			addEvent := &icore.AddEvent{Name: event.Name, Bytes: event.Bytes, Size: event.Size}
+			if event.Hash != "" {
+				if c, err := cid.Decode(event.Hash); err == nil {
+					addEvent.Path = path.IpfsPath(c)
+				}
+			}
+			select {
+			case settings.Events <- addEvent:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	if root == "" {
+		return nil, fmt.Errorf("add returned no CID")
+	}
+	c, err := cid.Decode(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CID %s: %w", root, err)
+	}
+
+	return path.IpfsPath(c), nil
+}
+
+// Get returns a file or directory. File contents are only fetched once read.
+func (api *UnixfsAPI) Get(ctx context.Context, p path.Path) (files.Node, error) {
+	var stat struct {
+		Hash string
+		Type string
+		Size int64
+	}
+	if err := api.shell.Request("files/stat", p.String()).Exec(ctx, &stat); err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", p, err)
+	}
+
+	if stat.Type != "directory" {
+		return &lazyFile{ctx: ctx, shell: api.shell, path: p.String(), size: stat.Size}, nil
+	}
+
+	entries, err := api.Ls(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]files.Node)
+	for entry := range entries {
+		if entry.Err != nil {
+			return nil, entry.Err
+		}
+		child, err := api.Get(ctx, path.IpfsPath(entry.Cid))
+		if err != nil {
+			return nil, err
+		}
+		nodes[entry.Name] = child
+	}
+
+	return files.NewMapDirectory(nodes), nil
+}
+
+// Ls lists the entries of a directory
+func (api *UnixfsAPI) Ls(ctx context.Context, p path.Path, opts ...options.UnixfsLsOption) (<-chan icore.DirEntry, error) {
+	settings, err := options.UnixfsLsOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := send(ctx, api.shell.Request("ls", p.String()).
+		Option("resolve-type", settings.ResolveChildren).
+		Option("size", settings.UseCumulativeSize).
+		Option("stream", true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", p, err)
+	}
+
+	ch := make(chan icore.DirEntry)
+	go func() {
+		defer close(ch)
+		defer output.Close()
+
+		dec := json.NewDecoder(output)
+		for {
+			var out struct {
+				Objects []struct {
+					Links []struct {
+						Name   string
+						Hash   string
+						Size   uint64
+						Type   int32
+						Target string
+					}
+				}
+			}
+			if err := dec.Decode(&out); err != nil {
+				if !errors.Is(err, io.EOF) {
+					sendDirEntry(ctx, ch, icore.DirEntry{Err: err})
+				}
+				return
+			}
+
+			for _, object := range out.Objects {
+				for _, link := range object.Links {
+					entry := icore.DirEntry{
+						Name:   link.Name,
+						Size:   link.Size,
+						Type:   unixfsFileType(link.Type),
+						Target: link.Target,
+					}
+					entry.Cid, entry.Err = cid.Decode(link.Hash)
+					if !sendDirEntry(ctx, ch, entry) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// sendDirEntry delivers an entry unless the context is cancelled first
+func sendDirEntry(ctx context.Context, ch chan<- icore.DirEntry, entry icore.DirEntry) bool {
+	select {
+	case ch <- entry:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// unixfsFileType maps the unixfs data type the daemon reports to a FileType
+func unixfsFileType(t int32) icore.FileType {
+	switch t {
+	case 0, 2: // Raw, File
+		return icore.TFile
+	case 1, 5: // Directory, HAMTShard
+		return icore.TDirectory
+	case 4: // Symlink
+		return icore.TSymlink
+	default:
+		return icore.TUnknown
+	}
+}
+
+// lazyFile is a file in IPFS that is only fetched with cat when first read
+type lazyFile struct {
+	ctx    context.Context
+	shell  *shell.Shell
+	path   string
+	size   int64
+	reader io.ReadCloser
+}
+
+func (f *lazyFile) Read(b []byte) (int, error) {
+	if f.reader == nil {
+		output, err := send(f.ctx, f.shell.Request("cat", f.path))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", f.path, err)
+		}
+		f.reader = output
+	}
+	return f.reader.Read(b)
+}
+
+func (f *lazyFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, fmt.Errorf("seeking is not supported")
+}
+
+func (f *lazyFile) Size() (int64, error) {
+	return f.size, nil
+}
+
+func (f *lazyFile) Close() error {
+	if f.reader == nil {
+		return nil
+	}
+	return f.reader.Close()
+}
+
+// withFileBody sends file as the multipart body that commands such as add and pubsub/pub read
+func withFileBody(req *shell.RequestBuilder, file files.Node) *shell.RequestBuilder {
+	dir := files.NewMapDirectory(map[string]files.Node{"": file})
+	body := files.NewMultiFileReader(dir, false)
+
+	return req.Body(body).
+		Header("Content-Type", "multipart/form-data; boundary="+body.Boundary()).
+		Header("Content-Disposition", `form-data; name="files"`)
+}
+
+// send sends a request whose output is read as a stream. The caller must close the output.
+func send(ctx context.Context, req *shell.RequestBuilder) (io.ReadCloser, error) {
+	resp, err := req.Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		resp.Close()
+		return nil, resp.Error
+	}
+	return resp.Output, nil
+}
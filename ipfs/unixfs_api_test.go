@@ -0,0 +1,205 @@
+package ipfs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-libipfs/files"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+func TestUnixfsAdd(t *testing.T) {
+	leaf := testCid(t, "leaf")
+	root := testCid(t, "root")
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		writeJSON(t, w,
+			map[string]interface{}{"Name": "", "Bytes": 5},
+			map[string]interface{}{"Name": "chunk", "Hash": leaf.String(), "Size": "13"},
+			map[string]interface{}{"Name": "", "Hash": root.String(), "Size": "120"},
+		)
+	})
+
+	events := make(chan interface{}, 10)
+	added, err := api.Unixfs().Add(context.Background(), files.NewBytesFile([]byte("<html>shop</html>")),
+		options.Unixfs.CidVersion(1),
+		options.Unixfs.Pin(true),
+		options.Unixfs.RawLeaves(true),
+		options.Unixfs.Layout(options.TrickleLayout),
+		options.Unixfs.Events(events),
+	)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	close(events)
+
+	// The root is the last hash streamed
+	if added.Cid() != root {
+		t.Errorf("Add() = %s, want %s", added.Cid(), root)
+	}
+
+	call := calls()[0]
+	checkCall(t, call, "add")
+	wantOptions := map[string]string{
+		"hash":        "sha2-256",
+		"cid-version": "1",
+		"pin":         "true",
+		"raw-leaves":  "true",
+		"trickle":     "true",
+		"only-hash":   "false",
+	}
+	for key, want := range wantOptions {
+		if call.options[key] != want {
+			t.Errorf("option %s = %q, want %q", key, call.options[key], want)
+		}
+	}
+	if string(call.body) != "<html>shop</html>" {
+		t.Errorf("body = %q", call.body)
+	}
+
+	var got []*icore.AddEvent
+	for event := range events {
+		got = append(got, event.(*icore.AddEvent))
+	}
+	if len(got) != 3 || got[0].Path != nil || got[0].Bytes != 5 || got[1].Path.Cid() != leaf || got[2].Path.Cid() != root || got[2].Size != "120" {
+		t.Errorf("events = %+v", got)
+	}
+}
+
+func TestUnixfsAddErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(w http.ResponseWriter)
+	}{
+		{name: "daemon error", handle: func(w http.ResponseWriter) { writeError(w, "repo is full") }},
+		{name: "no hash", handle: func(w http.ResponseWriter) { writeJSON(t, w, map[string]interface{}{"Name": "", "Bytes": 5}) }},
+		{name: "invalid hash", handle: func(w http.ResponseWriter) { writeJSON(t, w, map[string]interface{}{"Hash": "not-a-cid"}) }},
+		{name: "truncated output", handle: func(w http.ResponseWriter) { w.Write([]byte(`{"Hash":`)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, _ := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+				tt.handle(w)
+			})
+
+			if _, err := api.Unixfs().Add(context.Background(), files.NewBytesFile([]byte("shop"))); err == nil {
+				t.Fatalf("Add() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestUnixfsLs(t *testing.T) {
+	dir := testCid(t, "dir")
+	page := testCid(t, "page")
+	assets := testCid(t, "assets")
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		// Streamed listings send each link as its own object
+		writeJSON(t, w,
+			map[string]interface{}{"Objects": []map[string]interface{}{{"Hash": dir.String(), "Links": []map[string]interface{}{
+				{"Name": "index.html", "Hash": page.String(), "Size": 120, "Type": 2},
+			}}}},
+			map[string]interface{}{"Objects": []map[string]interface{}{{"Hash": dir.String(), "Links": []map[string]interface{}{
+				{"Name": "assets", "Hash": assets.String(), "Size": 0, "Type": 1},
+			}}}},
+		)
+	})
+
+	entries, err := api.Unixfs().Ls(context.Background(), path.IpfsPath(dir), options.Unixfs.ResolveChildren(true))
+	if err != nil {
+		t.Fatalf("Ls() error = %v", err)
+	}
+
+	var got []icore.DirEntry
+	for entry := range entries {
+		if entry.Err != nil {
+			t.Fatalf("Ls() entry error = %v", entry.Err)
+		}
+		got = append(got, entry)
+	}
+
+	call := calls()[0]
+	checkCall(t, call, "ls", "/ipfs/"+dir.String())
+	if call.options["stream"] != "true" || call.options["resolve-type"] != "true" {
+		t.Errorf("options = %v, want streamed with resolved types", call.options)
+	}
+	if len(got) != 2 ||
+		got[0].Name != "index.html" || got[0].Cid != page || got[0].Size != 120 || got[0].Type != icore.TFile ||
+		got[1].Name != "assets" || got[1].Cid != assets || got[1].Type != icore.TDirectory {
+		t.Errorf("Ls() = %+v", got)
+	}
+}
+
+func TestUnixfsGet(t *testing.T) {
+	dir := testCid(t, "dir")
+	page := testCid(t, "page")
+
+	api, calls := newTestAPI(t, func(w http.ResponseWriter, r *http.Request, call rpcCall) {
+		switch {
+		case call.command == "files/stat" && call.args[0] == "/ipfs/"+dir.String():
+			writeJSON(t, w, map[string]interface{}{"Hash": dir.String(), "Type": "directory"})
+		case call.command == "files/stat":
+			writeJSON(t, w, map[string]interface{}{"Hash": page.String(), "Type": "file", "Size": 17})
+		case call.command == "ls":
+			writeJSON(t, w, map[string]interface{}{"Objects": []map[string]interface{}{{"Links": []map[string]interface{}{
+				{"Name": "index.html", "Hash": page.String(), "Size": 17, "Type": 2},
+			}}}})
+		case call.command == "cat":
+			w.Write([]byte("<html>shop</html>"))
+		default:
+			t.Errorf("unexpected call %s %q", call.command, call.args)
+		}
+	})
+
+	node, err := api.Unixfs().Get(context.Background(), path.IpfsPath(dir))
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer node.Close()
+
+	d, ok := node.(files.Directory)
+	if !ok {
+		t.Fatalf("Get() = %T, want a directory", node)
+	}
+	it := d.Entries()
+	if !it.Next() || it.Name() != "index.html" {
+		t.Fatalf("directory entries = %v, %v", it.Name(), it.Err())
+	}
+	file, ok := it.Node().(files.File)
+	if !ok {
+		t.Fatalf("index.html = %T, want a file", it.Node())
+	}
+	if size, _ := file.Size(); size != 17 {
+		t.Errorf("Size() = %d, want 17", size)
+	}
+
+	// Contents are only fetched once read
+	for _, call := range calls() {
+		if call.command == "cat" {
+			t.Fatalf("file was fetched before it was read")
+		}
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(data) != "<html>shop</html>" {
+		t.Errorf("file = %q", data)
+	}
+
+	var commands []string
+	for _, call := range calls() {
+		commands = append(commands, call.command)
+	}
+	if got := strings.Join(commands, " "); got != "files/stat ls files/stat cat" {
+		t.Errorf("calls = %s, want files/stat ls files/stat cat", got)
+	}
+	checkCall(t, calls()[3], "cat", "/ipfs/"+page.String())
+}
@@ -0,0 +1,67 @@
+// Category and tag filters for IndieNode shop catalogs
+//
+// Filter chips link to #category=<id> or #tag=<tag>, so a filtered view can be
+// shared and the back button works without anything on the server. Items carry
+// their categories in data-categories, separated by spaces, and their tags in
+// data-tags, separated by commas.
+
+// Read the filter in the page's URL, or null if it shows every item
+function currentCatalogFilter() {
+    const hash = window.location.hash.slice(1);
+    const separator = hash.indexOf('=');
+    if (separator < 0) {
+        return null;
+    }
+
+    const type = hash.slice(0, separator);
+    let value;
+    try {
+        value = decodeURIComponent(hash.slice(separator + 1));
+    } catch (err) {
+        return null;
+    }
+    if ((type !== 'category' && type !== 'tag') || value === '') {
+        return null;
+    }
+    return { type, value };
+}
+
+// Check whether an item element passes the filter, tags are matched ignoring case
+function matchesCatalogFilter(element, filter) {
+    if (!filter) {
+        return true;
+    }
+    if (filter.type === 'category') {
+        return (element.dataset.categories || '').split(' ').includes(filter.value);
+    }
+    const tag = filter.value.toLowerCase();
+    return (element.dataset.tags || '').split(',').some(t => t.toLowerCase() === tag);
+}
+
+// Show the items matching the filter in the URL and mark its chip as active.
+// Called again by ShopAPI.renderItems after it replaces the items.
+function applyCatalogFilter() {
+    const filter = currentCatalogFilter();
+
+    document.querySelectorAll('[data-categories]').forEach(element => {
+        element.hidden = !matchesCatalogFilter(element, filter);
+    });
+
+    document.querySelectorAll('.filter-chip').forEach(chip => {
+        const type = chip.dataset.filterType || '';
+        const value = chip.dataset.filterValue || '';
+        const active = filter
+            ? type === filter.type && (type === 'tag' ? value.toLowerCase() === filter.value.toLowerCase() : value === filter.value)
+            : type === '';
+        chip.classList.toggle('active', active);
+        if (active) {
+            chip.setAttribute('aria-current', 'true');
+        } else {
+            chip.removeAttribute('aria-current');
+        }
+    });
+}
+
+window.applyCatalogFilter = applyCatalogFilter;
+window.addEventListener('hashchange', applyCatalogFilter);
+document.addEventListener('DOMContentLoaded', applyCatalogFilter);
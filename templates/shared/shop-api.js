@@ -0,0 +1,371 @@
+/**
+ * ShopAPI - Client-side library for accessing shop data from the IndieNode API
+ */
+class ShopAPI {
+    // How often recordOrder reports an order the node hasn't seen the transaction of yet, and how long it waits in between
+    static ORDER_ATTEMPTS = 12;
+    static ORDER_RETRY_DELAY = 5000;
+    
+    /**
+     * Initialize the ShopAPI
+     * 
+     * @param {Object} options - Configuration options
+     * @param {string} options.apiUrl - Base URL for the API (default: http://localhost:8000)
+     * @param {string} options.shopId - ID of the shop to load
+     * @param {function} options.onLoad - Callback when shop data is loaded
+     * @param {function} options.onError - Callback when an error occurs
+     */
+    constructor(options = {}) {
+        this.apiUrl = options.apiUrl || 'http://localhost:8000';
+        this.shopId = options.shopId;
+        this.onLoad = options.onLoad || (() => {});
+        this.onError = options.onError || ((err) => console.error('Shop API Error:', err));
+        
+        this.shop = null;
+        this.items = [];
+        this.isConnected = false;
+        
+        // Verify the API is running
+        this.checkConnection();
+    }
+    
+    /**
+     * Check if the API is running
+     */
+    async checkConnection() {
+        try {
+            const response = await fetch(`${this.apiUrl}/api`);
+            if (!response.ok) throw new Error('API returned an error');
+            
+            const data = await response.json();
+            if (data.success) {
+                console.log('Connected to IndieNode API:', data.data.status);
+                this.isConnected = true;
+                
+                // If shop ID is provided, load the shop data
+                if (this.shopId) {
+                    await this.loadShop();
+                }
+            } else {
+                throw new Error(data.error || 'Unknown API error');
+            }
+        } catch (err) {
+            console.error('Failed to connect to IndieNode API:', err);
+            this.isConnected = false;
+            this.onError(err);
+        }
+    }
+    
+    /**
+     * Load shop data for the specified shop ID
+     * 
+     * @param {string} shopId - Override the shop ID provided in constructor
+     */
+    async loadShop(shopId) {
+        if (shopId) this.shopId = shopId;
+        if (!this.shopId) {
+            throw new Error('No shop ID provided');
+        }
+        
+        try {
+            const response = await fetch(`${this.apiUrl}/api/shops/${this.shopId}`);
+            if (!response.ok) throw new Error('API returned an error');
+            
+            const data = await response.json();
+            if (data.success) {
+                this.shop = data.data;
+                console.log('Loaded shop data:', this.shop.Name);
+                this.onLoad(this.shop);
+                return this.shop;
+            } else {
+                throw new Error(data.error || 'Failed to load shop data');
+            }
+        } catch (err) {
+            console.error('Error loading shop data:', err);
+            this.onError(err);
+            return null;
+        }
+    }
+    
+    /**
+     * Load shop items separately
+     */
+    async loadItems() {
+        if (!this.shopId) {
+            throw new Error('No shop ID provided');
+        }
+        
+        try {
+            const response = await fetch(`${this.apiUrl}/api/shops/${this.shopId}/items`);
+            if (!response.ok) throw new Error('API returned an error');
+            
+            const data = await response.json();
+            if (data.success) {
+                this.items = data.data;
+                console.log(`Loaded ${this.items.length} items`);
+                return this.items;
+            } else {
+                throw new Error(data.error || 'Failed to load shop items');
+            }
+        } catch (err) {
+            console.error('Error loading shop items:', err);
+            this.onError(err);
+            return [];
+        }
+    }
+    
+    /**
+     * Report a purchase to the shop owner's node. The order is stored as
+     * pending until the node verifies the payment on-chain.
+     * 
+     * @param {Object} order - itemId, variantId, quantity, pricePaid (wei), priceUsd, token, txHash, buyer
+     */
+    async recordOrder(order) {
+        if (!this.shopId) {
+            throw new Error('No shop ID provided');
+        }
+        
+        // The node only takes orders whose transaction it has seen, which can
+        // be a few seconds after the wallet hands out the hash
+        for (let attempt = 1; ; attempt++) {
+            const response = await fetch(`${this.apiUrl}/api/shops/${this.shopId}/orders`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(order)
+            });
+            
+            if (response.status === 425 && attempt < ShopAPI.ORDER_ATTEMPTS) {
+                await new Promise(resolve => setTimeout(resolve, ShopAPI.ORDER_RETRY_DELAY));
+                continue;
+            }
+            
+            const data = await response.json();
+            if (!data.success) {
+                throw new Error(data.error || 'Failed to record order');
+            }
+            return data.data;
+        }
+    }
+    
+    /**
+     * Send a request signed by the connected wallet. Required for API calls
+     * that change shop data; the server checks the signer owns the shop.
+     * 
+     * @param {string} method - HTTP method
+     * @param {string} path - API path, e.g. /api/shops/{id}
+     * @param {Object} body - Optional JSON body
+     */
+    async signedRequest(method, path, body) {
+        if (!window.ethereum) {
+            throw new Error('No Ethereum wallet found');
+        }
+        
+        const accounts = await window.ethereum.request({ method: 'eth_requestAccounts' });
+        const address = accounts[0];
+        const payload = body === undefined ? '' : JSON.stringify(body);
+        const timestamp = Math.floor(Date.now() / 1000);
+        
+        // Must match SigningMessage in internal/api/signature.go
+        const digest = await crypto.subtle.digest('SHA-256', new TextEncoder().encode(payload));
+        const bodyHash = Array.from(new Uint8Array(digest))
+            .map(b => b.toString(16).padStart(2, '0'))
+            .join('');
+        const message = 'IndieNode API request\n' +
+            `Method: ${method.toUpperCase()}\n` +
+            `Path: ${path}\n` +
+            `Body SHA-256: ${bodyHash}\n` +
+            `Timestamp: ${timestamp}`;
+        
+        const signature = await window.ethereum.request({
+            method: 'personal_sign',
+            params: [message, address]
+        });
+        
+        const response = await fetch(`${this.apiUrl}${path}`, {
+            method: method,
+            headers: {
+                'Content-Type': 'application/json',
+                'X-IndieNode-Address': address,
+                'X-IndieNode-Timestamp': String(timestamp),
+                'X-IndieNode-Signature': signature
+            },
+            body: payload || undefined
+        });
+        
+        const data = await response.json();
+        if (!data.success) {
+            throw new Error(data.error || 'API request failed');
+        }
+        return data.data;
+    }
+    
+    /**
+     * Render items to a container element
+     * 
+     * @param {HTMLElement} container - Container element to render items into
+     */
+    renderItems(container) {
+        if (!container) {
+            console.error('No container provided for renderItems');
+            return;
+        }
+        
+        // Keep the links to item pages the generated cards had
+        const links = {};
+        container.querySelectorAll('a.item-link[data-item-id]').forEach(link => {
+            links[link.dataset.itemId] = link.getAttribute('href');
+        });
+        
+        // Clear the container
+        container.innerHTML = '';
+        
+        if (!this.items || this.items.length === 0) {
+            container.innerHTML = '<p class="empty-items">No items available</p>';
+            return;
+        }
+        
+        // Create and append item elements
+        this.items.forEach(item => {
+            const itemElement = document.createElement('div');
+            itemElement.className = 'item-card';
+            
+            // Categories and tags for the filters in catalog.js
+            itemElement.dataset.categories = (item.Categories || []).join(' ');
+            itemElement.dataset.tags = (item.Tags || []).join(',');
+            
+            // Item fields come from the merchant, so everything is escaped
+            const escape = ShopAPI.escapeHTML;
+            const imagesHtml = item.PhotoPaths && item.PhotoPaths.length > 0 
+                ? `<div class="item-images">
+                    ${item.PhotoPaths.map(path => 
+                        `<img src="../${escape(path)}" class="item-image" alt="Product Image" 
+                         onclick="openModal(this.src)">`
+                    ).join('')}
+                   </div>`
+                : '';
+            
+            // Items with variants start on the first one in stock, like the generated pages
+            const variants = item.Variants || [];
+            const selected = variants.find(v => !v.SoldOut) || variants[0];
+            const price = selected ? selected.UnitPrice : item.Price;
+            const variantAttr = selected ? ` data-variant-id="${escape(selected.ID)}"` : '';
+            const variantsHtml = variants.length > 0
+                ? `<select class="variant-select" data-item-id="${escape(item.ID)}" aria-label="Options for ${escape(item.Name)}">
+                    ${variants.map(v =>
+                        `<option value="${escape(v.ID)}" data-price="${escape(v.UnitPrice)}"${v.SoldOut ? ' data-sold-out="true"' : ''}${v === selected ? ' selected' : ''}>${escape(v.Label)}${v.SoldOut ? ' (sold out)' : ''}</option>`
+                    ).join('')}
+                   </select>`
+                : '';
+            
+            itemElement.innerHTML = `
+                ${imagesHtml}
+                <div class="item-info">
+                    <div class="item-name">${links[item.ID]
+                        ? `<a href="${escape(links[item.ID])}" class="item-link" data-item-id="${escape(item.ID)}">${escape(item.Name)}</a>`
+                        : escape(item.Name)}</div>
+                    <div class="item-price" data-price-for="${escape(item.ID)}">$${Number(price).toFixed(2)}</div>
+                    <div class="item-description">${ShopAPI.formatText(item.Description)}</div>
+                </div>
+                ${variantsHtml}
+                ${item.SoldOut
+                    ? `<button class="eth-buy-button" data-item-id="${escape(item.ID)}" data-item-price="${escape(price)}"${variantAttr} data-sold-out="true" disabled>
+                        Sold Out
+                       </button>`
+                    : `<button class="eth-buy-button" data-item-id="${escape(item.ID)}" data-item-price="${escape(price)}"${variantAttr}>
+                        Buy with ETH
+                       </button>`}
+            `;
+            
+            container.appendChild(itemElement);
+        });
+        
+        // Keep showing only the items of the chosen category or tag
+        if (window.applyCatalogFilter) {
+            window.applyCatalogFilter();
+        }
+        
+        // Initialize buy buttons if web3 is available
+        if (window.initializeBuyButtons) {
+            window.initializeBuyButtons();
+        }
+    }
+    
+    /**
+     * Escape a value for use in HTML text or a quoted attribute
+     * 
+     * @param {*} value - Value to escape
+     * @returns {string} Escaped value
+     */
+    static escapeHTML(value) {
+        return String(value ?? '')
+            .replace(/&/g, '&amp;')
+            .replace(/</g, '&lt;')
+            .replace(/>/g, '&gt;')
+            .replace(/"/g, '&#34;')
+            .replace(/'/g, '&#39;');
+    }
+    
+    /**
+     * Escape text such as a description, keeping the same safe subset of
+     * formatting the published pages allow: simple formatting tags without
+     * attributes and links to http(s), mailto, ipfs and ipns URLs
+     * 
+     * @param {string} text - Merchant supplied text
+     * @returns {string} Safe HTML
+     */
+    static formatText(text) {
+        const allowedTags = ['b', 'strong', 'i', 'em', 'u', 'p', 'br', 'ul', 'ol', 'li'];
+        const safeLink = /^(https?|mailto|ipfs|ipns):/i;
+        const tagPattern = /&lt;(\/?)([a-z]+)\s*\/?&gt;|&lt;a\s+href=&#34;((?:[^\s&]|&amp;)*)&#34;\s*&gt;/gi;
+        
+        const open = [];
+        let html = ShopAPI.escapeHTML(text).replace(tagPattern, (match, closing, tag, href) => {
+            if (!tag) {
+                if (!safeLink.test(href.replace(/&amp;/g, '&'))) {
+                    return match;
+                }
+                open.push('a');
+                return `<a href="${href}" rel="nofollow noopener noreferrer">`;
+            }
+            
+            tag = tag.toLowerCase();
+            if (tag !== 'a' && !allowedTags.includes(tag)) {
+                return match;
+            }
+            if (!closing) {
+                if (tag === 'a') {
+                    return match;
+                }
+                if (tag !== 'br') {
+                    open.push(tag);
+                }
+                return `<${tag}>`;
+            }
+            
+            // Close anything left open inside the tag as well, drop stray closing tags
+            const index = open.lastIndexOf(tag);
+            if (index < 0) {
+                return '';
+            }
+            return open.splice(index).reverse().map(t => `</${t}>`).join('');
+        });
+        
+        return html + open.reverse().map(t => `</${t}>`).join('');
+    }
+    
+    /**
+     * Handle shop offline state
+     * 
+     * @param {HTMLElement} container - Container to show offline message
+     */
+    showOfflineMessage(container) {
+        if (!container) return;
+        
+        container.innerHTML = `
+            <div class="shop-offline">
+                <h2>Shop is currently offline</h2>
+                <p>The shop owner's node is currently not available. Please try again later.</p>
+            </div>
+        `;
+    }
+} 
\ No newline at end of file
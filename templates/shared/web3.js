@@ -0,0 +1,413 @@
+// Web3 functionality for IndieNode shops
+
+// Global variables
+let web3;
+let userAccount;
+const ETH_USD_PRICE_API = 'https://api.coingecko.com/api/v3/simple/price?ids=ethereum&vs_currencies=usd';
+
+// Read a value the shop generator embedded in a meta tag
+function getShopMeta(name) {
+    const meta = document.querySelector(`meta[name="${name}"]`);
+    return meta ? meta.getAttribute('content') : '';
+}
+
+// Payment details embedded by the shop generator
+const PAYOUT_ADDRESS = getShopMeta('payout-address');
+const CHAIN_ID = parseInt(getShopMeta('chain-id'), 10) || 1;
+const NETWORK_NAME = getShopMeta('network-name') || 'mainnet';
+
+// Wait for the page to fully load
+window.addEventListener('load', async () => {
+    console.log('🚀 Page loaded, checking MetaMask status...');
+    console.log('window.ethereum:', window.ethereum);
+    console.log('window.web3:', window.web3);
+    
+    // Add a small delay to ensure MetaMask has time to inject
+    setTimeout(async () => {
+        try {
+            console.log('Checking providers after delay...');
+            console.log('window.ethereum:', window.ethereum);
+            console.log('window.ethereum?.isMetaMask:', window.ethereum?.isMetaMask);
+            console.log('window.web3:', window.web3);
+            
+            // Modern dapp browsers
+            if (window.ethereum) {
+                console.log('🦊 Modern MetaMask provider detected');
+                web3 = new Web3(window.ethereum);
+                
+                // Check if we're already connected
+                const accounts = await web3.eth.getAccounts();
+                console.log('Found accounts:', accounts);
+                
+                if (accounts.length > 0) {
+                    userAccount = accounts[0];
+                    updateConnectedState(true);
+                } else {
+                    updateConnectedState(false);
+                }
+                
+                // Listen for account changes
+                window.ethereum.on('accountsChanged', handleAccountsChanged);
+                // Checkout checks the network before each payment, so no reload is needed
+                window.ethereum.on('chainChanged', chainId => console.log('Network changed:', parseInt(chainId, 16)));
+                window.ethereum.on('connect', () => console.log('MetaMask Connected'));
+                window.ethereum.on('disconnect', () => {
+                    console.log('MetaMask Disconnected');
+                    handleAccountsChanged([]);
+                });
+                
+            } else if (window.web3) {
+                // Legacy dapp browsers
+                console.log('🦊 Legacy MetaMask provider detected');
+                web3 = new Web3(window.web3.currentProvider);
+                const accounts = await web3.eth.getAccounts();
+                if (accounts.length > 0) {
+                    userAccount = accounts[0];
+                    updateConnectedState(true);
+                } else {
+                    updateConnectedState(false);
+                }
+            } else {
+                console.log('⚠️ No MetaMask provider detected');
+                updateConnectedState(false);
+            }
+            
+            // Add click handlers to buttons
+            setupBuyButtons();
+            
+        } catch (error) {
+            console.error('Error initializing Web3:', error);
+            updateConnectedState(false);
+        }
+    }, 100); // 100ms delay
+});
+
+// Update UI to reflect connection state
+function updateConnectedState(isConnected) {
+    const buyButtons = document.querySelectorAll('.eth-buy-button');
+    buyButtons.forEach(button => {
+        if (!web3) {
+            button.textContent = 'Install MetaMask';
+            button.disabled = true;
+            return;
+        }
+        
+        if (!PAYOUT_ADDRESS) {
+            button.textContent = 'Payments Unavailable';
+            button.disabled = true;
+            return;
+        }
+        
+        if (button.dataset.soldOut === 'true') {
+            button.textContent = 'Sold Out';
+            button.disabled = true;
+            return;
+        }
+        
+        // Leave buttons with a payment in progress alone
+        if (button.dataset.paymentPending === 'true') {
+            return;
+        }
+        
+        if (isConnected && userAccount) {
+            button.textContent = 'Buy with MetaMask';
+            button.disabled = false;
+        } else {
+            button.textContent = 'Connect Wallet to Buy';
+            button.disabled = false;
+        }
+    });
+}
+
+// Handle MetaMask account changes
+function handleAccountsChanged(accounts) {
+    if (accounts.length === 0) {
+        userAccount = null;
+        updateConnectedState(false);
+    } else {
+        userAccount = accounts[0];
+        updateConnectedState(true);
+    }
+}
+
+// Connect to MetaMask
+async function connectWallet() {
+    try {
+        const accounts = await window.ethereum.request({ 
+            method: 'eth_requestAccounts' 
+        });
+        handleAccountsChanged(accounts);
+        return true;
+    } catch (error) {
+        console.error('Error connecting to MetaMask:', error);
+        if (error.code === 4001) {
+            alert('Please connect your MetaMask wallet to make purchases.');
+        } else {
+            alert('Error connecting to MetaMask. Please try again.');
+        }
+        return false;
+    }
+}
+
+// Setup buy button click handlers
+function setupBuyButtons() {
+    const buyButtons = document.querySelectorAll('.eth-buy-button');
+    buyButtons.forEach(button => {
+        // Buttons re-rendered by ShopAPI need handlers, existing ones already have them
+        if (button.dataset.handlerAttached === 'true') return;
+        button.dataset.handlerAttached = 'true';
+        
+        button.addEventListener('click', async () => {
+            if (!web3) {
+                window.open('https://metamask.io', '_blank');
+                return;
+            }
+            
+            if (!userAccount) {
+                const connected = await connectWallet();
+                if (!connected) return;
+            }
+            
+            try {
+                await prepareTransaction(button);
+            } catch (error) {
+                console.error('Error preparing transaction:', error);
+                showPaymentStatus(button, 'failed', error.message || 'Payment failed. Please try again.');
+            }
+        });
+    });
+    
+    setupVariantSelects();
+    updateConnectedState(!!userAccount);
+}
+
+// Called by ShopAPI after it re-renders the items
+window.initializeBuyButtons = setupBuyButtons;
+
+// Setup the variant pickers of items that have variants
+function setupVariantSelects() {
+    document.querySelectorAll('.variant-select').forEach(select => {
+        if (select.dataset.handlerAttached === 'true') return;
+        select.dataset.handlerAttached = 'true';
+        
+        select.addEventListener('change', () => selectVariant(select));
+    });
+}
+
+// Point the item's buy buttons and price at the picked variant
+function selectVariant(select) {
+    const option = select.options[select.selectedIndex];
+    if (!option) return;
+    
+    const itemId = select.dataset.itemId;
+    document.querySelectorAll('.eth-buy-button').forEach(button => {
+        if (button.dataset.itemId !== itemId || button.dataset.paymentPending === 'true') return;
+        
+        button.dataset.variantId = option.value;
+        button.dataset.itemPrice = option.dataset.price;
+        if (option.dataset.soldOut === 'true') {
+            button.dataset.soldOut = 'true';
+        } else {
+            delete button.dataset.soldOut;
+        }
+    });
+    
+    document.querySelectorAll('[data-price-for]').forEach(label => {
+        if (label.dataset.priceFor === itemId) {
+            label.textContent = `$${parseFloat(option.dataset.price).toFixed(2)}`;
+        }
+    });
+    
+    // Pages with a photo gallery show the variant's own photo
+    if (select.dataset.showPhotos === 'true' && option.dataset.src && typeof showImage === 'function') {
+        showImage(option);
+    }
+    
+    updateConnectedState(!!userAccount);
+}
+
+// Show the payment state below a buy button
+function showPaymentStatus(button, state, message) {
+    let status = button.nextElementSibling;
+    if (!status || !status.classList.contains('payment-status')) {
+        status = document.createElement('div');
+        status.className = 'payment-status';
+        button.insertAdjacentElement('afterend', status);
+    }
+    
+    status.className = `payment-status payment-${state}`;
+    status.textContent = message;
+}
+
+// Make sure the wallet is on the network the shop accepts payments on
+async function ensureCorrectChain() {
+    if (!window.ethereum) return;
+    
+    const currentChainId = parseInt(await window.ethereum.request({ method: 'eth_chainId' }), 16);
+    if (currentChainId === CHAIN_ID) return;
+    
+    try {
+        await window.ethereum.request({
+            method: 'wallet_switchEthereumChain',
+            params: [{ chainId: '0x' + CHAIN_ID.toString(16) }]
+        });
+    } catch (error) {
+        if (error.code === 4001) {
+            throw new Error(`Please switch your wallet to ${NETWORK_NAME} to pay.`);
+        }
+        throw new Error(`This shop accepts payments on ${NETWORK_NAME}. Please switch networks in your wallet.`);
+    }
+}
+
+// Get current ETH price
+async function getEthPrice() {
+    try {
+        const response = await fetch(ETH_USD_PRICE_API);
+        const data = await response.json();
+        return data.ethereum.usd;
+    } catch (error) {
+        console.error('Error fetching ETH price:', error);
+        throw new Error('Failed to get ETH price');
+    }
+}
+
+// Convert USD to ETH
+async function convertUSDToETH(usdAmount) {
+    const ethPrice = await getEthPrice();
+    return usdAmount / ethPrice;
+}
+
+// Report a sent payment to the shop owner's node so it shows up in their orders
+function recordOrder(itemId, variantId, priceUSD, priceWei, txHash) {
+    if (!window.shopApi) return;
+    
+    window.shopApi.recordOrder({
+        itemId: itemId,
+        variantId: variantId,
+        quantity: 1,
+        pricePaid: String(priceWei),
+        priceUsd: priceUSD,
+        token: 'ETH',
+        txHash: txHash,
+        buyer: userAccount
+    }).catch(error => {
+        // The payment itself went through, so only log this
+        console.error('Failed to record order with the shop:', error);
+    });
+}
+
+// Check the shop's current stock before taking a payment.
+// If the shop can't be reached the purchase is allowed and the node decides.
+async function isSoldOut(itemId, variantId) {
+    if (!window.shopApi) return false;
+    
+    const items = await window.shopApi.loadItems();
+    const item = items.find(i => i.ID === itemId);
+    if (item && variantId) {
+        const variant = (item.Variants || []).find(v => v.ID === variantId);
+        return !!(variant && variant.SoldOut);
+    }
+    return !!(item && item.SoldOut);
+}
+
+// Switch a buy button to its sold out state, along with its variant in the picker
+function markSoldOut(button) {
+    button.dataset.soldOut = 'true';
+    button.textContent = 'Sold Out';
+    button.disabled = true;
+    
+    if (!button.dataset.variantId) return;
+    document.querySelectorAll('.variant-select').forEach(select => {
+        if (select.dataset.itemId !== button.dataset.itemId) return;
+        Array.from(select.options).forEach(option => {
+            if (option.value === button.dataset.variantId && option.dataset.soldOut !== 'true') {
+                option.dataset.soldOut = 'true';
+                option.textContent += ' (sold out)';
+            }
+        });
+    });
+}
+
+// Prepare and send the payment for an item, then wait for it to be mined
+async function prepareTransaction(button) {
+    if (!PAYOUT_ADDRESS || !web3.utils.isAddress(PAYOUT_ADDRESS)) {
+        throw new Error('This shop has no payout address configured.');
+    }
+    
+    const itemId = button.dataset.itemId;
+    const variantId = button.dataset.variantId || '';
+    const priceUSD = parseFloat(button.dataset.itemPrice);
+    
+    if (await isSoldOut(itemId, variantId)) {
+        markSoldOut(button);
+        throw new Error('Sorry, this item has just sold out.');
+    }
+    
+    let priceWei;
+    let formattedPriceETH;
+    try {
+        const priceETH = await convertUSDToETH(priceUSD);
+        formattedPriceETH = priceETH.toFixed(6);
+        priceWei = web3.utils.toWei(formattedPriceETH, 'ether');
+    } catch (error) {
+        console.error('Error preparing transaction:', error);
+        throw new Error('Failed to get the current ETH price. Please try again.');
+    }
+    
+    if (!confirm(`Confirm purchase for $${priceUSD.toFixed(2)} (${formattedPriceETH} ETH)?`)) {
+        return null;
+    }
+    
+    const originalText = button.textContent;
+    button.disabled = true;
+    button.dataset.paymentPending = 'true';
+    
+    try {
+        await ensureCorrectChain();
+        
+        showPaymentStatus(button, 'pending', 'Waiting for wallet confirmation...');
+        button.textContent = 'Processing...';
+        
+        // sendTransaction resolves with the receipt once the transaction is mined
+        const receipt = await web3.eth.sendTransaction({
+            from: userAccount,
+            to: PAYOUT_ADDRESS,
+            value: priceWei
+        }).on('transactionHash', hash => {
+            console.log('Transaction sent:', { itemId, variantId, hash });
+            showPaymentStatus(button, 'pending', `Payment sent, waiting for confirmation (${hash.slice(0, 10)}...)`);
+            recordOrder(itemId, variantId, priceUSD, priceWei, hash);
+        });
+        
+        if (!receipt.status) {
+            throw new Error('The payment transaction failed on-chain.');
+        }
+        
+        console.log('Transaction confirmed:', {
+            itemId,
+            variantId,
+            priceUSD,
+            priceETH: formattedPriceETH,
+            priceWei,
+            transactionHash: receipt.transactionHash,
+            blockNumber: receipt.blockNumber
+        });
+        showPaymentStatus(button, 'confirmed', `Payment confirmed! Transaction ${receipt.transactionHash.slice(0, 10)}...`);
+        return receipt;
+    } catch (error) {
+        console.error('Payment failed:', error);
+        if (error.code === 4001) {
+            throw new Error('Payment cancelled in wallet.');
+        }
+        throw error;
+    } finally {
+        delete button.dataset.paymentPending;
+        button.textContent = originalText;
+        button.disabled = false;
+        
+        // The last unit may have just been bought
+        if (await isSoldOut(itemId, variantId)) {
+            markSoldOut(button);
+        }
+    }
+}